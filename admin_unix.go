@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+import "os"
+
+// adminRequiredMessage 缺少管理员权限时的提示信息
+const adminRequiredMessage = "此程序需要root权限运行。请使用sudo或以root用户身份运行。"
+
+// isAdmin 检查当前用户是否为root
+func isAdmin() bool {
+	return os.Geteuid() == 0
+}
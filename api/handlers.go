@@ -1,10 +1,14 @@
 package api
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"networkconfig/models"
 	"networkconfig/service"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -29,14 +33,59 @@ func (h *NetworkHandler) RegisterRoutes(router *gin.Engine) {
 		v1.GET("/interfaces/:name", h.GetInterface)
 		v1.PUT("/interfaces/:name/ipv4", h.ConfigureIPv4)
 		v1.PUT("/interfaces/:name/ipv6", h.ConfigureIPv6)
+		v1.POST("/interfaces/:name/validate", h.ValidateInterfaceConfig)
+		v1.GET("/interfaces/:name/addresses", h.GetInterfaceAddresses)
+		v1.POST("/interfaces/:name/addresses", h.AddInterfaceAddress)
+		v1.DELETE("/interfaces/:name/addresses/:ip", h.RemoveInterfaceAddress)
 		v1.GET("/connectivity", h.CheckConnectivity)
 		v1.POST("/interfaces/:name/connect", h.ConnectWiFi)
 		v1.GET("/interfaces/:name/hotspots", h.GetWiFiHotspots)
+		v1.GET("/wifi/profiles/:name/qrcode", h.GetWiFiProfileQRCode)
+
+		// 网络配置快照
+		v1.GET("/snapshot", h.ExportSnapshot)
+		v1.POST("/snapshot/restore", h.RestoreSnapshot)
+
+		// 期望状态与漂移检查
+		v1.GET("/desired-state", h.GetDesiredState)
+		v1.PUT("/desired-state", h.SetDesiredState)
+		v1.DELETE("/desired-state", h.ClearDesiredState)
+		v1.GET("/drift", h.GetDrift)
+		v1.POST("/reconcile", h.Reconcile)
+
+		// 配置变更确认与回滚
+		v1.GET("/changes", h.ListChanges)
+		v1.GET("/changes/:id", h.GetChange)
+		v1.POST("/changes/:id/confirm", h.ConfirmChange)
+		v1.POST("/changes/:id/rollback", h.RollbackChange)
 
 		// 移动热点相关接口
 		v1.GET("/hotspot", h.GetHotspotStatus)
 		v1.POST("/hotspot", h.ConfigureHotspot)
 		v1.PUT("/hotspot/status", h.SetHotspotStatus)
+		v1.GET("/hotspot/clients", h.GetHotspotClients)
+		v1.POST("/hotspot/clients/:mac/disconnect", h.DisconnectHotspotClient)
+		v1.GET("/hotspot/acl", h.GetHotspotACL)
+		v1.PUT("/hotspot/acl", h.SetHotspotACL)
+		v1.GET("/hotspot/qrcode", h.GetHotspotQRCode)
+		v1.GET("/hotspot/schedule", h.GetHotspotSchedule)
+		v1.PUT("/hotspot/schedule", h.SetHotspotSchedule)
+		v1.GET("/hotspot/schedule/actions", h.GetHotspotScheduledActions)
+
+		// 看门狗
+		v1.GET("/watchdogs", h.GetWatchdogs)
+		v1.GET("/monitor/hotspot", h.GetHotspotMonitor)
+		v1.PATCH("/monitor/hotspot", h.UpdateHotspotMonitor)
+
+		// 内置DHCP服务
+		v1.GET("/dhcp/scopes", h.GetDHCPScopes)
+		v1.PUT("/dhcp/scopes/:interface", h.SetDHCPScope)
+		v1.DELETE("/dhcp/scopes/:interface", h.DeleteDHCPScope)
+		v1.GET("/dhcp/leases", h.GetDHCPLeases)
+		v1.GET("/dhcp/reservations", h.GetDHCPReservations)
+		v1.POST("/dhcp/reservations", h.AddDHCPReservation)
+		v1.PUT("/dhcp/reservations/:mac", h.SetDHCPReservation)
+		v1.DELETE("/dhcp/reservations/:mac", h.DeleteDHCPReservation)
 	}
 }
 
@@ -98,17 +147,455 @@ func (h *NetworkHandler) ConfigureIPv4(c *gin.Context) {
 		return
 	}
 
-	err := h.networkService.ConfigureInterface(name, models.InterfaceConfig{
+	config := models.InterfaceConfig{
 		IPv4Config: request.IPv4Config,
+	}
+
+	// dry_run=true 时只返回执行计划，不修改系统
+	if c.Query("dry_run") == "true" {
+		h.planInterface(c, name, config)
+		return
+	}
+
+	h.applyInterfaceConfig(c, name, config)
+}
+
+// applyInterfaceConfig 应用网卡配置
+// 指定confirm_timeout(秒)或配置了默认确认超时时，变更需要通过 /changes/:id/confirm 确认，否则自动回滚
+func (h *NetworkHandler) applyInterfaceConfig(c *gin.Context, name string, config models.InterfaceConfig) {
+	timeout := service.DefaultConfirmTimeout()
+	if value := c.Query("confirm_timeout"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "无效的confirm_timeout参数: " + value,
+			})
+			return
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+
+	if timeout == 0 {
+		if err := h.networkService.ConfigureInterface(name, config); err != nil {
+			if respondValidationErrors(c, err) {
+				return
+			}
+			status := http.StatusInternalServerError
+			if errors.Is(err, service.ErrChangeInProgress) {
+				status = http.StatusConflict
+			}
+			c.JSON(status, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		c.Status(http.StatusOK)
+		return
+	}
+
+	change, err := h.networkService.ConfigureInterfaceWithConfirm(name, config, service.ChangeOptions{
+		Timeout:            timeout,
+		CheckConnectivity:  c.Query("check_connectivity") == "true",
+		ConnectivityTarget: c.Query("connectivity_target"),
+	})
+	if err != nil {
+		if respondValidationErrors(c, err) {
+			return
+		}
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrChangeInProgress) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"error":  err.Error(),
+			"change": change,
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, change)
+}
+
+// GetInterfaceAddresses 获取网卡上的全部地址
+func (h *NetworkHandler) GetInterfaceAddresses(c *gin.Context) {
+	iface, err := h.networkService.GetInterface(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, iface.Addresses)
+}
+
+// AddInterfaceAddress 为网卡追加附加地址，请求体为{"address": "10.0.0.6/24"}
+func (h *NetworkHandler) AddInterfaceAddress(c *gin.Context) {
+	var request struct {
+		Address string `json:"address"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的请求数据: " + err.Error(),
+		})
+		return
+	}
+
+	if request.Address == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "缺少address参数",
+		})
+		return
+	}
+
+	iface, err := h.networkService.AddInterfaceAddress(c.Param("name"), request.Address)
+	if err != nil {
+		if respondValidationErrors(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, iface.Addresses)
+}
+
+// RemoveInterfaceAddress 删除网卡上的附加地址，路径中的地址不带前缀长度
+func (h *NetworkHandler) RemoveInterfaceAddress(c *gin.Context) {
+	iface, err := h.networkService.RemoveInterfaceAddress(c.Param("name"), c.Param("ip"))
+	if err != nil {
+		if respondValidationErrors(c, err) {
+			return
+		}
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrAddressNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrPrimaryAddress):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, iface.Addresses)
+}
+
+// ValidateInterfaceConfig 只校验网卡配置，不修改系统
+func (h *NetworkHandler) ValidateInterfaceConfig(c *gin.Context) {
+	name := c.Param("name")
+	var config models.InterfaceConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的请求数据: " + err.Error(),
+		})
+		return
+	}
+
+	if config.IPv4Config == nil && config.IPv6Config == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "缺少ipv4_config或ipv6_config参数",
+		})
+		return
+	}
+
+	if errs := h.networkService.ValidateInterfaceConfig(name, config); len(errs) > 0 {
+		respondValidationErrors(c, errs)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"valid":  true,
+		"errors": []models.ValidationError{},
 	})
+}
+
+// respondValidationErrors 如果err是校验错误则返回422和字段级错误列表
+func respondValidationErrors(c *gin.Context, err error) bool {
+	var errs service.ValidationErrors
+	if !errors.As(err, &errs) {
+		return false
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error":  err.Error(),
+		"valid":  false,
+		"errors": errs,
+	})
+	return true
+}
+
+// ListChanges 获取配置变更列表
+func (h *NetworkHandler) ListChanges(c *gin.Context) {
+	c.JSON(http.StatusOK, h.networkService.ListChanges())
+}
+
+// GetChange 获取指定配置变更
+func (h *NetworkHandler) GetChange(c *gin.Context) {
+	change, err := h.networkService.GetChange(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, change)
+}
+
+// ConfirmChange 确认配置变更，取消自动回滚
+func (h *NetworkHandler) ConfirmChange(c *gin.Context) {
+	change, err := h.networkService.ConfirmChange(c.Param("id"))
+	if err != nil {
+		c.JSON(changeErrorStatus(err), gin.H{
+			"error":  err.Error(),
+			"change": change,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, change)
+}
+
+// RollbackChange 立即回滚未确认的配置变更
+func (h *NetworkHandler) RollbackChange(c *gin.Context) {
+	change, err := h.networkService.RollbackChange(c.Param("id"))
+	if err != nil {
+		c.JSON(changeErrorStatus(err), gin.H{
+			"error":  err.Error(),
+			"change": change,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, change)
+}
+
+// changeErrorStatus 把变更相关错误映射为HTTP状态码
+func changeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrChangeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrChangeNotPending):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ExportSnapshot 导出网络配置快照，format=yaml或Accept为YAML时返回YAML
+// 默认清除WiFi和热点密码，include_secrets=true时才返回
+func (h *NetworkHandler) ExportSnapshot(c *gin.Context) {
+	snapshot, err := h.networkService.ExportSnapshot()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !includeSecrets(c) {
+		snapshot = snapshotWithoutSecrets(snapshot)
+	}
+
+	if wantsYAML(c.Query("format"), c.GetHeader("Accept")) {
+		c.YAML(http.StatusOK, snapshot)
+		return
+	}
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// RestoreSnapshot 按快照恢复网络配置，请求体可以是JSON或YAML
+func (h *NetworkHandler) RestoreSnapshot(c *gin.Context) {
+	var snapshot models.NetworkSnapshot
+	var err error
+	if wantsYAML(c.Query("format"), c.ContentType()) {
+		err = c.ShouldBindYAML(&snapshot)
+	} else {
+		err = c.ShouldBindJSON(&snapshot)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的快照数据: " + err.Error(),
+		})
+		return
+	}
+
+	result, err := h.networkService.RestoreSnapshot(snapshot)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrSnapshotVersion) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// 部分项目失败时返回207，调用方根据每一项的结果处理
+	status := http.StatusOK
+	if !result.Success {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, result)
+}
+
+// GetDesiredState 获取期望状态，默认清除热点密码，include_secrets=true时才返回
+func (h *NetworkHandler) GetDesiredState(c *gin.Context) {
+	state, err := h.networkService.GetDesiredState()
+	if err != nil {
+		c.JSON(desiredStateErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !includeSecrets(c) {
+		state = desiredStateWithoutSecrets(state)
+	}
+
+	if wantsYAML(c.Query("format"), c.GetHeader("Accept")) {
+		c.YAML(http.StatusOK, state)
+		return
+	}
+	c.JSON(http.StatusOK, state)
+}
+
+// SetDesiredState 设置期望状态，请求体可以是JSON或YAML，响应与GetDesiredState一样默认清除热点密码
+func (h *NetworkHandler) SetDesiredState(c *gin.Context) {
+	var state models.DesiredState
+	var err error
+	if wantsYAML(c.Query("format"), c.ContentType()) {
+		err = c.ShouldBindYAML(&state)
+	} else {
+		err = c.ShouldBindJSON(&state)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的期望状态: " + err.Error(),
+		})
+		return
+	}
+
+	if err := h.networkService.SetDesiredState(state); err != nil {
+		if respondValidationErrors(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if !includeSecrets(c) {
+		state = desiredStateWithoutSecrets(state)
+	}
+	c.JSON(http.StatusOK, state)
+}
+
+// ClearDesiredState 清除期望状态
+func (h *NetworkHandler) ClearDesiredState(c *gin.Context) {
+	if err := h.networkService.ClearDesiredState(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetDrift 列出与期望状态不一致的字段
+func (h *NetworkHandler) GetDrift(c *gin.Context) {
+	report, err := h.networkService.GetDrift()
+	if err != nil {
+		c.JSON(desiredStateErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Reconcile 立即把实际状态修正为期望状态
+func (h *NetworkHandler) Reconcile(c *gin.Context) {
+	report, err := h.networkService.Reconcile()
+	if err != nil {
+		c.JSON(desiredStateErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// desiredStateErrorStatus 把期望状态相关错误映射为HTTP状态码
+func desiredStateErrorStatus(err error) int {
+	if errors.Is(err, service.ErrNoDesiredState) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// wantsYAML 根据format参数或MIME类型判断是否使用YAML
+func wantsYAML(format, mime string) bool {
+	return strings.EqualFold(format, "yaml") || strings.Contains(strings.ToLower(mime), "yaml")
+}
+
+// includeSecrets 判断是否在响应中返回密码，返回密码时禁止缓存
+func includeSecrets(c *gin.Context) bool {
+	if c.Query("include_secrets") != "true" {
+		return false
+	}
+	c.Header("Cache-Control", "no-store")
+	return true
+}
+
+// snapshotWithoutSecrets 返回清除了WiFi和热点密码的快照副本
+func snapshotWithoutSecrets(snapshot models.NetworkSnapshot) models.NetworkSnapshot {
+	profiles := make([]models.WiFiProfile, len(snapshot.WiFiProfiles))
+	for i, profile := range snapshot.WiFiProfiles {
+		profile.Password = ""
+		profiles[i] = profile
+	}
+	snapshot.WiFiProfiles = profiles
+	if snapshot.Hotspot != nil {
+		hotspot := *snapshot.Hotspot
+		hotspot.Password = ""
+		snapshot.Hotspot = &hotspot
+	}
+	return snapshot
+}
+
+// desiredStateWithoutSecrets 返回清除了热点密码的期望状态副本
+func desiredStateWithoutSecrets(state models.DesiredState) models.DesiredState {
+	if state.Hotspot != nil {
+		hotspot := *state.Hotspot
+		hotspot.Password = ""
+		state.Hotspot = &hotspot
+	}
+	return state
+}
+
+// planInterface 预演网卡配置并返回执行计划
+func (h *NetworkHandler) planInterface(c *gin.Context, name string, config models.InterfaceConfig) {
+	plan, err := h.networkService.PlanInterface(name, config)
 	if err != nil {
+		if respondValidationErrors(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
+			"plan":  plan,
 		})
 		return
 	}
 
-	c.Status(http.StatusOK)
+	c.JSON(http.StatusOK, plan)
 }
 
 // GetHotspotStatus 获取移动热点状态
@@ -141,27 +628,15 @@ func (h *NetworkHandler) ConfigureHotspot(c *gin.Context) {
 		return
 	}
 
-	log.Printf("请求配置: %+v", config)
-
-	// 验证请求数据
-	if config.SSID == "" {
-		log.Printf("验证失败: SSID不能为空")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "SSID不能为空",
-		})
-		return
-	}
-
-	if config.Password != "" && len(config.Password) < 8 {
-		log.Printf("验证失败: 密码长度不足8个字符")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "密码长度必须至少为8个字符",
-		})
-		return
-	}
+	log.Printf("请求配置: SSID=%s, 频段=%s, 信道=%d, 安全模式=%s, 最大客户端数=%d, 上行网卡=%s",
+		config.SSID, config.Band, config.Channel, config.Security, config.MaxClients, config.Uplink)
 
+	// 校验失败和后端不支持的选项都以422返回字段级错误
 	err := h.networkService.ConfigureHotspot(config)
 	if err != nil {
+		if respondValidationErrors(c, err) {
+			return
+		}
 		log.Printf("配置移动热点失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
@@ -213,6 +688,358 @@ func (h *NetworkHandler) SetHotspotStatus(c *gin.Context) {
 	})
 }
 
+// GetHotspotClients 获取已连接移动热点的客户端
+func (h *NetworkHandler) GetHotspotClients(c *gin.Context) {
+	clients, err := h.networkService.GetHotspotClients()
+	if err != nil {
+		log.Printf("获取热点客户端失败: %v", err)
+		c.JSON(hotspotErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, clients)
+}
+
+// DisconnectHotspotClient 断开指定MAC地址的热点客户端
+func (h *NetworkHandler) DisconnectHotspotClient(c *gin.Context) {
+	mac := c.Param("mac")
+	if err := h.networkService.DisconnectHotspotClient(mac); err != nil {
+		if respondValidationErrors(c, err) {
+			return
+		}
+		log.Printf("断开热点客户端 %s 失败: %v", mac, err)
+		c.JSON(hotspotErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "客户端已断开",
+	})
+}
+
+// GetHotspotACL 获取热点MAC地址过滤列表
+func (h *NetworkHandler) GetHotspotACL(c *gin.Context) {
+	c.JSON(http.StatusOK, h.networkService.GetHotspotACL())
+}
+
+// SetHotspotACL 设置热点MAC地址过滤列表，请求体为{"mode": "deny", "allow": [], "deny": ["aa:bb:cc:dd:ee:ff"]}
+func (h *NetworkHandler) SetHotspotACL(c *gin.Context) {
+	var acl models.HotspotACL
+	if err := c.ShouldBindJSON(&acl); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的请求数据: " + err.Error(),
+		})
+		return
+	}
+
+	saved, err := h.networkService.SetHotspotACL(acl)
+	if err != nil {
+		if respondValidationErrors(c, err) {
+			return
+		}
+		log.Printf("设置热点过滤列表失败: %v", err)
+		c.JSON(hotspotErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// GetHotspotSchedule 获取热点计划规则
+func (h *NetworkHandler) GetHotspotSchedule(c *gin.Context) {
+	c.JSON(http.StatusOK, h.networkService.GetHotspotSchedule())
+}
+
+// SetHotspotSchedule 设置热点计划规则，请求体为{"rules": [{"cron": "0 9 * * 1-5", "action": "enable"}]}
+func (h *NetworkHandler) SetHotspotSchedule(c *gin.Context) {
+	var schedule models.HotspotSchedule
+	if err := c.ShouldBindJSON(&schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的请求数据: " + err.Error(),
+		})
+		return
+	}
+
+	saved, err := h.networkService.SetHotspotSchedule(schedule)
+	if err != nil {
+		if respondValidationErrors(c, err) {
+			return
+		}
+		log.Printf("设置热点计划规则失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// GetHotspotScheduledActions 列出今后和已执行的计划动作，limit指定各自的最大条数，默认20
+func (h *NetworkHandler) GetHotspotScheduledActions(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "limit必须是1-200之间的整数",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, h.networkService.GetHotspotScheduledActions(limit))
+}
+
+// GetWatchdogs 列出所有看门狗的状态
+func (h *NetworkHandler) GetWatchdogs(c *gin.Context) {
+	c.JSON(http.StatusOK, h.networkService.WatchdogStatuses())
+}
+
+// GetHotspotMonitor 获取热点监控的设置、状态和恢复记录
+func (h *NetworkHandler) GetHotspotMonitor(c *gin.Context) {
+	c.JSON(http.StatusOK, h.networkService.GetHotspotMonitor())
+}
+
+// UpdateHotspotMonitor 修改热点监控设置，请求体为{"enabled": true, "interval_seconds": 60, "auto_recovery": false}，未提供的字段不修改
+func (h *NetworkHandler) UpdateHotspotMonitor(c *gin.Context) {
+	var settings models.MonitorSettings
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的请求数据: " + err.Error(),
+		})
+		return
+	}
+
+	info, err := h.networkService.UpdateHotspotMonitor(settings)
+	if err != nil {
+		if respondValidationErrors(c, err) {
+			return
+		}
+		log.Printf("修改热点监控设置失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// GetHotspotQRCode 生成扫码连接热点的二维码，format=png(默认)或svg，PNG可用size指定边长
+func (h *NetworkHandler) GetHotspotQRCode(c *gin.Context) {
+	payload, err := h.networkService.HotspotQRPayload()
+	if err != nil {
+		log.Printf("生成热点二维码失败: %v", err)
+		c.JSON(hotspotErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	respondQRCode(c, payload)
+}
+
+// GetWiFiProfileQRCode 生成扫码连接已保存WiFi的二维码，参数与GetHotspotQRCode相同
+func (h *NetworkHandler) GetWiFiProfileQRCode(c *gin.Context) {
+	payload, err := h.networkService.WiFiProfileQRPayload(c.Param("name"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrWiFiProfileNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	respondQRCode(c, payload)
+}
+
+// respondQRCode 按请求的格式返回二维码图片，二维码包含密码，禁止缓存
+func respondQRCode(c *gin.Context, payload string) {
+	format := strings.ToLower(c.DefaultQuery("format", "png"))
+	if c.Query("format") == "" && strings.Contains(c.GetHeader("Accept"), "image/svg+xml") {
+		format = "svg"
+	}
+	c.Header("Cache-Control", "no-store")
+
+	switch format {
+	case "svg":
+		data, err := service.RenderQRCodeSVG(payload)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		c.Data(http.StatusOK, "image/svg+xml", data)
+	case "png":
+		size, err := strconv.Atoi(c.DefaultQuery("size", "256"))
+		if err != nil || size < 64 || size > 2048 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "size必须是64-2048之间的整数",
+			})
+			return
+		}
+		data, err := service.RenderQRCodePNG(payload, size)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		c.Data(http.StatusOK, "image/png", data)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "format只能是png或svg",
+		})
+	}
+}
+
+// GetDHCPScopes 获取所有DHCP地址池及其运行状态
+func (h *NetworkHandler) GetDHCPScopes(c *gin.Context) {
+	c.JSON(http.StatusOK, h.networkService.GetDHCPScopes())
+}
+
+// SetDHCPScope 保存网卡的DHCP地址池，请求体为{"enabled": true, "range_start": "192.168.50.10", "range_end": "192.168.50.200"}
+func (h *NetworkHandler) SetDHCPScope(c *gin.Context) {
+	var scope models.DHCPScope
+	if err := c.ShouldBindJSON(&scope); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的请求数据: " + err.Error(),
+		})
+		return
+	}
+
+	saved, err := h.networkService.SetDHCPScope(c.Param("interface"), scope)
+	if err != nil {
+		if respondValidationErrors(c, err) {
+			return
+		}
+		log.Printf("保存DHCP地址池失败: %v", err)
+		c.JSON(dhcpErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// DeleteDHCPScope 删除网卡的DHCP地址池
+func (h *NetworkHandler) DeleteDHCPScope(c *gin.Context) {
+	if err := h.networkService.DeleteDHCPScope(c.Param("interface")); err != nil {
+		c.JSON(dhcpErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "DHCP地址池已删除",
+	})
+}
+
+// GetDHCPLeases 获取DHCP租约，可用interface参数只查看一个网卡
+func (h *NetworkHandler) GetDHCPLeases(c *gin.Context) {
+	c.JSON(http.StatusOK, h.networkService.GetDHCPLeases(c.Query("interface")))
+}
+
+// GetDHCPReservations 获取DHCP静态保留
+func (h *NetworkHandler) GetDHCPReservations(c *gin.Context) {
+	c.JSON(http.StatusOK, h.networkService.GetDHCPReservations())
+}
+
+// AddDHCPReservation 添加DHCP静态保留，请求体为{"mac": "aa:bb:cc:dd:ee:ff", "ip": "192.168.50.20"}
+func (h *NetworkHandler) AddDHCPReservation(c *gin.Context) {
+	var reservation models.DHCPReservation
+	if err := c.ShouldBindJSON(&reservation); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的请求数据: " + err.Error(),
+		})
+		return
+	}
+
+	saved, err := h.networkService.AddDHCPReservation(reservation)
+	if err != nil {
+		if respondValidationErrors(c, err) {
+			return
+		}
+		c.JSON(dhcpErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, saved)
+}
+
+// SetDHCPReservation 添加或修改指定MAC的DHCP静态保留
+func (h *NetworkHandler) SetDHCPReservation(c *gin.Context) {
+	var reservation models.DHCPReservation
+	if err := c.ShouldBindJSON(&reservation); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的请求数据: " + err.Error(),
+		})
+		return
+	}
+
+	saved, err := h.networkService.SetDHCPReservation(c.Param("mac"), reservation)
+	if err != nil {
+		if respondValidationErrors(c, err) {
+			return
+		}
+		c.JSON(dhcpErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// DeleteDHCPReservation 删除DHCP静态保留
+func (h *NetworkHandler) DeleteDHCPReservation(c *gin.Context) {
+	if err := h.networkService.DeleteDHCPReservation(c.Param("mac")); err != nil {
+		c.JSON(dhcpErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "DHCP静态保留已删除",
+	})
+}
+
+// dhcpErrorStatus 把DHCP操作的错误映射为HTTP状态码
+func dhcpErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrDHCPScopeNotFound), errors.Is(err, service.ErrDHCPReservationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrDHCPReservationExists):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// hotspotErrorStatus 把热点操作的错误映射为HTTP状态码
+func hotspotErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrHotspotClientNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrHotspotNotConfigured):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrNotSupported):
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // CheckConnectivity 检查网络连通性
 func (h *NetworkHandler) CheckConnectivity(c *gin.Context) {
 	target := c.Query("target") // 可选参数，不传则使用默认值
@@ -230,16 +1057,63 @@ func (h *NetworkHandler) CheckConnectivity(c *gin.Context) {
 
 // ConnectWiFi 连接指定WiFi热点
 // GetWiFiHotspots 获取可用WiFi热点列表
+// 支持sort(signal/ssid/channel/frequency)、order(asc/desc)、min_signal、band和security(可用逗号分隔多个值)过滤排序，
+// group=ssid时把同一SSID的接入点合并为一个网络返回
 func (h *NetworkHandler) GetWiFiHotspots(c *gin.Context) {
 	name := c.Param("name")
 
-	hotspots, err := h.networkService.GetWiFiHotspots(name)
+	query := models.WiFiScanQuery{
+		Sort:     c.Query("sort"),
+		Order:    c.Query("order"),
+		Bands:    queryList(c, "band"),
+		Security: queryList(c, "security"),
+	}
+	if value := c.Query("min_signal"); value != "" {
+		minSignal, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "无效的min_signal参数: " + value,
+			})
+			return
+		}
+		query.MinSignal = minSignal
+	}
+
+	var result interface{}
+	var err error
+	switch group := c.Query("group"); group {
+	case "":
+		result, err = h.networkService.GetWiFiHotspots(name, query)
+	case "ssid":
+		result, err = h.networkService.GetWiFiNetworks(name, query)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的group参数: " + group,
+		})
+		return
+	}
 	if err != nil {
+		if respondValidationErrors(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, hotspots)
+	c.JSON(http.StatusOK, result)
+}
+
+// queryList 读取可重复、也可用逗号分隔的查询参数
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, value := range c.QueryArray(key) {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+	}
+	return values
 }
 
 func (h *NetworkHandler) ConnectWiFi(c *gin.Context) {
@@ -257,6 +1131,15 @@ func (h *NetworkHandler) ConnectWiFi(c *gin.Context) {
 
 	err := h.networkService.ConnectWiFi(name, req.SSID, req.Password)
 	if err != nil {
+		// NetworkManager激活失败时附带状态变化原因，便于客户端区分密码错误、找不到网络等情况
+		var activationErr *service.NMActivationError
+		if errors.As(err, &activationErr) {
+			c.JSON(http.StatusBadGateway, gin.H{
+				"error":      err.Error(),
+				"activation": activationErr,
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -284,15 +1167,15 @@ func (h *NetworkHandler) ConfigureIPv6(c *gin.Context) {
 		return
 	}
 
-	err := h.networkService.ConfigureInterface(name, models.InterfaceConfig{
+	config := models.InterfaceConfig{
 		IPv6Config: request.IPv6Config,
-	})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+	}
+
+	// dry_run=true 时只返回执行计划，不修改系统
+	if c.Query("dry_run") == "true" {
+		h.planInterface(c, name, config)
 		return
 	}
 
-	c.Status(http.StatusOK)
+	h.applyInterfaceConfig(c, name, config)
 }
\ No newline at end of file
@@ -7,6 +7,8 @@ import (
 	"networkconfig/models"
 	"networkconfig/service"
 	"os"
+	"strings"
+	"time"
 )
 
 func main() {
@@ -16,15 +18,31 @@ func main() {
 
 	// 定义子命令
 	statusCmd := flag.NewFlagSet("status", flag.ExitOnError)
+	showQR := statusCmd.Bool("qr", false, "显示扫码连接热点的二维码")
 
 	enableCmd := flag.NewFlagSet("enable", flag.ExitOnError)
 
 	disableCmd := flag.NewFlagSet("disable", flag.ExitOnError)
 
+	clientsCmd := flag.NewFlagSet("clients", flag.ExitOnError)
+
+	disconnectCmd := flag.NewFlagSet("disconnect", flag.ExitOnError)
+	disconnectMAC := disconnectCmd.String("mac", "", "客户端MAC地址")
+
+	aclCmd := flag.NewFlagSet("acl", flag.ExitOnError)
+	aclMode := aclCmd.String("mode", "", "过滤模式: off、allow或deny，不指定时只显示当前列表")
+	aclAllow := aclCmd.String("allow", "", "允许列表，逗号分隔的MAC地址")
+	aclDeny := aclCmd.String("deny", "", "拒绝列表，逗号分隔的MAC地址")
+
 	configureCmd := flag.NewFlagSet("configure", flag.ExitOnError)
 	ssid := configureCmd.String("ssid", "", "热点SSID名称")
 	password := configureCmd.String("password", "", "热点密码")
 	autoEnable := configureCmd.Bool("enable", false, "配置后自动启用热点")
+	band := configureCmd.String("band", "", "频段: auto、2.4GHz、5GHz或6GHz")
+	channel := configureCmd.Int("channel", 0, "信道，需同时指定-band")
+	security := configureCmd.String("security", "", "安全模式: wpa2、wpa3或wpa3-transition")
+	maxClients := configureCmd.Int("max-clients", 0, "最大客户端数")
+	uplink := configureCmd.String("uplink", "", "共享上网的上行网卡: auto、none或网卡名(仅Linux)")
 
 	// 检查命令行参数
 	if len(os.Args) < 2 {
@@ -44,6 +62,18 @@ func main() {
 			log.Fatalf("获取热点状态失败: %v", err)
 		}
 		printHotspotStatus(status)
+		if *showQR {
+			payload, err := networkService.HotspotQRPayload()
+			if err != nil {
+				log.Fatalf("生成热点二维码失败: %v", err)
+			}
+			qr, err := service.RenderQRCodeTerminal(payload)
+			if err != nil {
+				log.Fatalf("生成热点二维码失败: %v", err)
+			}
+			fmt.Println("\n用手机相机扫描二维码连接热点:")
+			fmt.Print(qr)
+		}
 
 	case "enable":
 		enableCmd.Parse(os.Args[2:])
@@ -59,6 +89,42 @@ func main() {
 		}
 		fmt.Println("热点已成功禁用")
 
+	case "clients":
+		clientsCmd.Parse(os.Args[2:])
+		clients, err := networkService.GetHotspotClients()
+		if err != nil {
+			log.Fatalf("获取热点客户端失败: %v", err)
+		}
+		printHotspotClients(clients)
+
+	case "disconnect":
+		disconnectCmd.Parse(os.Args[2:])
+		if *disconnectMAC == "" {
+			fmt.Println("错误: 必须提供客户端MAC地址")
+			disconnectCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		if err := networkService.DisconnectHotspotClient(*disconnectMAC); err != nil {
+			log.Fatalf("断开客户端失败: %v", err)
+		}
+		fmt.Println("客户端已断开")
+
+	case "acl":
+		aclCmd.Parse(os.Args[2:])
+		acl := networkService.GetHotspotACL()
+		if *aclMode != "" {
+			acl = models.HotspotACL{
+				Mode:  *aclMode,
+				Allow: splitList(*aclAllow),
+				Deny:  splitList(*aclDeny),
+			}
+			var err error
+			if acl, err = networkService.SetHotspotACL(acl); err != nil {
+				log.Fatalf("设置过滤列表失败: %v", err)
+			}
+		}
+		printHotspotACL(acl)
+
 	case "configure":
 		configureCmd.Parse(os.Args[2:])
 		if *ssid == "" || *password == "" {
@@ -68,9 +134,14 @@ func main() {
 		}
 
 		config := models.HotspotConfig{
-			SSID:     *ssid,
-			Password: *password,
-			Enabled:  *autoEnable,
+			SSID:       *ssid,
+			Password:   *password,
+			Enabled:    *autoEnable,
+			Band:       *band,
+			Channel:    *channel,
+			Security:   *security,
+			MaxClients: *maxClients,
+			Uplink:     *uplink,
 		}
 
 		if err := networkService.ConfigureHotspot(config); err != nil {
@@ -86,10 +157,13 @@ func main() {
 
 func printUsage() {
 	fmt.Println("使用方法:")
-	fmt.Println("  hotspot status                           - 获取热点状态")
+	fmt.Println("  hotspot status [--qr]                    - 获取热点状态，--qr显示扫码连接的二维码")
 	fmt.Println("  hotspot enable                           - 启用热点")
 	fmt.Println("  hotspot disable                          - 禁用热点")
-	fmt.Println("  hotspot configure -ssid NAME -password PWD [-enable] - 配置热点")
+	fmt.Println("  hotspot clients                          - 列出已连接的客户端")
+	fmt.Println("  hotspot disconnect -mac MAC              - 断开指定客户端")
+	fmt.Println("  hotspot acl [-mode off|allow|deny -allow MACS -deny MACS] - 查看或设置MAC地址过滤列表")
+	fmt.Println("  hotspot configure -ssid NAME -password PWD [-enable] [-band BAND] [-channel N] [-security MODE] [-max-clients N] [-uplink IFACE] - 配置热点")
 }
 
 func printHotspotStatus(status models.HotspotStatus) {
@@ -100,4 +174,49 @@ func printHotspotStatus(status models.HotspotStatus) {
 	fmt.Printf("  加密方式: %s\n", status.Encryption)
 	fmt.Printf("  最大客户端数: %d\n", status.MaxClientCount)
 	fmt.Printf("  当前连接客户端数: %d\n", status.ClientsCount)
+	if sharing := status.Sharing; sharing != nil {
+		fmt.Printf("  共享上网: %s (上行网卡: %s, IP转发: %s)\n", map[bool]string{true: "已开启", false: "未开启"}[sharing.Enabled],
+			orDash(sharing.Uplink), map[bool]string{true: "开启", false: "关闭"}[sharing.Forwarding])
+		if sharing.Error != "" {
+			fmt.Printf("  共享上网错误: %s\n", sharing.Error)
+		}
+	}
+}
+
+func printHotspotClients(clients []models.HotspotClient) {
+	if len(clients) == 0 {
+		fmt.Println("当前没有客户端连接")
+		return
+	}
+	fmt.Printf("已连接客户端 (%d):\n", len(clients))
+	fmt.Printf("  %-17s  %-15s  %-20s  %-24s  %s\n", "MAC", "IP", "主机名", "厂商", "连接时长")
+	for _, client := range clients {
+		fmt.Printf("  %-17s  %-15s  %-20s  %-24s  %s\n", client.MAC, orDash(client.IP), orDash(client.Hostname),
+			orDash(client.Vendor), time.Duration(client.ConnectedSeconds)*time.Second)
+	}
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func printHotspotACL(acl models.HotspotACL) {
+	fmt.Println("MAC地址过滤列表:")
+	fmt.Printf("  模式: %s\n", acl.Mode)
+	fmt.Printf("  允许: %s\n", orDash(strings.Join(acl.Allow, ", ")))
+	fmt.Printf("  拒绝: %s\n", orDash(strings.Join(acl.Deny, ", ")))
+}
+
+// splitList 拆分逗号分隔的列表，忽略空项
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"networkconfig/models"
+	"networkconfig/service"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+func main() {
+	// 设置日志输出
+	log.SetOutput(os.Stdout)
+	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+
+	// 定义子命令
+	showCmd := flag.NewFlagSet("show", flag.ExitOnError)
+	showName := showCmd.String("name", "", "网卡名称")
+
+	ipv4Cmd := flag.NewFlagSet("ipv4", flag.ExitOnError)
+	ipv4Name := ipv4Cmd.String("name", "", "网卡名称")
+	ipv4DHCP := ipv4Cmd.Bool("dhcp", false, "使用DHCP自动获取IP")
+	ipv4IP := ipv4Cmd.String("ip", "", "静态IPv4地址")
+	ipv4Mask := ipv4Cmd.String("mask", "255.255.255.0", "子网掩码")
+	ipv4Address := ipv4Cmd.String("address", "", "CIDR形式的地址，多个用逗号分隔，第一个为主地址")
+	ipv4Gateway := ipv4Cmd.String("gateway", "", "默认网关")
+	ipv4DNS := ipv4Cmd.String("dns", "", "DNS服务器，多个用逗号分隔")
+	ipv4DNSAuto := ipv4Cmd.Bool("dns-auto", false, "自动获取DNS")
+	ipv4DryRun := ipv4Cmd.Bool("dry-run", false, "只输出执行计划，不修改系统")
+	ipv4Validate := ipv4Cmd.Bool("validate", false, "只校验配置，不修改系统")
+
+	ipv6Cmd := flag.NewFlagSet("ipv6", flag.ExitOnError)
+	ipv6Name := ipv6Cmd.String("name", "", "网卡名称")
+	ipv6IP := ipv6Cmd.String("ip", "", "IPv6地址")
+	ipv6Prefix := ipv6Cmd.Int("prefix", 64, "前缀长度")
+	ipv6Address := ipv6Cmd.String("address", "", "CIDR形式的地址，多个用逗号分隔，第一个为主地址")
+	ipv6Gateway := ipv6Cmd.String("gateway", "", "默认网关")
+	ipv6DNS := ipv6Cmd.String("dns", "", "DNS服务器，多个用逗号分隔")
+	ipv6DryRun := ipv6Cmd.Bool("dry-run", false, "只输出执行计划，不修改系统")
+	ipv6Validate := ipv6Cmd.Bool("validate", false, "只校验配置，不修改系统")
+
+	addrAddCmd := flag.NewFlagSet("addr-add", flag.ExitOnError)
+	addrAddName := addrAddCmd.String("name", "", "网卡名称")
+	addrAddAddress := addrAddCmd.String("address", "", "CIDR形式的附加地址")
+
+	addrDelCmd := flag.NewFlagSet("addr-del", flag.ExitOnError)
+	addrDelName := addrDelCmd.String("name", "", "网卡名称")
+	addrDelIP := addrDelCmd.String("ip", "", "要删除的地址(不带前缀长度)")
+
+	snapshotCmd := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	snapshotOut := snapshotCmd.String("o", "", "输出文件，为空时输出到标准输出")
+	snapshotFormat := snapshotCmd.String("format", "json", "输出格式(json/yaml)")
+
+	restoreCmd := flag.NewFlagSet("restore", flag.ExitOnError)
+	restoreFile := restoreCmd.String("f", "", "快照文件(.json/.yaml)")
+
+	// 检查命令行参数
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	// 创建网络服务
+	networkService := service.NewNetworkService(false)
+
+	// 解析子命令
+	switch os.Args[1] {
+	case "show":
+		showCmd.Parse(os.Args[2:])
+		requireName(showCmd, *showName)
+		iface, err := networkService.GetInterface(*showName)
+		if err != nil {
+			log.Fatalf("获取网卡信息失败: %v", err)
+		}
+		printJSON(iface)
+
+	case "ipv4":
+		ipv4Cmd.Parse(os.Args[2:])
+		requireName(ipv4Cmd, *ipv4Name)
+		if !*ipv4DHCP && *ipv4IP == "" && *ipv4Address == "" {
+			fmt.Println("错误: 静态配置必须提供 -ip 或 -address，或使用 -dhcp")
+			ipv4Cmd.PrintDefaults()
+			os.Exit(1)
+		}
+
+		config := models.InterfaceConfig{
+			IPv4Config: &models.IPv4Config{
+				DHCP:      *ipv4DHCP,
+				IP:        *ipv4IP,
+				Mask:      *ipv4Mask,
+				Addresses: splitList(*ipv4Address),
+				Gateway:   *ipv4Gateway,
+				DNS:       splitList(*ipv4DNS),
+				DNSAuto:   *ipv4DNSAuto,
+			},
+		}
+		if *ipv4Validate {
+			validate(networkService, *ipv4Name, config)
+			return
+		}
+		configure(networkService, *ipv4Name, config, *ipv4DryRun)
+
+	case "ipv6":
+		ipv6Cmd.Parse(os.Args[2:])
+		requireName(ipv6Cmd, *ipv6Name)
+		if *ipv6IP == "" && *ipv6Address == "" {
+			fmt.Println("错误: 必须提供 -ip 或 -address")
+			ipv6Cmd.PrintDefaults()
+			os.Exit(1)
+		}
+
+		config := models.InterfaceConfig{
+			IPv6Config: &models.IPv6Config{
+				IP:        *ipv6IP,
+				PrefixLen: *ipv6Prefix,
+				Addresses: splitList(*ipv6Address),
+				Gateway:   *ipv6Gateway,
+				DNS:       splitList(*ipv6DNS),
+			},
+		}
+		if *ipv6Validate {
+			validate(networkService, *ipv6Name, config)
+			return
+		}
+		configure(networkService, *ipv6Name, config, *ipv6DryRun)
+
+	case "addr-add":
+		addrAddCmd.Parse(os.Args[2:])
+		requireName(addrAddCmd, *addrAddName)
+		if *addrAddAddress == "" {
+			fmt.Println("错误: 必须提供 -address")
+			addrAddCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		iface, err := networkService.AddInterfaceAddress(*addrAddName, *addrAddAddress)
+		if err != nil {
+			exitOnValidationErrors(err)
+			log.Fatalf("添加地址失败: %v", err)
+		}
+		printAddresses(iface.Addresses)
+
+	case "addr-del":
+		addrDelCmd.Parse(os.Args[2:])
+		requireName(addrDelCmd, *addrDelName)
+		if *addrDelIP == "" {
+			fmt.Println("错误: 必须提供 -ip")
+			addrDelCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		iface, err := networkService.RemoveInterfaceAddress(*addrDelName, *addrDelIP)
+		if err != nil {
+			exitOnValidationErrors(err)
+			log.Fatalf("删除地址失败: %v", err)
+		}
+		printAddresses(iface.Addresses)
+
+	case "snapshot":
+		snapshotCmd.Parse(os.Args[2:])
+		if *snapshotOut == "" {
+			// 快照输出到标准输出时，日志改写到标准错误
+			log.SetOutput(os.Stderr)
+		}
+		snapshot, err := networkService.ExportSnapshot()
+		if err != nil {
+			log.Fatalf("导出快照失败: %v", err)
+		}
+		data, err := encodeSnapshot(snapshot, *snapshotFormat)
+		if err != nil {
+			log.Fatalf("序列化快照失败: %v", err)
+		}
+		if *snapshotOut == "" {
+			fmt.Println(string(data))
+			return
+		}
+		if err := os.WriteFile(*snapshotOut, data, 0600); err != nil {
+			log.Fatalf("写入快照文件失败: %v", err)
+		}
+		fmt.Printf("快照已保存到 %s\n", *snapshotOut)
+
+	case "restore":
+		restoreCmd.Parse(os.Args[2:])
+		if *restoreFile == "" {
+			fmt.Println("错误: 必须提供快照文件")
+			restoreCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		data, err := os.ReadFile(*restoreFile)
+		if err != nil {
+			log.Fatalf("读取快照文件失败: %v", err)
+		}
+		var snapshot models.NetworkSnapshot
+		if strings.HasSuffix(*restoreFile, ".yaml") || strings.HasSuffix(*restoreFile, ".yml") {
+			err = yaml.Unmarshal(data, &snapshot)
+		} else {
+			err = json.Unmarshal(data, &snapshot)
+		}
+		if err != nil {
+			log.Fatalf("解析快照文件失败: %v", err)
+		}
+		result, err := networkService.RestoreSnapshot(snapshot)
+		if err != nil {
+			log.Fatalf("恢复快照失败: %v", err)
+		}
+		printRestoreResult(result)
+		if !result.Success {
+			os.Exit(1)
+		}
+
+	default:
+		printUsage()
+		os.Exit(1)
+	}
+}
+
+// encodeSnapshot 按指定格式序列化快照
+func encodeSnapshot(snapshot models.NetworkSnapshot, format string) ([]byte, error) {
+	if strings.EqualFold(format, "yaml") {
+		return yaml.Marshal(snapshot)
+	}
+	return json.MarshalIndent(snapshot, "", "  ")
+}
+
+// configure 应用配置，dryRun为true时只打印执行计划
+func configure(networkService *service.NetworkService, name string, config models.InterfaceConfig, dryRun bool) {
+	if dryRun {
+		plan, err := networkService.PlanInterface(name, config)
+		if err != nil {
+			exitOnValidationErrors(err)
+			log.Fatalf("生成执行计划失败: %v", err)
+		}
+		printPlan(plan)
+		return
+	}
+
+	if err := networkService.ConfigureInterface(name, config); err != nil {
+		exitOnValidationErrors(err)
+		log.Fatalf("配置网卡失败: %v", err)
+	}
+	fmt.Println("网卡配置成功")
+}
+
+// validate 只校验配置并输出结果
+func validate(networkService *service.NetworkService, name string, config models.InterfaceConfig) {
+	if errs := networkService.ValidateInterfaceConfig(name, config); len(errs) > 0 {
+		exitOnValidationErrors(errs)
+	}
+	fmt.Println("配置校验通过")
+}
+
+// exitOnValidationErrors 如果err是校验错误则逐项输出并退出
+func exitOnValidationErrors(err error) {
+	var errs service.ValidationErrors
+	if !errors.As(err, &errs) {
+		return
+	}
+	fmt.Println("配置校验失败:")
+	for _, item := range errs {
+		fmt.Printf("  %s [%s]: %s\n", item.Field, item.Code, item.Message)
+	}
+	os.Exit(1)
+}
+
+func requireName(cmd *flag.FlagSet, name string) {
+	if name == "" {
+		fmt.Println("错误: 必须提供网卡名称")
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+}
+
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func printJSON(v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Fatalf("序列化输出失败: %v", err)
+	}
+	fmt.Println(string(data))
+}
+
+func printUsage() {
+	fmt.Println("使用方法:")
+	fmt.Println("  netconfig show -name NAME                                    - 查看网卡配置")
+	fmt.Println("  netconfig ipv4 -name NAME -dhcp [-dns-auto] [-dry-run|-validate] - 使用DHCP")
+	fmt.Println("  netconfig ipv4 -name NAME -ip IP [-mask M] [-gateway GW] [-dns D1,D2] [-dry-run|-validate] - 静态IPv4")
+	fmt.Println("  netconfig ipv4 -name NAME -address CIDR[,CIDR] [-gateway GW] [-dns D1,D2] [-dry-run|-validate] - 静态IPv4(CIDR)")
+	fmt.Println("  netconfig ipv6 -name NAME -ip IP [-prefix N] [-gateway GW] [-dns D1,D2] [-dry-run|-validate] - 静态IPv6")
+	fmt.Println("  netconfig ipv6 -name NAME -address CIDR[,CIDR] [-gateway GW] [-dns D1,D2] [-dry-run|-validate] - 静态IPv6(CIDR)")
+	fmt.Println("  netconfig addr-add -name NAME -address CIDR                  - 添加附加地址")
+	fmt.Println("  netconfig addr-del -name NAME -ip IP                         - 删除附加地址")
+	fmt.Println("  netconfig snapshot [-o FILE] [-format json|yaml]             - 导出网络配置快照")
+	fmt.Println("  netconfig restore -f FILE                                    - 从快照恢复网络配置")
+}
+
+func printAddresses(addresses []models.InterfaceAddress) {
+	fmt.Println("网卡地址:")
+	for _, addr := range addresses {
+		primary := ""
+		if addr.Primary {
+			primary = " (主地址)"
+		}
+		fmt.Printf("  %-45s %-5s %-10s %s%s\n", addr.Address, addr.Family, addr.Scope, addr.Origin, primary)
+	}
+}
+
+func printRestoreResult(result models.SnapshotRestoreResult) {
+	fmt.Printf("快照恢复结果(版本 %d):\n", result.Version)
+	for _, item := range result.Items {
+		state := "失败"
+		if item.Skipped {
+			state = "跳过"
+		} else if item.Success {
+			state = "成功"
+		}
+		if item.Message != "" {
+			fmt.Printf("  [%s] %s %s: %s\n", state, item.Kind, item.Name, item.Message)
+		} else {
+			fmt.Printf("  [%s] %s %s\n", state, item.Kind, item.Name)
+		}
+	}
+}
+
+func printPlan(plan models.ConfigPlan) {
+	fmt.Printf("接口 %s 的执行计划(后端: %s):\n", plan.Interface, plan.Backend)
+	fmt.Println("  变更:")
+	for _, change := range plan.Changes {
+		fmt.Printf("    %s: %q -> %q\n", change.Field, change.Before, change.After)
+	}
+	fmt.Println("  将执行的命令:")
+	for i, step := range plan.Steps {
+		if step.Description != "" {
+			fmt.Printf("    %d. %s  # %s\n", i+1, step.Command, step.Description)
+		} else {
+			fmt.Printf("    %d. %s\n", i+1, step.Command)
+		}
+	}
+	if len(plan.Warnings) > 0 {
+		fmt.Println("  警告:")
+		for _, warning := range plan.Warnings {
+			fmt.Printf("    - %s\n", warning)
+		}
+	}
+}
@@ -9,12 +9,11 @@ import (
 	"networkconfig/api"
 	"networkconfig/service"
 	"os"
-	"os/exec"
+	"runtime"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
-	"golang.org/x/sys/windows"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
@@ -49,8 +48,10 @@ func main() {
 		port        string
 		debug       bool
 		showVersion bool
+		backendName string
 	)
 	flag.StringVar(&port, "port", "", "服务器监听端口")
+	flag.StringVar(&backendName, "backend", "", "平台后端(windows/linux/networkmanager)，默认按操作系统自动选择")
 	flag.BoolVar(&debug, "debug", false, "启用调试模式(不过滤网卡)")
 	flag.BoolVar(&showVersion, "v", false, "显示版本信息")
 	flag.Parse()
@@ -65,7 +66,7 @@ func main() {
 	}
 
 	// 如果没有命令行参数，尝试从.env读取
-	if port == "" || !debug {
+	if port == "" || !debug || backendName == "" {
 		_ = godotenv.Load() // 忽略错误，文件不存在也没关系
 		if port == "" {
 			port = os.Getenv("NETWORK_CONFIG_PORT")
@@ -73,36 +74,63 @@ func main() {
 		if !debug {
 			debug = os.Getenv("NETWORK_CONFIG_DEBUG") == "true"
 		}
+		if backendName == "" {
+			backendName = os.Getenv("NETWORK_CONFIG_BACKEND")
+		}
 	}
 
 	// 检查管理员权限
 	if !isAdmin() {
-		log.Fatal("此程序需要管理员权限运行。请右键点击程序，选择'以管理员身份运行'。")
+		log.Fatal(adminRequiredMessage)
+	}
+
+	// 创建命令执行器，可通过环境变量录制真实命令输出或回放录制结果
+	runner, err := newCommandRunner()
+	if err != nil {
+		log.Fatalf("创建命令执行器失败: %v", err)
 	}
 
 	// 检查PowerShell执行策略
-	cmd := exec.Command("powershell", "-Command", "Get-ExecutionPolicy")
-	output, err := cmd.CombinedOutput()
-	if err == nil {
-		policy := strings.TrimSpace(string(output))
-		log.Printf("当前PowerShell执行策略: %s", policy)
-		if policy == "Restricted" {
-			log.Println("警告: PowerShell执行策略为Restricted，可能影响热点管理功能。建议使用管理员权限运行以下命令：")
-			log.Println("Set-ExecutionPolicy -Scope CurrentUser -ExecutionPolicy RemoteSigned")
+	if runtime.GOOS == "windows" {
+		cmd := service.NewCommand(runner, "powershell", "-Command", "Get-ExecutionPolicy")
+		output, err := cmd.CombinedOutput()
+		if err == nil {
+			policy := strings.TrimSpace(string(output))
+			log.Printf("当前PowerShell执行策略: %s", policy)
+			if policy == "Restricted" {
+				log.Println("警告: PowerShell执行策略为Restricted，可能影响热点管理功能。建议使用管理员权限运行以下命令：")
+				log.Println("Set-ExecutionPolicy -Scope CurrentUser -ExecutionPolicy RemoteSigned")
+			}
 		}
 	}
 
-	// 创建服务实例
-	networkService := service.NewNetworkService(debug)
+	// 创建平台后端和服务实例
+	backend, err := service.NewBackendWithRunner(backendName, debug, runner)
+	if err != nil {
+		log.Fatalf("创建平台后端失败: %v", err)
+	}
+	networkService := service.NewNetworkServiceWithBackend(backend, debug)
 	networkHandler := api.NewNetworkHandler(networkService)
 
 	if debug {
 		log.Println("警告: 调试模式已启用，网卡列表将不过滤")
 	}
 
-	// 启动热点监控服务
-	networkService.StartHotspotMonitor()
-	defer networkService.StopHotspotMonitor()
+	// 启动看门狗(热点监控等)
+	networkService.StartWatchdogs()
+	defer networkService.StopWatchdogs()
+
+	// 启动期望状态调和服务
+	networkService.StartReconciler()
+	defer networkService.StopReconciler()
+
+	// 启动热点计划任务服务
+	networkService.StartScheduler()
+	defer networkService.StopScheduler()
+
+	// 启动内置DHCP服务
+	networkService.StartDHCPServer()
+	defer networkService.StopDHCPServer()
 
 	// 设置gin模式
 	gin.SetMode(gin.ReleaseMode)
@@ -152,40 +180,17 @@ func main() {
 	}
 }
 
-// isAdmin 检查当前用户是否具有管理员权限
-func isAdmin() bool {
-	// 使用windows包提供的API检查管理员权限
-	var sid *windows.SID
-	err := windows.AllocateAndInitializeSid(
-		&windows.SECURITY_NT_AUTHORITY,
-		2,
-		windows.SECURITY_BUILTIN_DOMAIN_RID,
-		windows.DOMAIN_ALIAS_RID_ADMINS,
-		0, 0, 0, 0, 0, 0,
-		&sid)
-	if err != nil {
-		log.Printf("初始化SID失败: %v", err)
-		// 回退到物理驱动器检查
-		if _, err := os.Open("\\\\.\\PHYSICALDRIVE0"); err == nil {
-			return true
-		}
-		return false
+// newCommandRunner 根据NETWORK_CONFIG_REPLAY_DIR/NETWORK_CONFIG_RECORD_DIR创建命令执行器
+func newCommandRunner() (service.CommandRunner, error) {
+	if dir := os.Getenv("NETWORK_CONFIG_REPLAY_DIR"); dir != "" {
+		log.Printf("警告: 回放模式已启用，所有系统命令将从 %s 回放，不会真正执行", dir)
+		return service.NewReplayRunner(dir)
 	}
-	defer windows.FreeSid(sid)
-
-	// 检查当前进程令牌
-	token := windows.Token(0)
-	member, err := token.IsMember(sid)
-	if err != nil {
-		log.Printf("检查令牌成员关系失败: %v", err)
-		// 回退到物理驱动器检查
-		if _, err := os.Open("\\\\.\\PHYSICALDRIVE0"); err == nil {
-			return true
-		}
-		return false
+	if dir := os.Getenv("NETWORK_CONFIG_RECORD_DIR"); dir != "" {
+		log.Printf("录制模式已启用，系统命令输出将保存到 %s", dir)
+		return service.NewRecordingRunner(service.ExecRunner{}, dir)
 	}
-
-	return member
+	return service.ExecRunner{}, nil
 }
 
 // corsMiddleware 处理跨域请求
@@ -1,16 +1,19 @@
 package models
 
+import "time"
+
 // Interface 表示网卡信息
 type Interface struct {
-	Name          string     `json:"name"`
-	Description   string     `json:"description"`
-	Status        string     `json:"status"`
-	ConnectedSSID string     `json:"connected_ssid,omitempty"` // 当前连接的WiFi热点SSID(仅无线网卡有效)
-	DHCPEnabled   bool       `json:"dhcp_enabled"`
-	IPv4Config    IPv4Config `json:"ipv4_config"`
-	IPv6Config    IPv6Config `json:"ipv6_config"`
-	Hardware      Hardware   `json:"hardware"`
-	Driver        Driver     `json:"driver"`
+	Name          string             `json:"name"`
+	Description   string             `json:"description"`
+	Status        string             `json:"status"`
+	ConnectedSSID string             `json:"connected_ssid,omitempty"` // 当前连接的WiFi热点SSID(仅无线网卡有效)
+	DHCPEnabled   bool               `json:"dhcp_enabled"`
+	IPv4Config    IPv4Config         `json:"ipv4_config"`
+	IPv6Config    IPv6Config         `json:"ipv6_config"`
+	Addresses     []InterfaceAddress `json:"addresses"` // 网卡上的全部地址
+	Hardware      Hardware           `json:"hardware"`
+	Driver        Driver             `json:"driver"`
 }
 
 // Hardware 表示网卡硬件信息
@@ -42,21 +45,61 @@ type Driver struct {
 }
 
 // IPv4Config 表示IPv4配置信息
+// Address/Addresses使用CIDR形式，与IP/Mask等价，校验时会互相补全
 type IPv4Config struct {
-	IP      string   `json:"ip"`
-	Mask    string   `json:"mask"`
-	Gateway string   `json:"gateway"`
-	DNS     []string `json:"dns"`
-	DHCP    bool     `json:"dhcp"`
-	DNSAuto bool     `json:"dnsAuto"`
+	IP        string   `json:"ip" yaml:"ip"`
+	Mask      string   `json:"mask" yaml:"mask"`
+	Address   string   `json:"address,omitempty" yaml:"address,omitempty"`     // 主地址，如10.0.0.5/24
+	Addresses []string `json:"addresses,omitempty" yaml:"addresses,omitempty"` // 全部地址，第一个为主地址
+	Gateway   string   `json:"gateway" yaml:"gateway"`
+	DNS       []string `json:"dns" yaml:"dns"`
+	DHCP      bool     `json:"dhcp" yaml:"dhcp"`
+	DNSAuto   bool     `json:"dnsAuto" yaml:"dnsAuto"`
 }
 
 // IPv6Config 表示IPv6配置信息
+// Address/Addresses使用CIDR形式，与IP/PrefixLen等价，校验时会互相补全
 type IPv6Config struct {
-	IP        string   `json:"ip"`
-	PrefixLen int      `json:"prefix_len"`
-	Gateway   string   `json:"gateway"`
-	DNS       []string `json:"dns"`
+	IP        string   `json:"ip" yaml:"ip"`
+	PrefixLen int      `json:"prefix_len" yaml:"prefix_len"`
+	Address   string   `json:"address,omitempty" yaml:"address,omitempty"`     // 主地址，如2001:db8::5/64
+	Addresses []string `json:"addresses,omitempty" yaml:"addresses,omitempty"` // 全部地址，第一个为主地址
+	Gateway   string   `json:"gateway" yaml:"gateway"`
+	DNS       []string `json:"dns" yaml:"dns"`
+}
+
+// 地址族
+const (
+	AddressFamilyIPv4 = "ipv4"
+	AddressFamilyIPv6 = "ipv6"
+)
+
+// 地址作用域
+const (
+	AddressScopeHost      = "host"       // 回环地址
+	AddressScopeLinkLocal = "link-local" // 链路本地地址
+	AddressScopeGlobal    = "global"     // 全局地址(含私有地址)
+)
+
+// 地址来源
+const (
+	AddressOriginStatic    = "static"    // 手动配置
+	AddressOriginDHCP      = "dhcp"      // DHCP/DHCPv6分配
+	AddressOriginSLAAC     = "slaac"     // IPv6无状态自动配置
+	AddressOriginTemporary = "temporary" // IPv6临时(隐私)地址
+	AddressOriginLink      = "link"      // 系统自动生成的链路本地地址
+	AddressOriginUnknown   = "unknown"
+)
+
+// InterfaceAddress 表示网卡上的一个地址
+type InterfaceAddress struct {
+	Address   string `json:"address"`    // CIDR形式，如10.0.0.5/24
+	IP        string `json:"ip"`         // 不含前缀的地址
+	PrefixLen int    `json:"prefix_len"` // 前缀长度
+	Family    string `json:"family"`     // 地址族: ipv4/ipv6
+	Scope     string `json:"scope"`      // 作用域: host/link-local/global
+	Origin    string `json:"origin"`     // 来源: static/dhcp/slaac/temporary/link/unknown
+	Primary   bool   `json:"primary"`    // 是否为该地址族的主地址，即ipv4_config/ipv6_config中的地址
 }
 
 // InterfaceConfig 表示网卡配置请求
@@ -74,23 +117,85 @@ type ConnectivityResult struct {
 	Error      string `json:"error"`       // 错误信息(成功时为"")
 }
 
-// WiFiHotspot 表示可连接的WIFI热点信息
+// WiFiHotspot 表示扫描到的一个WiFi接入点(BSS)，各平台的扫描结果统一使用此结构
 type WiFiHotspot struct {
-	SSID         string `json:"ssid"`          // 热点名称
-	BSSID        string `json:"bssid"`         // 热点MAC地址
-	SignalLevel  int    `json:"signal_level"`  // 信号强度(百分比)
-	Channel      int    `json:"channel"`       // 信道
-	SecurityType string `json:"security_type"` // 加密类型(WPA2等)
-	IsConnected  bool   `json:"is_connected"`  // 是否已连接
-	Frequency    int    `json:"frequency"`     // 频率(MHz)
-	RadioType    string `json:"radio_type"`    // 无线类型(802.11ac等)
+	SSID           string       `json:"ssid"`                    // 热点名称，隐藏网络为空
+	BSSID          string       `json:"bssid"`                   // 接入点MAC地址
+	SignalStrength int          `json:"signal_strength"`         // 信号强度(百分比)
+	SignalDBm      int          `json:"signal_dbm"`              // 信号强度(dBm)，平台只提供百分比时为估算值
+	Frequency      int          `json:"frequency"`               // 中心频率(MHz)
+	Band           string       `json:"band"`                    // 频段(2.4GHz/5GHz/6GHz)
+	Channel        int          `json:"channel"`                 // 主信道
+	ChannelWidth   int          `json:"channel_width,omitempty"` // 信道宽度(MHz)，未知时为0
+	PHYType        string       `json:"phy_type,omitempty"`      // 无线标准(802.11n/ac/ax等)，未知时为空
+	Rate           int          `json:"rate"`                    // 最大速率(Mbit/s)，未知时为0
+	Security       string       `json:"security"`                // 加密类型摘要，如"WPA2 WPA3"，开放网络为空
+	SecurityInfo   WiFiSecurity `json:"security_info"`           // 加密方式详情
+	Connected      bool         `json:"connected"`               // 是否为当前连接的接入点
 }
 
-// HotspotConfig 表示移动热点配置信息
+// WiFiSecurity 表示接入点的加密方式详情，无法获取的项为空
+type WiFiSecurity struct {
+	AKM         []string `json:"akm"`                    // 认证套件，如psk、sae、802.1x、owe；WEP网络为wep，开放网络为空
+	Ciphers     []string `json:"ciphers"`                // 成对加密算法，如ccmp、tkip、gcmp-256
+	GroupCipher string   `json:"group_cipher,omitempty"` // 组播加密算法
+	PMF         string   `json:"pmf,omitempty"`          // 管理帧保护: required/capable，未知或不支持时为空
+	WPS         bool     `json:"wps"`                    // 是否开启WPS
+}
+
+// WiFiNetwork 表示同一SSID和加密类型下的一组接入点
+type WiFiNetwork struct {
+	SSID           string        `json:"ssid"`
+	Security       string        `json:"security"`
+	SignalStrength int           `json:"signal_strength"` // 信号最强的接入点的信号强度(百分比)
+	SignalDBm      int           `json:"signal_dbm"`      // 信号最强的接入点的信号强度(dBm)
+	Bands          []string      `json:"bands"`           // 接入点覆盖的频段
+	Connected      bool          `json:"connected"`       // 是否连接了其中一个接入点
+	AccessPoints   []WiFiHotspot `json:"access_points"`   // 按排序条件排列的接入点
+}
+
+// WiFi扫描结果的排序字段
+const (
+	WiFiSortSignal    = "signal"    // 信号强度，默认从强到弱
+	WiFiSortSSID      = "ssid"      // SSID，默认按字母顺序
+	WiFiSortChannel   = "channel"   // 信道，默认从小到大
+	WiFiSortFrequency = "frequency" // 频率，默认从低到高
+)
+
+// WiFiScanQuery 表示WiFi扫描结果的排序和过滤条件，条件为空表示不过滤
+type WiFiScanQuery struct {
+	Sort      string   // 排序字段，默认signal
+	Order     string   // asc或desc，为空时使用排序字段的默认方向
+	MinSignal int      // 最小信号强度(百分比)
+	Bands     []string // 只保留这些频段
+	Security  []string // 只保留包含任一加密类型的热点: open、wep、wpa1、wpa2、wpa3、owe、802.1x
+}
+
+// 热点频段
+const (
+	HotspotBandAuto  = "auto"   // 由系统选择
+	HotspotBand24GHz = "2.4GHz" // 2.4GHz
+	HotspotBand5GHz  = "5GHz"   // 5GHz
+	HotspotBand6GHz  = "6GHz"   // 6GHz，只能使用WPA3
+)
+
+// 热点安全模式
+const (
+	HotspotSecurityWPA2           = "wpa2"            // WPA2-Personal
+	HotspotSecurityWPA3           = "wpa3"            // WPA3-Personal(SAE)
+	HotspotSecurityWPA3Transition = "wpa3-transition" // WPA2/WPA3混合模式，兼容只支持WPA2的设备
+)
+
+// HotspotConfig 表示移动热点配置信息，频段、信道、安全模式和最大客户端数为空时使用平台默认值
 type HotspotConfig struct {
-	SSID     string `json:"ssid"`     // 热点名称
-	Password string `json:"password"` // 热点密码
-	Enabled  bool   `json:"enabled"`  // 是否启用
+	SSID       string `json:"ssid" yaml:"ssid"`                                   // 热点名称
+	Password   string `json:"password" yaml:"password"`                           // 热点密码
+	Enabled    bool   `json:"enabled" yaml:"enabled"`                             // 是否启用
+	Band       string `json:"band,omitempty" yaml:"band,omitempty"`               // 频段: auto、2.4GHz、5GHz或6GHz
+	Channel    int    `json:"channel,omitempty" yaml:"channel,omitempty"`         // 信道，需同时指定频段
+	Security   string `json:"security,omitempty" yaml:"security,omitempty"`       // 安全模式: wpa2、wpa3或wpa3-transition
+	MaxClients int    `json:"max_clients,omitempty" yaml:"max_clients,omitempty"` // 最大客户端数
+	Uplink     string `json:"uplink,omitempty" yaml:"uplink,omitempty"`           // 共享上网的上行网卡: auto(默认路由所在网卡)、none或网卡名，仅Linux支持
 }
 
 // HotspotStatus 表示移动热点状态信息
@@ -103,4 +208,331 @@ type HotspotStatus struct {
 	Authentication string `json:"Authentication"` // 认证方式
 	Encryption     string `json:"Encryption"`     // 加密方式
 	ClientsCount   int    `json:"ClientsCount"`   // 当前连接的客户端数
+
+	Sharing *HotspotSharing `json:"Sharing,omitempty"` // 共享上网状态，平台不支持时为空
+}
+
+// HotspotSharing 表示热点共享上网(NAT)的状态
+type HotspotSharing struct {
+	Enabled    bool   `json:"Enabled"`         // NAT规则是否生效
+	Uplink     string `json:"Uplink"`          // 上行网卡
+	Forwarding bool   `json:"Forwarding"`      // 系统IP转发是否开启
+	Error      string `json:"Error,omitempty"` // 共享异常时的错误信息
+}
+
+// HotspotClient 表示连接到移动热点的客户端
+type HotspotClient struct {
+	MAC              string     `json:"mac"`                    // 客户端MAC地址(小写，冒号分隔)
+	IP               string     `json:"ip,omitempty"`           // 分配的IPv4地址(来自DHCP租约或ARP表)
+	Hostname         string     `json:"hostname,omitempty"`     // 客户端主机名
+	Vendor           string     `json:"vendor,omitempty"`       // 按MAC地址OUI查到的厂商
+	ConnectedAt      *time.Time `json:"connected_at,omitempty"` // 连接时间，平台不提供时为服务首次发现该客户端的时间
+	ConnectedSeconds int64      `json:"connected_seconds"`      // 已连接秒数
+}
+
+// 热点MAC地址过滤模式
+const (
+	HotspotACLModeOff   = "off"   // 不过滤
+	HotspotACLModeAllow = "allow" // 只允许allow列表中的设备连接
+	HotspotACLModeDeny  = "deny"  // 拒绝deny列表中的设备连接
+)
+
+// HotspotACL 表示移动热点的MAC地址允许/拒绝列表，两个列表都会保存，mode决定哪一个生效
+type HotspotACL struct {
+	Mode  string   `json:"mode"`  // 过滤模式: off、allow或deny
+	Allow []string `json:"allow"` // 允许列表
+	Deny  []string `json:"deny"`  // 拒绝列表
+}
+
+// 热点计划任务动作
+const (
+	ScheduleActionEnable         = "enable"          // 开启热点
+	ScheduleActionDisable        = "disable"         // 关闭热点
+	ScheduleActionRotatePassword = "rotate_password" // 生成新的随机密码
+)
+
+// 计划动作执行状态
+const (
+	ScheduleStatusPending = "pending" // 尚未执行
+	ScheduleStatusSuccess = "success" // 执行成功
+	ScheduleStatusFailed  = "failed"  // 执行失败
+)
+
+// HotspotSchedule 表示热点计划任务，规则按服务所在时区计算
+type HotspotSchedule struct {
+	Rules []ScheduleRule `json:"rules" yaml:"rules"` // 计划规则
+}
+
+// ScheduleRule 表示一条计划规则
+type ScheduleRule struct {
+	ID             string `json:"id" yaml:"id"`                                               // 规则ID，为空时自动生成
+	Cron           string `json:"cron" yaml:"cron"`                                           // 5段cron表达式(分 时 日 月 周)，如"0 9 * * 1-5"
+	Action         string `json:"action" yaml:"action"`                                       // 动作: enable、disable或rotate_password
+	Disabled       bool   `json:"disabled,omitempty" yaml:"disabled,omitempty"`               // 暂停该规则
+	PasswordLength int    `json:"password_length,omitempty" yaml:"password_length,omitempty"` // rotate_password生成的密码长度(8-63)，默认16
+	Description    string `json:"description,omitempty" yaml:"description,omitempty"`         // 备注
+}
+
+// ScheduledAction 表示一次已执行或将要执行的计划动作
+type ScheduledAction struct {
+	RuleID string    `json:"rule_id"`         // 规则ID
+	Action string    `json:"action"`          // 动作
+	Time   time.Time `json:"time"`            // 计划执行时间
+	Status string    `json:"status"`          // 执行状态: pending、success或failed
+	Error  string    `json:"error,omitempty"` // 失败原因
+}
+
+// ScheduledActions 表示计划动作列表
+type ScheduledActions struct {
+	Upcoming []ScheduledAction `json:"upcoming"` // 即将执行的动作，按时间升序
+	Past     []ScheduledAction `json:"past"`     // 已执行的动作，按时间降序
+}
+
+// DHCPScope 表示绑定在一个网卡上的内置DHCPv4服务地址池
+type DHCPScope struct {
+	Interface    string   `json:"interface" yaml:"interface"`                             // 网卡名称
+	Enabled      bool     `json:"enabled" yaml:"enabled"`                                 // 是否启用
+	RangeStart   string   `json:"range_start" yaml:"range_start"`                         // 地址池起始地址
+	RangeEnd     string   `json:"range_end" yaml:"range_end"`                             // 地址池结束地址
+	SubnetMask   string   `json:"subnet_mask,omitempty" yaml:"subnet_mask,omitempty"`     // 子网掩码，为空时使用网卡地址的掩码
+	Router       string   `json:"router,omitempty" yaml:"router,omitempty"`               // 网关，为空时使用网卡地址
+	DNS          []string `json:"dns,omitempty" yaml:"dns,omitempty"`                     // DNS服务器，为空时使用网卡地址
+	LeaseSeconds int      `json:"lease_seconds,omitempty" yaml:"lease_seconds,omitempty"` // 租期(秒)，默认43200
+	Running      bool     `json:"running" yaml:"-"`                                       // 服务是否正在运行(只读)
+	Error        string   `json:"error,omitempty" yaml:"-"`                               // 启动失败的原因(只读)
+}
+
+// DHCPLease 表示内置DHCP服务分配的一条租约
+type DHCPLease struct {
+	Interface string    `json:"interface"`          // 网卡名称
+	MAC       string    `json:"mac"`                // 客户端MAC地址
+	IP        string    `json:"ip"`                 // 分配的IP地址
+	Hostname  string    `json:"hostname,omitempty"` // 客户端上报的主机名
+	Expires   time.Time `json:"expires"`            // 到期时间
+	Reserved  bool      `json:"reserved"`           // 是否为静态保留地址
+}
+
+// DHCPReservation 表示按MAC地址保留的静态地址，在包含该地址的地址池中生效
+type DHCPReservation struct {
+	MAC         string `json:"mac" yaml:"mac"`                                     // 客户端MAC地址
+	IP          string `json:"ip" yaml:"ip"`                                       // 保留的IP地址
+	Description string `json:"description,omitempty" yaml:"description,omitempty"` // 备注
+}
+
+// 看门狗状态
+const (
+	WatchdogStatePending     = "pending"      // 尚未检查
+	WatchdogStateOK          = "ok"           // 检查正常
+	WatchdogStateFailing     = "failing"      // 检查失败，正在按退避策略恢复
+	WatchdogStateCircuitOpen = "circuit_open" // 连续恢复失败次数达到上限，暂停恢复
+	WatchdogStateStandby     = "standby"      // 期望状态为关闭，不检查也不恢复
+	WatchdogStateError       = "error"        // 无法读取实际状态
+	WatchdogStateDisabled    = "disabled"     // 看门狗未启用
+)
+
+// 看门狗升级事件类型
+const (
+	WatchdogEventCircuitOpen   = "circuit_open"   // 熔断，停止自动恢复
+	WatchdogEventCircuitClosed = "circuit_closed" // 熔断后恢复正常
+)
+
+// WatchdogStatus 表示一个看门狗的运行状态
+type WatchdogStatus struct {
+	Name                string     `json:"name"`                         // 看门狗名称
+	State               string     `json:"state"`                        // 当前状态
+	Enabled             bool       `json:"enabled"`                      // 是否启用
+	AutoRecovery        bool       `json:"auto_recovery"`                // 是否自动恢复
+	IntervalSeconds     int        `json:"interval_seconds"`             // 检查间隔
+	MaxAttempts         int        `json:"max_attempts"`                 // 熔断前最多连续恢复次数，0表示不限
+	LastCheck           *time.Time `json:"last_check,omitempty"`         // 最近一次检查时间
+	LastResult          string     `json:"last_result,omitempty"`        // 最近一次检查结果说明
+	ConsecutiveFailures int        `json:"consecutive_failures"`         // 连续检查失败次数
+	Attempts            int        `json:"attempts"`                     // 本轮故障中已尝试恢复的次数
+	Recoveries          int        `json:"recoveries"`                   // 恢复成功的累计次数
+	FailedRecoveries    int        `json:"failed_recoveries"`            // 恢复失败的累计次数
+	LastRecovery        *time.Time `json:"last_recovery,omitempty"`      // 最近一次恢复成功的时间
+	NextAttempt         *time.Time `json:"next_attempt,omitempty"`       // 退避结束、允许下次恢复的时间
+	CircuitOpenUntil    *time.Time `json:"circuit_open_until,omitempty"` // 熔断结束时间
+}
+
+// 恢复操作结果
+const (
+	RecoveryOutcomeSuccess = "success" // 恢复操作执行成功
+	RecoveryOutcomeFailed  = "failed"  // 恢复操作执行失败
+)
+
+// RecoveryRecord 表示看门狗的一次恢复操作
+type RecoveryRecord struct {
+	Time    time.Time `json:"time"`            // 开始恢复的时间
+	Attempt int       `json:"attempt"`         // 本轮故障中的第几次恢复
+	Reason  string    `json:"reason"`          // 触发恢复的检查结果
+	Outcome string    `json:"outcome"`         // 结果: success或failed
+	Error   string    `json:"error,omitempty"` // 失败原因
+}
+
+// MonitorSettings 表示监控的运行时设置，用于PATCH时字段为空表示不修改
+type MonitorSettings struct {
+	Enabled         *bool `json:"enabled,omitempty"`          // 是否启用
+	IntervalSeconds *int  `json:"interval_seconds,omitempty"` // 检查间隔(秒)
+	AutoRecovery    *bool `json:"auto_recovery,omitempty"`    // 是否自动恢复
+}
+
+// MonitorInfo 表示监控的设置、状态和恢复记录
+type MonitorInfo struct {
+	Enabled         bool             `json:"enabled"`          // 是否启用
+	IntervalSeconds int              `json:"interval_seconds"` // 检查间隔(秒)
+	AutoRecovery    bool             `json:"auto_recovery"`    // 是否自动恢复
+	Status          WatchdogStatus   `json:"status"`           // 看门狗状态
+	History         []RecoveryRecord `json:"history"`          // 恢复记录，按时间降序
+}
+
+// WatchdogEvent 表示看门狗的升级事件，会交给已注册的升级处理函数
+type WatchdogEvent struct {
+	Watchdog            string    `json:"watchdog"`             // 看门狗名称
+	Type                string    `json:"type"`                 // 事件类型
+	Time                time.Time `json:"time"`                 // 发生时间
+	Reason              string    `json:"reason"`               // 原因说明
+	ConsecutiveFailures int       `json:"consecutive_failures"` // 连续检查失败次数
+}
+
+// ConfigPlan 表示配置预演结果，只描述将要执行的操作而不真正执行
+type ConfigPlan struct {
+	Interface string       `json:"interface"` // 网卡名称
+	Backend   string       `json:"backend"`   // 平台后端
+	Steps     []PlanStep   `json:"steps"`     // 按执行顺序排列的命令
+	Changes   []PlanChange `json:"changes"`   // 字段变更(当前值 -> 目标值)
+	Warnings  []string     `json:"warnings"`  // 需要注意的问题
+}
+
+// PlanStep 表示预演中的一条命令
+type PlanStep struct {
+	Command     string `json:"command"`               // 等价的命令行
+	Description string `json:"description,omitempty"` // 说明
+}
+
+// PlanChange 表示预演中的一项字段变更
+type PlanChange struct {
+	Field  string `json:"field"`  // 字段名，如ipv4.ip
+	Before string `json:"before"` // 当前值
+	After  string `json:"after"`  // 目标值
+}
+
+// 配置变更状态
+const (
+	ChangeStatusPending        = "pending"         // 已应用，等待确认
+	ChangeStatusConfirmed      = "confirmed"       // 已确认
+	ChangeStatusRolledBack     = "rolled_back"     // 已回滚
+	ChangeStatusRollbackFailed = "rollback_failed" // 回滚失败，需要人工处理
+)
+
+// ConfigChange 表示一次需要确认的配置变更
+type ConfigChange struct {
+	ID                string          `json:"id"`                    // 变更ID
+	Interface         string          `json:"interface"`             // 网卡名称
+	Status            string          `json:"status"`                // 变更状态
+	Config            InterfaceConfig `json:"config"`                // 应用的配置
+	Snapshot          Interface       `json:"snapshot"`              // 变更前的网卡状态
+	CheckConnectivity bool            `json:"check_connectivity"`    // 变更后是否检查连通性
+	CreatedAt         time.Time       `json:"created_at"`            // 应用时间
+	Deadline          time.Time       `json:"deadline"`              // 确认截止时间
+	FinishedAt        *time.Time      `json:"finished_at,omitempty"` // 确认或回滚的时间
+	Reason            string          `json:"reason,omitempty"`      // 回滚原因
+	Error             string          `json:"error,omitempty"`       // 回滚失败的错误信息
+}
+
+// SnapshotVersion 当前网络配置快照的格式版本
+const SnapshotVersion = 1
+
+// NetworkSnapshot 表示一台机器完整的网络配置快照，可导出为JSON或YAML并在其他机器上恢复
+type NetworkSnapshot struct {
+	Version      int                 `json:"version" yaml:"version"`                       // 快照格式版本
+	CreatedAt    time.Time           `json:"created_at" yaml:"created_at"`                 // 导出时间
+	Hostname     string              `json:"hostname,omitempty" yaml:"hostname,omitempty"` // 导出快照的主机名
+	Backend      string              `json:"backend,omitempty" yaml:"backend,omitempty"`   // 导出时使用的平台后端
+	Interfaces   []InterfaceSnapshot `json:"interfaces" yaml:"interfaces"`                 // 网卡配置
+	WiFiProfiles []WiFiProfile       `json:"wifi_profiles" yaml:"wifi_profiles"`           // 已保存的WiFi配置文件
+	Hotspot      *HotspotConfig      `json:"hotspot,omitempty" yaml:"hotspot,omitempty"`   // 移动热点配置
+}
+
+// InterfaceSnapshot 表示快照中单个网卡的地址配置
+type InterfaceSnapshot struct {
+	Name        string     `json:"name" yaml:"name"`                 // 网卡名称
+	DHCPEnabled bool       `json:"dhcp_enabled" yaml:"dhcp_enabled"` // 是否通过DHCP获取IPv4地址
+	IPv4Config  IPv4Config `json:"ipv4_config" yaml:"ipv4_config"`   // IPv4配置
+	IPv6Config  IPv6Config `json:"ipv6_config" yaml:"ipv6_config"`   // IPv6配置
+}
+
+// WiFiProfile 表示系统中保存的WiFi连接配置
+type WiFiProfile struct {
+	Name     string `json:"name" yaml:"name"`                             // 配置文件名称
+	SSID     string `json:"ssid" yaml:"ssid"`                             // 热点名称
+	Security string `json:"security,omitempty" yaml:"security,omitempty"` // 认证方式
+	Password string `json:"password,omitempty" yaml:"password,omitempty"` // 密码(开放网络为空)
+}
+
+// 快照恢复项类型
+const (
+	RestoreKindInterface   = "interface"
+	RestoreKindWiFiProfile = "wifi_profile"
+	RestoreKindHotspot     = "hotspot"
+)
+
+// RestoreItemResult 表示快照中单项配置的恢复结果
+type RestoreItemResult struct {
+	Kind    string `json:"kind"`              // 配置类型
+	Name    string `json:"name"`              // 网卡名称、WiFi配置名称或热点SSID
+	Success bool   `json:"success"`           // 是否恢复成功
+	Skipped bool   `json:"skipped,omitempty"` // 是否被跳过
+	Message string `json:"message,omitempty"` // 失败原因或提示信息
+}
+
+// SnapshotRestoreResult 表示快照恢复报告
+type SnapshotRestoreResult struct {
+	Version int                 `json:"version"` // 快照格式版本
+	Success bool                `json:"success"` // 所有项目是否都恢复成功
+	Items   []RestoreItemResult `json:"items"`   // 每一项的恢复结果
+}
+
+// DesiredState 表示期望的网络状态，由调和服务定期与实际状态比较并收敛
+type DesiredState struct {
+	Interfaces []DesiredInterface `json:"interfaces" yaml:"interfaces"`               // 网卡期望配置
+	Hotspot    *DesiredHotspot    `json:"hotspot,omitempty" yaml:"hotspot,omitempty"` // 移动热点期望配置
+}
+
+// DesiredInterface 表示单个网卡的期望配置，未设置的地址族不做检查
+type DesiredInterface struct {
+	Name       string      `json:"name" yaml:"name"`                                   // 网卡名称
+	IPv4Config *IPv4Config `json:"ipv4_config,omitempty" yaml:"ipv4_config,omitempty"` // IPv4期望配置
+	IPv6Config *IPv6Config `json:"ipv6_config,omitempty" yaml:"ipv6_config,omitempty"` // IPv6期望配置
+}
+
+// DesiredHotspot 表示移动热点的期望配置
+type DesiredHotspot struct {
+	SSID     string `json:"ssid,omitempty" yaml:"ssid,omitempty"`         // 热点名称，为空不检查
+	Password string `json:"password,omitempty" yaml:"password,omitempty"` // 热点密码，修正SSID时需要
+	Enabled  *bool  `json:"enabled,omitempty" yaml:"enabled,omitempty"`   // 是否启用，为空不检查
+}
+
+// DriftItem 表示一项与期望状态不一致的配置
+type DriftItem struct {
+	Kind    string `json:"kind"`    // 配置类型(interface/hotspot)
+	Name    string `json:"name"`    // 网卡名称或热点
+	Field   string `json:"field"`   // 字段名，如ipv4.ip
+	Desired string `json:"desired"` // 期望值
+	Actual  string `json:"actual"`  // 实际值
+}
+
+// DriftReport 表示一次期望状态比较的结果
+type DriftReport struct {
+	CheckedAt time.Time   `json:"checked_at"`       // 检查时间
+	InSync    bool        `json:"in_sync"`          // 是否与期望状态一致
+	Items     []DriftItem `json:"items"`            // 不一致的字段
+	Errors    []string    `json:"errors,omitempty"` // 读取实际状态时的错误
+}
+
+// ValidationError 表示配置中单个字段的校验错误
+type ValidationError struct {
+	Field   string `json:"field"`   // 字段路径，如ipv4_config.gateway、ipv4_config.dns[1]
+	Code    string `json:"code"`    // 机器可读的错误码，如gateway_outside_subnet
+	Message string `json:"message"` // 错误说明
 }
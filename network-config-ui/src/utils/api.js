@@ -74,6 +74,20 @@ export const networkApi = {
     return response.data
   },
 
+  // 校验网卡配置，422时返回字段级错误而不是抛出异常
+  validateInterfaceConfig: async (name, config) => {
+    const encodedName = encodeURIComponent(name)
+    try {
+      const response = await api.post(`/interfaces/${encodedName}/validate`, config)
+      return response.data
+    } catch (error) {
+      if (error.response?.status === 422) {
+        return error.response.data
+      }
+      throw error
+    }
+  },
+
   // 获取WIFI热点列表
   getWiFiHotspots: async (name) => {
     try {
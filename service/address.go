@@ -0,0 +1,430 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"networkconfig/models"
+	"strings"
+)
+
+var (
+	// ErrAddressNotFound 表示网卡上没有指定的地址
+	ErrAddressNotFound = errors.New("address not found")
+	// ErrPrimaryAddress 表示不能通过附加地址接口删除主地址
+	ErrPrimaryAddress = errors.New("cannot remove primary address")
+)
+
+// AddInterfaceAddress 为网卡追加一个CIDR形式的附加地址，返回更新后的网卡信息
+func (s *NetworkService) AddInterfaceAddress(name, address string) (models.Interface, error) {
+	current, err := s.backend.GetInterface(name)
+	if err != nil {
+		return current, fmt.Errorf("获取网卡信息失败: %v", err)
+	}
+
+	addr, errs := validateNewAddress(name, strings.TrimSpace(address), current)
+	if len(errs) > 0 {
+		log.Printf("为接口 %s 添加地址 %s 校验失败: %v", name, address, errs)
+		return current, errs
+	}
+
+	if err := s.backend.AddAddress(name, formatCIDR(addr)); err != nil {
+		return current, err
+	}
+	log.Printf("已为接口 %s 添加附加地址 %s", name, formatCIDR(addr))
+	return s.backend.GetInterface(name)
+}
+
+// RemoveInterfaceAddress 删除网卡上的附加地址，主地址需要通过IPv4/IPv6配置修改
+func (s *NetworkService) RemoveInterfaceAddress(name, ip string) (models.Interface, error) {
+	target := net.ParseIP(strings.TrimSpace(ip))
+	if target == nil {
+		var errs ValidationErrors
+		errs.add("address", ValidationInvalidAddress, "无效的地址: %s", ip)
+		return models.Interface{}, errs
+	}
+
+	current, err := s.backend.GetInterface(name)
+	if err != nil {
+		return current, fmt.Errorf("获取网卡信息失败: %v", err)
+	}
+
+	for _, addr := range current.Addresses {
+		if !net.ParseIP(addr.IP).Equal(target) {
+			continue
+		}
+		if addr.Primary {
+			return current, fmt.Errorf("%s 是网卡 %s 的主地址，请通过IPv4/IPv6配置修改: %w", addr.Address, name, ErrPrimaryAddress)
+		}
+		if err := s.backend.RemoveAddress(name, addr.Address); err != nil {
+			return current, err
+		}
+		log.Printf("已删除接口 %s 的附加地址 %s", name, addr.Address)
+		return s.backend.GetInterface(name)
+	}
+	return current, fmt.Errorf("网卡 %s 上没有地址 %s: %w", name, ip, ErrAddressNotFound)
+}
+
+// validateNewAddress 校验要追加的地址：格式、可用性、是否已存在以及与其他网卡的子网重叠
+func validateNewAddress(name, address string, current models.Interface) (*net.IPNet, ValidationErrors) {
+	var errs ValidationErrors
+	ip, _, err := net.ParseCIDR(address)
+	if err != nil {
+		errs.add("address", ValidationInvalidAddress, "无效的CIDR地址: %s，应为 地址/前缀长度 的形式", address)
+		return nil, errs
+	}
+	addr := parseCIDRField(&errs, "address", address, ip.To4() == nil)
+	if addr == nil {
+		return nil, errs
+	}
+
+	validateHostAddress(&errs, "address", address)
+	for _, existing := range current.Addresses {
+		if net.ParseIP(existing.IP).Equal(addr.IP) {
+			errs.add("address", ValidationDuplicateAddress, "网卡 %s 上已有地址 %s", name, existing.Address)
+		}
+	}
+	if !addr.IP.IsLinkLocalUnicast() {
+		checkSubnetOverlap(&errs, "address", name, &net.IPNet{IP: addr.IP.Mask(addr.Mask), Mask: addr.Mask})
+	}
+	return addr, errs
+}
+
+// NormalizeIPv4Config 把CIDR形式的address/addresses展开为IP/Mask，并按IP/Mask补全address/addresses
+// 返回的是副本，DHCP配置原样返回；地址本身是否可用由ValidateIPv4Config检查
+func NormalizeIPv4Config(config models.IPv4Config) (models.IPv4Config, ValidationErrors) {
+	var errs ValidationErrors
+	if config.DHCP {
+		return config, errs
+	}
+
+	var primary *net.IPNet
+	if config.Address != "" {
+		primary = parseCIDRField(&errs, "ipv4_config.address", config.Address, false)
+		if primary != nil && config.IP != "" {
+			ip := net.ParseIP(config.IP)
+			if ip == nil || !ip.Equal(primary.IP) || (config.Mask != "" && config.Mask != net.IP(primary.Mask).String()) {
+				errs.add("ipv4_config.address", ValidationConflictingAddress,
+					"address %s 与 ip/mask %s/%s 不一致", config.Address, config.IP, config.Mask)
+			}
+		}
+	}
+	addresses := parseCIDRList(&errs, "ipv4_config.addresses", config.Addresses, false)
+	if len(errs) > 0 {
+		return config, errs
+	}
+
+	if primary == nil {
+		if config.IP != "" {
+			primary = ipv4WithMask(config.IP, config.Mask)
+		} else if len(addresses) > 0 {
+			primary = addresses[0]
+		}
+	}
+	// IP/Mask无效时保持原样，由校验报告具体字段
+	if primary == nil {
+		return config, errs
+	}
+
+	merged, ok := mergeAddresses(&errs, "ipv4_config.addresses", primary, addresses)
+	if !ok {
+		return config, errs
+	}
+	config.IP = primary.IP.String()
+	config.Mask = net.IP(primary.Mask).String()
+	config.Address = formatCIDR(primary)
+	config.Addresses = merged
+	return config, errs
+}
+
+// NormalizeIPv6Config 把CIDR形式的address/addresses展开为IP/PrefixLen，并按IP/PrefixLen补全address/addresses
+// 未指定前缀长度时按64处理
+func NormalizeIPv6Config(config models.IPv6Config) (models.IPv6Config, ValidationErrors) {
+	var errs ValidationErrors
+
+	var primary *net.IPNet
+	if config.Address != "" {
+		primary = parseCIDRField(&errs, "ipv6_config.address", config.Address, true)
+		if primary != nil && config.IP != "" {
+			ones, _ := primary.Mask.Size()
+			ip := net.ParseIP(config.IP)
+			if ip == nil || !ip.Equal(primary.IP) || (config.PrefixLen != 0 && config.PrefixLen != ones) {
+				errs.add("ipv6_config.address", ValidationConflictingAddress,
+					"address %s 与 ip/prefix_len %s/%d 不一致", config.Address, config.IP, config.PrefixLen)
+			}
+		}
+	}
+	addresses := parseCIDRList(&errs, "ipv6_config.addresses", config.Addresses, true)
+	if len(errs) > 0 {
+		return config, errs
+	}
+
+	if primary == nil {
+		if config.IP != "" {
+			primary = ipv6WithPrefix(config.IP, config.PrefixLen)
+		} else if len(addresses) > 0 {
+			primary = addresses[0]
+		}
+	}
+	if primary == nil {
+		return config, errs
+	}
+
+	merged, ok := mergeAddresses(&errs, "ipv6_config.addresses", primary, addresses)
+	if !ok {
+		return config, errs
+	}
+	config.IP = primary.IP.String()
+	config.PrefixLen, _ = primary.Mask.Size()
+	config.Address = formatCIDR(primary)
+	config.Addresses = merged
+	return config, errs
+}
+
+// normalizeInterfaceConfig 规范化网卡配置中的IPv4和IPv6部分，返回新的配置
+func normalizeInterfaceConfig(config models.InterfaceConfig) (models.InterfaceConfig, ValidationErrors) {
+	var result models.InterfaceConfig
+	var errs ValidationErrors
+	if config.IPv4Config != nil {
+		v4, v4Errs := NormalizeIPv4Config(*config.IPv4Config)
+		result.IPv4Config = &v4
+		errs = append(errs, v4Errs...)
+	}
+	if config.IPv6Config != nil {
+		v6, v6Errs := NormalizeIPv6Config(*config.IPv6Config)
+		result.IPv6Config = &v6
+		errs = append(errs, v6Errs...)
+	}
+	return result, errs
+}
+
+// parseCIDRField 解析CIDR形式的地址，保留主机部分
+func parseCIDRField(errs *ValidationErrors, field, value string, ipv6 bool) *net.IPNet {
+	ip, subnet, err := net.ParseCIDR(value)
+	if err != nil {
+		errs.add(field, ValidationInvalidAddress, "无效的CIDR地址: %s，应为 地址/前缀长度 的形式", value)
+		return nil
+	}
+	if (ip.To4() == nil) != ipv6 {
+		family := "IPv4"
+		if ipv6 {
+			family = "IPv6"
+		}
+		errs.add(field, ValidationWrongFamily, "%s 不是%s地址", value, family)
+		return nil
+	}
+	if !ipv6 {
+		ip = ip.To4()
+	}
+	return &net.IPNet{IP: ip, Mask: subnet.Mask}
+}
+
+// parseCIDRList 解析CIDR地址列表，同一IP出现多次时报告重复
+func parseCIDRList(errs *ValidationErrors, field string, values []string, ipv6 bool) []*net.IPNet {
+	var result []*net.IPNet
+	seen := make(map[string]int)
+	for i, value := range values {
+		path := fmt.Sprintf("%s[%d]", field, i)
+		addr := parseCIDRField(errs, path, value, ipv6)
+		if addr == nil {
+			continue
+		}
+		if first, ok := seen[addr.IP.String()]; ok {
+			errs.add(path, ValidationDuplicateAddress, "地址 %s 与第%d项重复", value, first+1)
+			continue
+		}
+		seen[addr.IP.String()] = i
+		result = append(result, addr)
+	}
+	return result
+}
+
+// mergeAddresses 返回以主地址开头的CIDR地址列表，列表中同一IP的前缀与主地址不同时报告冲突
+func mergeAddresses(errs *ValidationErrors, field string, primary *net.IPNet, addresses []*net.IPNet) ([]string, bool) {
+	result := []string{formatCIDR(primary)}
+	for i, addr := range addresses {
+		if !addr.IP.Equal(primary.IP) {
+			result = append(result, formatCIDR(addr))
+			continue
+		}
+		if addr.Mask.String() != primary.Mask.String() {
+			errs.add(fmt.Sprintf("%s[%d]", field, i), ValidationConflictingAddress,
+				"%s 与主地址 %s 的前缀不一致", formatCIDR(addr), formatCIDR(primary))
+			return nil, false
+		}
+	}
+	return result, true
+}
+
+// ipv4WithMask 由IP和点分子网掩码构造地址，任一无效或掩码不连续时返回nil
+func ipv4WithMask(address, maskText string) *net.IPNet {
+	ip := net.ParseIP(address).To4()
+	maskIP := net.ParseIP(maskText).To4()
+	if ip == nil || maskIP == nil {
+		return nil
+	}
+	mask := net.IPMask(maskIP)
+	if ones, bits := mask.Size(); ones == 0 || bits == 0 {
+		return nil
+	}
+	return &net.IPNet{IP: ip, Mask: mask}
+}
+
+// ipv6WithPrefix 由IPv6地址和前缀长度构造地址，前缀长度为0时按64处理
+func ipv6WithPrefix(address string, prefixLen int) *net.IPNet {
+	ip := net.ParseIP(address)
+	if ip == nil || ip.To4() != nil {
+		return nil
+	}
+	if prefixLen == 0 {
+		prefixLen = 64
+	}
+	if prefixLen < 1 || prefixLen > 128 {
+		return nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(prefixLen, 128)}
+}
+
+// parseHostCIDR 解析CIDR形式的地址，保留主机部分
+func parseHostCIDR(address string) (*net.IPNet, error) {
+	ip, subnet, err := net.ParseCIDR(address)
+	if err != nil {
+		return nil, fmt.Errorf("无效的地址 %s: %v", address, err)
+	}
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	return &net.IPNet{IP: ip, Mask: subnet.Mask}, nil
+}
+
+// formatCIDR 按ip/前缀长度的形式输出地址，保留主机部分
+func formatCIDR(addr *net.IPNet) string {
+	ones, _ := addr.Mask.Size()
+	return fmt.Sprintf("%s/%d", addr.IP, ones)
+}
+
+// secondaryAddresses 返回addresses中除主地址以外的地址，供后端追加配置
+func secondaryAddresses(primaryIP string, addresses []string) []*net.IPNet {
+	primary := net.ParseIP(primaryIP)
+	var result []*net.IPNet
+	for _, value := range addresses {
+		addr, err := parseHostCIDR(value)
+		if err != nil || addr.IP.Equal(primary) {
+			continue
+		}
+		result = append(result, addr)
+	}
+	return result
+}
+
+// interfaceAddresses 把网卡地址转换为地址列表并标出各地址族的主地址
+// origins为平台后端识别出的地址来源，键为IP字符串，可以为nil
+func interfaceAddresses(addrs []net.Addr, origins map[string]string) []models.InterfaceAddress {
+	result := make([]models.InterfaceAddress, 0, len(addrs))
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+
+		ip := ipNet.IP
+		family := models.AddressFamilyIPv6
+		if v4 := ip.To4(); v4 != nil {
+			ip = v4
+			family = models.AddressFamilyIPv4
+		}
+		prefixLen, _ := ipNet.Mask.Size()
+		scope := addressScope(ip)
+
+		origin := origins[ip.String()]
+		if origin == "" {
+			origin = models.AddressOriginUnknown
+			if family == models.AddressFamilyIPv6 && scope == models.AddressScopeLinkLocal {
+				origin = models.AddressOriginLink
+			}
+		}
+
+		result = append(result, models.InterfaceAddress{
+			Address:   fmt.Sprintf("%s/%d", ip, prefixLen),
+			IP:        ip.String(),
+			PrefixLen: prefixLen,
+			Family:    family,
+			Scope:     scope,
+			Origin:    origin,
+		})
+	}
+
+	markPrimary(result, models.AddressFamilyIPv4)
+	markPrimary(result, models.AddressFamilyIPv6)
+	return result
+}
+
+// markPrimary 标出地址族的主地址：优先全局的非临时地址，其次全局地址，最后是第一个地址
+func markPrimary(addresses []models.InterfaceAddress, family string) {
+	best := -1
+	rank := func(addr models.InterfaceAddress) int {
+		switch {
+		case addr.Scope == models.AddressScopeGlobal && addr.Origin != models.AddressOriginTemporary:
+			return 2
+		case addr.Scope == models.AddressScopeGlobal:
+			return 1
+		default:
+			return 0
+		}
+	}
+	for i, addr := range addresses {
+		if addr.Family != family {
+			continue
+		}
+		if best < 0 || rank(addr) > rank(addresses[best]) {
+			best = i
+		}
+	}
+	if best >= 0 {
+		addresses[best].Primary = true
+	}
+}
+
+// primaryAddress 返回地址族的主地址，没有该地址族的地址时返回nil
+func primaryAddress(addresses []models.InterfaceAddress, family string) *models.InterfaceAddress {
+	for i := range addresses {
+		if addresses[i].Family == family && addresses[i].Primary {
+			return &addresses[i]
+		}
+	}
+	return nil
+}
+
+// addressScope 判断地址作用域
+func addressScope(ip net.IP) string {
+	switch {
+	case ip.IsLoopback():
+		return models.AddressScopeHost
+	case ip.IsLinkLocalUnicast():
+		return models.AddressScopeLinkLocal
+	default:
+		return models.AddressScopeGlobal
+	}
+}
+
+// fillAddressCIDR 按地址列表填充ipv4_config/ipv6_config的address/addresses，主地址排在第一位
+func fillAddressCIDR(iface *models.Interface) {
+	iface.IPv4Config.Address, iface.IPv4Config.Addresses = familyCIDRs(iface.Addresses, models.AddressFamilyIPv4)
+	iface.IPv6Config.Address, iface.IPv6Config.Addresses = familyCIDRs(iface.Addresses, models.AddressFamilyIPv6)
+}
+
+// familyCIDRs 返回地址族的主地址和以主地址开头的全部地址
+func familyCIDRs(addresses []models.InterfaceAddress, family string) (string, []string) {
+	primary := primaryAddress(addresses, family)
+	if primary == nil {
+		return "", nil
+	}
+	result := []string{primary.Address}
+	for _, addr := range addresses {
+		if addr.Family == family && !addr.Primary {
+			result = append(result, addr.Address)
+		}
+	}
+	return primary.Address, result
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"networkconfig/models"
+	"runtime"
+	"strings"
+)
+
+// ErrNotSupported 表示当前平台后端不支持该操作
+var ErrNotSupported = errors.New("operation not supported by this backend")
+
+// InterfaceBackend 网卡清单与地址配置能力
+type InterfaceBackend interface {
+	// ListInterfaces 快速获取网卡清单(已按平台规则过滤虚拟网卡)
+	ListInterfaces() ([]InterfaceFast, error)
+	// GetInterface 获取指定网卡的详细信息
+	GetInterface(name string) (models.Interface, error)
+	// ConfigureIPv4 配置IPv4地址、网关和DNS
+	ConfigureIPv4(name string, config models.IPv4Config) error
+	// ConfigureIPv6 配置IPv6地址、网关和DNS
+	ConfigureIPv6(name string, config models.IPv6Config) error
+	// AddAddress 为网卡追加一个CIDR形式的地址，不影响已有地址
+	AddAddress(name, address string) error
+	// RemoveAddress 删除网卡上的一个CIDR形式的地址
+	RemoveAddress(name, address string) error
+	// SetDNS 设置DNS服务器，servers为空时恢复为自动获取
+	SetDNS(name string, ipv6 bool, servers []string) error
+	// SetGateway 设置默认网关
+	SetGateway(name string, ipv6 bool, gateway string) error
+}
+
+// WiFiBackend WiFi扫描与连接能力
+type WiFiBackend interface {
+	ScanWiFi(name string) ([]models.WiFiHotspot, error)
+	ConnectWiFi(name, ssid, password string) error
+	// ListWiFiProfiles 列出系统中已保存的WiFi配置(含密码)
+	ListWiFiProfiles() ([]models.WiFiProfile, error)
+	// AddWiFiProfile 保存WiFi配置，同名配置会被替换
+	AddWiFiProfile(profile models.WiFiProfile) error
+}
+
+// HotspotBackend 移动热点管理能力
+type HotspotBackend interface {
+	GetHotspotStatus() (models.HotspotStatus, error)
+	// GetHotspotConfig 读取当前热点配置，包含密码
+	GetHotspotConfig() (models.HotspotConfig, error)
+	ConfigureHotspot(config models.HotspotConfig) error
+	SetHotspotStatus(enable bool) error
+	// GetHotspotClients 列出已连接热点的客户端，Vendor和缺失的连接时间由NetworkService补充
+	GetHotspotClients() ([]models.HotspotClient, error)
+	// ApplyHotspotACL 按MAC地址过滤列表限制可连接的设备，不负责断开已连接的设备
+	ApplyHotspotACL(acl models.HotspotACL) error
+	// DisconnectHotspotClient 断开指定MAC的客户端，客户端未连接时返回ErrHotspotClientNotFound
+	DisconnectHotspotClient(mac string) error
+}
+
+// Backend 平台后端，NetworkService的所有系统操作都通过它完成
+type Backend interface {
+	// Name 返回后端名称，如windows、linux
+	Name() string
+	InterfaceBackend
+	WiFiBackend
+	HotspotBackend
+}
+
+// 已知的后端名称
+const (
+	BackendWindows        = "windows"
+	BackendLinux          = "linux"
+	BackendNetworkManager = "networkmanager" // Linux上通过NetworkManager D-Bus接口配置网卡和WiFi
+)
+
+// NewBackend 根据名称创建平台后端，name为空时按当前操作系统自动选择
+func NewBackend(name string, debug bool) (Backend, error) {
+	return NewBackendWithRunner(name, debug, ExecRunner{})
+}
+
+// NewBackendWithRunner 使用指定的命令执行器创建平台后端
+func NewBackendWithRunner(name string, debug bool, runner CommandRunner) (Backend, error) {
+	if runner == nil {
+		runner = ExecRunner{}
+	}
+	if name == "" {
+		name = runtime.GOOS
+	}
+
+	switch strings.ToLower(name) {
+	case BackendWindows:
+		return newWindowsBackend(debug, runner), nil
+	case BackendLinux:
+		return newLinuxBackend(debug, runner), nil
+	case BackendNetworkManager:
+		return newNMBackend(debug, runner), nil
+	default:
+		return nil, fmt.Errorf("不支持的平台后端: %s", name)
+	}
+}
+
+// unsupportedBackend 在无法识别的平台上使用，所有操作均返回ErrNotSupported
+type unsupportedBackend struct {
+	goos string
+}
+
+func (b unsupportedBackend) Name() string { return b.goos }
+
+func (b unsupportedBackend) err() error {
+	return fmt.Errorf("不支持的操作系统 %s: %w", b.goos, ErrNotSupported)
+}
+
+func (b unsupportedBackend) ListInterfaces() ([]InterfaceFast, error) { return nil, b.err() }
+
+func (b unsupportedBackend) GetInterface(name string) (models.Interface, error) {
+	return models.Interface{}, b.err()
+}
+
+func (b unsupportedBackend) ConfigureIPv4(name string, config models.IPv4Config) error {
+	return b.err()
+}
+
+func (b unsupportedBackend) ConfigureIPv6(name string, config models.IPv6Config) error {
+	return b.err()
+}
+
+func (b unsupportedBackend) AddAddress(name, address string) error { return b.err() }
+
+func (b unsupportedBackend) RemoveAddress(name, address string) error { return b.err() }
+
+func (b unsupportedBackend) SetDNS(name string, ipv6 bool, servers []string) error { return b.err() }
+
+func (b unsupportedBackend) SetGateway(name string, ipv6 bool, gateway string) error {
+	return b.err()
+}
+
+func (b unsupportedBackend) ScanWiFi(name string) ([]models.WiFiHotspot, error) {
+	return make([]models.WiFiHotspot, 0), b.err()
+}
+
+func (b unsupportedBackend) ConnectWiFi(name, ssid, password string) error { return b.err() }
+
+func (b unsupportedBackend) ListWiFiProfiles() ([]models.WiFiProfile, error) { return nil, b.err() }
+
+func (b unsupportedBackend) AddWiFiProfile(profile models.WiFiProfile) error { return b.err() }
+
+func (b unsupportedBackend) GetHotspotStatus() (models.HotspotStatus, error) {
+	return models.HotspotStatus{}, b.err()
+}
+
+func (b unsupportedBackend) GetHotspotConfig() (models.HotspotConfig, error) {
+	return models.HotspotConfig{}, b.err()
+}
+
+func (b unsupportedBackend) ConfigureHotspot(config models.HotspotConfig) error { return b.err() }
+
+func (b unsupportedBackend) SetHotspotStatus(enable bool) error { return b.err() }
+
+func (b unsupportedBackend) GetHotspotClients() ([]models.HotspotClient, error) {
+	return nil, b.err()
+}
+
+func (b unsupportedBackend) ApplyHotspotACL(acl models.HotspotACL) error { return b.err() }
+
+func (b unsupportedBackend) DisconnectHotspotClient(mac string) error { return b.err() }
+
+// defaultBackend 创建当前平台的默认后端，失败时退化为unsupportedBackend
+func defaultBackend(debug bool) Backend {
+	backend, err := NewBackend("", debug)
+	if err != nil {
+		log.Printf("创建平台后端失败: %v", err)
+		return unsupportedBackend{goos: runtime.GOOS}
+	}
+	return backend
+}
@@ -0,0 +1,391 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"networkconfig/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 配置变更相关错误
+var (
+	ErrChangeNotFound   = errors.New("change not found")
+	ErrChangeNotPending = errors.New("change is not pending")
+	ErrChangeInProgress = errors.New("another change is pending on this interface")
+)
+
+// maxFinishedChanges 保留的已结束变更记录数量
+const maxFinishedChanges = 100
+
+// connectivitySettleDelay 应用配置后等待网络稳定再检查连通性的时间
+var connectivitySettleDelay = 5 * time.Second
+
+// ChangeOptions 确认模式配置选项
+type ChangeOptions struct {
+	Timeout            time.Duration // 等待确认的时间，超时自动回滚
+	CheckConnectivity  bool          // 应用后检查连通性，失败立即回滚
+	ConnectivityTarget string        // 连通性检查目标，为空使用默认值
+}
+
+// DefaultConfirmTimeout 从环境变量读取默认的确认超时时间，0表示不启用确认模式
+func DefaultConfirmTimeout() time.Duration {
+	return time.Duration(getEnvInt("NETWORK_CONFIG_CONFIRM_TIMEOUT", 0)) * time.Second
+}
+
+// changeTracker 管理等待确认的配置变更
+type changeTracker struct {
+	mu      sync.Mutex
+	changes map[string]*models.ConfigChange
+	timers  map[string]*time.Timer
+}
+
+func newChangeTracker() *changeTracker {
+	return &changeTracker{
+		changes: make(map[string]*models.ConfigChange),
+		timers:  make(map[string]*time.Timer),
+	}
+}
+
+// pending 返回接口上未确认的变更，没有时返回nil，调用方需持有tracker.mu
+func (tracker *changeTracker) pending(name string) *models.ConfigChange {
+	for _, change := range tracker.changes {
+		if change.Interface == name && change.Status == models.ChangeStatusPending {
+			return change
+		}
+	}
+	return nil
+}
+
+// checkNoPendingChange 接口有未确认的变更时返回ErrChangeInProgress，
+// 否则直接修改的配置会在该变更回滚时被覆盖
+func (s *NetworkService) checkNoPendingChange(name string) error {
+	s.changes.mu.Lock()
+	defer s.changes.mu.Unlock()
+	if pending := s.changes.pending(name); pending != nil {
+		return fmt.Errorf("接口 %s 有未确认的变更 %s，请先确认或回滚: %w", name, pending.ID, ErrChangeInProgress)
+	}
+	return nil
+}
+
+// ConfigureInterfaceWithConfirm 保存当前网卡状态后应用配置，
+// 在opts.Timeout内没有调用ConfirmChange时自动恢复为变更前的状态
+func (s *NetworkService) ConfigureInterfaceWithConfirm(name string, config models.InterfaceConfig, opts ChangeOptions) (models.ConfigChange, error) {
+	if opts.Timeout <= 0 {
+		return models.ConfigChange{}, fmt.Errorf("确认超时时间必须大于0")
+	}
+
+	config, errs := s.prepareInterfaceConfig(name, config)
+	if len(errs) > 0 {
+		return models.ConfigChange{}, errs
+	}
+
+	snapshot, err := s.backend.GetInterface(name)
+	if err != nil {
+		return models.ConfigChange{}, fmt.Errorf("保存接口 %s 的当前状态失败，无法回滚: %v", name, err)
+	}
+
+	now := time.Now()
+	change := &models.ConfigChange{
+		ID:                newChangeID(),
+		Interface:         name,
+		Status:            models.ChangeStatusPending,
+		Config:            config,
+		Snapshot:          snapshot,
+		CheckConnectivity: opts.CheckConnectivity,
+		CreatedAt:         now,
+		Deadline:          now.Add(opts.Timeout),
+	}
+
+	// 同一接口同时只允许一个未确认的变更，否则回滚快照会互相覆盖
+	tracker := s.changes
+	tracker.mu.Lock()
+	if pending := tracker.pending(name); pending != nil {
+		tracker.mu.Unlock()
+		return models.ConfigChange{}, fmt.Errorf("接口 %s 有未确认的变更 %s: %w", name, pending.ID, ErrChangeInProgress)
+	}
+	tracker.add(change)
+	tracker.mu.Unlock()
+
+	if err := s.applyInterfaceConfig(name, config); err != nil {
+		// 部分配置可能已经生效，立即恢复
+		log.Printf("应用变更 %s 失败，立即回滚: %v", change.ID, err)
+		s.rollbackChange(change.ID, fmt.Sprintf("应用配置失败: %v", err))
+		return s.getChange(change.ID), fmt.Errorf("应用配置失败，已回滚: %v", err)
+	}
+
+	tracker.mu.Lock()
+	if change.Status == models.ChangeStatusPending {
+		id := change.ID
+		tracker.timers[id] = time.AfterFunc(opts.Timeout, func() {
+			s.rollbackChange(id, "确认超时")
+		})
+	}
+	tracker.mu.Unlock()
+	log.Printf("变更 %s 已应用到接口 %s，请在 %v 内确认，否则自动回滚", change.ID, name, opts.Timeout)
+
+	if opts.CheckConnectivity {
+		go s.verifyChangeConnectivity(change.ID, opts.ConnectivityTarget)
+	}
+
+	return s.getChange(change.ID), nil
+}
+
+// ConfirmChange 确认变更，取消自动回滚
+func (s *NetworkService) ConfirmChange(id string) (models.ConfigChange, error) {
+	tracker := s.changes
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	change, ok := tracker.changes[id]
+	if !ok {
+		return models.ConfigChange{}, fmt.Errorf("变更 %s: %w", id, ErrChangeNotFound)
+	}
+	if change.Status != models.ChangeStatusPending {
+		return *change, fmt.Errorf("变更 %s 当前状态为 %s: %w", id, change.Status, ErrChangeNotPending)
+	}
+
+	if timer := tracker.timers[id]; timer != nil {
+		timer.Stop()
+		delete(tracker.timers, id)
+	}
+	finished := time.Now()
+	change.Status = models.ChangeStatusConfirmed
+	change.FinishedAt = &finished
+	log.Printf("变更 %s 已确认", id)
+	return *change, nil
+}
+
+// RollbackChange 立即回滚一个未确认的变更
+func (s *NetworkService) RollbackChange(id string) (models.ConfigChange, error) {
+	tracker := s.changes
+	tracker.mu.Lock()
+	change, ok := tracker.changes[id]
+	if !ok {
+		tracker.mu.Unlock()
+		return models.ConfigChange{}, fmt.Errorf("变更 %s: %w", id, ErrChangeNotFound)
+	}
+	if change.Status != models.ChangeStatusPending {
+		result := *change
+		tracker.mu.Unlock()
+		return result, fmt.Errorf("变更 %s 当前状态为 %s: %w", id, result.Status, ErrChangeNotPending)
+	}
+	tracker.mu.Unlock()
+
+	s.rollbackChange(id, "手动回滚")
+	result := s.getChange(id)
+	if result.Status == models.ChangeStatusRollbackFailed {
+		return result, fmt.Errorf("回滚失败: %s", result.Error)
+	}
+	return result, nil
+}
+
+// GetChange 获取指定变更
+func (s *NetworkService) GetChange(id string) (models.ConfigChange, error) {
+	s.changes.mu.Lock()
+	defer s.changes.mu.Unlock()
+
+	change, ok := s.changes.changes[id]
+	if !ok {
+		return models.ConfigChange{}, fmt.Errorf("变更 %s: %w", id, ErrChangeNotFound)
+	}
+	return *change, nil
+}
+
+// ListChanges 按创建时间倒序列出所有变更
+func (s *NetworkService) ListChanges() []models.ConfigChange {
+	s.changes.mu.Lock()
+	defer s.changes.mu.Unlock()
+
+	result := make([]models.ConfigChange, 0, len(s.changes.changes))
+	for _, change := range s.changes.changes {
+		result = append(result, *change)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result
+}
+
+// add 登记变更，并清理过多的已结束记录，调用方需持有锁
+func (tracker *changeTracker) add(change *models.ConfigChange) {
+	tracker.changes[change.ID] = change
+
+	var finished []*models.ConfigChange
+	for _, c := range tracker.changes {
+		if c.Status != models.ChangeStatusPending {
+			finished = append(finished, c)
+		}
+	}
+	if len(finished) <= maxFinishedChanges {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].CreatedAt.Before(finished[j].CreatedAt)
+	})
+	for _, c := range finished[:len(finished)-maxFinishedChanges] {
+		delete(tracker.changes, c.ID)
+	}
+}
+
+// hasPendingChange 接口是否有等待确认的变更
+func (s *NetworkService) hasPendingChange(name string) bool {
+	s.changes.mu.Lock()
+	defer s.changes.mu.Unlock()
+
+	for _, change := range s.changes.changes {
+		if change.Interface == name && change.Status == models.ChangeStatusPending {
+			return true
+		}
+	}
+	return false
+}
+
+// getChange 获取变更副本，不存在时返回空值
+func (s *NetworkService) getChange(id string) models.ConfigChange {
+	change, _ := s.GetChange(id)
+	return change
+}
+
+// rollbackChange 把接口恢复为变更前的快照，只有pending状态的变更会被回滚
+func (s *NetworkService) rollbackChange(id, reason string) {
+	tracker := s.changes
+	tracker.mu.Lock()
+	change, ok := tracker.changes[id]
+	if !ok || change.Status != models.ChangeStatusPending {
+		tracker.mu.Unlock()
+		return
+	}
+	if timer := tracker.timers[id]; timer != nil {
+		timer.Stop()
+		delete(tracker.timers, id)
+	}
+	// 先标记状态，避免超时和连通性检查同时触发回滚
+	change.Status = models.ChangeStatusRolledBack
+	change.Reason = reason
+	name := change.Interface
+	restore := configFromSnapshot(change.Snapshot, change.Config.IPv4Config != nil, change.Config.IPv6Config != nil)
+	tracker.mu.Unlock()
+
+	log.Printf("正在回滚接口 %s 的变更 %s，原因: %s", name, id, reason)
+	// 恢复的是变更前的实际状态，不再做校验
+	err := s.applyInterfaceConfig(name, restore)
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	finished := time.Now()
+	change.FinishedAt = &finished
+	if err != nil {
+		log.Printf("回滚变更 %s 失败: %v", id, err)
+		change.Status = models.ChangeStatusRollbackFailed
+		change.Error = err.Error()
+		return
+	}
+	log.Printf("变更 %s 已回滚", id)
+}
+
+// verifyChangeConnectivity 等待网络稳定后检查连通性，失败时回滚变更
+func (s *NetworkService) verifyChangeConnectivity(id, target string) {
+	time.Sleep(connectivitySettleDelay)
+
+	if change := s.getChange(id); change.Status != models.ChangeStatusPending {
+		return
+	}
+
+	result, err := s.CheckConnectivity(target)
+	if err != nil || !result.Success {
+		reason := "连通性检查失败"
+		if err != nil {
+			reason = fmt.Sprintf("%s: %v", reason, err)
+		} else if result.Error != "" {
+			reason = fmt.Sprintf("%s: %s", reason, result.Error)
+		}
+		s.rollbackChange(id, reason)
+		return
+	}
+	log.Printf("变更 %s 连通性检查通过，等待确认", id)
+}
+
+// configFromSnapshot 根据快照生成恢复配置，只包含需要恢复的地址族
+func configFromSnapshot(snapshot models.Interface, ipv4, ipv6 bool) models.InterfaceConfig {
+	var config models.InterfaceConfig
+
+	if ipv4 {
+		dns := snapshotDNSServers(snapshot.IPv4Config.DNS)
+		if snapshot.DHCPEnabled || snapshot.IPv4Config.IP == "" {
+			// DHCP获取地址时DNS可能是手动指定的，此时保留这些DNS服务器
+			config.IPv4Config = &models.IPv4Config{DHCP: true, DNSAuto: true}
+			if !snapshot.IPv4Config.DNSAuto && len(dns) > 0 {
+				config.IPv4Config.DNSAuto = false
+				config.IPv4Config.DNS = dns
+			}
+		} else {
+			config.IPv4Config = &models.IPv4Config{
+				IP:        snapshot.IPv4Config.IP,
+				Mask:      snapshot.IPv4Config.Mask,
+				Addresses: snapshot.IPv4Config.Addresses,
+				Gateway:   snapshot.IPv4Config.Gateway,
+				DNS:       dns,
+			}
+		}
+	}
+
+	// 链路本地地址由系统自动生成，不需要恢复
+	if ipv6 {
+		if addresses := globalIPv6Addresses(snapshot.IPv6Config); len(addresses) > 0 {
+			config.IPv6Config = &models.IPv6Config{
+				Addresses: addresses,
+				Gateway:   snapshot.IPv6Config.Gateway,
+				DNS:       snapshot.IPv6Config.DNS,
+			}
+		} else {
+			log.Printf("快照中没有可恢复的IPv6全局地址，跳过IPv6回滚")
+		}
+	}
+
+	// 回滚不经过校验，这里先把CIDR地址展开为后端使用的IP字段
+	normalized, errs := normalizeInterfaceConfig(config)
+	if len(errs) > 0 {
+		log.Printf("规范化快照中的地址失败: %v", errs)
+	}
+	return normalized
+}
+
+// snapshotDNSServers 返回快照中有效的DNS服务器地址，去掉读取失败时的占位值(如none、unavailable)
+func snapshotDNSServers(servers []string) []string {
+	var valid []string
+	for _, server := range servers {
+		if net.ParseIP(server) != nil {
+			valid = append(valid, server)
+		}
+	}
+	return valid
+}
+
+// globalIPv6Addresses 返回快照中的IPv6全局地址(CIDR形式)，上报的主地址排在第一位
+func globalIPv6Addresses(snapshot models.IPv6Config) []string {
+	var addresses []string
+	if primary := ipv6WithPrefix(snapshot.IP, snapshot.PrefixLen); primary != nil && !primary.IP.IsLinkLocalUnicast() {
+		addresses = append(addresses, formatCIDR(primary))
+	}
+	for _, value := range snapshot.Addresses {
+		if ip, _, err := net.ParseCIDR(value); err == nil && !ip.IsLinkLocalUnicast() && !ip.Equal(net.ParseIP(snapshot.IP)) {
+			addresses = append(addresses, value)
+		}
+	}
+	return addresses
+}
+
+// newChangeID 生成随机变更ID
+func newChangeID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
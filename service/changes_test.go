@@ -0,0 +1,71 @@
+package service
+
+import (
+	"errors"
+	"networkconfig/models"
+	"reflect"
+	"testing"
+)
+
+func TestConfigFromSnapshotIPv4(t *testing.T) {
+	tests := []struct {
+		name     string
+		snapshot models.Interface
+		want     models.IPv4Config
+	}{
+		{
+			name: "DHCP自动获取DNS",
+			snapshot: models.Interface{DHCPEnabled: true, IPv4Config: models.IPv4Config{
+				IP: "192.168.1.20", Mask: "255.255.255.0", DNS: []string{"192.168.1.1"}, DHCP: true, DNSAuto: true,
+			}},
+			want: models.IPv4Config{DHCP: true, DNSAuto: true},
+		},
+		{
+			name: "DHCP手动指定DNS",
+			snapshot: models.Interface{DHCPEnabled: true, IPv4Config: models.IPv4Config{
+				IP: "192.168.1.20", Mask: "255.255.255.0", DNS: []string{"223.5.5.5", "8.8.8.8"}, DHCP: true,
+			}},
+			want: models.IPv4Config{DHCP: true, DNS: []string{"223.5.5.5", "8.8.8.8"}},
+		},
+		{
+			name:     "没有地址且DNS读取失败",
+			snapshot: models.Interface{IPv4Config: models.IPv4Config{DNS: []string{"unavailable"}}},
+			want:     models.IPv4Config{DHCP: true, DNSAuto: true},
+		},
+		{
+			name: "静态地址",
+			snapshot: models.Interface{IPv4Config: models.IPv4Config{
+				IP: "10.0.0.5", Mask: "255.255.255.0", Gateway: "10.0.0.1", DNS: []string{"none"},
+			}},
+			want: models.IPv4Config{IP: "10.0.0.5", Mask: "255.255.255.0", Gateway: "10.0.0.1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configFromSnapshot(tt.snapshot, true, false)
+			if config.IPv4Config == nil {
+				t.Fatal("没有生成IPv4恢复配置")
+			}
+			got := *config.IPv4Config
+			got.Address, got.Addresses = "", nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("configFromSnapshot() = %+v，应为 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigureInterfaceWithPendingChange(t *testing.T) {
+	s := &NetworkService{backend: unsupportedBackend{goos: "test"}, changes: newChangeTracker()}
+	s.changes.add(&models.ConfigChange{ID: "c1", Interface: "eth0", Status: models.ChangeStatusPending})
+	s.changes.add(&models.ConfigChange{ID: "c2", Interface: "eth1", Status: models.ChangeStatusConfirmed})
+	config := models.InterfaceConfig{IPv4Config: &models.IPv4Config{DHCP: true, DNSAuto: true}}
+
+	if err := s.ConfigureInterface("eth0", config); !errors.Is(err, ErrChangeInProgress) {
+		t.Errorf("有未确认变更的网卡应返回ErrChangeInProgress，实际为 %v", err)
+	}
+	if err := s.ConfigureInterface("eth1", config); errors.Is(err, ErrChangeInProgress) {
+		t.Errorf("变更已确认的网卡不应返回ErrChangeInProgress: %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule 解析后的5段cron表达式(分 时 日 月 周)，每段用位图表示允许的取值
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// 日和周都有限制时，两者满足其一即可(与标准cron一致)
+	domRestricted, dowRestricted bool
+}
+
+// cron表达式的预定义别名
+var cronAliases = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+var cronMonthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var cronDayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// 查找下一次/上一次触发时间时最多向前或向后检查的天数，覆盖2月29日这类四年一次的规则
+const cronSearchDays = 366*4 + 1
+
+// parseCron 解析cron表达式，支持*、a-b、*/n、a-b/n、逗号列表、月份和星期的英文缩写以及@daily等别名
+func parseCron(expr string) (*cronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+	if alias, ok := cronAliases[strings.ToLower(expr)]; ok {
+		expr = alias
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron表达式应为5段(分 时 日 月 周)，实际为%d段", len(fields))
+	}
+
+	schedule := &cronSchedule{}
+	var err error
+	if schedule.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("分钟字段 %s 无效: %v", fields[0], err)
+	}
+	if schedule.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("小时字段 %s 无效: %v", fields[1], err)
+	}
+	if schedule.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("日期字段 %s 无效: %v", fields[2], err)
+	}
+	if schedule.month, err = parseCronField(fields[3], 1, 12, cronMonthNames); err != nil {
+		return nil, fmt.Errorf("月份字段 %s 无效: %v", fields[3], err)
+	}
+	if schedule.dow, err = parseCronField(fields[4], 0, 7, cronDayNames); err != nil {
+		return nil, fmt.Errorf("星期字段 %s 无效: %v", fields[4], err)
+	}
+	// 星期中的7与0都表示周日
+	if schedule.dow&(1<<7) != 0 {
+		schedule.dow |= 1
+	}
+	schedule.domRestricted = fields[2] != "*" && fields[2] != "?"
+	schedule.dowRestricted = fields[4] != "*" && fields[4] != "?"
+	return schedule, nil
+}
+
+// parseCronField 解析cron的一段，返回允许取值的位图
+func parseCronField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+				return 0, fmt.Errorf("步长 %s 无效", stepPart)
+			}
+		}
+
+		low, high := min, max
+		switch {
+		case rangePart == "*" || rangePart == "?":
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = parseCronValue(from, names); err != nil {
+				return 0, err
+			}
+			if high, err = parseCronValue(to, names); err != nil {
+				return 0, err
+			}
+		default:
+			value, err := parseCronValue(rangePart, names)
+			if err != nil {
+				return 0, err
+			}
+			low = value
+			if !hasStep {
+				high = value
+			}
+		}
+		if low < min || high > max || low > high {
+			return 0, fmt.Errorf("取值范围 %d-%d 超出 %d-%d", low, high, min, max)
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// parseCronValue 解析数字或英文缩写
+func parseCronValue(value string, names map[string]int) (int, error) {
+	if n, ok := names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("无法识别的取值 %s", value)
+	}
+	return n, nil
+}
+
+// matchesDay 判断某一天是否满足日、月、周的限制
+func (c *cronSchedule) matchesDay(day time.Time) bool {
+	if c.month&(1<<uint(day.Month())) == 0 {
+		return false
+	}
+	domMatch := c.dom&(1<<uint(day.Day())) != 0
+	dowMatch := c.dow&(1<<uint(day.Weekday())) != 0
+	if c.domRestricted && c.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// next 返回晚于after的第一个触发时间
+func (c *cronSchedule) next(after time.Time) (time.Time, bool) {
+	start := after.Truncate(time.Minute).Add(time.Minute)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	for i := 0; i < cronSearchDays; i++ {
+		if c.matchesDay(day) {
+			for h := 0; h < 24; h++ {
+				if c.hour&(1<<uint(h)) == 0 {
+					continue
+				}
+				for m := 0; m < 60; m++ {
+					if c.minute&(1<<uint(m)) == 0 {
+						continue
+					}
+					t := time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, day.Location())
+					if !t.Before(start) {
+						return t, true
+					}
+				}
+			}
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, day.Location())
+	}
+	return time.Time{}, false
+}
+
+// prev 返回不晚于before的最近一个触发时间
+func (c *cronSchedule) prev(before time.Time) (time.Time, bool) {
+	end := before.Truncate(time.Minute)
+	day := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, end.Location())
+	for i := 0; i < cronSearchDays; i++ {
+		if c.matchesDay(day) {
+			for h := 23; h >= 0; h-- {
+				if c.hour&(1<<uint(h)) == 0 {
+					continue
+				}
+				for m := 59; m >= 0; m-- {
+					if c.minute&(1<<uint(m)) == 0 {
+						continue
+					}
+					t := time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, day.Location())
+					if !t.After(end) {
+						return t, true
+					}
+				}
+			}
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day()-1, 0, 0, 0, 0, day.Location())
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// listenDHCP 在UDP 67端口监听，并用SO_BINDTODEVICE只接收指定网卡上的报文
+// 同一台机器上可以为多个网卡各启动一个DHCP服务，Go的UDP套接字默认允许发送广播
+func listenDHCP(iface string) (net.PacketConn, error) {
+	config := net.ListenConfig{
+		Control: func(network, address string, c syscall.RawConn) error {
+			var sockErr error
+			err := c.Control(func(fd uintptr) {
+				if sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEADDR, 1); sockErr != nil {
+					return
+				}
+				sockErr = unix.SetsockoptString(int(fd), unix.SOL_SOCKET, unix.SO_BINDTODEVICE, iface)
+			})
+			if err != nil {
+				return err
+			}
+			return sockErr
+		},
+	}
+	conn, err := config.ListenPacket(context.Background(), "udp4", ":67")
+	if err != nil {
+		return nil, fmt.Errorf("在网卡 %s 上监听DHCP端口失败: %v", iface, err)
+	}
+	return conn, nil
+}
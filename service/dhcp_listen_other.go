@@ -0,0 +1,22 @@
+//go:build !linux
+
+package service
+
+import (
+	"fmt"
+	"net"
+)
+
+// listenDHCP 在网卡地址的UDP 67端口监听，非Linux系统无法绑定到网卡本身
+// Go的UDP套接字默认允许发送广播
+func listenDHCP(iface string) (net.PacketConn, error) {
+	ip, _, err := dhcpInterfaceAddress(iface)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenPacket("udp4", net.JoinHostPort(ip.String(), "67"))
+	if err != nil {
+		return nil, fmt.Errorf("在网卡 %s 上监听DHCP端口失败: %v", iface, err)
+	}
+	return conn, nil
+}
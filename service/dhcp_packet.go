@@ -0,0 +1,197 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"time"
+)
+
+// DHCP消息类型(选项53)
+const (
+	dhcpDiscover = 1
+	dhcpOffer    = 2
+	dhcpRequest  = 3
+	dhcpDecline  = 4
+	dhcpAck      = 5
+	dhcpNak      = 6
+	dhcpRelease  = 7
+	dhcpInform   = 8
+)
+
+// 用到的DHCP选项编号(RFC 2132)
+const (
+	dhcpOptPad           = 0
+	dhcpOptSubnetMask    = 1
+	dhcpOptRouter        = 3
+	dhcpOptDNS           = 6
+	dhcpOptHostname      = 12
+	dhcpOptBroadcast     = 28
+	dhcpOptRequestedIP   = 50
+	dhcpOptLeaseTime     = 51
+	dhcpOptMessageType   = 53
+	dhcpOptServerID      = 54
+	dhcpOptRenewalTime   = 58
+	dhcpOptRebindingTime = 59
+	dhcpOptEnd           = 255
+)
+
+// dhcpMagicCookie BOOTP报文中选项区开头的固定值
+var dhcpMagicCookie = []byte{99, 130, 83, 99}
+
+// dhcpHeaderLen 选项区之前的BOOTP固定头部长度
+const dhcpHeaderLen = 236
+
+// dhcpPacket 一个DHCPv4报文，只保留服务端需要的字段
+type dhcpPacket struct {
+	op      byte
+	xid     [4]byte
+	secs    [2]byte
+	flags   [2]byte
+	ciaddr  net.IP
+	yiaddr  net.IP
+	giaddr  net.IP
+	chaddr  net.HardwareAddr
+	options map[byte][]byte
+	order   []byte // 选项写出顺序
+}
+
+// parseDHCPPacket 解析客户端发来的BOOTREQUEST报文
+func parseDHCPPacket(data []byte) (*dhcpPacket, error) {
+	if len(data) < dhcpHeaderLen+len(dhcpMagicCookie) {
+		return nil, errors.New("报文过短")
+	}
+	if !bytes.Equal(data[dhcpHeaderLen:dhcpHeaderLen+4], dhcpMagicCookie) {
+		return nil, errors.New("缺少DHCP magic cookie")
+	}
+	// 只处理以太网地址
+	if data[1] != 1 || data[2] != 6 {
+		return nil, errors.New("不支持的硬件地址类型")
+	}
+
+	p := &dhcpPacket{
+		op:      data[0],
+		ciaddr:  net.IP(append([]byte{}, data[12:16]...)),
+		yiaddr:  net.IP(append([]byte{}, data[16:20]...)),
+		giaddr:  net.IP(append([]byte{}, data[24:28]...)),
+		chaddr:  net.HardwareAddr(append([]byte{}, data[28:34]...)),
+		options: make(map[byte][]byte),
+	}
+	copy(p.xid[:], data[4:8])
+	copy(p.secs[:], data[8:10])
+	copy(p.flags[:], data[10:12])
+
+	opts := data[dhcpHeaderLen+4:]
+	for i := 0; i < len(opts); {
+		code := opts[i]
+		if code == dhcpOptEnd {
+			break
+		}
+		if code == dhcpOptPad {
+			i++
+			continue
+		}
+		if i+1 >= len(opts) || i+2+int(opts[i+1]) > len(opts) {
+			return nil, errors.New("选项长度错误")
+		}
+		length := int(opts[i+1])
+		// 同一选项出现多次时按RFC 3396拼接
+		p.options[code] = append(p.options[code], opts[i+2:i+2+length]...)
+		i += 2 + length
+	}
+	if p.messageType() == 0 {
+		return nil, errors.New("缺少DHCP消息类型")
+	}
+	return p, nil
+}
+
+// messageType 返回选项53，没有时为0
+func (p *dhcpPacket) messageType() byte {
+	if v := p.options[dhcpOptMessageType]; len(v) == 1 {
+		return v[0]
+	}
+	return 0
+}
+
+// optionIP 返回IPv4地址类型的选项
+func (p *dhcpPacket) optionIP(code byte) net.IP {
+	if v := p.options[code]; len(v) == 4 {
+		return net.IP(v)
+	}
+	return nil
+}
+
+// newDHCPReply 按请求报文创建回复报文
+func newDHCPReply(req *dhcpPacket, msgType byte) *dhcpPacket {
+	reply := &dhcpPacket{
+		op:      2,
+		xid:     req.xid,
+		flags:   req.flags,
+		ciaddr:  net.IPv4zero.To4(),
+		yiaddr:  net.IPv4zero.To4(),
+		giaddr:  req.giaddr,
+		chaddr:  req.chaddr,
+		options: make(map[byte][]byte),
+	}
+	reply.setOption(dhcpOptMessageType, []byte{msgType})
+	return reply
+}
+
+func (p *dhcpPacket) setOption(code byte, value []byte) {
+	if _, exists := p.options[code]; !exists {
+		p.order = append(p.order, code)
+	}
+	p.options[code] = value
+}
+
+func (p *dhcpPacket) setOptionIPs(code byte, ips ...net.IP) {
+	var value []byte
+	for _, ip := range ips {
+		if ip4 := ip.To4(); ip4 != nil {
+			value = append(value, ip4...)
+		}
+	}
+	if len(value) > 0 {
+		p.setOption(code, value)
+	}
+}
+
+func (p *dhcpPacket) setOptionDuration(code byte, d time.Duration) {
+	value := make([]byte, 4)
+	binary.BigEndian.PutUint32(value, uint32(d/time.Second))
+	p.setOption(code, value)
+}
+
+// marshal 编码为报文，长度不足300字节时补零，兼容只接受最小BOOTP长度的客户端
+func (p *dhcpPacket) marshal() []byte {
+	data := make([]byte, dhcpHeaderLen, 300)
+	data[0] = p.op
+	data[1] = 1
+	data[2] = 6
+	copy(data[4:8], p.xid[:])
+	copy(data[8:10], p.secs[:])
+	copy(data[10:12], p.flags[:])
+	copy(data[12:16], p.ciaddr.To4())
+	copy(data[16:20], p.yiaddr.To4())
+	copy(data[24:28], p.giaddr.To4())
+	copy(data[28:34], p.chaddr)
+
+	data = append(data, dhcpMagicCookie...)
+	for _, code := range p.order {
+		value := p.options[code]
+		// 超过255字节的选项拆分为多段
+		for len(value) > 255 {
+			data = append(data, code, 255)
+			data = append(data, value[:255]...)
+			value = value[255:]
+		}
+		data = append(data, code, byte(len(value)))
+		data = append(data, value...)
+	}
+	data = append(data, dhcpOptEnd)
+	for len(data) < 300 {
+		data = append(data, dhcpOptPad)
+	}
+	return data
+}
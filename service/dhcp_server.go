@@ -0,0 +1,846 @@
+package service
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"networkconfig/models"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 内置DHCP服务的错误
+var (
+	ErrDHCPScopeNotFound       = errors.New("dhcp scope not found")
+	ErrDHCPReservationNotFound = errors.New("dhcp reservation not found")
+	ErrDHCPReservationExists   = errors.New("dhcp reservation already exists")
+)
+
+const (
+	defaultDHCPLeaseSeconds = 43200
+	maxDHCPLeaseSeconds     = 365 * 24 * 3600
+	// 发出OFFER后为客户端保留地址的时间
+	dhcpOfferTimeout = time.Minute
+	// 地址池最多检查的地址数，避免配置过大的范围时逐个遍历
+	dhcpMaxPoolScan = 1 << 16
+)
+
+// DHCPServer 进程内的DHCPv4服务，每个启用的地址池在对应网卡上监听UDP 67端口
+// 地址池、静态保留和租约保存在同一个文件中，重启后不会把已分配的地址再分给其他设备
+type DHCPServer struct {
+	file         string
+	mu           sync.Mutex
+	scopes       map[string]models.DHCPScope
+	reservations map[string]models.DHCPReservation
+	leases       map[string]models.DHCPLease // 键为"网卡|MAC"
+	offers       map[string]dhcpPendingOffer // 键为"网卡|MAC"
+	declined     map[string]time.Time        // 客户端报告冲突的地址，键为"网卡|IP"
+	listeners    map[string]net.PacketConn
+	errors       map[string]string
+	wg           sync.WaitGroup
+	debug        bool
+}
+
+// dhcpPendingOffer 已发出OFFER、等待客户端REQUEST的地址
+type dhcpPendingOffer struct {
+	ip      net.IP
+	expires time.Time
+}
+
+// dhcpPool 地址池在当前网卡地址下的实际参数
+type dhcpPool struct {
+	serverIP net.IP
+	subnet   *net.IPNet
+	start    uint32
+	end      uint32
+	router   net.IP
+	dns      []net.IP
+	lease    time.Duration
+}
+
+// dhcpFile DHCP持久化文件内容
+type dhcpFile struct {
+	Scopes       []models.DHCPScope       `json:"scopes"`
+	Reservations []models.DHCPReservation `json:"reservations"`
+	Leases       []models.DHCPLease       `json:"leases"`
+}
+
+// NewDHCPServer 创建内置DHCP服务，文件位置由DHCP_FILE指定
+func NewDHCPServer(debug bool) *DHCPServer {
+	file := os.Getenv("DHCP_FILE")
+	if file == "" {
+		file = "dhcp.json"
+	}
+
+	s := &DHCPServer{
+		file:         file,
+		scopes:       make(map[string]models.DHCPScope),
+		reservations: make(map[string]models.DHCPReservation),
+		leases:       make(map[string]models.DHCPLease),
+		offers:       make(map[string]dhcpPendingOffer),
+		declined:     make(map[string]time.Time),
+		listeners:    make(map[string]net.PacketConn),
+		errors:       make(map[string]string),
+		debug:        debug,
+	}
+	s.load()
+	return s
+}
+
+// Start 为所有启用的地址池启动监听
+func (s *DHCPServer) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, scope := range s.scopes {
+		if scope.Enabled {
+			s.startLocked(name)
+		}
+	}
+}
+
+// Stop 停止所有监听
+func (s *DHCPServer) Stop() {
+	s.mu.Lock()
+	for name := range s.listeners {
+		s.stopLocked(name)
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// startLocked 在网卡上启动监听，调用方需持有锁
+func (s *DHCPServer) startLocked(iface string) {
+	if _, ok := s.listeners[iface]; ok {
+		return
+	}
+	conn, err := listenDHCP(iface)
+	if err != nil {
+		s.errors[iface] = err.Error()
+		log.Printf("启动网卡 %s 的DHCP服务失败: %v", iface, err)
+		return
+	}
+	delete(s.errors, iface)
+	s.listeners[iface] = conn
+	s.wg.Add(1)
+	go s.serve(iface, conn)
+	log.Printf("网卡 %s 的DHCP服务已启动", iface)
+}
+
+// stopLocked 停止网卡上的监听，调用方需持有锁
+func (s *DHCPServer) stopLocked(iface string) {
+	if conn, ok := s.listeners[iface]; ok {
+		conn.Close()
+		delete(s.listeners, iface)
+		log.Printf("网卡 %s 的DHCP服务已停止", iface)
+	}
+	delete(s.errors, iface)
+}
+
+// serve 接收并回复一个网卡上的DHCP报文
+func (s *DHCPServer) serve(iface string, conn net.PacketConn) {
+	defer s.wg.Done()
+
+	buf := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Printf("读取网卡 %s 的DHCP报文失败: %v", iface, err)
+			continue
+		}
+
+		reply, dst := s.handle(iface, buf[:n], time.Now())
+		if reply == nil {
+			continue
+		}
+		if _, err := conn.WriteTo(reply, dst); err != nil {
+			log.Printf("发送网卡 %s 的DHCP回复失败: %v", iface, err)
+		}
+	}
+}
+
+// handle 处理一个DHCP请求，返回回复报文和目的地址，不需要回复时返回nil
+func (s *DHCPServer) handle(iface string, data []byte, now time.Time) ([]byte, *net.UDPAddr) {
+	req, err := parseDHCPPacket(data)
+	if err != nil {
+		if s.debug {
+			log.Printf("忽略网卡 %s 上无法解析的DHCP报文: %v", iface, err)
+		}
+		return nil, nil
+	}
+	if req.op != 1 {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scope, ok := s.scopes[iface]
+	if !ok || !scope.Enabled {
+		return nil, nil
+	}
+	pool, err := dhcpPoolFor(scope)
+	if err != nil {
+		log.Printf("网卡 %s 的DHCP地址池不可用: %v", iface, err)
+		return nil, nil
+	}
+
+	mac := req.chaddr.String()
+	key := iface + "|" + mac
+	var reply *dhcpPacket
+
+	switch req.messageType() {
+	case dhcpDiscover:
+		ip := s.chooseLocked(iface, mac, pool, req.optionIP(dhcpOptRequestedIP), now)
+		if ip == nil {
+			log.Printf("网卡 %s 的DHCP地址池已用完，无法为 %s 分配地址", iface, mac)
+			return nil, nil
+		}
+		s.offers[key] = dhcpPendingOffer{ip: ip, expires: now.Add(dhcpOfferTimeout)}
+		reply = newDHCPReply(req, dhcpOffer)
+		reply.yiaddr = ip
+		pool.setLeaseOptions(reply)
+
+	case dhcpRequest:
+		// 客户端选择了其他DHCP服务器
+		if serverID := req.optionIP(dhcpOptServerID); serverID != nil && !serverID.Equal(pool.serverIP) {
+			delete(s.offers, key)
+			return nil, nil
+		}
+		ip := req.optionIP(dhcpOptRequestedIP)
+		if ip == nil {
+			ip = req.ciaddr
+		}
+		if ip == nil || ip.IsUnspecified() || !pool.subnet.Contains(ip) {
+			return nil, nil
+		}
+		if !s.assignableLocked(iface, mac, ip, pool, now, true) {
+			log.Printf("拒绝 %s 在网卡 %s 上请求的地址 %s", mac, iface, ip)
+			reply = newDHCPReply(req, dhcpNak)
+			reply.setOptionIPs(dhcpOptServerID, pool.serverIP)
+			break
+		}
+
+		lease := models.DHCPLease{
+			Interface: iface,
+			MAC:       mac,
+			IP:        ip.String(),
+			Hostname:  string(req.options[dhcpOptHostname]),
+			Expires:   now.Add(pool.lease),
+		}
+		if reservation, ok := s.reservations[mac]; ok && reservation.IP == lease.IP {
+			lease.Reserved = true
+		}
+		if previous, ok := s.leases[key]; !ok || previous.IP != lease.IP {
+			log.Printf("DHCP: 把 %s 分配给 %s(%s)，网卡 %s", lease.IP, mac, lease.Hostname, iface)
+		}
+		s.leases[key] = lease
+		delete(s.offers, key)
+		if err := s.saveLocked(); err != nil {
+			log.Printf("保存DHCP租约失败: %v", err)
+		}
+		reply = newDHCPReply(req, dhcpAck)
+		reply.ciaddr = req.ciaddr
+		reply.yiaddr = ip
+		pool.setLeaseOptions(reply)
+
+	case dhcpDecline:
+		ip := req.optionIP(dhcpOptRequestedIP)
+		if ip == nil {
+			return nil, nil
+		}
+		log.Printf("%s 报告地址 %s 已被占用，暂停分配该地址", mac, ip)
+		s.declined[iface+"|"+ip.String()] = now.Add(pool.lease)
+		if lease, ok := s.leases[key]; ok && lease.IP == ip.String() {
+			delete(s.leases, key)
+		}
+		delete(s.offers, key)
+		return nil, nil
+
+	case dhcpRelease:
+		if lease, ok := s.leases[key]; ok && lease.IP == req.ciaddr.String() {
+			delete(s.leases, key)
+			if err := s.saveLocked(); err != nil {
+				log.Printf("保存DHCP租约失败: %v", err)
+			}
+			log.Printf("DHCP: %s 释放了地址 %s", mac, lease.IP)
+		}
+		return nil, nil
+
+	case dhcpInform:
+		// 已有地址的客户端只查询网关、DNS等参数
+		reply = newDHCPReply(req, dhcpAck)
+		reply.ciaddr = req.ciaddr
+		pool.setOptions(reply)
+
+	default:
+		return nil, nil
+	}
+
+	return reply.marshal(), dhcpReplyAddress(req, reply)
+}
+
+// dhcpReplyAddress 按RFC 2131 4.1选择回复的目的地址
+// 没有ARP表项时无法单播给尚无地址的客户端，因此这种情况统一广播
+func dhcpReplyAddress(req, reply *dhcpPacket) *net.UDPAddr {
+	switch {
+	case !req.giaddr.IsUnspecified():
+		return &net.UDPAddr{IP: req.giaddr, Port: 67}
+	case reply.messageType() != dhcpNak && !req.ciaddr.IsUnspecified():
+		return &net.UDPAddr{IP: req.ciaddr, Port: 68}
+	default:
+		return &net.UDPAddr{IP: net.IPv4bcast, Port: 68}
+	}
+}
+
+// setOptions 写入子网掩码、网关、DNS和服务器标识
+func (p dhcpPool) setOptions(reply *dhcpPacket) {
+	reply.setOptionIPs(dhcpOptServerID, p.serverIP)
+	reply.setOption(dhcpOptSubnetMask, []byte(p.subnet.Mask))
+	reply.setOptionIPs(dhcpOptBroadcast, broadcastAddress(p.subnet))
+	reply.setOptionIPs(dhcpOptRouter, p.router)
+	reply.setOptionIPs(dhcpOptDNS, p.dns...)
+}
+
+// setLeaseOptions 写入租期相关选项和其他参数
+func (p dhcpPool) setLeaseOptions(reply *dhcpPacket) {
+	reply.setOptionDuration(dhcpOptLeaseTime, p.lease)
+	reply.setOptionDuration(dhcpOptRenewalTime, p.lease/2)
+	reply.setOptionDuration(dhcpOptRebindingTime, p.lease*7/8)
+	p.setOptions(reply)
+}
+
+// chooseLocked 为客户端选择地址：静态保留、已有租约、客户端请求的地址、从未分配过的地址、已过期的地址
+func (s *DHCPServer) chooseLocked(iface, mac string, pool dhcpPool, requested net.IP, now time.Time) net.IP {
+	if reservation, ok := s.reservations[mac]; ok {
+		if ip := net.ParseIP(reservation.IP).To4(); ip != nil && pool.subnet.Contains(ip) {
+			if s.assignableLocked(iface, mac, ip, pool, now, true) {
+				return ip
+			}
+			return nil
+		}
+	}
+
+	key := iface + "|" + mac
+	candidates := []net.IP{requested}
+	if lease, ok := s.leases[key]; ok {
+		candidates = append([]net.IP{net.ParseIP(lease.IP).To4()}, candidates...)
+	}
+	if offer, ok := s.offers[key]; ok {
+		candidates = append([]net.IP{offer.ip}, candidates...)
+	}
+	for _, ip := range candidates {
+		if ip != nil && s.assignableLocked(iface, mac, ip, pool, now, true) {
+			return ip
+		}
+	}
+
+	for _, allowExpired := range []bool{false, true} {
+		for n, scanned := pool.start, 0; n <= pool.end && scanned < dhcpMaxPoolScan; n, scanned = n+1, scanned+1 {
+			ip := uint32ToIP(n)
+			if s.assignableLocked(iface, mac, ip, pool, now, allowExpired) {
+				return ip
+			}
+			if n == pool.end {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// assignableLocked 判断地址能否分配给该客户端，allowExpired为false时不使用曾分配给其他客户端的地址
+func (s *DHCPServer) assignableLocked(iface, mac string, ip net.IP, pool dhcpPool, now time.Time, allowExpired bool) bool {
+	ip = ip.To4()
+	if ip == nil || !pool.subnet.Contains(ip) || ip.Equal(pool.serverIP) || ip.Equal(pool.subnet.IP) || ip.Equal(broadcastAddress(pool.subnet)) {
+		return false
+	}
+	if until, ok := s.declined[iface+"|"+ip.String()]; ok {
+		if now.Before(until) {
+			return false
+		}
+		delete(s.declined, iface+"|"+ip.String())
+	}
+
+	// 有静态保留的客户端只能使用保留地址，保留地址也不分给其他客户端
+	if reservation, ok := s.reservations[mac]; ok {
+		if reserved := net.ParseIP(reservation.IP); reserved != nil && pool.subnet.Contains(reserved) {
+			return reserved.Equal(ip)
+		}
+	}
+	reserved := false
+	for owner, reservation := range s.reservations {
+		if owner != mac && reservation.IP == ip.String() {
+			return false
+		}
+		if owner == mac && reservation.IP == ip.String() {
+			reserved = true
+		}
+	}
+
+	for key, lease := range s.leases {
+		if lease.IP != ip.String() || !strings.HasPrefix(key, iface+"|") || lease.MAC == mac {
+			continue
+		}
+		if !allowExpired || now.Before(lease.Expires) {
+			return false
+		}
+	}
+	for key, offer := range s.offers {
+		if offer.ip.Equal(ip) && key != iface+"|"+mac && strings.HasPrefix(key, iface+"|") && now.Before(offer.expires) {
+			return false
+		}
+	}
+
+	n := ipToUint32(ip)
+	return reserved || (n >= pool.start && n <= pool.end)
+}
+
+// dhcpPoolFor 按网卡当前地址计算地址池参数
+func dhcpPoolFor(scope models.DHCPScope) (dhcpPool, error) {
+	serverIP, ifaceNet, err := dhcpInterfaceAddress(scope.Interface)
+	if err != nil {
+		return dhcpPool{}, err
+	}
+
+	mask := ifaceNet.Mask
+	if scope.SubnetMask != "" {
+		mask = net.IPMask(net.ParseIP(scope.SubnetMask).To4())
+	}
+	pool := dhcpPool{
+		serverIP: serverIP,
+		subnet:   &net.IPNet{IP: serverIP.Mask(mask), Mask: mask},
+		start:    ipToUint32(net.ParseIP(scope.RangeStart)),
+		end:      ipToUint32(net.ParseIP(scope.RangeEnd)),
+		router:   serverIP,
+		dns:      []net.IP{serverIP},
+		lease:    time.Duration(scope.LeaseSeconds) * time.Second,
+	}
+	if !pool.subnet.Contains(net.ParseIP(scope.RangeStart)) || !pool.subnet.Contains(net.ParseIP(scope.RangeEnd)) {
+		return dhcpPool{}, fmt.Errorf("地址池 %s-%s 不在网卡地址 %s/%d 的子网内", scope.RangeStart, scope.RangeEnd, serverIP, maskOnes(mask))
+	}
+	if scope.Router != "" {
+		pool.router = net.ParseIP(scope.Router).To4()
+	}
+	if len(scope.DNS) > 0 {
+		pool.dns = nil
+		for _, server := range scope.DNS {
+			pool.dns = append(pool.dns, net.ParseIP(server).To4())
+		}
+	}
+	if pool.lease == 0 {
+		pool.lease = defaultDHCPLeaseSeconds * time.Second
+	}
+	return pool, nil
+}
+
+// dhcpInterfaceAddress 返回网卡的第一个IPv4地址
+func dhcpInterfaceAddress(name string) (net.IP, *net.IPNet, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("网卡 %s 不存在: %v", name, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取网卡 %s 的地址失败: %v", name, err)
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+			return ipNet.IP.To4(), &net.IPNet{IP: ipNet.IP.To4(), Mask: ipNet.Mask[len(ipNet.Mask)-4:]}, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("网卡 %s 没有IPv4地址", name)
+}
+
+func maskOnes(mask net.IPMask) int {
+	ones, _ := mask.Size()
+	return ones
+}
+
+func ipToUint32(ip net.IP) uint32 {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(ip4)
+}
+
+func uint32ToIP(n uint32) net.IP {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, n)
+	return ip
+}
+
+// validateDHCPScope 校验地址池配置，网卡地址只在运行时检查，以便网卡尚未配置地址时先保存
+func validateDHCPScope(scope models.DHCPScope) ValidationErrors {
+	var errs ValidationErrors
+	if scope.Interface == "" {
+		errs.add("interface", ValidationRequired, "必须指定网卡")
+	}
+
+	parse := func(field, value string) net.IP {
+		ip := net.ParseIP(value)
+		switch {
+		case value == "":
+			errs.add(field, ValidationRequired, "必须提供%s", field)
+		case ip == nil:
+			errs.add(field, ValidationInvalidAddress, "无效的IPv4地址: %s", value)
+		case ip.To4() == nil:
+			errs.add(field, ValidationWrongFamily, "%s 不是IPv4地址", value)
+		case !usableUnicast(ip):
+			errs.add(field, ValidationInvalidAddress, "%s 不能分配给客户端", value)
+		default:
+			return ip.To4()
+		}
+		return nil
+	}
+	start := parse("range_start", scope.RangeStart)
+	end := parse("range_end", scope.RangeEnd)
+	if start != nil && end != nil && ipToUint32(start) > ipToUint32(end) {
+		errs.add("range_end", ValidationInvalidValue, "结束地址 %s 小于起始地址 %s", scope.RangeEnd, scope.RangeStart)
+	}
+
+	if scope.SubnetMask != "" {
+		parsed := net.ParseIP(scope.SubnetMask)
+		if parsed == nil || parsed.To4() == nil {
+			errs.add("subnet_mask", ValidationInvalidMask, "无效的子网掩码: %s", scope.SubnetMask)
+		} else if mask := net.IPMask(parsed.To4()); maskOnes(mask) == 0 {
+			errs.add("subnet_mask", ValidationNonContiguousMask, "子网掩码不连续或为0: %s", scope.SubnetMask)
+		} else if start != nil && end != nil && !start.Mask(mask).Equal(end.Mask(mask)) {
+			errs.add("range_end", ValidationInvalidValue, "起始地址和结束地址不在同一子网")
+		}
+	}
+
+	if scope.Router != "" {
+		if ip := net.ParseIP(scope.Router); ip == nil || ip.To4() == nil || !usableUnicast(ip) {
+			errs.add("router", ValidationInvalidAddress, "无效的网关地址: %s", scope.Router)
+		}
+	}
+	validateDNSServers(&errs, "dns", scope.DNS, false)
+
+	if scope.LeaseSeconds != 0 && (scope.LeaseSeconds < 60 || scope.LeaseSeconds > maxDHCPLeaseSeconds) {
+		errs.add("lease_seconds", ValidationInvalidValue, "租期必须为60-%d秒", maxDHCPLeaseSeconds)
+	}
+	return errs
+}
+
+// normalizeDHCPReservation 校验静态保留，MAC统一为小写冒号分隔
+func normalizeDHCPReservation(reservation models.DHCPReservation) (models.DHCPReservation, ValidationErrors) {
+	var errs ValidationErrors
+	mac := normalizeMAC(reservation.MAC)
+	if mac == "" {
+		errs.add("mac", ValidationInvalidMAC, "MAC地址 %s 格式错误", reservation.MAC)
+	}
+	ip := net.ParseIP(reservation.IP)
+	switch {
+	case reservation.IP == "":
+		errs.add("ip", ValidationRequired, "必须提供保留的IP地址")
+	case ip == nil:
+		errs.add("ip", ValidationInvalidAddress, "无效的IPv4地址: %s", reservation.IP)
+	case ip.To4() == nil:
+		errs.add("ip", ValidationWrongFamily, "%s 不是IPv4地址", reservation.IP)
+	case !usableUnicast(ip):
+		errs.add("ip", ValidationInvalidAddress, "%s 不能分配给客户端", reservation.IP)
+	default:
+		reservation.IP = ip.To4().String()
+	}
+	reservation.MAC = mac
+	return reservation, errs
+}
+
+// Scopes 返回所有地址池及其运行状态，按网卡名称排序
+func (s *DHCPServer) Scopes() []models.DHCPScope {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scopes := make([]models.DHCPScope, 0, len(s.scopes))
+	for name, scope := range s.scopes {
+		_, scope.Running = s.listeners[name]
+		scope.Error = s.errors[name]
+		scopes = append(scopes, scope)
+	}
+	sort.Slice(scopes, func(i, j int) bool { return scopes[i].Interface < scopes[j].Interface })
+	return scopes
+}
+
+// SetScope 保存网卡的地址池，并按enabled启动或停止监听
+func (s *DHCPServer) SetScope(scope models.DHCPScope) (models.DHCPScope, error) {
+	scope.Running, scope.Error = false, ""
+	if errs := validateDHCPScope(scope); len(errs) > 0 {
+		return models.DHCPScope{}, errs
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, existed := s.scopes[scope.Interface]
+	s.scopes[scope.Interface] = scope
+	if err := s.saveLocked(); err != nil {
+		if existed {
+			s.scopes[scope.Interface] = previous
+		} else {
+			delete(s.scopes, scope.Interface)
+		}
+		return models.DHCPScope{}, err
+	}
+
+	if scope.Enabled {
+		s.startLocked(scope.Interface)
+	} else {
+		s.stopLocked(scope.Interface)
+	}
+	_, scope.Running = s.listeners[scope.Interface]
+	scope.Error = s.errors[scope.Interface]
+	return scope, nil
+}
+
+// DeleteScope 停止并删除网卡的地址池，该网卡的租约一并删除
+func (s *DHCPServer) DeleteScope(iface string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.scopes[iface]; !ok {
+		return fmt.Errorf("网卡 %s 没有DHCP地址池: %w", iface, ErrDHCPScopeNotFound)
+	}
+	s.stopLocked(iface)
+	delete(s.scopes, iface)
+	for key := range s.leases {
+		if strings.HasPrefix(key, iface+"|") {
+			delete(s.leases, key)
+		}
+	}
+	return s.saveLocked()
+}
+
+// Leases 返回未过期的租约，iface为空时返回所有网卡的租约
+func (s *DHCPServer) Leases(iface string) []models.DHCPLease {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	leases := []models.DHCPLease{}
+	for _, lease := range s.leases {
+		if now.After(lease.Expires) || (iface != "" && lease.Interface != iface) {
+			continue
+		}
+		leases = append(leases, lease)
+	}
+	sort.Slice(leases, func(i, j int) bool {
+		if leases[i].Interface != leases[j].Interface {
+			return leases[i].Interface < leases[j].Interface
+		}
+		return ipToUint32(net.ParseIP(leases[i].IP)) < ipToUint32(net.ParseIP(leases[j].IP))
+	})
+	return leases
+}
+
+// leaseByMAC 返回指定MAC未过期的租约
+func (s *DHCPServer) leaseByMAC(mac string) (models.DHCPLease, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for _, lease := range s.leases {
+		if lease.MAC == mac && now.Before(lease.Expires) {
+			return lease, true
+		}
+	}
+	return models.DHCPLease{}, false
+}
+
+// Reservations 返回所有静态保留，按IP排序
+func (s *DHCPServer) Reservations() []models.DHCPReservation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reservations := make([]models.DHCPReservation, 0, len(s.reservations))
+	for _, reservation := range s.reservations {
+		reservations = append(reservations, reservation)
+	}
+	sort.Slice(reservations, func(i, j int) bool {
+		return ipToUint32(net.ParseIP(reservations[i].IP)) < ipToUint32(net.ParseIP(reservations[j].IP))
+	})
+	return reservations
+}
+
+// SetReservation 添加或修改静态保留，create为true时MAC已存在返回ErrDHCPReservationExists
+func (s *DHCPServer) SetReservation(reservation models.DHCPReservation, create bool) (models.DHCPReservation, error) {
+	reservation, errs := normalizeDHCPReservation(reservation)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for mac, other := range s.reservations {
+		if mac != reservation.MAC && other.IP == reservation.IP && reservation.IP != "" {
+			errs.add("ip", ValidationDuplicateAddress, "地址 %s 已保留给 %s", reservation.IP, mac)
+		}
+	}
+	if len(errs) > 0 {
+		return models.DHCPReservation{}, errs
+	}
+
+	previous, existed := s.reservations[reservation.MAC]
+	if create && existed {
+		return models.DHCPReservation{}, fmt.Errorf("MAC地址 %s 已有静态保留: %w", reservation.MAC, ErrDHCPReservationExists)
+	}
+	s.reservations[reservation.MAC] = reservation
+	if err := s.saveLocked(); err != nil {
+		if existed {
+			s.reservations[reservation.MAC] = previous
+		} else {
+			delete(s.reservations, reservation.MAC)
+		}
+		return models.DHCPReservation{}, err
+	}
+	log.Printf("DHCP静态保留已更新: %s -> %s", reservation.MAC, reservation.IP)
+	return reservation, nil
+}
+
+// DeleteReservation 删除静态保留，已分配的租约到期前继续有效
+func (s *DHCPServer) DeleteReservation(mac string) error {
+	normalized := normalizeMAC(mac)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.reservations[normalized]; !ok {
+		return fmt.Errorf("MAC地址 %s 没有静态保留: %w", mac, ErrDHCPReservationNotFound)
+	}
+	delete(s.reservations, normalized)
+	log.Printf("DHCP静态保留已删除: %s", normalized)
+	return s.saveLocked()
+}
+
+// load 从文件加载地址池、静态保留和租约
+func (s *DHCPServer) load() {
+	data, err := os.ReadFile(s.file)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("读取DHCP配置文件 %s 失败: %v", s.file, err)
+		}
+		return
+	}
+
+	var content dhcpFile
+	if err := json.Unmarshal(data, &content); err != nil {
+		log.Printf("解析DHCP配置文件 %s 失败: %v", s.file, err)
+		return
+	}
+	for _, scope := range content.Scopes {
+		if errs := validateDHCPScope(scope); len(errs) > 0 {
+			log.Printf("忽略网卡 %s 无效的DHCP地址池: %v", scope.Interface, errs)
+			continue
+		}
+		s.scopes[scope.Interface] = scope
+	}
+	for _, reservation := range content.Reservations {
+		normalized, errs := normalizeDHCPReservation(reservation)
+		if len(errs) > 0 {
+			log.Printf("忽略无效的DHCP静态保留 %s: %v", reservation.MAC, errs)
+			continue
+		}
+		s.reservations[normalized.MAC] = normalized
+	}
+	for _, lease := range content.Leases {
+		s.leases[lease.Interface+"|"+lease.MAC] = lease
+	}
+	log.Printf("已从 %s 加载 %d 个DHCP地址池、%d 个静态保留", s.file, len(s.scopes), len(s.reservations))
+}
+
+// saveLocked 写入文件，过期的租约不再保存，调用方需持有锁
+func (s *DHCPServer) saveLocked() error {
+	content := dhcpFile{
+		Scopes:       []models.DHCPScope{},
+		Reservations: []models.DHCPReservation{},
+		Leases:       []models.DHCPLease{},
+	}
+	for _, scope := range s.scopes {
+		scope.Running, scope.Error = false, ""
+		content.Scopes = append(content.Scopes, scope)
+	}
+	for _, reservation := range s.reservations {
+		content.Reservations = append(content.Reservations, reservation)
+	}
+	now := time.Now()
+	for key, lease := range s.leases {
+		if now.After(lease.Expires) {
+			delete(s.leases, key)
+			continue
+		}
+		content.Leases = append(content.Leases, lease)
+	}
+	sort.Slice(content.Scopes, func(i, j int) bool { return content.Scopes[i].Interface < content.Scopes[j].Interface })
+	sort.Slice(content.Reservations, func(i, j int) bool { return content.Reservations[i].MAC < content.Reservations[j].MAC })
+	sort.Slice(content.Leases, func(i, j int) bool {
+		return content.Leases[i].Interface+"|"+content.Leases[i].MAC < content.Leases[j].Interface+"|"+content.Leases[j].MAC
+	})
+
+	data, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化DHCP配置失败: %v", err)
+	}
+	if dir := filepath.Dir(s.file); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建DHCP配置目录失败: %v", err)
+		}
+	}
+	if err := os.WriteFile(s.file, data, 0644); err != nil {
+		return fmt.Errorf("保存DHCP配置失败: %v", err)
+	}
+	return nil
+}
+
+// StartDHCPServer 启动内置DHCP服务中所有启用的地址池
+func (s *NetworkService) StartDHCPServer() {
+	s.dhcp.Start()
+}
+
+// StopDHCPServer 停止内置DHCP服务
+func (s *NetworkService) StopDHCPServer() {
+	s.dhcp.Stop()
+}
+
+// GetDHCPScopes 获取所有DHCP地址池及其运行状态
+func (s *NetworkService) GetDHCPScopes() []models.DHCPScope {
+	return s.dhcp.Scopes()
+}
+
+// SetDHCPScope 保存网卡的DHCP地址池，并按enabled启动或停止服务
+func (s *NetworkService) SetDHCPScope(iface string, scope models.DHCPScope) (models.DHCPScope, error) {
+	scope.Interface = iface
+	return s.dhcp.SetScope(scope)
+}
+
+// DeleteDHCPScope 删除网卡的DHCP地址池
+func (s *NetworkService) DeleteDHCPScope(iface string) error {
+	return s.dhcp.DeleteScope(iface)
+}
+
+// GetDHCPLeases 获取DHCP租约，iface为空时返回所有网卡的租约
+func (s *NetworkService) GetDHCPLeases(iface string) []models.DHCPLease {
+	return s.dhcp.Leases(iface)
+}
+
+// GetDHCPReservations 获取DHCP静态保留
+func (s *NetworkService) GetDHCPReservations() []models.DHCPReservation {
+	return s.dhcp.Reservations()
+}
+
+// AddDHCPReservation 添加DHCP静态保留
+func (s *NetworkService) AddDHCPReservation(reservation models.DHCPReservation) (models.DHCPReservation, error) {
+	return s.dhcp.SetReservation(reservation, true)
+}
+
+// SetDHCPReservation 添加或修改指定MAC的DHCP静态保留
+func (s *NetworkService) SetDHCPReservation(mac string, reservation models.DHCPReservation) (models.DHCPReservation, error) {
+	reservation.MAC = mac
+	return s.dhcp.SetReservation(reservation, false)
+}
+
+// DeleteDHCPReservation 删除DHCP静态保留
+func (s *NetworkService) DeleteDHCPReservation(mac string) error {
+	return s.dhcp.DeleteReservation(mac)
+}
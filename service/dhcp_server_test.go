@@ -0,0 +1,446 @@
+package service
+
+import (
+	"net"
+	"networkconfig/models"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// dhcpTestServer 在回环网卡上配置地址池的DHCP服务，handle直接在进程内调用，不监听端口
+// 地址池为回环网段中的3个地址，便于测试地址池耗尽
+type dhcpTestServer struct {
+	*DHCPServer
+	iface    string
+	serverIP net.IP
+	pool     []net.IP
+}
+
+// newDHCPTestServer 创建使用临时文件的DHCP服务
+func newDHCPTestServer(t *testing.T) *dhcpTestServer {
+	t.Helper()
+	iface, serverIP := dhcpLoopback(t)
+	t.Setenv("DHCP_FILE", filepath.Join(t.TempDir(), "dhcp.json"))
+
+	base := ipToUint32(serverIP.Mask(net.CIDRMask(24, 32)))
+	s := &dhcpTestServer{DHCPServer: NewDHCPServer(false), iface: iface, serverIP: serverIP}
+	for n := base + 10; n <= base+12; n++ {
+		s.pool = append(s.pool, uint32ToIP(n))
+	}
+	s.scopes[iface] = models.DHCPScope{
+		Interface:    iface,
+		Enabled:      true,
+		RangeStart:   s.pool[0].String(),
+		RangeEnd:     s.pool[2].String(),
+		SubnetMask:   "255.255.255.0",
+		LeaseSeconds: 3600,
+	}
+	return s
+}
+
+// dhcpLoopback 返回回环网卡名及其IPv4地址，地址池参数按网卡的实际地址计算
+func dhcpLoopback(t *testing.T) (string, net.IP) {
+	t.Helper()
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		t.Skipf("无法获取本机网卡: %v", err)
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback == 0 {
+			continue
+		}
+		if ip, _, err := dhcpInterfaceAddress(iface.Name); err == nil {
+			return iface.Name, ip
+		}
+	}
+	t.Skip("没有带IPv4地址的回环网卡")
+	return "", nil
+}
+
+// dhcpTestClient 模拟的DHCP客户端
+type dhcpTestClient struct {
+	mac      net.HardwareAddr
+	hostname string
+}
+
+func newDHCPTestClient(mac, hostname string) dhcpTestClient {
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		panic(err)
+	}
+	return dhcpTestClient{mac: hw, hostname: hostname}
+}
+
+// packet 生成客户端报文，ciaddr、requested、serverID为nil时不设置
+func (c dhcpTestClient) packet(msgType byte, ciaddr, requested, serverID net.IP) []byte {
+	p := &dhcpPacket{
+		op:      1,
+		xid:     [4]byte{0x12, 0x34, 0x56, 0x78},
+		ciaddr:  net.IPv4zero,
+		yiaddr:  net.IPv4zero,
+		giaddr:  net.IPv4zero,
+		chaddr:  c.mac,
+		options: make(map[byte][]byte),
+	}
+	if ciaddr != nil {
+		p.ciaddr = ciaddr
+	}
+	p.setOption(dhcpOptMessageType, []byte{msgType})
+	p.setOptionIPs(dhcpOptRequestedIP, requested)
+	p.setOptionIPs(dhcpOptServerID, serverID)
+	if c.hostname != "" {
+		p.setOption(dhcpOptHostname, []byte(c.hostname))
+	}
+	return p.marshal()
+}
+
+// exchange 处理一个客户端报文并解析回复，没有回复时返回nil
+func (s *dhcpTestServer) exchange(t *testing.T, data []byte, now time.Time) (*dhcpPacket, *net.UDPAddr) {
+	t.Helper()
+	out, dst := s.handle(s.iface, data, now)
+	if out == nil {
+		return nil, nil
+	}
+	reply, err := parseDHCPPacket(out)
+	if err != nil {
+		t.Fatalf("无法解析回复报文: %v", err)
+	}
+	if reply.op != 2 {
+		t.Fatalf("回复报文op应为2，得到 %d", reply.op)
+	}
+	return reply, dst
+}
+
+// lease 获取地址：DISCOVER后按OFFER的地址REQUEST，返回ACK中的地址
+func (s *dhcpTestServer) lease(t *testing.T, c dhcpTestClient, now time.Time) net.IP {
+	t.Helper()
+	offer, _ := s.exchange(t, c.packet(dhcpDiscover, nil, nil, nil), now)
+	if offer == nil || offer.messageType() != dhcpOffer {
+		t.Fatalf("%s 没有收到OFFER: %+v", c.mac, offer)
+	}
+	ack, _ := s.exchange(t, c.packet(dhcpRequest, nil, offer.yiaddr, s.serverIP), now)
+	if ack == nil || ack.messageType() != dhcpAck {
+		t.Fatalf("%s 请求 %s 没有收到ACK: %+v", c.mac, offer.yiaddr, ack)
+	}
+	if !ack.yiaddr.Equal(offer.yiaddr) {
+		t.Fatalf("ACK的地址 %s 与OFFER的地址 %s 不一致", ack.yiaddr, offer.yiaddr)
+	}
+	return ack.yiaddr
+}
+
+func TestDHCPServerLeaseFlow(t *testing.T) {
+	s := newDHCPTestServer(t)
+	client := newDHCPTestClient("52:54:00:12:34:56", "laptop")
+	now := time.Now()
+	broadcast := &net.UDPAddr{IP: net.IPv4bcast, Port: 68}
+
+	offer, dst := s.exchange(t, client.packet(dhcpDiscover, nil, nil, nil), now)
+	if offer == nil || offer.messageType() != dhcpOffer {
+		t.Fatalf("DISCOVER应收到OFFER，得到: %+v", offer)
+	}
+	if !offer.yiaddr.Equal(s.pool[0]) {
+		t.Errorf("应分配地址池中的第一个地址 %s，得到 %s", s.pool[0], offer.yiaddr)
+	}
+	if !reflect.DeepEqual(dst, broadcast) {
+		t.Errorf("尚无地址的客户端应广播回复，得到 %v", dst)
+	}
+	if offer.xid != [4]byte{0x12, 0x34, 0x56, 0x78} || offer.chaddr.String() != client.mac.String() {
+		t.Errorf("OFFER的xid或chaddr与请求不一致: %+v", offer)
+	}
+	wantOptions := map[byte][]byte{
+		dhcpOptServerID:      s.serverIP.To4(),
+		dhcpOptSubnetMask:    {255, 255, 255, 0},
+		dhcpOptRouter:        s.serverIP.To4(),
+		dhcpOptDNS:           s.serverIP.To4(),
+		dhcpOptLeaseTime:     {0, 0, 0x0e, 0x10},
+		dhcpOptRenewalTime:   {0, 0, 0x07, 0x08},
+		dhcpOptRebindingTime: {0, 0, 0x0c, 0x4e},
+	}
+	for code, want := range wantOptions {
+		if got := offer.options[code]; !reflect.DeepEqual(got, want) {
+			t.Errorf("OFFER选项%d应为 %v，得到 %v", code, want, got)
+		}
+	}
+
+	// 其他客户端在OFFER有效期内拿不到这个地址
+	other, _ := s.exchange(t, newDHCPTestClient("52:54:00:ab:cd:ef", "").packet(dhcpDiscover, nil, offer.yiaddr, nil), now)
+	if other == nil || other.yiaddr.Equal(offer.yiaddr) {
+		t.Errorf("已OFFER的地址不应再分给其他客户端，得到: %+v", other)
+	}
+
+	ack, dst := s.exchange(t, client.packet(dhcpRequest, nil, offer.yiaddr, s.serverIP), now)
+	if ack == nil || ack.messageType() != dhcpAck || !ack.yiaddr.Equal(offer.yiaddr) {
+		t.Fatalf("REQUEST应收到ACK %s，得到: %+v", offer.yiaddr, ack)
+	}
+	if !reflect.DeepEqual(dst, broadcast) {
+		t.Errorf("ACK应广播，得到 %v", dst)
+	}
+	leases := s.Leases(s.iface)
+	if len(leases) != 1 || leases[0].IP != offer.yiaddr.String() || leases[0].MAC != client.mac.String() ||
+		leases[0].Hostname != "laptop" || leases[0].Reserved {
+		t.Fatalf("租约不正确: %+v", leases)
+	}
+	if want := now.Add(time.Hour); !leases[0].Expires.Equal(want) {
+		t.Errorf("租约到期时间应为 %v，得到 %v", want, leases[0].Expires)
+	}
+
+	// 续租时使用ciaddr并单播回复
+	renew, dst := s.exchange(t, client.packet(dhcpRequest, offer.yiaddr, nil, nil), now.Add(30*time.Minute))
+	if renew == nil || renew.messageType() != dhcpAck || !renew.ciaddr.Equal(offer.yiaddr) {
+		t.Fatalf("续租应收到ACK，得到: %+v", renew)
+	}
+	if !dst.IP.Equal(offer.yiaddr) || dst.Port != 68 {
+		t.Errorf("续租应单播给 %s:68，得到 %v", offer.yiaddr, dst)
+	}
+
+	// 租约写入文件，重启后仍然有效
+	reloaded := NewDHCPServer(false)
+	if got := reloaded.Leases(s.iface); len(got) != 1 || got[0].IP != offer.yiaddr.String() {
+		t.Errorf("重新加载后的租约不正确: %+v", got)
+	}
+
+	// 同一客户端再次DISCOVER得到原来的地址
+	again, _ := s.exchange(t, client.packet(dhcpDiscover, nil, nil, nil), now)
+	if again == nil || !again.yiaddr.Equal(offer.yiaddr) {
+		t.Errorf("已有租约的客户端应得到原地址 %s，得到: %+v", offer.yiaddr, again)
+	}
+}
+
+func TestDHCPServerReservation(t *testing.T) {
+	s := newDHCPTestServer(t)
+	reserved := newDHCPTestClient("52:54:00:00:00:01", "printer")
+	other := newDHCPTestClient("52:54:00:00:00:02", "")
+	now := time.Now()
+
+	// 回环地址通不过静态保留的校验，直接写入
+	s.reservations[reserved.mac.String()] = models.DHCPReservation{MAC: reserved.mac.String(), IP: s.pool[2].String()}
+
+	// 有保留的客户端请求其他地址时仍然得到保留地址
+	offer, _ := s.exchange(t, reserved.packet(dhcpDiscover, nil, s.pool[0], nil), now)
+	if offer == nil || !offer.yiaddr.Equal(s.pool[2]) {
+		t.Fatalf("应OFFER保留地址 %s，得到: %+v", s.pool[2], offer)
+	}
+	nak, _ := s.exchange(t, reserved.packet(dhcpRequest, nil, s.pool[0], s.serverIP), now)
+	if nak == nil || nak.messageType() != dhcpNak {
+		t.Errorf("有保留的客户端请求其他地址应收到NAK，得到: %+v", nak)
+	}
+	if ip := s.lease(t, reserved, now); !ip.Equal(s.pool[2]) {
+		t.Fatalf("应分配保留地址 %s，得到 %s", s.pool[2], ip)
+	}
+	if leases := s.Leases(s.iface); len(leases) != 1 || !leases[0].Reserved {
+		t.Errorf("保留地址的租约应标记为reserved: %+v", leases)
+	}
+
+	// 保留地址不分给其他客户端
+	offer, _ = s.exchange(t, other.packet(dhcpDiscover, nil, s.pool[2], nil), now)
+	if offer == nil || offer.yiaddr.Equal(s.pool[2]) {
+		t.Errorf("保留地址不应OFFER给其他客户端，得到: %+v", offer)
+	}
+	nak, _ = s.exchange(t, other.packet(dhcpRequest, nil, s.pool[2], s.serverIP), now)
+	if nak == nil || nak.messageType() != dhcpNak {
+		t.Errorf("其他客户端请求保留地址应收到NAK，得到: %+v", nak)
+	}
+}
+
+func TestDHCPServerRequestRejected(t *testing.T) {
+	owner := newDHCPTestClient("52:54:00:00:00:01", "")
+	client := newDHCPTestClient("52:54:00:00:00:02", "")
+	outside := func(s *dhcpTestServer) net.IP { return uint32ToIP(ipToUint32(s.pool[2]) + 10) }
+
+	tests := []struct {
+		name    string
+		request func(s *dhcpTestServer) []byte
+		wantNak bool
+	}{
+		{
+			name:    "请求其他客户端正在使用的地址",
+			request: func(s *dhcpTestServer) []byte { return client.packet(dhcpRequest, nil, s.pool[0], s.serverIP) },
+			wantNak: true,
+		},
+		{
+			name:    "请求子网内地址池外的地址",
+			request: func(s *dhcpTestServer) []byte { return client.packet(dhcpRequest, nil, outside(s), s.serverIP) },
+			wantNak: true,
+		},
+		{
+			name:    "请求服务器自己的地址",
+			request: func(s *dhcpTestServer) []byte { return client.packet(dhcpRequest, nil, s.serverIP, s.serverIP) },
+			wantNak: true,
+		},
+		{
+			name:    "续租其他客户端的地址",
+			request: func(s *dhcpTestServer) []byte { return client.packet(dhcpRequest, s.pool[0], nil, nil) },
+			wantNak: true,
+		},
+		{
+			name: "请求其他子网的地址时不回复",
+			request: func(s *dhcpTestServer) []byte {
+				return client.packet(dhcpRequest, nil, net.IPv4(192, 0, 2, 10), s.serverIP)
+			},
+		},
+		{
+			name: "选择了其他DHCP服务器时不回复",
+			request: func(s *dhcpTestServer) []byte {
+				return client.packet(dhcpRequest, nil, s.pool[1], net.IPv4(192, 0, 2, 1))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newDHCPTestServer(t)
+			now := time.Now()
+			s.lease(t, owner, now)
+
+			reply, dst := s.exchange(t, tt.request(s), now)
+			if !tt.wantNak {
+				if reply != nil {
+					t.Fatalf("不应回复，得到: %+v", reply)
+				}
+				return
+			}
+			if reply == nil || reply.messageType() != dhcpNak {
+				t.Fatalf("应收到NAK，得到: %+v", reply)
+			}
+			if !reply.yiaddr.Equal(net.IPv4zero) || !reflect.DeepEqual(reply.options[dhcpOptServerID], []byte(s.serverIP.To4())) {
+				t.Errorf("NAK不应包含地址且应带服务器标识: %+v", reply)
+			}
+			if !dst.IP.Equal(net.IPv4bcast) {
+				t.Errorf("NAK应广播，得到 %v", dst)
+			}
+			if leases := s.Leases(s.iface); len(leases) != 1 || leases[0].MAC != owner.mac.String() {
+				t.Errorf("NAK后租约不应变化: %+v", leases)
+			}
+		})
+	}
+}
+
+func TestDHCPServerDeclineAndRelease(t *testing.T) {
+	s := newDHCPTestServer(t)
+	client := newDHCPTestClient("52:54:00:00:00:01", "")
+	now := time.Now()
+
+	ip := s.lease(t, client, now)
+	if reply, _ := s.exchange(t, client.packet(dhcpDecline, nil, ip, s.serverIP), now); reply != nil {
+		t.Fatalf("DECLINE不应回复，得到: %+v", reply)
+	}
+	if leases := s.Leases(s.iface); len(leases) != 0 {
+		t.Errorf("DECLINE后应删除租约: %+v", leases)
+	}
+
+	// 冲突地址在一个租期内不再分配
+	offer, _ := s.exchange(t, client.packet(dhcpDiscover, nil, ip, nil), now)
+	if offer == nil || offer.yiaddr.Equal(ip) {
+		t.Fatalf("冲突地址 %s 不应再分配，得到: %+v", ip, offer)
+	}
+	nak, _ := s.exchange(t, newDHCPTestClient("52:54:00:00:00:02", "").packet(dhcpRequest, nil, ip, s.serverIP), now)
+	if nak == nil || nak.messageType() != dhcpNak {
+		t.Errorf("请求冲突地址应收到NAK，得到: %+v", nak)
+	}
+	later, _ := s.exchange(t, newDHCPTestClient("52:54:00:00:00:03", "").packet(dhcpDiscover, nil, nil, nil), now.Add(time.Hour+time.Second))
+	if later == nil || !later.yiaddr.Equal(ip) {
+		t.Errorf("暂停期过后应重新分配 %s，得到: %+v", ip, later)
+	}
+
+	// 只有租约中的地址能被释放
+	leased := s.lease(t, client, now)
+	s.exchange(t, client.packet(dhcpRelease, uint32ToIP(ipToUint32(leased)+1), nil, s.serverIP), now)
+	if leases := s.Leases(s.iface); len(leases) != 1 {
+		t.Fatalf("释放其他地址不应删除租约: %+v", leases)
+	}
+	if reply, _ := s.exchange(t, client.packet(dhcpRelease, leased, nil, s.serverIP), now); reply != nil {
+		t.Fatalf("RELEASE不应回复，得到: %+v", reply)
+	}
+	if leases := s.Leases(s.iface); len(leases) != 0 {
+		t.Errorf("RELEASE后应删除租约: %+v", leases)
+	}
+	if reloaded := NewDHCPServer(false); len(reloaded.Leases(s.iface)) != 0 {
+		t.Error("RELEASE后文件中不应保留租约")
+	}
+}
+
+func TestDHCPServerPoolExhausted(t *testing.T) {
+	s := newDHCPTestServer(t)
+	now := time.Now()
+
+	for i, ip := range s.pool {
+		client := newDHCPTestClient(net.HardwareAddr{0x52, 0x54, 0, 0, 0, byte(i + 1)}.String(), "")
+		if got := s.lease(t, client, now); !got.Equal(ip) {
+			t.Fatalf("第 %d 个客户端应分配 %s，得到 %s", i+1, ip, got)
+		}
+	}
+
+	late := newDHCPTestClient("52:54:00:00:00:ff", "")
+	if reply, _ := s.exchange(t, late.packet(dhcpDiscover, nil, nil, nil), now); reply != nil {
+		t.Fatalf("地址池用完时不应回复，得到: %+v", reply)
+	}
+
+	// 租约过期后地址可以分给新客户端
+	offer, _ := s.exchange(t, late.packet(dhcpDiscover, nil, nil, nil), now.Add(2*time.Hour))
+	if offer == nil || !offer.yiaddr.Equal(s.pool[0]) {
+		t.Errorf("租约过期后应分配 %s，得到: %+v", s.pool[0], offer)
+	}
+}
+
+func TestDHCPServerIgnoredPackets(t *testing.T) {
+	s := newDHCPTestServer(t)
+	client := newDHCPTestClient("52:54:00:00:00:01", "")
+	now := time.Now()
+
+	reply := client.packet(dhcpDiscover, nil, nil, nil)
+	reply[0] = 2
+	if out, _ := s.handle(s.iface, reply, now); out != nil {
+		t.Error("不应回复BOOTREPLY报文")
+	}
+	if out, _ := s.handle(s.iface, []byte{1, 1, 6}, now); out != nil {
+		t.Error("不应回复无法解析的报文")
+	}
+	if out, _ := s.handle("unknown0", client.packet(dhcpDiscover, nil, nil, nil), now); out != nil {
+		t.Error("没有地址池的网卡不应回复")
+	}
+
+	scope := s.scopes[s.iface]
+	scope.Enabled = false
+	s.scopes[s.iface] = scope
+	if out, _ := s.handle(s.iface, client.packet(dhcpDiscover, nil, nil, nil), now); out != nil {
+		t.Error("地址池停用时不应回复")
+	}
+}
+
+func TestDHCPReplyAddress(t *testing.T) {
+	client := net.IPv4(192, 168, 1, 50).To4()
+	relay := net.IPv4(10, 0, 0, 1).To4()
+	tests := []struct {
+		name    string
+		ciaddr  net.IP
+		giaddr  net.IP
+		msgType byte
+		want    *net.UDPAddr
+	}{
+		{name: "没有地址的客户端广播", msgType: dhcpOffer, want: &net.UDPAddr{IP: net.IPv4bcast, Port: 68}},
+		{name: "已有地址的客户端单播", ciaddr: client, msgType: dhcpAck, want: &net.UDPAddr{IP: client, Port: 68}},
+		{name: "NAK总是广播", ciaddr: client, msgType: dhcpNak, want: &net.UDPAddr{IP: net.IPv4bcast, Port: 68}},
+		{name: "经过中继时发给中继", giaddr: relay, msgType: dhcpOffer, want: &net.UDPAddr{IP: relay, Port: 67}},
+		{name: "中继的NAK也发给中继", ciaddr: client, giaddr: relay, msgType: dhcpNak, want: &net.UDPAddr{IP: relay, Port: 67}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &dhcpPacket{ciaddr: net.IPv4zero.To4(), giaddr: net.IPv4zero.To4(), options: make(map[byte][]byte)}
+			if tt.ciaddr != nil {
+				req.ciaddr = tt.ciaddr
+			}
+			if tt.giaddr != nil {
+				req.giaddr = tt.giaddr
+			}
+			reply := newDHCPReply(req, tt.msgType)
+
+			got := dhcpReplyAddress(req, reply)
+			if !got.IP.Equal(tt.want.IP) || got.Port != tt.want.Port {
+				t.Errorf("目的地址应为 %v，得到 %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"networkconfig/models"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrHotspotClientNotFound 表示指定的客户端没有连接到热点
+var ErrHotspotClientNotFound = errors.New("hotspot client not found")
+
+// hotspotACLStore 保存热点MAC地址过滤列表，修改时写入文件，服务重启后自动加载
+type hotspotACLStore struct {
+	file string
+	mu   sync.Mutex
+	acl  models.HotspotACL
+}
+
+// newHotspotACLStore 创建过滤列表存储，文件位置由HOTSPOT_ACL_FILE指定
+func newHotspotACLStore() *hotspotACLStore {
+	file := os.Getenv("HOTSPOT_ACL_FILE")
+	if file == "" {
+		file = "hotspot_acl.json"
+	}
+	store := &hotspotACLStore{file: file, acl: emptyHotspotACL()}
+	store.load()
+	return store
+}
+
+func emptyHotspotACL() models.HotspotACL {
+	return models.HotspotACL{Mode: models.HotspotACLModeOff, Allow: []string{}, Deny: []string{}}
+}
+
+// get 返回过滤列表的副本
+func (st *hotspotACLStore) get() models.HotspotACL {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return models.HotspotACL{
+		Mode:  st.acl.Mode,
+		Allow: append([]string{}, st.acl.Allow...),
+		Deny:  append([]string{}, st.acl.Deny...),
+	}
+}
+
+// load 从文件加载过滤列表，文件不存在时为不过滤
+func (st *hotspotACLStore) load() {
+	data, err := os.ReadFile(st.file)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("读取热点过滤列表 %s 失败: %v", st.file, err)
+		}
+		return
+	}
+
+	var acl models.HotspotACL
+	if err := json.Unmarshal(data, &acl); err != nil {
+		log.Printf("解析热点过滤列表 %s 失败: %v", st.file, err)
+		return
+	}
+	normalized, errs := normalizeHotspotACL(acl)
+	if len(errs) > 0 {
+		log.Printf("热点过滤列表 %s 无效: %v", st.file, errs)
+		return
+	}
+	st.acl = normalized
+	log.Printf("已从 %s 加载热点过滤列表: 模式=%s", st.file, normalized.Mode)
+}
+
+// set 保存过滤列表
+func (st *hotspotACLStore) set(acl models.HotspotACL) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	data, err := json.MarshalIndent(acl, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化热点过滤列表失败: %v", err)
+	}
+	if dir := filepath.Dir(st.file); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建热点过滤列表目录失败: %v", err)
+		}
+	}
+	if err := os.WriteFile(st.file, data, 0644); err != nil {
+		return fmt.Errorf("保存热点过滤列表失败: %v", err)
+	}
+	st.acl = acl
+	return nil
+}
+
+// normalizeHotspotACL 校验过滤模式和MAC地址，MAC统一为小写冒号分隔并去重
+func normalizeHotspotACL(acl models.HotspotACL) (models.HotspotACL, ValidationErrors) {
+	var errs ValidationErrors
+	result := emptyHotspotACL()
+
+	switch acl.Mode {
+	case "":
+	case models.HotspotACLModeOff, models.HotspotACLModeAllow, models.HotspotACLModeDeny:
+		result.Mode = acl.Mode
+	default:
+		errs.add("mode", ValidationInvalidValue, "过滤模式 %s 无效，应为off、allow或deny", acl.Mode)
+	}
+
+	normalizeList := func(field string, macs []string) []string {
+		list := make([]string, 0, len(macs))
+		seen := make(map[string]bool)
+		for i, value := range macs {
+			mac := normalizeMAC(value)
+			if mac == "" {
+				errs.add(fmt.Sprintf("%s[%d]", field, i), ValidationInvalidMAC, "MAC地址 %s 格式错误", value)
+				continue
+			}
+			if !seen[mac] {
+				seen[mac] = true
+				list = append(list, mac)
+			}
+		}
+		return list
+	}
+	result.Allow = normalizeList("allow", acl.Allow)
+	result.Deny = normalizeList("deny", acl.Deny)
+
+	if result.Mode == models.HotspotACLModeAllow && len(result.Allow) == 0 {
+		errs.add("allow", ValidationRequired, "允许列表为空时所有设备都无法连接")
+	}
+	return result, errs
+}
+
+// hotspotACLAllows 判断过滤列表是否允许指定MAC连接
+func hotspotACLAllows(acl models.HotspotACL, mac string) bool {
+	switch acl.Mode {
+	case models.HotspotACLModeAllow:
+		return containsString(acl.Allow, mac)
+	case models.HotspotACLModeDeny:
+		return !containsString(acl.Deny, mac)
+	default:
+		return true
+	}
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// GetHotspotACL 获取热点MAC地址过滤列表
+func (s *NetworkService) GetHotspotACL() models.HotspotACL {
+	return s.hotspotACL.get()
+}
+
+// SetHotspotACL 校验并应用热点MAC地址过滤列表，应用成功后保存
+// 热点尚未配置时只保存，配置热点后生效；后端不支持过滤时返回ErrNotSupported且不保存
+func (s *NetworkService) SetHotspotACL(acl models.HotspotACL) (models.HotspotACL, error) {
+	normalized, errs := normalizeHotspotACL(acl)
+	if len(errs) > 0 {
+		return models.HotspotACL{}, errs
+	}
+
+	if err := s.enforceHotspotACL(normalized); err != nil && !errors.Is(err, ErrHotspotNotConfigured) {
+		return models.HotspotACL{}, err
+	}
+	if err := s.hotspotACL.set(normalized); err != nil {
+		return models.HotspotACL{}, err
+	}
+	log.Printf("热点过滤列表已更新: 模式=%s, 允许%d个, 拒绝%d个", normalized.Mode, len(normalized.Allow), len(normalized.Deny))
+	return normalized, nil
+}
+
+// reapplyHotspotACL 重新应用已保存的过滤列表，用于热点被重新启动之后
+func (s *NetworkService) reapplyHotspotACL() error {
+	return s.enforceHotspotACL(s.hotspotACL.get())
+}
+
+// enforceHotspotACL 把过滤列表交给后端，并断开已连接但不再允许的客户端
+func (s *NetworkService) enforceHotspotACL(acl models.HotspotACL) error {
+	if err := s.backend.ApplyHotspotACL(acl); err != nil {
+		return fmt.Errorf("应用热点过滤列表失败: %w", err)
+	}
+	if acl.Mode == models.HotspotACLModeOff {
+		return nil
+	}
+
+	clients, err := s.backend.GetHotspotClients()
+	if err != nil {
+		log.Printf("获取热点客户端失败，跳过断开不允许的客户端: %v", err)
+		return nil
+	}
+	for _, client := range clients {
+		if hotspotACLAllows(acl, client.MAC) {
+			continue
+		}
+		if err := s.backend.DisconnectHotspotClient(client.MAC); err != nil {
+			log.Printf("断开不允许的客户端 %s 失败: %v", client.MAC, err)
+		} else {
+			log.Printf("已断开不允许的客户端 %s", client.MAC)
+		}
+	}
+	return nil
+}
+
+// DisconnectHotspotClient 断开指定客户端，过滤列表允许时客户端仍可重新连接
+func (s *NetworkService) DisconnectHotspotClient(mac string) error {
+	normalized := normalizeMAC(mac)
+	if normalized == "" {
+		var errs ValidationErrors
+		errs.add("mac", ValidationInvalidMAC, "MAC地址 %s 格式错误", mac)
+		return errs
+	}
+	if err := s.backend.DisconnectHotspotClient(normalized); err != nil {
+		return err
+	}
+	log.Printf("已断开热点客户端 %s", normalized)
+	return nil
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"networkconfig/models"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GetHotspotClients 获取已连接热点的客户端，补充厂商信息和连接时间
+func (s *NetworkService) GetHotspotClients() ([]models.HotspotClient, error) {
+	clients, err := s.backend.GetHotspotClients()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	clientSeen.track(clients, now)
+	for i := range clients {
+		if clients[i].Vendor == "" {
+			clients[i].Vendor = lookupVendor(clients[i].MAC)
+		}
+		// 由内置DHCP服务分配地址时，IP和主机名取自其租约
+		if clients[i].IP == "" || clients[i].Hostname == "" {
+			if lease, ok := s.dhcp.leaseByMAC(clients[i].MAC); ok {
+				if clients[i].IP == "" {
+					clients[i].IP = lease.IP
+				}
+				if clients[i].Hostname == "" {
+					clients[i].Hostname = lease.Hostname
+				}
+			}
+		}
+		if clients[i].ConnectedAt != nil {
+			clients[i].ConnectedSeconds = int64(now.Sub(*clients[i].ConnectedAt).Seconds())
+		}
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].MAC < clients[j].MAC })
+	return clients, nil
+}
+
+// clientSeenTracker 记录客户端首次被发现的时间，用于平台不提供连接时间的情况
+type clientSeenTracker struct {
+	mu    sync.Mutex
+	first map[string]time.Time
+}
+
+var clientSeen = &clientSeenTracker{first: make(map[string]time.Time)}
+
+// track 为没有连接时间的客户端填入首次发现时间，并清理已断开的客户端
+func (t *clientSeenTracker) track(clients []models.HotspotClient, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	present := make(map[string]bool, len(clients))
+	for i := range clients {
+		mac := clients[i].MAC
+		present[mac] = true
+		if clients[i].ConnectedAt != nil {
+			t.first[mac] = *clients[i].ConnectedAt
+			continue
+		}
+		seen, ok := t.first[mac]
+		if !ok {
+			seen = now
+			t.first[mac] = seen
+		}
+		clients[i].ConnectedAt = &seen
+	}
+	for mac := range t.first {
+		if !present[mac] {
+			delete(t.first, mac)
+		}
+	}
+}
+
+// normalizeMAC 把各平台的MAC地址格式统一为小写冒号分隔，无法解析时返回空字符串
+func normalizeMAC(mac string) string {
+	hw, err := net.ParseMAC(strings.TrimSpace(mac))
+	if err != nil || len(hw) != 6 {
+		return ""
+	}
+	return hw.String()
+}
+
+var macPattern = regexp.MustCompile(`(?i)\b[0-9a-f]{2}([:-][0-9a-f]{2}){5}\b`)
+
+// parseArpTable 解析`arp -a`或/proc/net/arp的输出，返回MAC到IPv4地址的映射
+func parseArpTable(output string) map[string]string {
+	table := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		mac := normalizeMAC(macPattern.FindString(line))
+		if mac == "" || mac == "00:00:00:00:00:00" || mac == "ff:ff:ff:ff:ff:ff" {
+			continue
+		}
+		for _, field := range strings.Fields(line) {
+			if ip := net.ParseIP(field); ip != nil && ip.To4() != nil {
+				table[mac] = ip.String()
+				break
+			}
+		}
+	}
+	return table
+}
+
+// 常见的OUI数据库位置，分别来自ieee-data、nmap和arp-scan软件包
+var ouiFiles = []string{
+	"/usr/share/ieee-data/oui.txt",
+	"/var/lib/ieee-data/oui.txt",
+	"/usr/share/misc/oui.txt",
+	"/usr/share/nmap/nmap-mac-prefixes",
+	"/usr/share/arp-scan/ieee-oui.txt",
+}
+
+var (
+	ouiOnce  sync.Once
+	ouiTable map[string]string
+)
+
+// lookupVendor 按MAC地址前三字节查厂商，随机化的本地管理地址无法对应厂商
+func lookupVendor(mac string) string {
+	hw, err := net.ParseMAC(mac)
+	if err != nil || len(hw) < 3 {
+		return ""
+	}
+	if hw[0]&0x02 != 0 {
+		return "随机MAC地址"
+	}
+
+	ouiOnce.Do(func() {
+		files := ouiFiles
+		if path := os.Getenv("HOTSPOT_OUI_FILE"); path != "" {
+			files = []string{path}
+		}
+		ouiTable = loadOUITable(files)
+	})
+	return ouiTable[strings.ToUpper(hw[:3].String())]
+}
+
+// loadOUITable 读取第一个存在的OUI数据库，键为大写冒号分隔的前缀，如"00:1A:2B"
+func loadOUITable(files []string) map[string]string {
+	table := make(map[string]string)
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			prefix, vendor := parseOUILine(scanner.Text())
+			if prefix != "" {
+				table[prefix] = vendor
+			}
+		}
+		if len(table) > 0 {
+			return table
+		}
+	}
+	return table
+}
+
+// parseOUILine 解析IEEE的"00-1A-2B   (hex)  Vendor"格式或nmap/arp-scan的"001A2B Vendor"格式
+func parseOUILine(line string) (string, string) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", ""
+	}
+	if prefix, vendor, ok := strings.Cut(line, "(hex)"); ok {
+		return ouiPrefix(strings.ReplaceAll(strings.TrimSpace(prefix), "-", "")), strings.TrimSpace(vendor)
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return "", ""
+	}
+	return ouiPrefix(fields[0]), strings.TrimSpace(strings.TrimPrefix(line, fields[0]))
+}
+
+// ouiPrefix 把6位十六进制前缀转为"00:1A:2B"形式
+func ouiPrefix(hex string) string {
+	hex = strings.ToUpper(hex)
+	if len(hex) != 6 {
+		return ""
+	}
+	for _, c := range hex {
+		if !strings.ContainsRune("0123456789ABCDEF", c) {
+			return ""
+		}
+	}
+	return hex[0:2] + ":" + hex[2:4] + ":" + hex[4:6]
+}
@@ -0,0 +1,665 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"networkconfig/models"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrHotspotNotConfigured 表示热点尚未配置过SSID和密码
+var ErrHotspotNotConfigured = errors.New("hotspot not configured")
+
+// hostapdHotspot 在Linux上用hostapd提供AP、dnsmasq提供DHCP/DNS实现移动热点
+// 两个进程都以守护进程方式启动，通过pid文件监管，状态从hostapd控制接口读取
+type hostapdHotspot struct {
+	runner     CommandRunner
+	dir        string // 配置文件、pid文件、租约文件和控制接口所在目录
+	iface      string // AP使用的无线网卡，为空时自动选择第一块无线网卡
+	address    string // AP网卡地址(CIDR)，同时作为客户端的网关和DNS
+	channel    int
+	country    string
+	maxClients int
+	leaseTime  string
+	// 为embedded时dnsmasq只提供DNS，客户端地址由内置DHCP服务分配
+	dhcp string
+}
+
+// newHostapdHotspot 按环境变量创建hostapd热点管理器
+func newHostapdHotspot(runner CommandRunner) *hostapdHotspot {
+	h := &hostapdHotspot{
+		runner:     runner,
+		dir:        os.Getenv("HOTSPOT_CONFIG_DIR"),
+		iface:      os.Getenv("HOTSPOT_INTERFACE"),
+		address:    os.Getenv("HOTSPOT_ADDRESS"),
+		channel:    getEnvInt("HOTSPOT_CHANNEL", 6),
+		country:    os.Getenv("HOTSPOT_COUNTRY"),
+		maxClients: getEnvInt("HOTSPOT_MAX_CLIENTS", 8),
+		leaseTime:  os.Getenv("HOTSPOT_LEASE_TIME"),
+		dhcp:       os.Getenv("HOTSPOT_DHCP"),
+	}
+	if h.dir == "" {
+		h.dir = "hotspot"
+	}
+	if h.address == "" {
+		h.address = "192.168.50.1/24"
+	}
+	if h.leaseTime == "" {
+		h.leaseTime = "12h"
+	}
+	return h
+}
+
+// withRunner 返回使用指定命令执行器的副本
+func (h *hostapdHotspot) withRunner(runner CommandRunner) *hostapdHotspot {
+	clone := *h
+	clone.runner = runner
+	return &clone
+}
+
+func (h *hostapdHotspot) command(name string, args ...string) *Command {
+	return NewCommand(h.runner, name, args...)
+}
+
+func (h *hostapdHotspot) hostapdConf() string { return filepath.Join(h.dir, "hostapd.conf") }
+func (h *hostapdHotspot) dnsmasqConf() string { return filepath.Join(h.dir, "dnsmasq.conf") }
+func (h *hostapdHotspot) hostapdPid() string  { return filepath.Join(h.dir, "hostapd.pid") }
+func (h *hostapdHotspot) dnsmasqPid() string  { return filepath.Join(h.dir, "dnsmasq.pid") }
+func (h *hostapdHotspot) leaseFile() string   { return filepath.Join(h.dir, "dnsmasq.leases") }
+func (h *hostapdHotspot) ctrlDir() string     { return filepath.Join(h.dir, "ctrl") }
+func (h *hostapdHotspot) aclConf() string     { return filepath.Join(h.dir, "acl.conf") }
+func (h *hostapdHotspot) acceptFile() string  { return filepath.Join(h.dir, "accept.mac") }
+func (h *hostapdHotspot) denyFile() string    { return filepath.Join(h.dir, "deny.mac") }
+
+// Status 从hostapd控制接口读取热点状态
+func (h *hostapdHotspot) Status() (models.HotspotStatus, error) {
+	conf := readKeyValueFile(h.hostapdConf())
+	if conf["ssid"] == "" {
+		return models.HotspotStatus{}, fmt.Errorf("请先配置热点SSID和密码: %w", ErrHotspotNotConfigured)
+	}
+
+	status := models.HotspotStatus{
+		Success:        true,
+		SSID:           conf["ssid"],
+		Authentication: conf["wpa_key_mgmt"],
+		Encryption:     conf["rsn_pairwise"],
+	}
+	status.MaxClientCount, _ = strconv.Atoi(conf["max_num_sta"])
+	status.Sharing = h.sharingStatus()
+
+	hostapdRunning := processRunning(h.hostapdPid(), "hostapd")
+	dnsmasqRunning := processRunning(h.dnsmasqPid(), "dnsmasq")
+	switch {
+	case !hostapdRunning && !dnsmasqRunning:
+		return status, nil
+	case !hostapdRunning:
+		status.Error = "hostapd未运行"
+		return status, nil
+	case !dnsmasqRunning:
+		status.Error = "dnsmasq未运行，客户端无法获取地址"
+	}
+
+	iface := conf["interface"]
+	output, err := h.command("hostapd_cli", "-p", h.ctrlDir(), "-i", iface, "status").Output()
+	if err != nil {
+		status.Success = false
+		status.Error = fmt.Sprintf("读取hostapd状态失败: %v", err)
+		return status, nil
+	}
+	values := parseKeyValueLines(string(output))
+	status.Enabled = values["state"] == "ENABLED" && dnsmasqRunning
+	if ssid := values["ssid[0]"]; ssid != "" {
+		status.SSID = ssid
+	}
+	status.ClientsCount, _ = strconv.Atoi(values["num_sta[0]"])
+
+	// get_config给出实际生效的认证和加密方式
+	if output, err := h.command("hostapd_cli", "-p", h.ctrlDir(), "-i", iface, "get_config").Output(); err == nil {
+		config := parseKeyValueLines(string(output))
+		if keyMgmt := config["key_mgmt"]; keyMgmt != "" {
+			status.Authentication = keyMgmt
+		}
+		if cipher := config["rsn_pairwise_cipher"]; cipher != "" {
+			status.Encryption = cipher
+		}
+	}
+	return status, nil
+}
+
+// Config 从hostapd.conf还原热点配置，Enabled表示hostapd是否在运行
+func (h *hostapdHotspot) Config() (models.HotspotConfig, error) {
+	conf := readKeyValueFile(h.hostapdConf())
+	if conf["ssid"] == "" {
+		return models.HotspotConfig{}, fmt.Errorf("请先配置热点SSID和密码: %w", ErrHotspotNotConfigured)
+	}
+
+	config := models.HotspotConfig{
+		SSID:     conf["ssid"],
+		Password: conf["wpa_passphrase"],
+		Enabled:  processRunning(h.hostapdPid(), "hostapd"),
+		Band:     models.HotspotBand24GHz,
+		Security: models.HotspotSecurityWPA2,
+	}
+	config.Channel, _ = strconv.Atoi(conf["channel"])
+	config.MaxClients, _ = strconv.Atoi(conf["max_num_sta"])
+	config.Uplink = h.configuredUplink()
+	switch {
+	case conf["op_class"] == "131":
+		config.Band = models.HotspotBand6GHz
+	case conf["hw_mode"] == "a":
+		config.Band = models.HotspotBand5GHz
+	}
+	switch conf["wpa_key_mgmt"] {
+	case "SAE":
+		config.Security = models.HotspotSecurityWPA3
+	case "WPA-PSK SAE":
+		config.Security = models.HotspotSecurityWPA3Transition
+	}
+	return config, nil
+}
+
+// Clients 列出hostapd中已关联的客户端，IP和主机名取自dnsmasq租约，缺失时查ARP表
+func (h *hostapdHotspot) Clients() ([]models.HotspotClient, error) {
+	conf := readKeyValueFile(h.hostapdConf())
+	if conf["ssid"] == "" {
+		return nil, fmt.Errorf("请先配置热点SSID和密码: %w", ErrHotspotNotConfigured)
+	}
+	clients := make([]models.HotspotClient, 0)
+	if !processRunning(h.hostapdPid(), "hostapd") {
+		return clients, nil
+	}
+
+	output, err := h.command("hostapd_cli", "-p", h.ctrlDir(), "-i", conf["interface"], "all_sta").Output()
+	if err != nil {
+		return nil, fmt.Errorf("读取热点客户端失败: %v", err)
+	}
+
+	leases := readDnsmasqLeases(h.leaseFile())
+	arp := make(map[string]string)
+	if data, err := os.ReadFile("/proc/net/arp"); err == nil {
+		arp = parseArpTable(string(data))
+	}
+
+	now := time.Now()
+	for _, station := range parseHostapdStations(string(output)) {
+		client := models.HotspotClient{MAC: station.mac}
+		if lease, ok := leases[station.mac]; ok {
+			client.IP = lease.ip
+			client.Hostname = lease.hostname
+		}
+		if client.IP == "" {
+			client.IP = arp[station.mac]
+		}
+		if seconds, err := strconv.ParseInt(station.values["connected_time"], 10, 64); err == nil {
+			connectedAt := now.Add(-time.Duration(seconds) * time.Second)
+			client.ConnectedAt = &connectedAt
+		}
+		clients = append(clients, client)
+	}
+	return clients, nil
+}
+
+// hostapdStation hostapd_cli all_sta输出中的一个客户端
+type hostapdStation struct {
+	mac    string
+	values map[string]string
+}
+
+// parseHostapdStations 解析hostapd_cli all_sta的输出，每个客户端以单独一行MAC地址开头，后跟key=value属性
+func parseHostapdStations(output string) []hostapdStation {
+	var stations []hostapdStation
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if mac := normalizeMAC(line); mac != "" {
+			stations = append(stations, hostapdStation{mac: mac, values: make(map[string]string)})
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && len(stations) > 0 {
+			stations[len(stations)-1].values[key] = value
+		}
+	}
+	return stations
+}
+
+// dnsmasqLease dnsmasq租约文件中的一条记录
+type dnsmasqLease struct {
+	ip       string
+	hostname string
+}
+
+// readDnsmasqLeases 读取dnsmasq租约文件，格式为"到期时间 MAC IP 主机名 客户端ID"，主机名未知时为"*"
+func readDnsmasqLeases(path string) map[string]dnsmasqLease {
+	leases := make(map[string]dnsmasqLease)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return leases
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		mac := normalizeMAC(fields[1])
+		if mac == "" {
+			continue
+		}
+		lease := dnsmasqLease{ip: fields[2]}
+		if fields[3] != "*" {
+			lease.hostname = fields[3]
+		}
+		leases[mac] = lease
+	}
+	return leases
+}
+
+// hostapd的max_num_sta上限
+const hostapdMaxStations = 2007
+
+// Configure 生成hostapd和dnsmasq配置，按enabled启动热点，未启用时停止正在运行的热点
+func (h *hostapdHotspot) Configure(config models.HotspotConfig) error {
+	errs := ValidateHotspotConfig(config)
+	if config.MaxClients > hostapdMaxStations {
+		errs.add("max_clients", ValidationUnsupported, "hostapd最多支持%d个客户端", hostapdMaxStations)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	iface, err := h.resolveInterface()
+	if err != nil {
+		return err
+	}
+	if config.Uplink == iface {
+		errs.add("uplink", ValidationInvalidValue, "上行网卡不能与热点网卡 %s 相同", iface)
+		return errs
+	}
+	if err := h.writeConfig(iface, config); err != nil {
+		return err
+	}
+	if err := h.writeSharingConf(config.Uplink); err != nil {
+		return err
+	}
+	log.Printf("已生成热点配置: 接口=%s, SSID=%s, 地址=%s", iface, config.SSID, h.address)
+
+	// 正在运行时先停止，要求启用时再用新配置启动
+	running := processRunning(h.hostapdPid(), "hostapd") || processRunning(h.dnsmasqPid(), "dnsmasq")
+	if running {
+		if err := h.stop(); err != nil {
+			return err
+		}
+	}
+	if config.Enabled {
+		return h.start()
+	}
+	return nil
+}
+
+// hostapd中与MAC地址过滤相关的配置项
+var hostapdACLKeys = []string{"macaddr_acl", "accept_mac_file", "deny_mac_file"}
+
+// ApplyACL 写入MAC地址列表文件和过滤配置片段，更新hostapd.conf，热点运行时让hostapd重新加载配置
+// 热点尚未配置时只生成片段，writeConfig生成hostapd.conf时会带上它
+func (h *hostapdHotspot) ApplyACL(acl models.HotspotACL) error {
+	if err := os.MkdirAll(h.dir, 0700); err != nil {
+		return fmt.Errorf("创建热点配置目录失败: %v", err)
+	}
+
+	// macaddr_acl=0时拒绝deny_mac_file中的设备，=1时只允许accept_mac_file中的设备
+	macaddrACL, deny := 0, []string{}
+	switch acl.Mode {
+	case models.HotspotACLModeAllow:
+		macaddrACL = 1
+	case models.HotspotACLModeDeny:
+		deny = acl.Deny
+	}
+	if err := writeMACFile(h.acceptFile(), acl.Allow); err != nil {
+		return err
+	}
+	if err := writeMACFile(h.denyFile(), deny); err != nil {
+		return err
+	}
+	lines := []string{
+		fmt.Sprintf("macaddr_acl=%d", macaddrACL),
+		"accept_mac_file=" + h.acceptFile(),
+		"deny_mac_file=" + h.denyFile(),
+	}
+	if err := os.WriteFile(h.aclConf(), []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("写入热点过滤配置失败: %v", err)
+	}
+
+	data, err := os.ReadFile(h.hostapdConf())
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("读取hostapd配置失败: %v", err)
+	}
+	conf := make([]string, 0)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		key, _, _ := strings.Cut(line, "=")
+		if !containsString(hostapdACLKeys, key) {
+			conf = append(conf, line)
+		}
+	}
+	conf = append(conf, lines...)
+	if err := os.WriteFile(h.hostapdConf(), []byte(strings.Join(conf, "\n")+"\n"), 0600); err != nil {
+		return fmt.Errorf("写入hostapd配置失败: %v", err)
+	}
+
+	if !processRunning(h.hostapdPid(), "hostapd") {
+		return nil
+	}
+	iface := readKeyValueFile(h.hostapdConf())["interface"]
+	output, err := h.command("hostapd_cli", "-p", h.ctrlDir(), "-i", iface, "reload").CombinedOutput()
+	if err != nil || strings.TrimSpace(string(output)) != "OK" {
+		return fmt.Errorf("hostapd重新加载配置失败: %v, 输出: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// writeMACFile 写入hostapd的MAC地址列表文件，每行一个地址
+func writeMACFile(path string, macs []string) error {
+	content := ""
+	if len(macs) > 0 {
+		content = strings.Join(macs, "\n") + "\n"
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("写入MAC地址列表 %s 失败: %v", path, err)
+	}
+	return nil
+}
+
+// Disconnect 向客户端发送解除认证帧，断开其连接
+func (h *hostapdHotspot) Disconnect(mac string) error {
+	clients, err := h.Clients()
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, client := range clients {
+		found = found || client.MAC == mac
+	}
+	if !found {
+		return fmt.Errorf("客户端 %s 未连接: %w", mac, ErrHotspotClientNotFound)
+	}
+
+	iface := readKeyValueFile(h.hostapdConf())["interface"]
+	output, err := h.command("hostapd_cli", "-p", h.ctrlDir(), "-i", iface, "deauthenticate", mac).CombinedOutput()
+	if err != nil || strings.TrimSpace(string(output)) != "OK" {
+		return fmt.Errorf("断开客户端 %s 失败: %v, 输出: %s", mac, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// SetEnabled 启动或停止hostapd和dnsmasq
+func (h *hostapdHotspot) SetEnabled(enable bool) error {
+	if enable {
+		return h.start()
+	}
+	return h.stop()
+}
+
+// resolveInterface 返回AP使用的无线网卡
+func (h *hostapdHotspot) resolveInterface() (string, error) {
+	if h.iface != "" {
+		return h.iface, nil
+	}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", fmt.Errorf("获取网卡列表失败: %v", err)
+	}
+	for _, iface := range ifaces {
+		if _, err := os.Stat(filepath.Join("/sys/class/net", iface.Name, "wireless")); err == nil {
+			return iface.Name, nil
+		}
+	}
+	return "", fmt.Errorf("没有找到无线网卡，请通过HOTSPOT_INTERFACE指定")
+}
+
+// writeConfig 写入hostapd.conf和dnsmasq.conf，hostapd.conf包含密码，只允许当前用户读取
+func (h *hostapdHotspot) writeConfig(iface string, config models.HotspotConfig) error {
+	ip, subnet, err := net.ParseCIDR(h.address)
+	if err != nil || ip.To4() == nil {
+		return fmt.Errorf("无效的热点地址 %s，应为IPv4 CIDR形式", h.address)
+	}
+	if ones, _ := subnet.Mask.Size(); ones > 30 {
+		return fmt.Errorf("无效的热点地址 %s，应为前缀不超过/30的IPv4 CIDR", h.address)
+	}
+	if err := os.MkdirAll(h.ctrlDir(), 0700); err != nil {
+		return fmt.Errorf("创建热点配置目录失败: %v", err)
+	}
+
+	maxClients := h.maxClients
+	if config.MaxClients > 0 {
+		maxClients = config.MaxClients
+	}
+	hostapd := []string{
+		"interface=" + iface,
+		"driver=nl80211",
+		"ctrl_interface=" + h.ctrlDir(),
+		"ssid=" + config.SSID,
+		"utf8_ssid=1",
+	}
+	hostapd = append(hostapd, h.radioLines(config.Band, config.Channel)...)
+	hostapd = append(hostapd,
+		"wmm_enabled=1",
+		"auth_algs=1",
+		"wpa=2",
+		"rsn_pairwise=CCMP",
+		"wpa_passphrase="+config.Password,
+		fmt.Sprintf("max_num_sta=%d", maxClients),
+	)
+	hostapd = append(hostapd, hostapdSecurityLines(config.Security)...)
+	if h.country != "" {
+		hostapd = append(hostapd, "country_code="+h.country, "ieee80211d=1")
+	}
+	// 保留ApplyACL生成的MAC地址过滤配置
+	if data, err := os.ReadFile(h.aclConf()); err == nil {
+		hostapd = append(hostapd, strings.Split(strings.TrimSpace(string(data)), "\n")...)
+	}
+	if err := os.WriteFile(h.hostapdConf(), []byte(strings.Join(hostapd, "\n")+"\n"), 0600); err != nil {
+		return fmt.Errorf("写入hostapd配置失败: %v", err)
+	}
+
+	start, end := dhcpRange(ip.To4(), subnet)
+	dnsmasq := []string{
+		"interface=" + iface,
+		"bind-interfaces",
+		"except-interface=lo",
+		"pid-file=" + h.dnsmasqPid(),
+	}
+	if h.dhcp != "embedded" {
+		dnsmasq = append(dnsmasq,
+			fmt.Sprintf("dhcp-range=%s,%s,%s,%s", start, end, net.IP(subnet.Mask), h.leaseTime),
+			"dhcp-option=option:router,"+ip.String(),
+			"dhcp-option=option:dns-server,"+ip.String(),
+			"dhcp-leasefile="+h.leaseFile(),
+		)
+	}
+	if err := os.WriteFile(h.dnsmasqConf(), []byte(strings.Join(dnsmasq, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("写入dnsmasq配置失败: %v", err)
+	}
+	return nil
+}
+
+// radioLines 返回频段和信道配置，未指定频段时使用2.4GHz，未指定信道时使用HOTSPOT_CHANNEL或频段的默认信道
+func (h *hostapdHotspot) radioLines(band string, channel int) []string {
+	if band == "" || band == models.HotspotBandAuto {
+		band = models.HotspotBand24GHz
+	}
+	if channel == 0 {
+		channel = h.channel
+		if !validHotspotChannel(band, channel) {
+			channel = map[string]int{models.HotspotBand24GHz: 6, models.HotspotBand5GHz: 36, models.HotspotBand6GHz: 5}[band]
+		}
+	}
+
+	switch band {
+	case models.HotspotBand5GHz:
+		return []string{"hw_mode=a", fmt.Sprintf("channel=%d", channel), "ieee80211n=1", "ieee80211ac=1"}
+	case models.HotspotBand6GHz:
+		// 6GHz信道通过op_class 131(20MHz)区分
+		return []string{"hw_mode=a", "op_class=131", fmt.Sprintf("channel=%d", channel), "ieee80211ax=1"}
+	default:
+		return []string{"hw_mode=g", fmt.Sprintf("channel=%d", channel), "ieee80211n=1"}
+	}
+}
+
+// hostapdSecurityLines 返回安全模式对应的密钥管理配置，WPA3(SAE)要求启用管理帧保护
+func hostapdSecurityLines(security string) []string {
+	switch security {
+	case models.HotspotSecurityWPA3:
+		return []string{"wpa_key_mgmt=SAE", "ieee80211w=2", "sae_require_mfp=1"}
+	case models.HotspotSecurityWPA3Transition:
+		return []string{"wpa_key_mgmt=WPA-PSK SAE", "ieee80211w=1"}
+	default:
+		return []string{"wpa_key_mgmt=WPA-PSK"}
+	}
+}
+
+// start 启动未运行的hostapd和dnsmasq，已运行的进程保持不变
+func (h *hostapdHotspot) start() error {
+	conf := readKeyValueFile(h.hostapdConf())
+	iface := conf["interface"]
+	if iface == "" {
+		return fmt.Errorf("请先配置热点SSID和密码: %w", ErrHotspotNotConfigured)
+	}
+
+	// 避免NetworkManager接管AP网卡
+	if _, err := h.runner.LookPath("nmcli"); err == nil {
+		if output, err := h.command("nmcli", "device", "set", iface, "managed", "no").CombinedOutput(); err != nil {
+			log.Printf("设置NetworkManager不管理接口 %s 失败: %v, 输出: %s", iface, err, string(output))
+		}
+	}
+	if err := h.ensureAddress(iface); err != nil {
+		return err
+	}
+
+	if !processRunning(h.hostapdPid(), "hostapd") {
+		log.Printf("正在启动hostapd: 接口=%s", iface)
+		output, err := h.command("hostapd", "-B", "-P", h.hostapdPid(), h.hostapdConf()).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("启动hostapd失败: %v, 输出: %s", err, string(output))
+		}
+	}
+	if !processRunning(h.dnsmasqPid(), "dnsmasq") {
+		log.Printf("正在启动dnsmasq: 接口=%s", iface)
+		output, err := h.command("dnsmasq", "--conf-file="+h.dnsmasqConf()).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("启动dnsmasq失败: %v, 输出: %s", err, string(output))
+		}
+	}
+
+	log.Printf("热点已启动: SSID=%s", conf["ssid"])
+
+	if err := h.startSharing(iface); err != nil {
+		return fmt.Errorf("热点已启动，但共享上网失败: %v", err)
+	}
+	return nil
+}
+
+// ensureAddress 确保AP网卡已启用并配置了热点地址
+func (h *hostapdHotspot) ensureAddress(iface string) error {
+	ip, _, err := net.ParseCIDR(h.address)
+	if err != nil {
+		return fmt.Errorf("无效的热点地址 %s: %v", h.address, err)
+	}
+	link, err := net.InterfaceByName(iface)
+	if err != nil {
+		return fmt.Errorf("接口 %s 不存在: %v", iface, err)
+	}
+	if addrs, err := link.Addrs(); err == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+				return nil
+			}
+		}
+	}
+	if err := netlinkAddAddress(h.runner, iface, h.address); err != nil {
+		return fmt.Errorf("为热点网卡配置地址失败: %v", err)
+	}
+	return nil
+}
+
+// stop 停止共享上网以及hostapd和dnsmasq
+func (h *hostapdHotspot) stop() error {
+	var errs []string
+	if err := h.stopSharing(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := h.stopProcess(h.dnsmasqPid(), "dnsmasq"); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := h.stopProcess(h.hostapdPid(), "hostapd"); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("停止热点失败: %s", strings.Join(errs, "; "))
+	}
+	log.Println("热点已停止")
+	return nil
+}
+
+// stopProcess 结束pid文件对应的进程并等待其退出
+func (h *hostapdHotspot) stopProcess(pidFile, name string) error {
+	pid, ok := readPidFile(pidFile)
+	if !ok || !processRunning(pidFile, name) {
+		os.Remove(pidFile)
+		return nil
+	}
+
+	if output, err := h.command("kill", strconv.Itoa(pid)).CombinedOutput(); err != nil {
+		return fmt.Errorf("结束进程 %d 失败: %v, 输出: %s", pid, err, string(output))
+	}
+	for i := 0; i < 20 && processRunning(pidFile, name); i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	os.Remove(pidFile)
+	return nil
+}
+
+// readPidFile 读取pid文件
+func readPidFile(path string) (int, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid, err == nil && pid > 0
+}
+
+// processRunning 判断pid文件对应的进程是否存在，并核对进程名以免pid被其他进程复用
+func processRunning(pidFile, name string) bool {
+	pid, ok := readPidFile(pidFile)
+	if !ok {
+		return false
+	}
+	comm, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "comm"))
+	return err == nil && strings.TrimSpace(string(comm)) == name
+}
+
+// dhcpRange 返回子网中分配给客户端的地址范围，跳过网关所在的低位地址
+func dhcpRange(gateway net.IP, subnet *net.IPNet) (net.IP, net.IP) {
+	network := subnet.IP.To4()
+	broadcast := broadcastAddress(subnet)
+	ones, _ := subnet.Mask.Size()
+
+	offset := func(base net.IP, delta int) net.IP {
+		value := uint32(base[0])<<24 | uint32(base[1])<<16 | uint32(base[2])<<8 | uint32(base[3])
+		value = uint32(int64(value) + int64(delta))
+		return net.IPv4(byte(value>>24), byte(value>>16), byte(value>>8), byte(value)).To4()
+	}
+
+	// /24及更大的子网从.10开始分配，小子网从网关之后开始
+	start := offset(network, 10)
+	if ones > 24 || !subnet.Contains(start) {
+		start = offset(gateway, 1)
+	}
+	end := offset(broadcast, -1)
+	return start, end
+}
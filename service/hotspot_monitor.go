@@ -1,128 +1,113 @@
 package service
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"strconv"
-	"sync"
 	"time"
 )
 
-// HotspotMonitor 热点监控服务
+// HotspotMonitor 热点看门狗的检查项：热点应开启而未开启时重启热点
 type HotspotMonitor struct {
 	networkService *NetworkService
-	enabled        bool
-	interval       time.Duration
-	autoRecovery   bool
-	stopChan       chan struct{}
-	wg             sync.WaitGroup
 	debug          bool
 }
 
-// NewHotspotMonitor 创建新的热点监控服务
-func NewHotspotMonitor(networkService *NetworkService, debug bool) *HotspotMonitor {
-	// 从环境变量读取配置
-	enabled := getEnvBool("HOTSPOT_MONITOR_ENABLED", true)
-	interval := getEnvInt("HOTSPOT_MONITOR_INTERVAL", 30)
-	autoRecovery := getEnvBool("HOTSPOT_AUTO_RECOVERY", true)
-
-	return &HotspotMonitor{
-		networkService: networkService,
-		enabled:        enabled,
-		interval:       time.Duration(interval) * time.Second,
-		autoRecovery:   autoRecovery,
-		stopChan:       make(chan struct{}),
-		debug:          debug,
+// NewHotspotMonitor 创建热点看门狗，策略从环境变量读取
+// 通过API修改的启用状态、间隔和自动恢复保存在HOTSPOT_MONITOR_FILE中，优先于环境变量
+func NewHotspotMonitor(networkService *NetworkService, debug bool) *Watchdog {
+	interval := time.Duration(getEnvInt("HOTSPOT_MONITOR_INTERVAL", 30)) * time.Second
+	policy := WatchdogPolicy{
+		Enabled:         getEnvBool("HOTSPOT_MONITOR_ENABLED", true),
+		Interval:        interval,
+		AutoRecovery:    getEnvBool("HOTSPOT_AUTO_RECOVERY", true),
+		InitialBackoff:  time.Duration(getEnvInt("HOTSPOT_MONITOR_BACKOFF", 30)) * time.Second,
+		MaxBackoff:      time.Duration(getEnvInt("HOTSPOT_MONITOR_MAX_BACKOFF", 600)) * time.Second,
+		MaxAttempts:     getEnvInt("HOTSPOT_MONITOR_MAX_ATTEMPTS", 5),
+		CircuitCooldown: time.Duration(getEnvInt("HOTSPOT_MONITOR_COOLDOWN", 1800)) * time.Second,
 	}
-}
 
-// Start 启动热点监控服务
-func (m *HotspotMonitor) Start() {
-	if !m.enabled {
-		log.Println("热点监控服务未启用")
-		return
+	file := os.Getenv("HOTSPOT_MONITOR_FILE")
+	if file == "" {
+		file = "hotspot_monitor.json"
 	}
 
-	m.wg.Add(1)
-	go m.monitorLoop()
-	log.Printf("热点监控服务已启动，监控间隔: %v, 自动恢复: %v", m.interval, m.autoRecovery)
-}
-
-// Stop 停止热点监控服务
-func (m *HotspotMonitor) Stop() {
-	if !m.enabled {
-		return
+	watchdog := NewWatchdog(&HotspotMonitor{networkService: networkService, debug: debug}, policy, file, debug)
+	if url := os.Getenv("WATCHDOG_WEBHOOK_URL"); url != "" {
+		watchdog.OnEscalate(webhookEscalation(url))
 	}
-
-	close(m.stopChan)
-	m.wg.Wait()
-	log.Println("热点监控服务已停止")
+	return watchdog
 }
 
-// monitorLoop 监控循环
-func (m *HotspotMonitor) monitorLoop() {
-	defer m.wg.Done()
-
-	ticker := time.NewTicker(m.interval)
-	defer ticker.Stop()
+// Name 看门狗名称
+func (m *HotspotMonitor) Name() string {
+	return "hotspot"
+}
 
-	for {
-		select {
-		case <-m.stopChan:
-			return
-		case <-ticker.C:
-			m.checkHotspotStatus()
+// Wanted 用户主动关闭热点，或期望状态、计划规则要求关闭热点时返回false，避免把用户主动关闭的热点"恢复"
+// 用户最近一次通过API或命令行开关热点的状态优先
+func (m *HotspotMonitor) Wanted() bool {
+	if m.networkService.hotspotMonitor != nil {
+		if enabled, ok := m.networkService.hotspotMonitor.Desired(); ok {
+			return enabled
 		}
 	}
-}
-
-// checkHotspotStatus 检查热点状态
-func (m *HotspotMonitor) checkHotspotStatus() {
-	if m.debug {
-		log.Println("正在检查热点状态...")
+	if m.networkService.reconciler != nil && m.networkService.reconciler.hotspotDisabled() {
+		return false
+	}
+	if enabled, ok := m.networkService.hotspotScheduledState(); ok && !enabled {
+		return false
 	}
+	return true
+}
 
+// Check 检查热点是否开启
+func (m *HotspotMonitor) Check() (bool, string, error) {
 	status, err := m.networkService.GetHotspotStatus()
 	if err != nil {
-		log.Printf("获取热点状态失败: %v", err)
-		return
+		return false, "", fmt.Errorf("获取热点状态失败: %v", err)
 	}
-
-	// 检查热点是否需要恢复
 	if !status.Success || !status.Enabled {
-		log.Printf("检测到热点异常 - Success: %v, Enabled: %v", status.Success, status.Enabled)
-
-		if m.autoRecovery {
-			m.recoverHotspot()
-		} else {
-			log.Println("自动恢复未启用，跳过恢复操作")
-		}
-	} else if m.debug {
-		log.Println("热点状态正常")
+		return false, fmt.Sprintf("热点异常 - Success: %v, Enabled: %v", status.Success, status.Enabled), nil
 	}
+	return true, "热点状态正常", nil
 }
 
-// recoverHotspot 恢复热点
-func (m *HotspotMonitor) recoverHotspot() {
-	log.Println("正在尝试恢复热点...")
-
-	// 先尝试停止热点
-	if err := m.networkService.SetHotspotStatus(false); err != nil {
+// Recover 重启热点，并重新应用过滤列表
+func (m *HotspotMonitor) Recover() error {
+	// 直接调用后端，恢复操作不改变用户设置的期望状态
+	// 先尝试停止热点，失败时忽略，继续尝试启动
+	if err := m.networkService.backend.SetHotspotStatus(false); err != nil {
 		log.Printf("停止热点失败: %v", err)
-		// 停止热点失败忽略，继续尝试启动热点
-		// return
+	} else {
+		m.waitHotspotStopped(5 * time.Second)
 	}
 
-	// 等待一段时间
-	time.Sleep(2 * time.Second)
+	if err := m.networkService.backend.SetHotspotStatus(true); err != nil {
+		return fmt.Errorf("启动热点失败: %v", err)
+	}
 
-	// 重新启动热点
-	if err := m.networkService.SetHotspotStatus(true); err != nil {
-		log.Printf("启动热点失败: %v", err)
-		return
+	// 重新启动后过滤列表可能失效，需要重新应用
+	if err := m.networkService.reapplyHotspotACL(); err != nil {
+		log.Printf("重新应用热点过滤列表失败: %v", err)
 	}
+	return nil
+}
 
-	log.Println("热点恢复完成")
+// waitHotspotStopped 等待热点确实停止，最多等待timeout
+func (m *HotspotMonitor) waitHotspotStopped(timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		status, err := m.networkService.GetHotspotStatus()
+		if err == nil && !status.Enabled {
+			return
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	if m.debug {
+		log.Printf("等待热点停止超时(%v)", timeout)
+	}
 }
 
 // getEnvBool 获取布尔类型的环境变量
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net"
+	"networkconfig/models"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// hotspotNATTable 热点共享上网使用的nftables表，停止共享时整表删除，不影响其他规则
+const hotspotNATTable = "networkconfig_hotspot"
+
+// 上行网卡的特殊取值
+const (
+	hotspotUplinkAuto = "auto" // 使用默认路由所在的网卡
+	hotspotUplinkNone = "none" // 不共享上网
+)
+
+// 可替换的procfs路径
+var (
+	ipForwardPath = "/proc/sys/net/ipv4/ip_forward"
+	routeTable    = "/proc/net/route"
+)
+
+func (h *hostapdHotspot) sharingConf() string  { return filepath.Join(h.dir, "sharing.conf") }
+func (h *hostapdHotspot) sharingState() string { return filepath.Join(h.dir, "sharing.state") }
+func (h *hostapdHotspot) natRules() string     { return filepath.Join(h.dir, "nat.nft") }
+
+// configuredUplink 返回配置的上行网卡，未配置时为auto
+func (h *hostapdHotspot) configuredUplink() string {
+	if uplink := readKeyValueFile(h.sharingConf())["uplink"]; uplink != "" {
+		return uplink
+	}
+	return hotspotUplinkAuto
+}
+
+// writeSharingConf 保存上行网卡设置
+func (h *hostapdHotspot) writeSharingConf(uplink string) error {
+	if uplink == "" {
+		uplink = hotspotUplinkAuto
+	}
+	if err := os.WriteFile(h.sharingConf(), []byte("uplink="+uplink+"\n"), 0644); err != nil {
+		return fmt.Errorf("写入共享上网配置失败: %v", err)
+	}
+	return nil
+}
+
+// startSharing 开启IP转发并添加NAT规则，把热点子网经上行网卡转发出去
+func (h *hostapdHotspot) startSharing(iface string) error {
+	uplink := h.configuredUplink()
+	if uplink == hotspotUplinkNone {
+		return h.stopSharing()
+	}
+	if uplink == hotspotUplinkAuto {
+		var err error
+		if uplink, err = defaultRouteInterface(); err != nil {
+			return err
+		}
+	}
+	if uplink == iface {
+		return fmt.Errorf("上行网卡 %s 不能与热点网卡相同", uplink)
+	}
+	if _, err := net.InterfaceByName(uplink); err != nil {
+		return fmt.Errorf("上行网卡 %s 不存在: %v", uplink, err)
+	}
+	_, subnet, err := net.ParseCIDR(h.address)
+	if err != nil {
+		return fmt.Errorf("无效的热点地址 %s: %v", h.address, err)
+	}
+
+	// 先记录开启前的IP转发设置，后续步骤失败或停止共享时据此恢复
+	state := readKeyValueFile(h.sharingState())
+	previous := state["ip_forward"]
+	if previous == "" {
+		previous = readIPForward()
+	}
+	content := fmt.Sprintf("uplink=%s\ninterface=%s\nip_forward=%s\n", uplink, iface, previous)
+	err = applyOp(h.runner, fmt.Sprintf("write %s", h.sharingState()), "保存共享上网状态", func() error {
+		return os.WriteFile(h.sharingState(), []byte(content), 0644)
+	})
+	if err != nil {
+		return fmt.Errorf("保存共享上网状态失败: %v", err)
+	}
+	err = applyOp(h.runner, "sysctl -w net.ipv4.ip_forward=1", "开启IP转发", func() error {
+		return os.WriteFile(ipForwardPath, []byte("1\n"), 0644)
+	})
+	if err != nil {
+		h.stopSharing()
+		return fmt.Errorf("开启IP转发失败: %v", err)
+	}
+
+	// 先创建再删除表，使规则文件可以重复加载
+	rules := fmt.Sprintf(`table ip %[1]s
+delete table ip %[1]s
+table ip %[1]s {
+	chain postrouting {
+		type nat hook postrouting priority srcnat; policy accept;
+		ip saddr %[2]s oifname "%[3]s" masquerade
+	}
+	chain forward {
+		type filter hook forward priority filter; policy accept;
+		iifname "%[4]s" oifname "%[3]s" accept
+		iifname "%[3]s" oifname "%[4]s" ct state established,related accept
+	}
+}
+`, hotspotNATTable, subnet, uplink, iface)
+	err = applyOp(h.runner, fmt.Sprintf("write %s", h.natRules()), "写入nftables规则文件", func() error {
+		return os.WriteFile(h.natRules(), []byte(rules), 0644)
+	})
+	if err != nil {
+		h.stopSharing()
+		return fmt.Errorf("写入nftables规则失败: %v", err)
+	}
+	if output, err := h.command("nft", "-f", h.natRules()).CombinedOutput(); err != nil {
+		h.stopSharing()
+		return fmt.Errorf("添加nftables规则失败: %v, 输出: %s", err, string(output))
+	}
+	log.Printf("热点已通过 %s 共享上网", uplink)
+	return nil
+}
+
+// stopSharing 删除NAT规则，并把IP转发恢复为开启共享之前的设置
+func (h *hostapdHotspot) stopSharing() error {
+	state := readKeyValueFile(h.sharingState())
+	if len(state) == 0 {
+		return nil
+	}
+
+	// 没有安装nft时规则不可能存在，只需恢复IP转发
+	var errs []string
+	if _, err := h.runner.LookPath("nft"); err == nil {
+		output, err := h.command("nft", "delete", "table", "ip", hotspotNATTable).CombinedOutput()
+		if err != nil && !strings.Contains(string(output), "No such file") {
+			errs = append(errs, fmt.Sprintf("删除nftables规则失败: %v, 输出: %s", err, string(output)))
+		}
+	}
+	if previous := state["ip_forward"]; previous == "0" {
+		err := applyOp(h.runner, "sysctl -w net.ipv4.ip_forward=0", "恢复IP转发设置", func() error {
+			return os.WriteFile(ipForwardPath, []byte("0\n"), 0644)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("恢复IP转发设置失败: %v", err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("停止共享上网失败: %s", strings.Join(errs, "; "))
+	}
+	applyOp(h.runner, fmt.Sprintf("rm -f %s", h.sharingState()), "删除共享上网状态", func() error {
+		os.Remove(h.sharingState())
+		return nil
+	})
+	log.Printf("已停止通过 %s 共享上网", state["uplink"])
+	return nil
+}
+
+// sharingStatus 读取共享上网状态，规则是否存在以nftables为准
+func (h *hostapdHotspot) sharingStatus() *models.HotspotSharing {
+	sharing := &models.HotspotSharing{
+		Uplink:     h.configuredUplink(),
+		Forwarding: readIPForward() == "1",
+	}
+	state := readKeyValueFile(h.sharingState())
+	if uplink := state["uplink"]; uplink != "" {
+		sharing.Uplink = uplink
+	}
+	if sharing.Uplink == hotspotUplinkNone {
+		return sharing
+	}
+
+	if _, err := h.command("nft", "list", "table", "ip", hotspotNATTable).Output(); err == nil {
+		sharing.Enabled = true
+	} else if len(state) > 0 {
+		sharing.Error = "nftables规则已丢失"
+	}
+	if sharing.Enabled && !sharing.Forwarding {
+		sharing.Error = "IP转发已被关闭"
+	}
+	return sharing
+}
+
+// readIPForward 返回当前IPv4转发设置，读取失败时为空
+func readIPForward() string {
+	data, err := os.ReadFile(ipForwardPath)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// defaultRouteInterface 从/proc/net/route中找出IPv4默认路由所在的网卡，有多条时取metric最小的
+func defaultRouteInterface() (string, error) {
+	file, err := os.Open(routeTable)
+	if err != nil {
+		return "", fmt.Errorf("读取路由表失败: %v", err)
+	}
+	defer file.Close()
+
+	best, bestMetric := "", -1
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Iface Destination Gateway Flags RefCnt Use Metric Mask ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 || fields[1] != "00000000" || fields[7] != "00000000" {
+			continue
+		}
+		metric, err := strconv.Atoi(fields[6])
+		if err != nil {
+			continue
+		}
+		if bestMetric < 0 || metric < bestMetric {
+			best, bestMetric = fields[0], metric
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("没有默认路由，无法确定上行网卡，请在热点配置中指定uplink")
+	}
+	return best, nil
+}
@@ -4,19 +4,24 @@ import (
 	"encoding/json"
 	"fmt"
 	"networkconfig/models"
-	"os/exec"
 	"strings"
 )
 
 // Win11HotspotManager 管理Windows移动热点
 type Win11HotspotManager struct {
-	debug bool
+	debug  bool
+	runner CommandRunner
 	// PowerShell通用代码块，包含Windows Runtime assemblies加载和辅助函数
 	commonCode string
 	// 是否已初始化执行策略
 	policyInitialized bool
 }
 
+// command 创建由管理器runner执行的命令
+func (m *Win11HotspotManager) command(name string, args ...string) *Command {
+	return NewCommand(m.runner, name, args...)
+}
+
 // 设置PowerShell执行策略
 func (m *Win11HotspotManager) setExecutionPolicy() error {
 	if m.policyInitialized {
@@ -24,8 +29,7 @@ func (m *Win11HotspotManager) setExecutionPolicy() error {
 	}
 
 	// 首先检查当前执行策略
-	checkCmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command",
-		"Get-ExecutionPolicy -Scope CurrentUser")
+	checkCmd := m.command("powershell", "-NoProfile", "-NonInteractive", "-Command", psUserExecutionPolicyScript)
 	output, err := checkCmd.CombinedOutput()
 	if err == nil && strings.Contains(string(output), "RemoteSigned") {
 		m.policyInitialized = true
@@ -33,7 +37,7 @@ func (m *Win11HotspotManager) setExecutionPolicy() error {
 	}
 
 	// 尝试设置执行策略
-	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command",
+	cmd := m.command("powershell", "-NoProfile", "-NonInteractive", "-Command",
 		"Set-ExecutionPolicy -Scope CurrentUser -ExecutionPolicy RemoteSigned -Force")
 	output, err = cmd.CombinedOutput()
 	if err != nil {
@@ -124,9 +128,10 @@ catch {
 `
 
 // NewWin11HotspotManager 创建新的热点管理器
-func NewWin11HotspotManager(debug bool) *Win11HotspotManager {
+func NewWin11HotspotManager(debug bool, runner CommandRunner) *Win11HotspotManager {
 	manager := &Win11HotspotManager{
 		debug:             debug,
+		runner:            runner,
 		commonCode:        psCommonCode,
 		policyInitialized: false,
 	}
@@ -191,7 +196,7 @@ catch {
 `, m.commonCode)
 
 	// 执行PowerShell脚本
-	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "RemoteSigned", "-Command", psScript)
+	cmd := m.command("powershell", "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "RemoteSigned", "-Command", psScript)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return models.HotspotStatus{}, fmt.Errorf("获取热点状态失败: %v", err)
@@ -229,7 +234,188 @@ catch {
 	}, nil
 }
 
-// Configure 配置热点
+// GetConfig 读取当前热点配置，包括密码；较旧的Windows版本没有Band和AuthenticationKind属性
+func (m *Win11HotspotManager) GetConfig() (models.HotspotConfig, error) {
+	if err := m.setExecutionPolicy(); err != nil {
+		return models.HotspotConfig{}, fmt.Errorf("读取热点配置前设置PowerShell执行策略失败: %v", err)
+	}
+
+	psScript := fmt.Sprintf(`
+%s
+try {
+    $tetheringManager = Get-TetheringManager
+    $config = $tetheringManager.GetCurrentAccessPointConfiguration()
+
+    $band = -1
+    if ($config | Get-Member -Name Band) { $band = [int]$config.Band }
+    $authenticationKind = -1
+    if ($config | Get-Member -Name AuthenticationKind) { $authenticationKind = [int]$config.AuthenticationKind }
+
+    @{
+        Success = $true
+        SSID = $config.Ssid
+        Passphrase = $config.Passphrase
+        Enabled = $tetheringManager.TetheringOperationalState -eq 1
+        Band = $band
+        AuthenticationKind = $authenticationKind
+    } | ConvertTo-Json
+}
+catch {
+    @{
+        Success = $false
+        Error = $_.Exception.Message
+    } | ConvertTo-Json
+}
+`, m.commonCode)
+
+	cmd := m.command("powershell", "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "RemoteSigned", "-Command", psScript)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return models.HotspotConfig{}, fmt.Errorf("读取热点配置失败: %v", err)
+	}
+
+	var result struct {
+		Success            bool   `json:"Success"`
+		Error              string `json:"Error"`
+		SSID               string `json:"SSID"`
+		Passphrase         string `json:"Passphrase"`
+		Enabled            bool   `json:"Enabled"`
+		Band               int    `json:"Band"`
+		AuthenticationKind int    `json:"AuthenticationKind"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return models.HotspotConfig{}, fmt.Errorf("解析热点配置失败: %v", err)
+	}
+	if !result.Success {
+		return models.HotspotConfig{}, fmt.Errorf("读取热点配置失败: %s", result.Error)
+	}
+
+	config := models.HotspotConfig{
+		SSID:     result.SSID,
+		Password: result.Passphrase,
+		Enabled:  result.Enabled,
+	}
+	for _, band := range []string{models.HotspotBandAuto, models.HotspotBand24GHz, models.HotspotBand5GHz, models.HotspotBand6GHz} {
+		if win11BandValue(band) == result.Band {
+			config.Band = band
+		}
+	}
+	for _, security := range []string{models.HotspotSecurityWPA2, models.HotspotSecurityWPA3Transition, models.HotspotSecurityWPA3} {
+		if win11AuthenticationKind(security) == result.AuthenticationKind {
+			config.Security = security
+		}
+	}
+	return config, nil
+}
+
+// GetClients 获取已连接热点的客户端，IP地址优先取自客户端的HostNames，缺失时查邻居表
+func (m *Win11HotspotManager) GetClients() ([]models.HotspotClient, error) {
+	if err := m.setExecutionPolicy(); err != nil {
+		return nil, fmt.Errorf("获取热点客户端前设置PowerShell执行策略失败: %v", err)
+	}
+
+	psScript := fmt.Sprintf(`
+%s
+try {
+    $tetheringManager = Get-TetheringManager
+
+    # HostName.Type: 0 = DomainName, 1 = Ipv4, 2 = Ipv6
+    $clients = @()
+    foreach ($client in $tetheringManager.GetTetheringClients()) {
+        $names = @($client.HostNames | ForEach-Object {
+            @{
+                Name = $_.CanonicalName
+                Type = [int]$_.Type
+            }
+        })
+        $clients += @{
+            MacAddress = $client.MacAddress
+            HostNames = $names
+        }
+    }
+
+    $neighbors = @(Get-NetNeighbor -AddressFamily IPv4 -ErrorAction SilentlyContinue |
+        Where-Object { $_.State -ne 'Unreachable' -and $_.LinkLayerAddress } |
+        ForEach-Object {
+            @{
+                IPAddress = $_.IPAddress
+                LinkLayerAddress = $_.LinkLayerAddress
+            }
+        })
+
+    @{
+        Success = $true
+        Clients = $clients
+        Neighbors = $neighbors
+    } | ConvertTo-Json -Depth 4
+}
+catch {
+    @{
+        Success = $false
+        Error = $_.Exception.Message
+    } | ConvertTo-Json
+}
+`, m.commonCode)
+
+	cmd := m.command("powershell", "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "RemoteSigned", "-Command", psScript)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("获取热点客户端失败: %v", err)
+	}
+
+	var result struct {
+		Success bool   `json:"Success"`
+		Error   string `json:"Error"`
+		Clients []struct {
+			MacAddress string `json:"MacAddress"`
+			HostNames  []struct {
+				Name string `json:"Name"`
+				Type int    `json:"Type"`
+			} `json:"HostNames"`
+		} `json:"Clients"`
+		Neighbors []struct {
+			IPAddress        string `json:"IPAddress"`
+			LinkLayerAddress string `json:"LinkLayerAddress"`
+		} `json:"Neighbors"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("解析热点客户端失败: %v", err)
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("获取热点客户端失败: %s", result.Error)
+	}
+
+	neighbors := make(map[string]string)
+	for _, neighbor := range result.Neighbors {
+		if mac := normalizeMAC(neighbor.LinkLayerAddress); mac != "" {
+			neighbors[mac] = neighbor.IPAddress
+		}
+	}
+
+	clients := make([]models.HotspotClient, 0, len(result.Clients))
+	for _, item := range result.Clients {
+		mac := normalizeMAC(item.MacAddress)
+		if mac == "" {
+			continue
+		}
+		client := models.HotspotClient{MAC: mac}
+		for _, host := range item.HostNames {
+			switch {
+			case host.Type == 1 && client.IP == "":
+				client.IP = host.Name
+			case host.Type == 0 && client.Hostname == "":
+				client.Hostname = host.Name
+			}
+		}
+		if client.IP == "" {
+			client.IP = neighbors[mac]
+		}
+		clients = append(clients, client)
+	}
+	return clients, nil
+}
+
+// Configure 配置热点，按enabled启动或停止热点
 func (m *Win11HotspotManager) Configure(config models.HotspotConfig) error {
 	// 确保PowerShell执行策略已设置
 	if err := m.setExecutionPolicy(); err != nil {
@@ -237,20 +423,22 @@ func (m *Win11HotspotManager) Configure(config models.HotspotConfig) error {
 	}
 
 	// 验证参数
-	if config.SSID == "" {
-		return fmt.Errorf("SSID不能为空")
+	errs := ValidateHotspotConfig(config)
+	if config.Channel != 0 {
+		errs.add("channel", ValidationUnsupported, "Windows移动热点不支持指定信道")
 	}
-	if len(config.SSID) > 32 {
-		return fmt.Errorf("SSID长度不能超过32个字符")
+	if config.MaxClients != 0 {
+		errs.add("max_clients", ValidationUnsupported, "Windows移动热点不支持设置最大客户端数")
 	}
-	if config.Password == "" {
-		return fmt.Errorf("密码不能为空")
+	if config.Uplink != "" {
+		errs.add("uplink", ValidationUnsupported, "Windows移动热点由系统选择共享的网络连接，不支持指定上行网卡")
 	}
-	if len(config.Password) < 8 || len(config.Password) > 63 {
-		return fmt.Errorf("密码长度必须在8-63个字符之间")
+	if len(errs) > 0 {
+		return errs
 	}
 
 	// 构建PowerShell脚本内容
+	// 频段和认证方式需要较新的Windows版本以及网卡支持，先检查再设置，不支持时不修改任何配置
 	psScript := fmt.Sprintf(`
 %s
 try {
@@ -258,8 +446,40 @@ try {
     
     # Create new configuration
     $config = New-Object Windows.Networking.NetworkOperators.NetworkOperatorTetheringAccessPointConfiguration
-    $config.Ssid = "%s"
-    $config.Passphrase = "%s"
+    $config.Ssid = '%s'
+    $config.Passphrase = '%s'
+
+    # TetheringWiFiBand: 0 = Auto, 1 = 2.4GHz, 2 = 5GHz, 3 = 6GHz; -1 = not requested
+    # TetheringWiFiAuthenticationKind: 0 = Wpa2, 1 = Wpa3TransitionMode, 2 = Wpa3; -1 = not requested
+    $band = %d
+    $authenticationKind = %d
+    $unsupported = @()
+    if ($band -ge 0) {
+        if (-not ($config | Get-Member -Name IsBandSupported)) {
+            $unsupported += @{ Field = "band"; Reason = "os" }
+        } elseif ($config.IsBandSupported($band)) {
+            $config.Band = $band
+        } else {
+            $unsupported += @{ Field = "band"; Reason = "adapter" }
+        }
+    }
+    if ($authenticationKind -ge 0) {
+        if (-not ($config | Get-Member -Name IsAuthenticationKindSupported)) {
+            $unsupported += @{ Field = "security"; Reason = "os" }
+        } elseif ($config.IsAuthenticationKindSupported($authenticationKind)) {
+            $config.AuthenticationKind = $authenticationKind
+        } else {
+            $unsupported += @{ Field = "security"; Reason = "adapter" }
+        }
+    }
+    if ($unsupported.Count -gt 0) {
+        @{
+            Success = $false
+            Error = "Unsupported options"
+            Unsupported = $unsupported
+        } | ConvertTo-Json -Depth 3
+        return
+    }
     
     # Configure the access point
     $operation = $tetheringManager.ConfigureAccessPointAsync($config)
@@ -268,6 +488,9 @@ try {
     if ($%v) {
         $operation = $tetheringManager.StartTetheringAsync()
         Await -WinRtTask $operation -ResultType ([Windows.Networking.NetworkOperators.NetworkOperatorTetheringOperationResult])
+    } elseif ($tetheringManager.TetheringOperationalState -eq 1) {
+        $operation = $tetheringManager.StopTetheringAsync()
+        Await -WinRtTask $operation -ResultType ([Windows.Networking.NetworkOperators.NetworkOperatorTetheringOperationResult])
     }
     
     @{
@@ -280,10 +503,11 @@ catch {
         Error = $_.Exception.Message
     } | ConvertTo-Json
 }
-`, m.commonCode, config.SSID, config.Password, config.Enabled)
+`, m.commonCode, strings.ReplaceAll(config.SSID, "'", "''"), strings.ReplaceAll(config.Password, "'", "''"),
+		win11BandValue(config.Band), win11AuthenticationKind(config.Security), config.Enabled)
 
 	// 执行PowerShell脚本
-	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "RemoteSigned", "-Command", psScript)
+	cmd := m.command("powershell", "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "RemoteSigned", "-Command", psScript)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("配置热点失败: %v", err)
@@ -291,14 +515,30 @@ catch {
 
 	// 解析JSON输出
 	var result struct {
-		Success bool   `json:"Success"`
-		Error   string `json:"Error"`
+		Success     bool   `json:"Success"`
+		Error       string `json:"Error"`
+		Unsupported []struct {
+			Field  string `json:"Field"`
+			Reason string `json:"Reason"`
+		} `json:"Unsupported"`
 	}
 
 	if err := json.Unmarshal(output, &result); err != nil {
 		return fmt.Errorf("解析配置结果失败: %v", err)
 	}
 
+	if len(result.Unsupported) > 0 {
+		for _, item := range result.Unsupported {
+			option := map[string]string{"band": "频段" + config.Band, "security": "安全模式" + config.Security}[item.Field]
+			if item.Reason == "os" {
+				errs.add(item.Field, ValidationUnsupported, "当前Windows版本不支持设置%s", option)
+			} else {
+				errs.add(item.Field, ValidationUnsupported, "无线网卡不支持%s", option)
+			}
+		}
+		return errs
+	}
+
 	if !result.Success {
 		return fmt.Errorf("配置热点失败: %s", result.Error)
 	}
@@ -306,6 +546,36 @@ catch {
 	return nil
 }
 
+// win11BandValue 把频段转换为TetheringWiFiBand枚举值，未指定时返回-1
+func win11BandValue(band string) int {
+	switch band {
+	case models.HotspotBandAuto:
+		return 0
+	case models.HotspotBand24GHz:
+		return 1
+	case models.HotspotBand5GHz:
+		return 2
+	case models.HotspotBand6GHz:
+		return 3
+	default:
+		return -1
+	}
+}
+
+// win11AuthenticationKind 把安全模式转换为TetheringWiFiAuthenticationKind枚举值，未指定时返回-1
+func win11AuthenticationKind(security string) int {
+	switch security {
+	case models.HotspotSecurityWPA2:
+		return 0
+	case models.HotspotSecurityWPA3Transition:
+		return 1
+	case models.HotspotSecurityWPA3:
+		return 2
+	default:
+		return -1
+	}
+}
+
 // getActionWord 根据启用状态返回对应的动作词
 func getActionWord(enable bool) string {
 	if enable {
@@ -349,7 +619,7 @@ catch {
 `, m.commonCode, getActionWord(enable), action)
 
 	// 执行PowerShell脚本
-	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "RemoteSigned", "-Command", psScript)
+	cmd := m.command("powershell", "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "RemoteSigned", "-Command", psScript)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("设置热点状态失败: %v", err)
@@ -0,0 +1,363 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"networkconfig/models"
+	"strconv"
+	"strings"
+)
+
+// iwBSS iw dev <网卡> scan 输出中的一个BSS
+type iwBSS struct {
+	bssid      string
+	ssid       string
+	freq       int     // 中心频率(MHz)
+	channel    int     // 主信道，iw没有给出时由频率换算
+	signal     float64 // 信号强度(dBm)
+	privacy    bool    // capability中的Privacy位
+	associated bool    // 当前已关联的BSS
+
+	rsn *iwSecurityIE // RSN(WPA2/WPA3)信息元素
+	wpa *iwSecurityIE // WPA1信息元素
+	wps bool
+
+	ht, vht, he, eht bool // 支持的无线标准(802.11n/ac/ax/be)
+	width            int  // 信道宽度(MHz)
+}
+
+// iwSecurityIE RSN或WPA信息元素的内容
+type iwSecurityIE struct {
+	groupCipher string
+	pairwise    []string // 成对加密套件，如CCMP、TKIP
+	akm         []string // 认证套件，如PSK、SAE、IEEE 802.1X
+	mfp         string   // 管理帧保护: required、capable或空
+}
+
+// iwLink iw dev <网卡> link 给出的当前连接
+type iwLink struct {
+	bssid  string
+	ssid   string
+	freq   int
+	signal int // dBm
+}
+
+// scanWiFiIw 使用iw扫描WiFi热点，没有权限触发扫描或网卡正忙时读取内核缓存的扫描结果
+func (b *linuxBackend) scanWiFiIw(interfaceName string) ([]models.WiFiHotspot, error) {
+	log.Printf("开始使用iw扫描接口 %s 的WiFi热点...", interfaceName)
+
+	output, err := b.command("iw", "dev", interfaceName, "scan").CombinedOutput()
+	if err != nil {
+		log.Printf("iw扫描失败: %v, 输出: %s，读取缓存的扫描结果", err, strings.TrimSpace(string(output)))
+		if output, err = b.command("iw", "dev", interfaceName, "scan", "dump").CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("iw扫描失败: %v, 输出: %s", err, strings.TrimSpace(string(output)))
+		}
+	}
+
+	bssList := parseIwScan(string(output))
+	hotspots := make([]models.WiFiHotspot, 0, len(bssList))
+	for _, bss := range bssList {
+		hotspots = append(hotspots, bss.hotspot())
+	}
+	log.Printf("iw扫描完成，发现 %d 个热点", len(hotspots))
+	return hotspots, nil
+}
+
+// iwConnectedLink 使用iw读取网卡当前的连接，未连接时返回nil
+func (b *linuxBackend) iwConnectedLink(interfaceName string) (*iwLink, error) {
+	output, err := b.command("iw", "dev", interfaceName, "link").Output()
+	if err != nil {
+		return nil, fmt.Errorf("读取网卡 %s 的连接状态失败: %v", interfaceName, err)
+	}
+	return parseIwLink(string(output)), nil
+}
+
+// hotspot 转换为扫描结果
+func (bss iwBSS) hotspot() models.WiFiHotspot {
+	hotspot := models.WiFiHotspot{
+		SSID:           bss.ssid,
+		SignalStrength: signalPercent(int(bss.signal)),
+		SignalDBm:      int(bss.signal),
+		Security:       bss.security(),
+		BSSID:          strings.ToUpper(bss.bssid),
+		Channel:        bss.channel,
+		Frequency:      bss.freq,
+		Band:           frequencyBand(bss.freq),
+		ChannelWidth:   bss.width,
+		Connected:      bss.associated,
+		SecurityInfo:   bss.securityInfo(),
+	}
+	if hotspot.Channel == 0 {
+		hotspot.Channel = frequencyToChannel(bss.freq)
+	}
+	// 没有HT/VHT操作信息时为20MHz
+	if hotspot.ChannelWidth == 0 && bss.freq != 0 {
+		hotspot.ChannelWidth = 20
+	}
+	hotspot.PHYType = wifiPHYType(bss.ht, bss.vht, bss.he, bss.eht, hotspot.Band)
+	return hotspot
+}
+
+// securityInfo 返回加密方式详情，同时存在RSN和WPA时以RSN为准
+func (bss iwBSS) securityInfo() models.WiFiSecurity {
+	ie := bss.rsn
+	if ie == nil {
+		ie = bss.wpa
+	}
+	var security models.WiFiSecurity
+	switch {
+	case ie != nil:
+		security = newWiFiSecurity(ie.akm, ie.pairwise, ie.groupCipher)
+		security.PMF = ie.mfp
+	case bss.privacy:
+		security = newWiFiSecurity([]string{"wep"}, nil, "")
+	default:
+		security = newWiFiSecurity(nil, nil, "")
+	}
+	security.WPS = bss.wps
+	return security
+}
+
+// security 按RSN/WPA认证套件生成与nmcli SECURITY列一致的加密类型，开放网络为空
+func (bss iwBSS) security() string {
+	var wep, wpa1, wpa2, wpa3, owe, eap bool
+	if bss.wpa != nil {
+		wpa1 = true
+		for _, akm := range bss.wpa.akm {
+			eap = eap || strings.Contains(akm, "802.1X")
+		}
+	}
+	if bss.rsn != nil {
+		for _, akm := range bss.rsn.akm {
+			switch {
+			case strings.Contains(akm, "SAE"), strings.Contains(akm, "SuiteB"):
+				wpa3 = true
+			case strings.Contains(akm, "OWE"):
+				owe = true
+			case strings.Contains(akm, "PSK"), strings.Contains(akm, "802.1X"):
+				wpa2 = true
+			}
+			eap = eap || strings.Contains(akm, "802.1X")
+		}
+	}
+	if bss.privacy && bss.rsn == nil && bss.wpa == nil {
+		wep = true
+	}
+
+	var parts []string
+	for _, part := range []struct {
+		set  bool
+		name string
+	}{{wep, "WEP"}, {wpa1, "WPA1"}, {wpa2, "WPA2"}, {wpa3, "WPA3"}, {owe, "OWE"}, {eap, "802.1X"}} {
+		if part.set {
+			parts = append(parts, part.name)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseIwScan 解析iw dev <网卡> scan 的输出
+// 每个BSS以"BSS <MAC>(on <网卡>)"开头，属性行缩进一个制表符，RSN/WPA等信息元素的子项以"* "开头并缩进两个制表符
+func parseIwScan(output string) []iwBSS {
+	var result []iwBSS
+	var bss *iwBSS
+	var section string
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "BSS ") {
+			if bss != nil {
+				result = append(result, *bss)
+			}
+			// BSS 00:11:22:33:44:55(on wlan0) -- associated
+			header := strings.TrimPrefix(line, "BSS ")
+			bssid := header
+			if i := strings.IndexAny(header, "( "); i >= 0 {
+				bssid = header[:i]
+			}
+			bss = &iwBSS{bssid: bssid, associated: strings.Contains(header, "-- associated")}
+			section = ""
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if bss == nil || trimmed == "" {
+			continue
+		}
+
+		// 子项: "* Authentication suites: PSK SAE"
+		if strings.HasPrefix(trimmed, "* ") && strings.HasPrefix(line, "\t\t") {
+			parseIwSubfield(bss, section, trimmed)
+			continue
+		}
+		if strings.HasPrefix(line, "\t\t") {
+			continue
+		}
+
+		key, value, _ := strings.Cut(trimmed, ":")
+		value = strings.TrimSpace(value)
+		section = key
+		switch key {
+		case "freq":
+			// 新版iw输出小数，如2437.0
+			if freq, err := strconv.ParseFloat(value, 64); err == nil {
+				bss.freq = int(freq)
+			}
+		case "signal":
+			// -45.00 dBm
+			if signal, err := strconv.ParseFloat(strings.TrimSuffix(value, " dBm"), 64); err == nil {
+				bss.signal = signal
+			}
+		case "SSID":
+			bss.ssid = decodeIwSSID(value)
+		case "capability":
+			bss.privacy = strings.Contains(value, "Privacy")
+		case "DS Parameter set":
+			if channel := strings.TrimPrefix(value, "channel "); channel != value {
+				bss.channel, _ = strconv.Atoi(channel)
+			}
+		case "RSN":
+			bss.rsn = &iwSecurityIE{}
+		case "WPA":
+			bss.wpa = &iwSecurityIE{}
+		case "WPS":
+			bss.wps = true
+		case "HT capabilities":
+			bss.ht = true
+		case "VHT capabilities":
+			bss.vht = true
+		case "HE capabilities":
+			bss.he = true
+		case "EHT capabilities":
+			bss.eht = true
+		}
+		// 第一个子项与信息元素名称在同一行，如"RSN:\t * Version: 1"
+		if strings.HasPrefix(value, "* ") {
+			parseIwSubfield(bss, section, value)
+		}
+	}
+	if bss != nil {
+		result = append(result, *bss)
+	}
+	return result
+}
+
+// parseIwSubfield 解析信息元素的一个子项
+func parseIwSubfield(bss *iwBSS, section, item string) {
+	key, value, _ := strings.Cut(strings.TrimPrefix(item, "* "), ":")
+	value = strings.TrimSpace(value)
+
+	switch {
+	case section == "HT operation" && key == "primary channel":
+		if bss.channel == 0 {
+			bss.channel, _ = strconv.Atoi(value)
+		}
+		return
+	case section == "HT operation" && key == "STA channel width":
+		// any表示可使用40MHz
+		if value == "any" && bss.width < 40 {
+			bss.width = 40
+		}
+		return
+	case section == "VHT operation" && key == "channel width":
+		// 0 (20 or 40 MHz)、1 (80 MHz)、2 (160 MHz)、3 (80+80 MHz)
+		switch leadingInt(value) {
+		case 1:
+			bss.width = 80
+		case 2, 3:
+			bss.width = 160
+		}
+		return
+	}
+
+	var ie *iwSecurityIE
+	switch section {
+	case "RSN":
+		ie = bss.rsn
+	case "WPA":
+		ie = bss.wpa
+	}
+	if ie == nil {
+		return
+	}
+	switch key {
+	case "Group cipher":
+		ie.groupCipher = value
+	case "Pairwise ciphers":
+		ie.pairwise = strings.Fields(value)
+	case "Authentication suites":
+		ie.akm = splitIwSuites(value)
+	case "Capabilities":
+		switch {
+		case strings.Contains(value, "MFP-required"):
+			ie.mfp = "required"
+		case strings.Contains(value, "MFP-capable"):
+			ie.mfp = "capable"
+		}
+	}
+}
+
+// splitIwSuites 拆分认证套件列表；"IEEE 802.1X"、"FT/IEEE 802.1X"这类名称本身含空格，需要与下一个词合并
+func splitIwSuites(value string) []string {
+	var suites []string
+	words := strings.Fields(value)
+	for i := 0; i < len(words); i++ {
+		if strings.HasSuffix(words[i], "IEEE") && i+1 < len(words) {
+			suites = append(suites, words[i]+" "+words[i+1])
+			i++
+			continue
+		}
+		suites = append(suites, words[i])
+	}
+	return suites
+}
+
+// parseIwLink 解析iw dev <网卡> link 的输出，未连接("Not connected.")时返回nil
+func parseIwLink(output string) *iwLink {
+	var link *iwLink
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "Connected to ") {
+			// Connected to 00:11:22:33:44:55 (on wlan0)
+			fields := strings.Fields(strings.TrimPrefix(trimmed, "Connected to "))
+			link = &iwLink{}
+			if len(fields) > 0 {
+				link.bssid = strings.ToUpper(fields[0])
+			}
+			continue
+		}
+		if link == nil {
+			continue
+		}
+		key, value, _ := strings.Cut(trimmed, ":")
+		value = strings.TrimSpace(value)
+		switch key {
+		case "SSID":
+			link.ssid = decodeIwSSID(value)
+		case "freq":
+			if freq, err := strconv.ParseFloat(value, 64); err == nil {
+				link.freq = int(freq)
+			}
+		case "signal":
+			link.signal = leadingInt(value)
+		}
+	}
+	return link
+}
+
+// decodeIwSSID 还原iw输出中以\xNN转义的SSID字节(不可打印字符、反斜杠以及首尾空格)
+func decodeIwSSID(s string) string {
+	if !strings.Contains(s, `\x`) {
+		return s
+	}
+	var decoded []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) && s[i+1] == 'x' {
+			if v, err := strconv.ParseUint(s[i+2:i+4], 16, 8); err == nil {
+				decoded = append(decoded, byte(v))
+				i += 3
+				continue
+			}
+		}
+		decoded = append(decoded, s[i])
+	}
+	return string(decoded)
+}
@@ -0,0 +1,1072 @@
+package service
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"networkconfig/models"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// linuxBackend Linux平台后端，网卡信息来自sysfs/procfs，地址配置使用rtnetlink，
+// WiFi操作使用wpa_supplicant控制接口、NetworkManager D-Bus接口或nmcli/iwlist，移动热点使用hostapd和dnsmasq
+type linuxBackend struct {
+	debug       bool
+	runner      CommandRunner
+	hotspot     *hostapdHotspot
+	wpa         *wpaSupplicant
+	nm          *nmClient
+	wifiBackend string // WiFi扫描和连接方式，见wifiBackendAuto等常量
+}
+
+// newLinuxBackend 创建Linux平台后端
+func newLinuxBackend(debug bool, runner CommandRunner) *linuxBackend {
+	wifiBackend := strings.ToLower(os.Getenv("LINUX_WIFI_BACKEND"))
+	switch wifiBackend {
+	case wifiBackendAuto, wifiBackendNmcli, wifiBackendNetworkManager, wifiBackendWPASupplicant:
+	case "":
+		wifiBackend = wifiBackendAuto
+	default:
+		log.Printf("未知的LINUX_WIFI_BACKEND: %s，将自动选择", wifiBackend)
+		wifiBackend = wifiBackendAuto
+	}
+	return &linuxBackend{
+		debug:       debug,
+		runner:      runner,
+		hotspot:     newHostapdHotspot(runner),
+		wpa:         newWPASupplicant(),
+		nm:          newNMClient(),
+		wifiBackend: wifiBackend,
+	}
+}
+
+// commandRunner 返回后端使用的命令执行器
+func (b *linuxBackend) commandRunner() CommandRunner {
+	return b.runner
+}
+
+// withRunner 返回使用指定命令执行器的后端副本
+func (b *linuxBackend) withRunner(runner CommandRunner) Backend {
+	clone := *b
+	clone.runner = runner
+	clone.hotspot = b.hotspot.withRunner(runner)
+	return &clone
+}
+
+// useWPASupplicant 判断该网卡的WiFi操作是否直接使用wpa_supplicant控制接口
+// 自动模式下以控制接口是否存在为准: 由NetworkManager管理的wpa_supplicant通常只开放D-Bus接口
+func (b *linuxBackend) useWPASupplicant(interfaceName string) bool {
+	switch b.wifiBackend {
+	case wifiBackendWPASupplicant:
+		return true
+	case wifiBackendNmcli:
+		return false
+	default:
+		return b.wpa.available(interfaceName)
+	}
+}
+
+// useNetworkManager 判断WiFi操作是否使用NetworkManager D-Bus接口，interfaceName为空表示与网卡无关的操作(如WiFi配置列表)
+// 自动模式下在没有wpa_supplicant控制接口且NetworkManager正在运行时使用
+func (b *linuxBackend) useNetworkManager(interfaceName string) bool {
+	switch b.wifiBackend {
+	case wifiBackendNetworkManager:
+		return true
+	case wifiBackendAuto:
+		return (interfaceName == "" || !b.wpa.available(interfaceName)) && b.nm.available()
+	default:
+		return false
+	}
+}
+
+// command 创建由后端runner执行的命令
+func (b *linuxBackend) command(name string, args ...string) *Command {
+	return NewCommand(b.runner, name, args...)
+}
+
+// Name 返回后端名称
+func (b *linuxBackend) Name() string {
+	return BackendLinux
+}
+
+// ListInterfaces 快速获取网卡列表
+func (b *linuxBackend) ListInterfaces() ([]InterfaceFast, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("获取网卡列表失败: %v", err)
+	}
+
+	log.Printf("系统中共发现 %d 个网络接口", len(ifaces))
+
+	var interfaces []InterfaceFast
+	for _, iface := range ifaces {
+		// 跳过回环接口
+		if iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+
+		// 跳过容器、网桥和隧道等虚拟接口
+		if strings.HasPrefix(iface.Name, "docker") ||
+			strings.HasPrefix(iface.Name, "veth") ||
+			strings.HasPrefix(iface.Name, "br-") ||
+			strings.HasPrefix(iface.Name, "virbr") ||
+			strings.HasPrefix(iface.Name, "tun") {
+			continue
+		}
+
+		// 跳过MAC地址为空的无效网卡
+		if len(iface.HardwareAddr) == 0 {
+			log.Printf("跳过MAC地址为空的接口: %s", iface.Name)
+			continue
+		}
+
+		interfaces = append(interfaces, InterfaceFast{
+			Name:        iface.Name,
+			Status:      getInterfaceStatusFast(iface.Flags),
+			ProductName: linuxHardwareInfo(iface).ProductName,
+		})
+	}
+
+	return interfaces, nil
+}
+
+// GetInterface 获取指定网卡的详细信息
+func (b *linuxBackend) GetInterface(name string) (models.Interface, error) {
+	log.Printf("开始获取接口 %s 的信息", name)
+
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		log.Printf("获取网卡 %s 信息失败: %v", name, err)
+		return models.Interface{}, fmt.Errorf("获取网卡信息失败: %v", err)
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		log.Printf("获取网卡 %s 地址失败: %v", name, err)
+		return models.Interface{}, fmt.Errorf("获取网卡地址失败: %v", err)
+	}
+
+	hardware := linuxHardwareInfo(*iface)
+	ifaceInfo := models.Interface{
+		Name:        iface.Name,
+		Description: hardware.ProductName,
+		Status:      getInterfaceStatus(iface.Flags),
+		DHCPEnabled: netlinkDHCPEnabled(name),
+		Hardware:    hardware,
+		Driver:      linuxDriverInfo(name),
+	}
+
+	// 地址列表包含全部地址，ipv4_config/ipv6_config只描述各地址族的主地址
+	ifaceInfo.Addresses = interfaceAddresses(addrs, netlinkAddressOrigins(name))
+	dns := readResolvConf()
+	if v4 := primaryAddress(ifaceInfo.Addresses, models.AddressFamilyIPv4); v4 != nil {
+		var dns4 []string
+		for _, server := range dns {
+			if net.ParseIP(server).To4() != nil {
+				dns4 = append(dns4, server)
+			}
+		}
+		ifaceInfo.IPv4Config = models.IPv4Config{
+			IP:      v4.IP,
+			Mask:    net.IP(net.CIDRMask(v4.PrefixLen, 32)).String(),
+			Gateway: linuxIPv4Gateway(name),
+			DNS:     dns4,
+			DHCP:    ifaceInfo.DHCPEnabled,
+			DNSAuto: ifaceInfo.DHCPEnabled,
+		}
+	}
+	if v6 := primaryAddress(ifaceInfo.Addresses, models.AddressFamilyIPv6); v6 != nil {
+		var dns6 []string
+		for _, server := range dns {
+			if ip := net.ParseIP(server); ip != nil && ip.To4() == nil {
+				dns6 = append(dns6, server)
+			}
+		}
+		ifaceInfo.IPv6Config = models.IPv6Config{
+			IP:        v6.IP,
+			PrefixLen: v6.PrefixLen,
+			Gateway:   linuxIPv6Gateway(name),
+			DNS:       dns6,
+		}
+	}
+	if b.wifiBackend == wifiBackendAuto || b.wifiBackend == wifiBackendNmcli {
+		b.applyNmcliDevice(name, &ifaceInfo)
+	}
+	fillAddressCIDR(&ifaceInfo)
+	if hardware.AdapterType == models.AdapterTypeWireless {
+		ifaceInfo.ConnectedSSID = b.connectedSSID(name)
+	}
+
+	log.Printf("成功获取接口 %s 的完整信息", name)
+	return ifaceInfo, nil
+}
+
+// applyNmcliDevice 网卡由NetworkManager管理时，用nmcli device show给出的网关、DNS和DHCP状态替换从系统读取的值；
+// resolv.conf可能只有本地缓存地址(如127.0.0.53)，nmcli给出的是网卡实际使用的DNS
+func (b *linuxBackend) applyNmcliDevice(name string, ifaceInfo *models.Interface) {
+	if _, err := b.runner.LookPath("nmcli"); err != nil {
+		return
+	}
+	output, err := b.command("nmcli", "-t", "--escape", "yes", "device", "show", name).Output()
+	if err != nil {
+		log.Printf("nmcli读取网卡 %s 信息失败: %v", name, err)
+		return
+	}
+	device := parseNmcliDeviceShow(string(output))
+	// 状态形如"100 (connected)"，未管理或未连接时没有IP配置
+	state := leadingInt(firstValue(device["GENERAL.STATE"]))
+	if state < nmDeviceStateActivated {
+		return
+	}
+
+	ifaceInfo.DHCPEnabled = len(device["DHCP4.OPTION"]) > 0
+	if ifaceInfo.IPv4Config.IP == "" {
+		if addr, err := parseHostCIDR(firstValue(device["IP4.ADDRESS"])); err == nil {
+			ifaceInfo.IPv4Config.IP = addr.IP.String()
+			ifaceInfo.IPv4Config.Mask = net.IP(addr.Mask).String()
+		}
+	}
+	if ifaceInfo.IPv4Config.IP != "" {
+		ifaceInfo.IPv4Config.Gateway = nmcliValue(firstValue(device["IP4.GATEWAY"]))
+		ifaceInfo.IPv4Config.DNS = device["IP4.DNS"]
+		ifaceInfo.IPv4Config.DHCP = ifaceInfo.DHCPEnabled
+		ifaceInfo.IPv4Config.DNSAuto = ifaceInfo.DHCPEnabled
+	}
+
+	if ifaceInfo.IPv6Config.IP == "" {
+		if addr, err := parseHostCIDR(firstValue(device["IP6.ADDRESS"])); err == nil {
+			ones, _ := addr.Mask.Size()
+			ifaceInfo.IPv6Config.IP = addr.IP.String()
+			ifaceInfo.IPv6Config.PrefixLen = ones
+		}
+	}
+	if ifaceInfo.IPv6Config.IP != "" {
+		ifaceInfo.IPv6Config.Gateway = nmcliValue(firstValue(device["IP6.GATEWAY"]))
+		ifaceInfo.IPv6Config.DNS = device["IP6.DNS"]
+	}
+}
+
+// connectedSSID 返回无线网卡当前连接的SSID，优先使用wpa_supplicant控制接口，否则使用iw，未连接或无法读取时为空
+func (b *linuxBackend) connectedSSID(name string) string {
+	if b.wpa.available(name) {
+		if status, err := b.wpa.Status(name); err == nil {
+			if status["wpa_state"] == "COMPLETED" {
+				return decodeWPAString(status["ssid"])
+			}
+			return ""
+		}
+	}
+	if _, err := b.runner.LookPath("iw"); err != nil {
+		return ""
+	}
+	link, err := b.iwConnectedLink(name)
+	if err != nil {
+		log.Printf("%v", err)
+		return ""
+	}
+	if link == nil {
+		return ""
+	}
+	return link.ssid
+}
+
+// parseNmcliDeviceShow 解析nmcli -t device show的输出，每行为"名称:值"，
+// 多值字段(如IP4.ADDRESS[1]、IP4.DNS[2])去掉序号后按出现顺序合并
+func parseNmcliDeviceShow(output string) map[string][]string {
+	device := make(map[string][]string)
+	for _, line := range strings.Split(output, "\n") {
+		// 名称中不含冒号，值中的冒号(如IPv6地址)可能被转义
+		name, value, ok := strings.Cut(strings.TrimRight(line, "\r"), ":")
+		if !ok {
+			continue
+		}
+		if i := strings.Index(name, "["); i > 0 {
+			name = name[:i]
+		}
+		value = strings.Join(splitNmcliTerse(value), ":")
+		if value == "" {
+			continue
+		}
+		device[name] = append(device[name], value)
+	}
+	return device
+}
+
+// firstValue 返回列表的第一项，列表为空时返回空字符串
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// ConfigureIPv4 通过rtnetlink配置IPv4地址、网关和DHCP模式，DNS单独设置
+func (b *linuxBackend) ConfigureIPv4(name string, config models.IPv4Config) error {
+	if err := netlinkConfigureIPv4(b.runner, name, config); err != nil {
+		return err
+	}
+
+	if config.DHCP && config.DNSAuto {
+		return b.SetDNS(name, false, nil)
+	}
+	if len(config.DNS) > 0 {
+		return b.SetDNS(name, false, config.DNS)
+	}
+	return nil
+}
+
+// ConfigureIPv6 通过rtnetlink配置IPv6地址和网关，DNS单独设置
+func (b *linuxBackend) ConfigureIPv6(name string, config models.IPv6Config) error {
+	if err := netlinkConfigureIPv6(b.runner, name, config); err != nil {
+		return err
+	}
+
+	if len(config.DNS) > 0 {
+		return b.SetDNS(name, true, config.DNS)
+	}
+	return nil
+}
+
+// AddAddress 通过rtnetlink为网卡追加一个地址
+func (b *linuxBackend) AddAddress(name, address string) error {
+	return netlinkAddAddress(b.runner, name, address)
+}
+
+// RemoveAddress 通过rtnetlink删除网卡上的一个地址
+func (b *linuxBackend) RemoveAddress(name, address string) error {
+	return netlinkRemoveAddress(b.runner, name, address)
+}
+
+// SetDNS 设置DNS服务器，优先使用systemd-resolved按接口设置，否则改写/etc/resolv.conf
+func (b *linuxBackend) SetDNS(name string, ipv6 bool, servers []string) error {
+	if _, err := b.runner.LookPath("resolvectl"); err == nil {
+		args := append([]string{"dns", name}, servers...)
+		if len(servers) == 0 {
+			args = []string{"revert", name}
+		}
+		log.Printf("执行命令: resolvectl %v", args)
+		output, err := b.command("resolvectl", args...).CombinedOutput()
+		if err != nil {
+			log.Printf("设置DNS服务器失败: %v, 输出: %s", err, string(output))
+			return fmt.Errorf("设置DNS服务器失败: %v, 输出: %s", err, string(output))
+		}
+		log.Printf("成功设置接口 %s 的DNS服务器: %v", name, servers)
+		return nil
+	}
+
+	if len(servers) == 0 {
+		// 没有resolved时由DHCP客户端自行改写resolv.conf
+		log.Printf("未找到resolvectl，DNS自动获取交由DHCP客户端处理")
+		return nil
+	}
+	return applyOp(b.runner, fmt.Sprintf("write /etc/resolv.conf nameserver %s", strings.Join(servers, ",")), "更新resolv.conf", func() error {
+		return writeResolvConf(ipv6, servers)
+	})
+}
+
+// SetGateway 通过rtnetlink替换默认网关
+func (b *linuxBackend) SetGateway(name string, ipv6 bool, gateway string) error {
+	return netlinkSetGateway(b.runner, name, ipv6, gateway)
+}
+
+// GetHotspotStatus 从hostapd控制接口读取热点状态
+func (b *linuxBackend) GetHotspotStatus() (models.HotspotStatus, error) {
+	return b.hotspot.Status()
+}
+
+// GetHotspotConfig 从hostapd.conf读取热点配置
+func (b *linuxBackend) GetHotspotConfig() (models.HotspotConfig, error) {
+	return b.hotspot.Config()
+}
+
+// ConfigureHotspot 生成hostapd/dnsmasq配置，按enabled启动或停止热点
+func (b *linuxBackend) ConfigureHotspot(config models.HotspotConfig) error {
+	return b.hotspot.Configure(config)
+}
+
+// SetHotspotStatus 启动或停止hostapd和dnsmasq
+func (b *linuxBackend) SetHotspotStatus(enable bool) error {
+	return b.hotspot.SetEnabled(enable)
+}
+
+// GetHotspotClients 从hostapd和dnsmasq租约读取已连接的客户端
+func (b *linuxBackend) GetHotspotClients() ([]models.HotspotClient, error) {
+	return b.hotspot.Clients()
+}
+
+// ApplyHotspotACL 生成hostapd的MAC地址过滤配置，热点运行时重新加载
+func (b *linuxBackend) ApplyHotspotACL(acl models.HotspotACL) error {
+	return b.hotspot.ApplyACL(acl)
+}
+
+// DisconnectHotspotClient 通过hostapd_cli断开客户端
+func (b *linuxBackend) DisconnectHotspotClient(mac string) error {
+	return b.hotspot.Disconnect(mac)
+}
+
+// linuxHardwareInfo 从sysfs读取网卡硬件信息
+func linuxHardwareInfo(iface net.Interface) models.Hardware {
+	base := filepath.Join("/sys/class/net", iface.Name)
+
+	hw := models.Hardware{
+		MACAddress:  iface.HardwareAddr.String(),
+		AdapterType: models.AdapterTypeEthernet,
+		Speed:       "Unknown",
+	}
+	if _, err := os.Stat(filepath.Join(base, "wireless")); err == nil {
+		hw.AdapterType = models.AdapterTypeWireless
+		hw.PhysicalMedia = "802.11 Wireless"
+	} else {
+		hw.PhysicalMedia = "Ethernet"
+	}
+
+	if speed, err := os.ReadFile(filepath.Join(base, "speed")); err == nil {
+		if mbps, err := strconv.Atoi(strings.TrimSpace(string(speed))); err == nil && mbps > 0 {
+			hw.Speed = fmt.Sprintf("%d Mbps", mbps)
+		}
+	}
+
+	// 设备所在总线，如pci、usb
+	if subsystem, err := filepath.EvalSymlinks(filepath.Join(base, "device", "subsystem")); err == nil {
+		hw.BusType = strings.ToUpper(filepath.Base(subsystem))
+	}
+
+	uevent := readKeyValueFile(filepath.Join(base, "device", "uevent"))
+	hw.PNPDeviceID = uevent["MODALIAS"]
+	hw.ProductName = uevent["DRIVER"]
+	if hw.ProductName == "" {
+		hw.ProductName = iface.Name
+	}
+	return hw
+}
+
+// linuxDriverInfo 从sysfs读取网卡驱动信息
+func linuxDriverInfo(name string) models.Driver {
+	driver := models.Driver{Status: "OK"}
+	if link, err := filepath.EvalSymlinks(filepath.Join("/sys/class/net", name, "device", "driver")); err == nil {
+		driver.Name = filepath.Base(link)
+		driver.Path = link
+		if version, err := os.ReadFile(filepath.Join(link, "module", "version")); err == nil {
+			driver.Version = strings.TrimSpace(string(version))
+		}
+	}
+	return driver
+}
+
+// readKeyValueFile 读取KEY=VALUE格式的文件
+func readKeyValueFile(path string) map[string]string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return make(map[string]string)
+	}
+	return parseKeyValueLines(string(data))
+}
+
+// parseKeyValueLines 解析每行一个key=value的文本，如hostapd_cli的输出
+func parseKeyValueLines(text string) map[string]string {
+	values := make(map[string]string)
+	for _, line := range strings.Split(text, "\n") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(line), "="); ok {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// readResolvConf 读取/etc/resolv.conf中的nameserver
+func readResolvConf() []string {
+	file, err := os.Open("/etc/resolv.conf")
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var servers []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" && net.ParseIP(fields[1]) != nil {
+			servers = append(servers, fields[1])
+		}
+	}
+	return servers
+}
+
+// writeResolvConf 替换/etc/resolv.conf中同一地址族的nameserver，保留其他行
+func writeResolvConf(ipv6 bool, servers []string) error {
+	const path = "/etc/resolv.conf"
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("读取%s失败: %v", path, err)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			if ip := net.ParseIP(fields[1]); ip != nil && (ip.To4() == nil) == ipv6 {
+				continue
+			}
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	for _, server := range servers {
+		lines = append(lines, "nameserver "+server)
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("写入%s失败: %v", path, err)
+	}
+	log.Printf("已更新%s中的DNS服务器: %v", path, servers)
+	return nil
+}
+
+// linuxIPv4Gateway 从/proc/net/route读取指定网卡的IPv4默认网关
+func linuxIPv4Gateway(name string) string {
+	data, err := os.ReadFile("/proc/net/route")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n")[1:] {
+		// Iface Destination Gateway Flags ...，地址为小端序十六进制
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] != name || fields[1] != "00000000" {
+			continue
+		}
+		raw, err := hex.DecodeString(fields[2])
+		if err != nil || len(raw) != 4 {
+			continue
+		}
+		return net.IPv4(raw[3], raw[2], raw[1], raw[0]).String()
+	}
+	return ""
+}
+
+// linuxIPv6Gateway 从/proc/net/ipv6_route读取指定网卡的IPv6默认网关
+func linuxIPv6Gateway(name string) string {
+	data, err := os.ReadFile("/proc/net/ipv6_route")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		// dest destlen src srclen nexthop metric refcnt use flags iface
+		fields := strings.Fields(line)
+		if len(fields) < 10 || fields[9] != name || fields[1] != "00" {
+			continue
+		}
+		raw, err := hex.DecodeString(fields[4])
+		if err != nil || len(raw) != net.IPv6len {
+			continue
+		}
+		if ip := net.IP(raw); !ip.IsUnspecified() {
+			return ip.String()
+		}
+	}
+	return ""
+}
+
+// ScanWiFi 扫描WiFi热点，网卡由wpa_supplicant直接管理时使用其控制接口，NetworkManager运行时使用其D-Bus接口，
+// 否则优先使用nmcli，失败时依次回退到iw和iwlist
+func (b *linuxBackend) ScanWiFi(interfaceName string) ([]models.WiFiHotspot, error) {
+	if b.useWPASupplicant(interfaceName) {
+		log.Printf("开始使用wpa_supplicant扫描接口 %s 的WiFi热点...", interfaceName)
+		return b.wpa.Scan(interfaceName)
+	}
+	if b.useNetworkManager(interfaceName) {
+		log.Printf("开始使用NetworkManager扫描接口 %s 的WiFi热点...", interfaceName)
+		return b.nm.scan(interfaceName)
+	}
+
+	// 初始化空切片，确保不返回nil
+	hotspots := make([]models.WiFiHotspot, 0)
+
+	log.Printf("开始使用nmcli扫描接口 %s 的WiFi热点...", interfaceName)
+
+	// 显式开启转义，SSID和BSSID中的冒号输出为\:，由parseNmcliOutput还原
+	args := []string{
+		"-t", "--escape", "yes", "-f", strings.Join(nmcliWiFiFields, ","),
+		"device", "wifi", "list",
+		"ifname", interfaceName,
+	}
+	cmd := b.command("nmcli", args...)
+	log.Printf("执行命令: nmcli %v", args)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("nmcli扫描失败: %v，将尝试使用iw", err)
+		if stderr, ok := commandStderr(err); ok {
+			log.Printf("nmcli错误输出: %s", string(stderr))
+		}
+		return b.scanWiFiFallback(interfaceName)
+	}
+
+	rawOutput := string(out)
+	log.Printf("nmcli扫描原始输出(前100字符): %q...", safeSubstring(rawOutput, 100))
+	if len(rawOutput) > 1000 {
+		log.Printf("完整输出已记录到调试日志")
+	}
+
+	hotspots, err = parseNmcliOutput(rawOutput)
+	if err != nil {
+		log.Printf("解析nmcli输出失败: %v", err)
+		return nil, fmt.Errorf("解析nmcli输出失败: %v", err)
+	}
+
+	log.Printf("nmcli扫描完成，发现 %d 个热点", len(hotspots))
+	return hotspots, nil
+}
+
+// scanWiFiFallback 没有nmcli时优先使用iw扫描，iw不可用或失败时再尝试已逐渐被发行版移除的iwlist
+func (b *linuxBackend) scanWiFiFallback(interfaceName string) ([]models.WiFiHotspot, error) {
+	if _, err := b.runner.LookPath("iw"); err == nil {
+		hotspots, err := b.scanWiFiIw(interfaceName)
+		if err == nil {
+			return hotspots, nil
+		}
+		log.Printf("%v，将尝试使用iwlist", err)
+	}
+	return b.scanWiFiIwlist(interfaceName)
+}
+
+// scanWiFiIwlist 使用iwlist扫描WiFi热点
+func (b *linuxBackend) scanWiFiIwlist(interfaceName string) ([]models.WiFiHotspot, error) {
+	log.Printf("开始使用iwlist扫描接口 %s 的WiFi热点...", interfaceName)
+
+	cmd := b.command("iwlist", interfaceName, "scan")
+	log.Printf("执行命令: iwlist %s scan", interfaceName)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("iwlist扫描失败: %v", err)
+		if stderr, ok := commandStderr(err); ok {
+			log.Printf("iwlist错误输出: %s", string(stderr))
+		}
+		return nil, fmt.Errorf("扫描WiFi失败: %v", err)
+	}
+
+	rawOutput := string(out)
+	log.Printf("iwlist扫描原始输出(前100字符): %q...", safeSubstring(rawOutput, 100))
+	if len(rawOutput) > 1000 {
+		log.Printf("完整输出已记录到调试日志")
+	}
+
+	hotspots, err := parseIwlistOutput(rawOutput)
+	if err != nil {
+		log.Printf("解析iwlist输出失败: %v", err)
+		return nil, fmt.Errorf("解析iwlist输出失败: %v", err)
+	}
+
+	log.Printf("iwlist扫描完成，发现 %d 个热点", len(hotspots))
+	return hotspots, nil
+}
+
+// nmcliWiFiFields nmcli扫描时请求的字段，parseNmcliOutput按此顺序解析
+var nmcliWiFiFields = []string{"SSID", "BSSID", "SIGNAL", "BARS", "SECURITY", "WPA-FLAGS", "RSN-FLAGS", "CHAN", "FREQ", "RATE", "ACTIVE"}
+
+// 解析nmcli命令输出 (Linux)
+// 单个字段无效时尽量由其他字段推算: 信号强度取自BARS，信道与频率互相换算
+func parseNmcliOutput(output string) ([]models.WiFiHotspot, error) {
+	log.Printf("开始解析nmcli输出...")
+	startTime := time.Now()
+	defer func() {
+		log.Printf("nmcli输出解析完成，耗时: %v", time.Since(startTime))
+	}()
+
+	// 初始化空切片，确保不返回nil
+	hotspots := make([]models.WiFiHotspot, 0)
+	var parseErrors int
+
+	lines := strings.Split(output, "\n")
+	log.Printf("需要解析 %d 行nmcli输出", len(lines))
+
+	for i, line := range lines {
+		// SSID可能以空格开头或结尾，只去掉行尾的回车
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		values := splitNmcliTerse(line)
+		if len(values) != len(nmcliWiFiFields) {
+			log.Printf("警告: 行 %d 字段数不符(需要%d个，得到%d个): %q",
+				i+1, len(nmcliWiFiFields), len(values), line)
+			parseErrors++
+			continue
+		}
+		fields := make(map[string]string, len(values))
+		for j, name := range nmcliWiFiFields {
+			fields[name] = values[j]
+		}
+
+		hotspot := models.WiFiHotspot{
+			SSID:      nmcliValue(fields["SSID"]),
+			BSSID:     strings.ToUpper(nmcliValue(fields["BSSID"])),
+			Security:  nmcliValue(fields["SECURITY"]),
+			Rate:      leadingInt(fields["RATE"]),
+			Connected: strings.TrimSpace(fields["ACTIVE"]) == "yes",
+		}
+
+		// 解析信号强度，无效时按信号格数估算
+		if signal, err := strconv.Atoi(strings.TrimSpace(fields["SIGNAL"])); err == nil {
+			hotspot.SignalStrength = clamp(signal, 0, 100)
+		} else if bars := nmcliBars(fields["BARS"]); bars >= 0 {
+			hotspot.SignalStrength = bars * 25
+		} else {
+			log.Printf("警告: 行 %d 无效的信号强度值: %q", i+1, fields["SIGNAL"])
+			parseErrors++
+		}
+
+		// 解析信道和频率(如"2437 MHz")，缺少其一时由另一个换算
+		hotspot.Channel = leadingInt(fields["CHAN"])
+		hotspot.Frequency = leadingInt(fields["FREQ"])
+		switch {
+		case hotspot.Frequency == 0 && hotspot.Channel == 0:
+			log.Printf("警告: 行 %d 无效的信道和频率值: %q, %q", i+1, fields["CHAN"], fields["FREQ"])
+			parseErrors++
+		case hotspot.Frequency == 0:
+			hotspot.Frequency = channelToFrequency(hotspot.Channel)
+		case hotspot.Channel == 0:
+			hotspot.Channel = frequencyToChannel(hotspot.Frequency)
+		}
+		hotspot.Band = frequencyBand(hotspot.Frequency)
+		hotspot.SignalDBm = percentToDBm(hotspot.SignalStrength)
+
+		var privacy uint32
+		if strings.Contains(hotspot.Security, "WEP") {
+			privacy = nmAPFlagPrivacy
+		}
+		hotspot.SecurityInfo = nmAPSecurityInfo(privacy, nmcliSecFlags(fields["WPA-FLAGS"]), nmcliSecFlags(fields["RSN-FLAGS"]))
+
+		log.Printf("解析热点: %s (信号: %d%%, 加密: %s, 频段: %s)",
+			hotspot.SSID, hotspot.SignalStrength, hotspot.Security, hotspot.Band)
+		hotspots = append(hotspots, hotspot)
+	}
+
+	log.Printf("解析完成: 共 %d 个热点，解析错误 %d 处",
+		len(hotspots), parseErrors)
+	return hotspots, nil
+}
+
+// splitNmcliTerse 按未转义的冒号拆分nmcli -t --escape yes输出的一行，并还原字段中的\:和\\
+func splitNmcliTerse(line string) []string {
+	var fields []string
+	var field strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\' && i+1 < len(line):
+			i++
+			field.WriteByte(line[i])
+		case c == ':':
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteByte(c)
+		}
+	}
+	return append(fields, field.String())
+}
+
+// nmcliValue 把nmcli表示空值的"--"转换为空字符串
+func nmcliValue(value string) string {
+	if value == "--" {
+		return ""
+	}
+	return value
+}
+
+// leadingInt 解析字符串开头的整数(如"2437 MHz"、"54 Mbit/s")，无效时返回0
+func leadingInt(value string) int {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0
+	}
+	n, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// nmcliBars 返回BARS字段(如"▂▄▆_"或"**  ")中点亮的格数，共4格，字段为空时返回-1
+func nmcliBars(value string) int {
+	if strings.TrimSpace(value) == "" {
+		return -1
+	}
+	bars := 0
+	for _, r := range value {
+		if r != ' ' && r != '_' {
+			bars++
+		}
+	}
+	return clamp(bars, 0, 4)
+}
+
+// 解析iwlist命令输出 (Linux)
+// WPA/RSN信息元素(IE)按iw的同名结构解析，加密类型与iw扫描结果一致
+func parseIwlistOutput(output string) ([]models.WiFiHotspot, error) {
+	log.Printf("开始解析iwlist输出...")
+	startTime := time.Now()
+	defer func() {
+		log.Printf("iwlist输出解析完成，耗时: %v", time.Since(startTime))
+	}()
+
+	// 初始化空切片，确保不返回nil
+	hotspots := make([]models.WiFiHotspot, 0)
+	var currentHotspot *models.WiFiHotspot
+	var cell iwBSS
+	var ie *iwSecurityIE
+	var parseErrors int
+	var cellCount int
+
+	finish := func() {
+		if currentHotspot == nil {
+			return
+		}
+		currentHotspot.Security = cell.security()
+		currentHotspot.SecurityInfo = cell.securityInfo()
+		currentHotspot.Band = frequencyBand(currentHotspot.Frequency)
+		if currentHotspot.Channel == 0 {
+			currentHotspot.Channel = frequencyToChannel(currentHotspot.Frequency)
+		}
+		hotspots = append(hotspots, *currentHotspot)
+		log.Printf("完成解析热点: %s (信号: %d%%, 加密: %s)",
+			currentHotspot.SSID, currentHotspot.SignalStrength, currentHotspot.Security)
+	}
+
+	lines := strings.Split(output, "\n")
+	log.Printf("需要解析 %d 行iwlist输出", len(lines))
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		// 检测新Cell开始，如"Cell 01 - Address: AA:BB:CC:DD:EE:FF"
+		if strings.HasPrefix(line, "Cell") {
+			cellCount++
+			finish()
+			currentHotspot = &models.WiFiHotspot{}
+			cell, ie = iwBSS{}, nil
+			if _, address, ok := strings.Cut(line, "Address:"); ok {
+				currentHotspot.BSSID = strings.ToUpper(strings.TrimSpace(address))
+			}
+			continue
+		}
+
+		if currentHotspot == nil {
+			continue
+		}
+
+		// 解析ESSID
+		if strings.HasPrefix(line, "ESSID:") {
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) > 1 {
+				currentHotspot.SSID = strings.Trim(strings.TrimSpace(parts[1]), `"`)
+				log.Printf("发现新热点: %s (Cell %d)", currentHotspot.SSID, cellCount)
+			}
+		}
+
+		// 解析信号质量
+		if strings.Contains(line, "Quality=") && strings.Contains(line, "Signal level=") {
+			// 示例: Quality=70/70  Signal level=-40 dBm
+			if parts := strings.Split(line, "Signal level="); len(parts) > 1 {
+				signalParts := strings.Split(parts[1], " ")
+				if len(signalParts) > 0 {
+					// 将dBm转换为百分比 (近似)
+					if dbm, err := strconv.Atoi(strings.TrimSpace(signalParts[0])); err == nil {
+						// -30dBm ~ 100%, -90dBm ~ 0%
+						currentHotspot.SignalStrength = clamp((dbm+90)*100/60, 0, 100)
+						currentHotspot.SignalDBm = dbm
+					} else {
+						parseErrors++
+					}
+				}
+			}
+		}
+
+		// 解析加密开关
+		if strings.Contains(line, "Encryption key:") {
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) > 1 {
+				cell.privacy = strings.TrimSpace(parts[1]) == "on"
+			}
+		}
+
+		// 解析信道
+		if strings.HasPrefix(line, "Channel:") {
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) > 1 {
+				if channel, err := strconv.Atoi(strings.TrimSpace(parts[1])); err == nil {
+					currentHotspot.Channel = channel
+				}
+			}
+		}
+
+		// 解析频率，示例: Frequency:2.437 GHz (Channel 6)
+		if strings.HasPrefix(line, "Frequency:") {
+			fields := strings.Fields(strings.TrimPrefix(line, "Frequency:"))
+			if len(fields) > 0 {
+				if ghz, err := strconv.ParseFloat(fields[0], 64); err == nil {
+					currentHotspot.Frequency = int(ghz*1000 + 0.5)
+				}
+			}
+		}
+
+		// 解析WPA/RSN信息元素，示例: IE: IEEE 802.11i/WPA2 Version 1、IE: WPA Version 1
+		if strings.HasPrefix(line, "IE:") {
+			ie = nil
+			switch {
+			case strings.Contains(line, "WPA2"):
+				cell.rsn = &iwSecurityIE{}
+				ie = cell.rsn
+			case strings.Contains(line, "WPA Version"):
+				cell.wpa = &iwSecurityIE{}
+				ie = cell.wpa
+			}
+			continue
+		}
+		if ie != nil {
+			// 示例: Pairwise Ciphers (2) : CCMP TKIP
+			key, value, _ := strings.Cut(line, ":")
+			value = strings.TrimSpace(value)
+			switch {
+			case strings.HasPrefix(key, "Group Cipher"):
+				ie.groupCipher = value
+			case strings.HasPrefix(key, "Pairwise Ciphers"):
+				ie.pairwise = strings.Fields(value)
+			case strings.HasPrefix(key, "Authentication Suites"):
+				ie.akm = splitIwSuites(value)
+			}
+		}
+	}
+
+	// 添加最后一个热点
+	finish()
+
+	log.Printf("解析完成: 共 %d 个Cell，有效热点 %d 个，解析错误 %d 处",
+		cellCount, len(hotspots), parseErrors)
+	return hotspots, nil
+}
+
+// ConnectWiFi 连接WiFi热点，选择方式与ScanWiFi相同；NetworkManager激活失败时返回带原因的NMActivationError
+func (b *linuxBackend) ConnectWiFi(interfaceName, ssid, password string) error {
+	if b.useWPASupplicant(interfaceName) {
+		return applyOp(b.runner,
+			fmt.Sprintf("wpa_cli -p %s -i %s add_network; set_network ssid %q; select_network", b.wpa.dir, interfaceName, ssid),
+			fmt.Sprintf("通过wpa_supplicant控制接口连接WiFi %s", ssid),
+			func() error { return b.wpa.Connect(interfaceName, ssid, password) })
+	}
+	if b.useNetworkManager(interfaceName) {
+		return applyOp(b.runner,
+			fmt.Sprintf("nmcli device wifi connect %q ifname %s", ssid, interfaceName),
+			fmt.Sprintf("通过NetworkManager连接WiFi %s", ssid),
+			func() error { return b.nm.connect(interfaceName, ssid, password) })
+	}
+
+	// Linux实现使用nmcli
+	var cmd *Command
+	if password == "" {
+		cmd = b.command("nmcli", "device", "wifi", "connect",
+			ssid,
+			"ifname", interfaceName)
+	} else {
+		cmd = b.command("nmcli", "device", "wifi", "connect",
+			ssid,
+			"password", password,
+			"ifname", interfaceName)
+	}
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("连接失败: %s, %v", string(out), err)
+	}
+
+	return nil
+}
+
+// ListWiFiProfiles 通过NetworkManager D-Bus接口或nmcli列出已保存的WiFi连接，包含明文密码
+func (b *linuxBackend) ListWiFiProfiles() ([]models.WiFiProfile, error) {
+	if b.useNetworkManager("") {
+		return b.nm.wifiProfiles()
+	}
+
+	output, err := b.command("nmcli", "-t", "--escape", "yes", "-f", "NAME,TYPE", "connection", "show").Output()
+	if err != nil {
+		return nil, fmt.Errorf("获取WiFi连接列表失败: %v", err)
+	}
+
+	profiles := make([]models.WiFiProfile, 0)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		columns := splitNmcliTerse(line)
+		if len(columns) != 2 || columns[1] != "802-11-wireless" {
+			continue
+		}
+		name := columns[0]
+
+		detail, err := b.command("nmcli", "-s", "-g",
+			"802-11-wireless.ssid,802-11-wireless-security.key-mgmt,802-11-wireless-security.psk",
+			"connection", "show", "id", name).Output()
+		if err != nil {
+			log.Printf("获取WiFi连接 %s 详情失败: %v", name, err)
+			profiles = append(profiles, models.WiFiProfile{Name: name, SSID: name})
+			continue
+		}
+		fields := strings.Split(strings.TrimRight(string(detail), "\n"), "\n")
+		for len(fields) < 3 {
+			fields = append(fields, "")
+		}
+		profile := models.WiFiProfile{
+			Name:     name,
+			SSID:     fields[0],
+			Security: fields[1],
+			Password: fields[2],
+		}
+		if profile.SSID == "" {
+			profile.SSID = name
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// AddWiFiProfile 通过NetworkManager D-Bus接口或nmcli按认证方式创建WiFi连接，同名连接会被替换
+func (b *linuxBackend) AddWiFiProfile(profile models.WiFiProfile) error {
+	name := profile.Name
+	if name == "" {
+		name = profile.SSID
+	}
+	auth, err := wifiProfileAuthFor(profile)
+	if err != nil {
+		return err
+	}
+	if b.useNetworkManager("") {
+		return applyOp(b.runner, fmt.Sprintf("nmcli connection add type wifi con-name %q ssid %q", name, profile.SSID),
+			fmt.Sprintf("通过NetworkManager保存WiFi配置 %s", name),
+			func() error { return b.nm.addWiFiProfile(profile) })
+	}
+	if out, err := b.command("nmcli", "connection", "delete", "id", name).CombinedOutput(); err != nil && b.debug {
+		log.Printf("删除旧WiFi连接失败(可能不存在): %s", string(out))
+	}
+
+	args := []string{"connection", "add", "type", "wifi", "con-name", name, "ifname", "*", "ssid", profile.SSID}
+	switch auth {
+	case wifiAuthOpen:
+	case wifiAuthWEP:
+		args = append(args, "wifi-sec.key-mgmt", "none", "wifi-sec.wep-key-type", "key", "wifi-sec.wep-key0", profile.Password)
+	default:
+		args = append(args, "wifi-sec.key-mgmt", nmKeyMgmt(auth), "wifi-sec.psk", profile.Password)
+	}
+	if out, err := b.command("nmcli", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("创建WiFi连接失败: %s, %v", string(out), err)
+	}
+	return nil
+}
@@ -0,0 +1,385 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"networkconfig/models"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// dhcpClients 按优先级尝试的DHCP客户端及其启动、释放参数
+var dhcpClients = []struct {
+	name    string
+	start   func(iface string) []string
+	release func(iface string) []string
+}{
+	{
+		name:    "dhclient",
+		start:   func(iface string) []string { return []string{"-nw", iface} },
+		release: func(iface string) []string { return []string{"-r", iface} },
+	},
+	{
+		name:    "dhcpcd",
+		start:   func(iface string) []string { return []string{"-b", iface} },
+		release: func(iface string) []string { return []string{"-k", iface} },
+	},
+	{
+		name:    "udhcpc",
+		start:   func(iface string) []string { return []string{"-b", "-i", iface} },
+		release: nil,
+	},
+}
+
+// netlinkConfigureIPv4 通过rtnetlink配置IPv4地址、默认路由和DHCP模式
+func netlinkConfigureIPv4(runner CommandRunner, name string, config models.IPv4Config) error {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return fmt.Errorf("接口 %s 不存在: %v", name, err)
+	}
+
+	if config.DHCP {
+		log.Printf("开始为接口 %s 配置DHCP自动获取IP", name)
+
+		// 删除静态地址和默认路由，交给DHCP客户端重新获取
+		if err := flushAddrs(runner, link, netlink.FAMILY_V4, true); err != nil {
+			return err
+		}
+		if err := deleteDefaultRoutes(runner, link, netlink.FAMILY_V4); err != nil {
+			return err
+		}
+		if err := linkSetUp(runner, link); err != nil {
+			return err
+		}
+		return startDHCPClient(runner, name)
+	}
+
+	log.Printf("开始配置接口 %s 的静态IPv4设置: IP=%s, Mask=%s, Gateway=%s",
+		name, config.IP, config.Mask, config.Gateway)
+
+	ip := net.ParseIP(config.IP).To4()
+	if ip == nil {
+		return fmt.Errorf("无效的IPv4地址: %s", config.IP)
+	}
+	maskIP := net.ParseIP(config.Mask).To4()
+	if maskIP == nil {
+		return fmt.Errorf("无效的子网掩码: %s", config.Mask)
+	}
+	mask := net.IPMask(maskIP)
+	if ones, bits := mask.Size(); ones == 0 && bits == 0 {
+		return fmt.Errorf("子网掩码不连续: %s", config.Mask)
+	}
+
+	// 切换为静态地址前先释放DHCP租约
+	stopDHCPClient(runner, name)
+
+	if err := flushAddrs(runner, link, netlink.FAMILY_V4, false); err != nil {
+		return err
+	}
+	addr := &netlink.Addr{IPNet: &net.IPNet{IP: ip, Mask: mask}}
+	err = applyOp(runner, fmt.Sprintf("ip addr add %s dev %s", addr.IPNet, name), "设置静态IPv4地址", func() error {
+		return netlink.AddrAdd(link, addr)
+	})
+	if err != nil {
+		return fmt.Errorf("设置静态IPv4地址失败: %v", err)
+	}
+	if err := addSecondaryAddrs(runner, link, false, secondaryAddresses(config.IP, config.Addresses)); err != nil {
+		return err
+	}
+	if err := linkSetUp(runner, link); err != nil {
+		return err
+	}
+	log.Printf("成功设置静态IPv4地址 %s", addr.IPNet)
+
+	if err := deleteDefaultRoutes(runner, link, netlink.FAMILY_V4); err != nil {
+		return err
+	}
+	if config.Gateway != "" {
+		if err := netlinkSetGateway(runner, name, false, config.Gateway); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// netlinkConfigureIPv6 通过rtnetlink配置IPv6地址和默认路由
+func netlinkConfigureIPv6(runner CommandRunner, name string, config models.IPv6Config) error {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return fmt.Errorf("接口 %s 不存在: %v", name, err)
+	}
+
+	ip := net.ParseIP(config.IP)
+	if ip == nil || ip.To4() != nil {
+		return fmt.Errorf("无效的IPv6地址: %s", config.IP)
+	}
+	prefixLen := config.PrefixLen
+	if prefixLen == 0 {
+		prefixLen = 64
+	}
+	if prefixLen < 1 || prefixLen > 128 {
+		return fmt.Errorf("无效的IPv6前缀长度: %d", config.PrefixLen)
+	}
+
+	// 只替换静态配置的全局地址，保留链路本地地址和SLAAC地址
+	if err := flushAddrs(runner, link, netlink.FAMILY_V6, false); err != nil {
+		return err
+	}
+	addr := &netlink.Addr{IPNet: &net.IPNet{IP: ip, Mask: net.CIDRMask(prefixLen, 128)}}
+	err = applyOp(runner, fmt.Sprintf("ip -6 addr add %s dev %s", addr.IPNet, name), "设置IPv6地址", func() error {
+		return netlink.AddrAdd(link, addr)
+	})
+	if err != nil {
+		return fmt.Errorf("设置IPv6地址失败: %v", err)
+	}
+	if err := addSecondaryAddrs(runner, link, true, secondaryAddresses(config.IP, config.Addresses)); err != nil {
+		return err
+	}
+	log.Printf("成功设置IPv6地址 %s", addr.IPNet)
+
+	if config.Gateway != "" {
+		if err := netlinkSetGateway(runner, name, true, config.Gateway); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// netlinkSetGateway 通过rtnetlink替换默认路由
+func netlinkSetGateway(runner CommandRunner, name string, ipv6 bool, gateway string) error {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return fmt.Errorf("接口 %s 不存在: %v", name, err)
+	}
+
+	gw := net.ParseIP(gateway)
+	if gw == nil || (gw.To4() == nil) != ipv6 {
+		return fmt.Errorf("无效的网关地址: %s", gateway)
+	}
+
+	dst := &net.IPNet{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)}
+	if ipv6 {
+		dst = &net.IPNet{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)}
+	}
+	route := &netlink.Route{LinkIndex: link.Attrs().Index, Dst: dst, Gw: gw}
+	err = applyOp(runner, fmt.Sprintf("%s route replace default via %s dev %s", ipCommand(ipv6), gateway, name), "设置默认网关", func() error {
+		return netlink.RouteReplace(route)
+	})
+	if err != nil {
+		return fmt.Errorf("设置默认网关失败: %v", err)
+	}
+	log.Printf("成功设置接口 %s 的默认网关: %s", name, gateway)
+	return nil
+}
+
+// netlinkDHCPEnabled 判断接口的IPv4地址是否由DHCP动态获取
+func netlinkDHCPEnabled(name string) bool {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return false
+	}
+	addrs, err := netlink.AddrList(link, netlink.FAMILY_V4)
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if addr.Flags&unix.IFA_F_PERMANENT == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// flushAddrs 删除接口上的地址，dynamic为true时连同动态地址一起删除，链路本地地址始终保留
+func flushAddrs(runner CommandRunner, link netlink.Link, family int, dynamic bool) error {
+	addrs, err := netlink.AddrList(link, family)
+	if err != nil {
+		return fmt.Errorf("获取接口地址失败: %v", err)
+	}
+	for _, addr := range addrs {
+		if addr.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if !dynamic && addr.Flags&unix.IFA_F_PERMANENT == 0 {
+			continue
+		}
+		err := applyOp(runner, fmt.Sprintf("%s addr del %s dev %s", ipCommand(family == netlink.FAMILY_V6), addr.IPNet, link.Attrs().Name), "删除原有地址", func() error {
+			return netlink.AddrDel(link, &addr)
+		})
+		if err != nil {
+			return fmt.Errorf("删除地址 %s 失败: %v", addr.IPNet, err)
+		}
+		log.Printf("已删除接口 %s 的地址 %s", link.Attrs().Name, addr.IPNet)
+	}
+	return nil
+}
+
+// deleteDefaultRoutes 删除经由该接口的默认路由
+func deleteDefaultRoutes(runner CommandRunner, link netlink.Link, family int) error {
+	routes, err := netlink.RouteList(link, family)
+	if err != nil {
+		return fmt.Errorf("获取路由表失败: %v", err)
+	}
+	for _, route := range routes {
+		if route.Dst != nil {
+			if ones, _ := route.Dst.Mask.Size(); ones != 0 {
+				continue
+			}
+		}
+		err := applyOp(runner, fmt.Sprintf("%s route del default via %s dev %s", ipCommand(family == netlink.FAMILY_V6), route.Gw, link.Attrs().Name), "删除原有默认路由", func() error {
+			return netlink.RouteDel(&route)
+		})
+		if err != nil && !errors.Is(err, unix.ESRCH) {
+			return fmt.Errorf("删除默认路由失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// netlinkAddAddress 通过rtnetlink为接口追加一个CIDR形式的地址
+func netlinkAddAddress(runner CommandRunner, name, address string) error {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return fmt.Errorf("接口 %s 不存在: %v", name, err)
+	}
+	ipNet, err := parseHostCIDR(address)
+	if err != nil {
+		return err
+	}
+	return addSecondaryAddrs(runner, link, ipNet.IP.To4() == nil, []*net.IPNet{ipNet})
+}
+
+// netlinkRemoveAddress 通过rtnetlink删除接口上的一个CIDR形式的地址
+func netlinkRemoveAddress(runner CommandRunner, name, address string) error {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return fmt.Errorf("接口 %s 不存在: %v", name, err)
+	}
+	ipNet, err := parseHostCIDR(address)
+	if err != nil {
+		return err
+	}
+
+	addr := &netlink.Addr{IPNet: ipNet}
+	err = applyOp(runner, fmt.Sprintf("%s addr del %s dev %s", ipCommand(ipNet.IP.To4() == nil), ipNet, name), "删除地址", func() error {
+		return netlink.AddrDel(link, addr)
+	})
+	if err != nil {
+		return fmt.Errorf("删除地址 %s 失败: %v", ipNet, err)
+	}
+	log.Printf("成功删除接口 %s 的地址 %s", name, ipNet)
+	return nil
+}
+
+// netlinkAddressOrigins 根据地址标志判断各地址的来源，失败时返回nil
+// IPv4非永久地址视为DHCP获取；IPv6非永久地址视为SLAAC，带临时标志的为隐私地址
+func netlinkAddressOrigins(name string) map[string]string {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return nil
+	}
+	addrs, err := netlink.AddrList(link, netlink.FAMILY_ALL)
+	if err != nil {
+		log.Printf("获取接口 %s 的地址标志失败: %v", name, err)
+		return nil
+	}
+
+	origins := make(map[string]string, len(addrs))
+	for _, addr := range addrs {
+		ip := addr.IP
+		permanent := addr.Flags&unix.IFA_F_PERMANENT != 0
+		var origin string
+		switch {
+		case ip.To4() != nil && permanent:
+			origin = models.AddressOriginStatic
+		case ip.To4() != nil:
+			origin = models.AddressOriginDHCP
+		case ip.IsLinkLocalUnicast():
+			origin = models.AddressOriginLink
+		case addr.Flags&unix.IFA_F_TEMPORARY != 0:
+			origin = models.AddressOriginTemporary
+		case permanent:
+			origin = models.AddressOriginStatic
+		default:
+			origin = models.AddressOriginSLAAC
+		}
+		if v4 := ip.To4(); v4 != nil {
+			ip = v4
+		}
+		origins[ip.String()] = origin
+	}
+	return origins
+}
+
+// addSecondaryAddrs 在主地址之后追加附加地址
+func addSecondaryAddrs(runner CommandRunner, link netlink.Link, ipv6 bool, addrs []*net.IPNet) error {
+	name := link.Attrs().Name
+	for _, ipNet := range addrs {
+		addr := &netlink.Addr{IPNet: ipNet}
+		err := applyOp(runner, fmt.Sprintf("%s addr add %s dev %s", ipCommand(ipv6), ipNet, name), "添加附加地址", func() error {
+			return netlink.AddrAdd(link, addr)
+		})
+		if err != nil {
+			return fmt.Errorf("添加附加地址 %s 失败: %v", ipNet, err)
+		}
+		log.Printf("成功为接口 %s 添加附加地址 %s", name, ipNet)
+	}
+	return nil
+}
+
+// linkSetUp 启用接口
+func linkSetUp(runner CommandRunner, link netlink.Link) error {
+	name := link.Attrs().Name
+	err := applyOp(runner, fmt.Sprintf("ip link set %s up", name), "启用接口", func() error {
+		return netlink.LinkSetUp(link)
+	})
+	if err != nil {
+		return fmt.Errorf("启用接口失败: %v", err)
+	}
+	return nil
+}
+
+// ipCommand 返回与地址族对应的ip命令前缀，用于预演时展示等价命令
+func ipCommand(ipv6 bool) string {
+	if ipv6 {
+		return "ip -6"
+	}
+	return "ip"
+}
+
+// startDHCPClient 启动系统中可用的DHCP客户端
+func startDHCPClient(runner CommandRunner, name string) error {
+	for _, client := range dhcpClients {
+		if _, err := runner.LookPath(client.name); err != nil {
+			continue
+		}
+		args := client.start(name)
+		log.Printf("执行命令: %s %v", client.name, args)
+		output, err := NewCommand(runner, client.name, args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("启动DHCP客户端%s失败: %v, 输出: %s", client.name, err, string(output))
+		}
+		log.Printf("成功为接口 %s 启动DHCP客户端 %s", name, client.name)
+		return nil
+	}
+	return fmt.Errorf("未找到可用的DHCP客户端(dhclient/dhcpcd/udhcpc)")
+}
+
+// stopDHCPClient 释放接口上的DHCP租约，失败时只记录日志
+func stopDHCPClient(runner CommandRunner, name string) {
+	for _, client := range dhcpClients {
+		if client.release == nil {
+			continue
+		}
+		if _, err := runner.LookPath(client.name); err != nil {
+			continue
+		}
+		args := client.release(name)
+		if output, err := NewCommand(runner, client.name, args...).CombinedOutput(); err != nil {
+			log.Printf("释放DHCP租约失败(可能未运行): %s %v: %v, 输出: %s", client.name, args, err, string(output))
+		}
+	}
+}
@@ -0,0 +1,43 @@
+//go:build !linux
+
+package service
+
+import (
+	"fmt"
+	"networkconfig/models"
+)
+
+// netlinkConfigureIPv4 rtnetlink仅在Linux上可用
+func netlinkConfigureIPv4(runner CommandRunner, name string, config models.IPv4Config) error {
+	return fmt.Errorf("配置IPv4: %w", ErrNotSupported)
+}
+
+// netlinkConfigureIPv6 rtnetlink仅在Linux上可用
+func netlinkConfigureIPv6(runner CommandRunner, name string, config models.IPv6Config) error {
+	return fmt.Errorf("配置IPv6: %w", ErrNotSupported)
+}
+
+// netlinkSetGateway rtnetlink仅在Linux上可用
+func netlinkSetGateway(runner CommandRunner, name string, ipv6 bool, gateway string) error {
+	return fmt.Errorf("设置网关: %w", ErrNotSupported)
+}
+
+// netlinkDHCPEnabled rtnetlink仅在Linux上可用
+func netlinkDHCPEnabled(name string) bool {
+	return false
+}
+
+// netlinkAddAddress rtnetlink仅在Linux上可用
+func netlinkAddAddress(runner CommandRunner, name, address string) error {
+	return fmt.Errorf("添加地址: %w", ErrNotSupported)
+}
+
+// netlinkRemoveAddress rtnetlink仅在Linux上可用
+func netlinkRemoveAddress(runner CommandRunner, name, address string) error {
+	return fmt.Errorf("删除地址: %w", ErrNotSupported)
+}
+
+// netlinkAddressOrigins rtnetlink仅在Linux上可用
+func netlinkAddressOrigins(name string) map[string]string {
+	return nil
+}
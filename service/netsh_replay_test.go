@@ -0,0 +1,248 @@
+package service
+
+import (
+	"encoding/json"
+	"net"
+	"networkconfig/models"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recordedInterface 录制testdata/netsh时使用的网卡名
+const recordedInterface = "Ethernet"
+
+// loadNetshReplay 加载testdata/netsh/<dir>下录制的命令
+// iface非空时把参数中录制的网卡名替换为iface，用于需要本机存在该网卡的代码路径
+func loadNetshReplay(t *testing.T, dir, iface string) *ReplayRunner {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join("testdata", "netsh", dir, "*.json"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("没有找到录制文件 %s: %v", dir, err)
+	}
+
+	runner := &ReplayRunner{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("读取录制文件 %s 失败: %v", file, err)
+		}
+		var fixtures []CommandFixture
+		if err := json.Unmarshal(data, &fixtures); err != nil {
+			t.Fatalf("解析录制文件 %s 失败: %v", file, err)
+		}
+		for _, fixture := range fixtures {
+			if iface != "" {
+				for i, arg := range fixture.Args {
+					fixture.Args[i] = strings.ReplaceAll(arg, "name="+recordedInterface, "name="+iface)
+				}
+			}
+			runner.Add(fixture)
+		}
+	}
+	return runner
+}
+
+// assertReplayed 检查录制的每条命令都按录制次数执行过，命令参数变化时回放会找不到录制结果
+func assertReplayed(t *testing.T, runner *ReplayRunner) {
+	t.Helper()
+	for key, fixtures := range runner.fixtures {
+		if calls := runner.calls[key]; calls < len(fixtures) {
+			t.Errorf("录制的命令 %s %v 执行了 %d 次，应为 %d 次", fixtures[0].Name, fixtures[0].Args, calls, len(fixtures))
+		}
+	}
+}
+
+// localInterfaceName 返回本机任一网卡名，静态地址配置会先检查网卡是否存在
+func localInterfaceName(t *testing.T) string {
+	t.Helper()
+	ifaces, err := net.Interfaces()
+	if err != nil || len(ifaces) == 0 {
+		t.Skipf("无法获取本机网卡: %v", err)
+	}
+	return ifaces[0].Name
+}
+
+func TestParseNetshOutput(t *testing.T) {
+	wpa2 := models.WiFiSecurity{AKM: []string{"psk"}, Ciphers: []string{"ccmp"}}
+	tests := []struct {
+		dir  string
+		want []models.WiFiHotspot
+	}{
+		{
+			dir: "scan_en",
+			want: []models.WiFiHotspot{
+				{SSID: "HomeNet", BSSID: "A4:2B:B0:11:22:33", SignalStrength: 92, SignalDBm: -54, Frequency: 5180,
+					Band: models.HotspotBand5GHz, Channel: 36, PHYType: "802.11ax", Rate: 54, Security: "WPA2", SecurityInfo: wpa2},
+				{SSID: "HomeNet", BSSID: "A4:2B:B0:11:22:34", SignalStrength: 60, SignalDBm: -70, Frequency: 2437,
+					Band: models.HotspotBand24GHz, Channel: 6, PHYType: "802.11n", Rate: 54, Security: "WPA2", SecurityInfo: wpa2},
+				{SSID: "CoffeeShop", BSSID: "00:0C:42:AA:BB:CC", SignalStrength: 35, SignalDBm: -83, Frequency: 2462,
+					Band: models.HotspotBand24GHz, Channel: 11, PHYType: "802.11g", Rate: 54,
+					SecurityInfo: models.WiFiSecurity{AKM: []string{}, Ciphers: []string{}}},
+			},
+		},
+		{
+			dir: "scan_zh",
+			want: []models.WiFiHotspot{
+				{SSID: "办公室", BSSID: "3C:52:82:AA:BB:CC", SignalStrength: 76, SignalDBm: -62, Frequency: 5745,
+					Band: models.HotspotBand5GHz, Channel: 149, PHYType: "802.11ac", Rate: 54, Security: "WPA2", SecurityInfo: wpa2},
+				{SSID: "Guest", BSSID: "3C:52:82:AA:BB:CD", SignalStrength: 40, SignalDBm: -80, Frequency: 6135,
+					Band: models.HotspotBand6GHz, Channel: 37, PHYType: "802.11ax", Security: "WPA3",
+					SecurityInfo: models.WiFiSecurity{AKM: []string{"sae"}, Ciphers: []string{"ccmp"}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			runner := loadNetshReplay(t, tt.dir, "")
+			output, err := NewCommand(runner, "netsh", "wlan", "show", "networks", "mode=bssid", "interface=WLAN").CombinedOutput()
+			if err != nil {
+				t.Fatalf("回放扫描输出失败: %v", err)
+			}
+			got, err := parseNetshOutput(string(output))
+			if err != nil {
+				t.Fatalf("parseNetshOutput返回错误: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseNetshOutput结果不一致\n得到: %+v\n应为: %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetHotspotStatusWithNetsh(t *testing.T) {
+	tests := []struct {
+		dir     string
+		want    models.HotspotStatus
+		wantErr bool
+	}{
+		{
+			dir: "hostednetwork_started_en",
+			want: models.HotspotStatus{Success: true, Enabled: true, SSID: "NetConfig-AP", MaxClientCount: 100,
+				Authentication: "WPA2-Personal", Encryption: "CCMP", ClientsCount: 2},
+		},
+		{
+			dir: "hostednetwork_stopped_en",
+			want: models.HotspotStatus{Success: true, SSID: "NetConfig-AP", MaxClientCount: 100,
+				Authentication: "WPA2-Personal", Encryption: "CCMP"},
+		},
+		{
+			dir: "hostednetwork_started_zh",
+			want: models.HotspotStatus{Success: true, Enabled: true, SSID: "办公室热点", MaxClientCount: 50,
+				Authentication: "WPA2 - 个人", Encryption: "CCMP"},
+		},
+		{dir: "hostednetwork_error", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			runner := loadNetshReplay(t, tt.dir, "")
+			got, err := newWindowsBackend(false, runner).getHotspotStatusWithNetsh()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("应返回错误，得到: %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getHotspotStatusWithNetsh返回错误: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("热点状态不一致\n得到: %+v\n应为: %+v", got, tt.want)
+			}
+			assertReplayed(t, runner)
+		})
+	}
+}
+
+func TestConfigureIPv4(t *testing.T) {
+	tests := []struct {
+		name    string
+		dir     string
+		local   bool // 静态地址需要本机存在该网卡
+		config  models.IPv4Config
+		wantErr string
+	}{
+		{
+			name:   "已是DHCP时只恢复自动DNS",
+			dir:    "ipv4_dhcp_unchanged",
+			config: models.IPv4Config{DHCP: true, DNSAuto: true},
+		},
+		{
+			name:   "切换到DHCP并设置静态DNS",
+			dir:    "ipv4_dhcp_switch",
+			config: models.IPv4Config{DHCP: true, DNS: []string{"223.5.5.5", "119.29.29.29"}},
+		},
+		{
+			name:  "静态地址、附加地址和DNS",
+			dir:   "ipv4_static",
+			local: true,
+			config: models.IPv4Config{IP: "192.168.10.20", Mask: "255.255.255.0", Gateway: "192.168.10.1",
+				Addresses: []string{"192.168.10.20/24", "192.168.20.20/24"}, DNS: []string{"192.168.10.1"}},
+		},
+		{
+			name:    "netsh失败时返回输出",
+			dir:     "ipv4_static_error",
+			local:   true,
+			config:  models.IPv4Config{IP: "192.168.10.20", Mask: "255.255.255.0", Gateway: "192.168.10.1"},
+			wantErr: "The object already exists.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			iface := recordedInterface
+			if tt.local {
+				iface = localInterfaceName(t)
+			}
+			runner := loadNetshReplay(t, tt.dir, iface)
+			err := newWindowsBackend(false, runner).ConfigureIPv4(iface, tt.config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("应返回包含 %q 的错误，得到: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ConfigureIPv4返回错误: %v", err)
+			}
+			assertReplayed(t, runner)
+		})
+	}
+}
+
+func TestConfigureIPv6(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  models.IPv6Config
+		address string
+	}{
+		{name: "未指定前缀长度时使用64", config: models.IPv6Config{IP: "2001:db8::20"}, address: "address=2001:db8::20/64"},
+		{name: "指定前缀长度", config: models.IPv6Config{IP: "2001:db8:1::20", PrefixLen: 48}, address: "address=2001:db8:1::20/48"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &ReplayRunner{}
+			runner.Add(CommandFixture{Name: "netsh", Args: []string{"interface", "ipv6", "set", "address",
+				"interface=" + recordedInterface, tt.address, "store=persistent"}})
+			if err := newWindowsBackend(false, runner).ConfigureIPv6(recordedInterface, tt.config); err != nil {
+				t.Fatalf("ConfigureIPv6返回错误: %v", err)
+			}
+			assertReplayed(t, runner)
+		})
+	}
+}
+
+func TestGetDriverInfoReplayStderr(t *testing.T) {
+	// 录制的失败调用回放为*ReplayError，应同样能读取到标准错误输出
+	runner := loadNetshReplay(t, "driver_not_found", "")
+	_, err := newWindowsBackend(false, runner).getDriverInfo(recordedInterface)
+	if err == nil || err.Error() != "找不到网卡: "+recordedInterface {
+		t.Fatalf("应按stderr返回找不到网卡的错误，得到: %v", err)
+	}
+	assertReplayed(t, runner)
+}
@@ -2,22 +2,26 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"networkconfig/models"
-	"os/exec"
-	"runtime"
 	"strconv"
 	"strings"
 )
 
-// isWin11OrLater 检查是否是Windows 11或更高版本
-func isWin11OrLater() bool {
-	if runtime.GOOS != "windows" {
-		return false
-	}
+// 检查系统版本和环境的只读PowerShell脚本
+const (
+	psOSVersionScript           = "[System.Environment]::OSVersion.Version | ConvertTo-Json"
+	psExecutionPolicyScript     = "Get-ExecutionPolicy"
+	psUserExecutionPolicyScript = "Get-ExecutionPolicy -Scope CurrentUser"
+	psActiveAdaptersScript      = "Get-NetAdapter | Where-Object { $_.Status -eq 'Up' } | ConvertTo-Json"
+	psSharedAccessScript        = "Get-Service -Name SharedAccess | Select-Object Name, Status | ConvertTo-Json"
+)
 
-	cmd := exec.Command("powershell", "-Command", "[System.Environment]::OSVersion.Version | ConvertTo-Json")
+// isWin11OrLater 检查是否是Windows 11或更高版本
+func (b *windowsBackend) isWin11OrLater() bool {
+	cmd := b.command("powershell", "-Command", psOSVersionScript)
 	output, err := cmd.Output()
 	if err != nil {
 		return false
@@ -38,11 +42,11 @@ func isWin11OrLater() bool {
 }
 
 // runHotspotDiagnostic 运行热点诊断
-func (s *NetworkService) runHotspotDiagnostic() {
+func (b *windowsBackend) runHotspotDiagnostic() {
 	log.Println("运行热点诊断...")
 
 	// 运行诊断命令
-	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-File", "hotspot.ps1", "diagnostic")
+	cmd := b.command("powershell", "-NoProfile", "-NonInteractive", "-File", "hotspot.ps1", "diagnostic")
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		log.Printf("运行热点诊断失败: %v", err)
@@ -53,13 +57,13 @@ func (s *NetworkService) runHotspotDiagnostic() {
 	log.Printf("热点诊断信息: %s", string(output))
 
 	// 检查系统环境
-	s.checkSystemEnvironment()
+	b.checkSystemEnvironment()
 }
 
 // checkSystemEnvironment 检查系统环境
-func (s *NetworkService) checkSystemEnvironment() {
+func (b *windowsBackend) checkSystemEnvironment() {
 	// 检查PowerShell执行策略
-	cmd := exec.Command("powershell", "-Command", "Get-ExecutionPolicy")
+	cmd := b.command("powershell", "-Command", psExecutionPolicyScript)
 	output, err := cmd.CombinedOutput()
 	if err == nil {
 		policy := strings.TrimSpace(string(output))
@@ -70,14 +74,14 @@ func (s *NetworkService) checkSystemEnvironment() {
 	}
 
 	// 检查网络适配器状态
-	cmd = exec.Command("powershell", "-Command", "Get-NetAdapter | Where-Object { $_.Status -eq 'Up' } | ConvertTo-Json")
+	cmd = b.command("powershell", "-Command", psActiveAdaptersScript)
 	output, err = cmd.CombinedOutput()
 	if err == nil {
 		log.Printf("活动网络适配器: %s", string(output))
 	}
 
 	// 检查移动热点服务
-	cmd = exec.Command("powershell", "-Command", "Get-Service -Name SharedAccess | Select-Object Name, Status | ConvertTo-Json")
+	cmd = b.command("powershell", "-Command", psSharedAccessScript)
 	output, err = cmd.CombinedOutput()
 	if err == nil {
 		log.Printf("Internet连接共享服务状态: %s", string(output))
@@ -85,35 +89,35 @@ func (s *NetworkService) checkSystemEnvironment() {
 }
 
 // GetHotspotStatus 获取移动热点状态
-func (s *NetworkService) GetHotspotStatus() (models.HotspotStatus, error) {
-	if isWin11OrLater() {
-		manager := NewWin11HotspotManager(s.Debug)
+func (b *windowsBackend) GetHotspotStatus() (models.HotspotStatus, error) {
+	if b.isWin11OrLater() {
+		manager := NewWin11HotspotManager(b.debug, b.runner)
 		status, err := manager.GetStatus()
-		if err != nil && s.Debug {
+		if err != nil && b.debug {
 			log.Printf("Windows 11 API获取热点状态失败: %v, 尝试运行诊断", err)
-			s.runHotspotDiagnostic()
+			b.runHotspotDiagnostic()
 
 			// 尝试使用netsh命令作为备选方案
 			log.Println("尝试使用netsh命令获取热点状态...")
-			return s.getHotspotStatusWithNetsh()
+			return b.getHotspotStatusWithNetsh()
 		}
 		return status, err
 	}
 
 	// 对于Windows 10及更早版本，使用原有的netsh实现
-	return s.getHotspotStatusWithNetsh()
+	return b.getHotspotStatusWithNetsh()
 }
 
 // getHotspotStatusWithNetsh 使用netsh命令获取热点状态
-func (s *NetworkService) getHotspotStatusWithNetsh() (models.HotspotStatus, error) {
+func (b *windowsBackend) getHotspotStatusWithNetsh() (models.HotspotStatus, error) {
 	log.Printf("开始获取移动热点状态...")
 
-	cmd := exec.Command("netsh", "wlan", "show", "hostednetwork")
+	cmd := b.command("netsh", "wlan", "show", "hostednetwork")
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		log.Printf("获取移动热点状态失败: %v", err)
-		if s.Debug {
-			s.runHotspotDiagnostic()
+		if b.debug {
+			b.runHotspotDiagnostic()
 		}
 		return models.HotspotStatus{}, fmt.Errorf("获取热点状态失败: %v", err)
 	}
@@ -167,40 +171,151 @@ func (s *NetworkService) getHotspotStatusWithNetsh() (models.HotspotStatus, erro
 	return status, nil
 }
 
-// ConfigureHotspot 配置移动热点
-func (s *NetworkService) ConfigureHotspot(config models.HotspotConfig) error {
-	if isWin11OrLater() {
-		manager := NewWin11HotspotManager(s.Debug)
+// GetHotspotConfig 读取当前移动热点配置，包含密码
+func (b *windowsBackend) GetHotspotConfig() (models.HotspotConfig, error) {
+	if b.isWin11OrLater() {
+		manager := NewWin11HotspotManager(b.debug, b.runner)
+		config, err := manager.GetConfig()
+		if err != nil && b.debug {
+			log.Printf("Windows 11 API读取热点配置失败: %v, 尝试使用netsh命令", err)
+			return b.getHotspotConfigWithNetsh()
+		}
+		return config, err
+	}
+
+	return b.getHotspotConfigWithNetsh()
+}
+
+// getHotspotConfigWithNetsh 从netsh承载网络的状态和安全设置中读取配置
+func (b *windowsBackend) getHotspotConfigWithNetsh() (models.HotspotConfig, error) {
+	status, err := b.getHotspotStatusWithNetsh()
+	if err != nil {
+		return models.HotspotConfig{}, err
+	}
+	config := models.HotspotConfig{
+		SSID:     status.SSID,
+		Enabled:  status.Enabled,
+		Security: models.HotspotSecurityWPA2,
+	}
+
+	output, err := b.command("netsh", "wlan", "show", "hostednetwork", "setting=security").CombinedOutput()
+	if err != nil {
+		return models.HotspotConfig{}, fmt.Errorf("读取热点密码失败: %v", err)
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		key = strings.TrimSpace(key)
+		if ok && (key == "User security key" || key == "用户安全密钥") {
+			config.Password = strings.TrimSpace(value)
+		}
+	}
+	return config, nil
+}
+
+// GetHotspotClients 获取已连接移动热点的客户端
+func (b *windowsBackend) GetHotspotClients() ([]models.HotspotClient, error) {
+	if b.isWin11OrLater() {
+		manager := NewWin11HotspotManager(b.debug, b.runner)
+		clients, err := manager.GetClients()
+		if err != nil && b.debug {
+			log.Printf("Windows 11 API获取热点客户端失败: %v, 尝试使用netsh命令", err)
+			return b.getHotspotClientsWithNetsh()
+		}
+		return clients, err
+	}
+
+	return b.getHotspotClientsWithNetsh()
+}
+
+// getHotspotClientsWithNetsh 从netsh承载网络状态中读取客户端MAC，再从ARP表查IP
+func (b *windowsBackend) getHotspotClientsWithNetsh() ([]models.HotspotClient, error) {
+	output, err := b.command("netsh", "wlan", "show", "hostednetwork").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("获取热点客户端失败: %v", err)
+	}
+
+	arp := make(map[string]string)
+	if arpOutput, err := b.command("arp", "-a").CombinedOutput(); err == nil {
+		arp = parseArpTable(string(arpOutput))
+	}
+
+	// 客户端列在"Number of clients"之后，每行以MAC地址开头，后跟认证状态；BSSID行不以MAC开头
+	clients := make([]models.HotspotClient, 0)
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		mac := normalizeMAC(fields[0])
+		if mac == "" {
+			continue
+		}
+		clients = append(clients, models.HotspotClient{MAC: mac, IP: arp[mac]})
+	}
+	return clients, nil
+}
+
+// ApplyHotspotACL Windows移动热点API和netsh承载网络都不支持按MAC地址过滤客户端
+func (b *windowsBackend) ApplyHotspotACL(acl models.HotspotACL) error {
+	if acl.Mode == models.HotspotACLModeOff {
+		return nil
+	}
+	return fmt.Errorf("Windows移动热点不支持按MAC地址过滤客户端: %w", ErrNotSupported)
+}
+
+// DisconnectHotspotClient Windows移动热点API和netsh承载网络都不支持断开指定客户端
+func (b *windowsBackend) DisconnectHotspotClient(mac string) error {
+	return fmt.Errorf("Windows移动热点不支持断开指定客户端: %w", ErrNotSupported)
+}
+
+// ConfigureHotspot 配置移动热点，按enabled启动或停止热点
+func (b *windowsBackend) ConfigureHotspot(config models.HotspotConfig) error {
+	if b.isWin11OrLater() {
+		manager := NewWin11HotspotManager(b.debug, b.runner)
 		err := manager.Configure(config)
-		if err != nil && s.Debug {
+		var errs ValidationErrors
+		if err != nil && b.debug && !errors.As(err, &errs) {
 			log.Printf("Windows 11 API配置热点失败: %v, 尝试运行诊断", err)
-			s.runHotspotDiagnostic()
+			b.runHotspotDiagnostic()
 
 			// 尝试使用netsh命令作为备选方案
 			log.Println("尝试使用netsh命令配置热点...")
-			return s.configureHotspotWithNetsh(config)
+			return b.configureHotspotWithNetsh(config)
 		}
 		return err
 	}
 
 	// 对于Windows 10及更早版本，使用原有的netsh实现
-	return s.configureHotspotWithNetsh(config)
+	return b.configureHotspotWithNetsh(config)
 }
 
-// configureHotspotWithNetsh 使用netsh命令配置热点
-func (s *NetworkService) configureHotspotWithNetsh(config models.HotspotConfig) error {
-	log.Printf("开始配置移动热点: %+v", config)
+// configureHotspotWithNetsh 使用netsh命令配置热点，按enabled启动或停止承载网络
+func (b *windowsBackend) configureHotspotWithNetsh(config models.HotspotConfig) error {
+	log.Printf("开始配置移动热点: SSID=%s", config.SSID)
 
-	// 验证SSID和密码
-	if config.SSID == "" {
-		return fmt.Errorf("SSID不能为空")
+	// 承载网络只支持WPA2-PSK，频段、信道和客户端数量由网卡驱动决定
+	errs := ValidateHotspotConfig(config)
+	if config.Band != "" && config.Band != models.HotspotBandAuto {
+		errs.add("band", ValidationUnsupported, "netsh承载网络不支持选择频段")
+	}
+	if config.Channel != 0 {
+		errs.add("channel", ValidationUnsupported, "netsh承载网络不支持指定信道")
+	}
+	if config.Security != "" && config.Security != models.HotspotSecurityWPA2 {
+		errs.add("security", ValidationUnsupported, "netsh承载网络只支持wpa2安全模式")
 	}
-	if len(config.Password) < 8 {
-		return fmt.Errorf("密码长度必须至少为8个字符")
+	if config.MaxClients != 0 {
+		errs.add("max_clients", ValidationUnsupported, "netsh承载网络不支持设置最大客户端数")
+	}
+	if config.Uplink != "" {
+		errs.add("uplink", ValidationUnsupported, "netsh承载网络不支持指定上行网卡")
+	}
+	if len(errs) > 0 {
+		return errs
 	}
 
 	// 设置热点配置
-	cmd := exec.Command("netsh", "wlan", "set", "hostednetwork",
+	cmd := b.command("netsh", "wlan", "set", "hostednetwork",
 		fmt.Sprintf("mode=allow"),
 		fmt.Sprintf("ssid=%s", config.SSID),
 		fmt.Sprintf("key=%s", config.Password))
@@ -208,17 +323,27 @@ func (s *NetworkService) configureHotspotWithNetsh(config models.HotspotConfig)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		log.Printf("配置移动热点失败: %v, 输出: %s", err, string(output))
-		if s.Debug {
-			s.runHotspotDiagnostic()
+		if b.debug {
+			b.runHotspotDiagnostic()
 		}
 		return fmt.Errorf("配置热点失败: %v", err)
 	}
 
-	// 如果需要启用热点
+	// 按enabled启用热点，或停止正在运行的热点
 	if config.Enabled {
-		if err := s.setHotspotStatusWithNetsh(true); err != nil {
+		if err := b.setHotspotStatusWithNetsh(true); err != nil {
 			return fmt.Errorf("启用移动热点失败: %v", err)
 		}
+	} else {
+		status, err := b.getHotspotStatusWithNetsh()
+		if err != nil {
+			return fmt.Errorf("获取热点状态失败: %v", err)
+		}
+		if status.Enabled {
+			if err := b.setHotspotStatusWithNetsh(false); err != nil {
+				return fmt.Errorf("禁用移动热点失败: %v", err)
+			}
+		}
 	}
 
 	log.Printf("成功配置移动热点")
@@ -226,41 +351,41 @@ func (s *NetworkService) configureHotspotWithNetsh(config models.HotspotConfig)
 }
 
 // SetHotspotStatus 启用或禁用移动热点
-func (s *NetworkService) SetHotspotStatus(enable bool) error {
-	if isWin11OrLater() {
-		manager := NewWin11HotspotManager(s.Debug)
+func (b *windowsBackend) SetHotspotStatus(enable bool) error {
+	if b.isWin11OrLater() {
+		manager := NewWin11HotspotManager(b.debug, b.runner)
 		err := manager.SetStatus(enable)
-		if err != nil && s.Debug {
+		if err != nil && b.debug {
 			log.Printf("Windows 11 API设置热点状态失败: %v, 尝试运行诊断", err)
-			s.runHotspotDiagnostic()
+			b.runHotspotDiagnostic()
 
 			// 尝试使用netsh命令作为备选方案
 			log.Println("尝试使用netsh命令设置热点状态...")
-			return s.setHotspotStatusWithNetsh(enable)
+			return b.setHotspotStatusWithNetsh(enable)
 		}
 		return err
 	}
 
 	// 对于Windows 10及更早版本，使用原有的netsh实现
-	return s.setHotspotStatusWithNetsh(enable)
+	return b.setHotspotStatusWithNetsh(enable)
 }
 
 // setHotspotStatusWithNetsh 使用netsh命令设置热点状态
-func (s *NetworkService) setHotspotStatusWithNetsh(enable bool) error {
-	var cmd *exec.Cmd
+func (b *windowsBackend) setHotspotStatusWithNetsh(enable bool) error {
+	var cmd *Command
 	if enable {
 		log.Printf("正在启用移动热点...")
-		cmd = exec.Command("netsh", "wlan", "start", "hostednetwork")
+		cmd = b.command("netsh", "wlan", "start", "hostednetwork")
 	} else {
 		log.Printf("正在禁用移动热点...")
-		cmd = exec.Command("netsh", "wlan", "stop", "hostednetwork")
+		cmd = b.command("netsh", "wlan", "stop", "hostednetwork")
 	}
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		log.Printf("修改移动热点状态失败: %v, 输出: %s", err, string(output))
-		if s.Debug {
-			s.runHotspotDiagnostic()
+		if b.debug {
+			b.runHotspotDiagnostic()
 		}
 		return fmt.Errorf("修改热点状态失败: %v", err)
 	}
@@ -0,0 +1,952 @@
+package service
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+	"networkconfig/models"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// NetworkManager D-Bus服务、对象路径和接口名
+const (
+	nmService         = "org.freedesktop.NetworkManager"
+	nmPath            = dbus.ObjectPath("/org/freedesktop/NetworkManager")
+	nmSettingsPath    = dbus.ObjectPath("/org/freedesktop/NetworkManager/Settings")
+	nmDeviceIface     = nmService + ".Device"
+	nmWirelessIface   = nmService + ".Device.Wireless"
+	nmAccessPoint     = nmService + ".AccessPoint"
+	nmIP4ConfigIface  = nmService + ".IP4Config"
+	nmIP6ConfigIface  = nmService + ".IP6Config"
+	nmActiveIface     = nmService + ".Connection.Active"
+	nmSettingsIface   = nmService + ".Settings"
+	nmConnectionIface = nmService + ".Settings.Connection"
+)
+
+// NetworkManager设备类型(NMDeviceType)，只列出用到的
+const (
+	nmDeviceTypeEthernet = 1
+	nmDeviceTypeWiFi     = 2
+	nmDeviceTypeModem    = 8
+	nmDeviceTypeBond     = 10
+	nmDeviceTypeVLAN     = 11
+)
+
+// NetworkManager设备状态(NMDeviceState)
+const (
+	nmDeviceStateUnmanaged = 10
+	nmDeviceStateActivated = 100
+	nmDeviceStateFailed    = 120
+)
+
+// NetworkManager激活连接状态(NMActiveConnectionState)
+const (
+	nmActiveStateActivating   = 1
+	nmActiveStateActivated    = 2
+	nmActiveStateDeactivating = 3
+	nmActiveStateDeactivated  = 4
+)
+
+// AP安全标志(NM80211ApFlags/NM80211ApSecurityFlags)
+const (
+	nmAPFlagPrivacy     = 0x1
+	nmAPSecKeyMgmtPSK   = 0x100
+	nmAPSecKeyMgmt8021X = 0x200
+	nmAPSecKeyMgmtSAE   = 0x400
+	nmAPSecKeyMgmtOWE   = 0x800
+	nmAPSecKeyMgmtOWETM = 0x1000
+	nmAPSecEAPSuiteB192 = 0x2000
+	nmAPFlagsWPS        = 0xe  // WPS、WPS_PBC和WPS_PIN
+	nmAPSecGroupMask    = 0xf0 // 组播加密算法
+)
+
+// nmAPSecFlags 安全标志在nmcli WPA-FLAGS/RSN-FLAGS列中的名称及对应的加密算法或认证套件
+var nmAPSecFlags = []struct {
+	bit   uint32
+	name  string
+	value string
+}{
+	{0x1, "pair_wep40", "wep40"},
+	{0x2, "pair_wep104", "wep104"},
+	{0x4, "pair_tkip", "tkip"},
+	{0x8, "pair_ccmp", "ccmp"},
+	{0x10, "group_wep40", "wep40"},
+	{0x20, "group_wep104", "wep104"},
+	{0x40, "group_tkip", "tkip"},
+	{0x80, "group_ccmp", "ccmp"},
+	{nmAPSecKeyMgmtPSK, "psk", "psk"},
+	{nmAPSecKeyMgmt8021X, "802.1X", "802.1x"},
+	{nmAPSecKeyMgmtSAE, "sae", "sae"},
+	{nmAPSecKeyMgmtOWE, "owe", "owe"},
+	{nmAPSecKeyMgmtOWETM, "owe_tm", "owe"},
+	{nmAPSecEAPSuiteB192, "eap_suite_b_192", "802.1x-suite-b-192"},
+}
+
+// nmActiveStateNames 激活连接状态名称
+var nmActiveStateNames = map[uint32]string{
+	0:                         "unknown",
+	nmActiveStateActivating:   "activating",
+	nmActiveStateActivated:    "activated",
+	nmActiveStateDeactivating: "deactivating",
+	nmActiveStateDeactivated:  "deactivated",
+}
+
+// nmActiveReasons 激活连接状态变化原因(NMActiveConnectionStateReason)的名称和说明
+var nmActiveReasons = map[uint32][2]string{
+	0:  {"unknown", "未知原因"},
+	1:  {"none", "没有给出原因"},
+	2:  {"user-disconnected", "用户断开了连接"},
+	3:  {"device-disconnected", "设备断开了连接"},
+	4:  {"service-stopped", "VPN服务已停止"},
+	5:  {"ip-config-invalid", "IP配置无效"},
+	6:  {"connect-timeout", "连接超时"},
+	7:  {"service-start-timeout", "VPN服务启动超时"},
+	8:  {"service-start-failed", "VPN服务启动失败"},
+	9:  {"no-secrets", "缺少密码或密码错误"},
+	10: {"login-failed", "登录失败"},
+	11: {"connection-removed", "连接已被删除"},
+	12: {"dependency-failed", "依赖的连接失败"},
+	13: {"device-realize-failed", "创建虚拟设备失败"},
+	14: {"device-removed", "设备已移除"},
+}
+
+// nmDeviceReasons 设备状态变化原因(NMDeviceStateReason)中与连接失败相关的名称和说明
+var nmDeviceReasons = map[uint32][2]string{
+	0:  {"none", "没有给出原因"},
+	1:  {"unknown", "未知原因"},
+	4:  {"config-failed", "设备配置失败"},
+	5:  {"ip-config-unavailable", "无法获取IP配置(DHCP无响应)"},
+	6:  {"ip-config-expired", "IP配置已过期"},
+	7:  {"no-secrets", "缺少密码"},
+	8:  {"supplicant-disconnect", "认证过程中断开，通常是密码错误"},
+	9:  {"supplicant-config-failed", "wpa_supplicant配置失败"},
+	10: {"supplicant-failed", "wpa_supplicant失败"},
+	11: {"supplicant-timeout", "认证超时，通常是密码错误或信号太弱"},
+	15: {"dhcp-start-failed", "DHCP客户端启动失败"},
+	16: {"dhcp-error", "DHCP客户端出错"},
+	17: {"dhcp-failed", "DHCP获取地址失败"},
+	36: {"removed", "设备已移除"},
+	37: {"sleeping", "系统正在休眠"},
+	38: {"connection-removed", "连接已被删除"},
+	39: {"user-requested", "用户请求断开"},
+	40: {"carrier", "网线已断开"},
+	50: {"dependency-failed", "依赖的连接失败"},
+	53: {"ssid-not-found", "找不到该SSID的网络"},
+	60: {"new-activation", "有新的连接正在激活"},
+	64: {"ip-address-duplicate", "IP地址冲突"},
+	65: {"ip-method-unsupported", "不支持该IP配置方式"},
+}
+
+// nmReason 返回原因的名称和说明，表中没有时使用编号
+func nmReason(table map[uint32][2]string, code uint32) (string, string) {
+	if reason, ok := table[code]; ok {
+		return reason[0], reason[1]
+	}
+	return fmt.Sprintf("reason-%d", code), fmt.Sprintf("原因代码%d", code)
+}
+
+// NMActivationError NetworkManager激活连接失败，带有NetworkManager给出的状态变化原因
+type NMActivationError struct {
+	Interface        string `json:"interface"`
+	Connection       string `json:"connection"`                   // 连接名称
+	State            string `json:"state"`                        // 激活连接的最终状态，如deactivated
+	Reason           string `json:"reason"`                       // 激活连接状态变化原因，如no-secrets
+	ReasonCode       uint32 `json:"reason_code"`                  // NMActiveConnectionStateReason
+	DeviceReason     string `json:"device_reason,omitempty"`      // 设备进入failed状态的原因，如supplicant-disconnect
+	DeviceReasonCode uint32 `json:"device_reason_code,omitempty"` // NMDeviceStateReason
+	Message          string `json:"message"`                      // 原因说明
+}
+
+func (e *NMActivationError) Error() string {
+	return fmt.Sprintf("接口 %s 激活连接 %s 失败: %s", e.Interface, e.Connection, e.Message)
+}
+
+// newNMActivationError 按激活连接和设备的原因构造错误，设备原因更具体时优先用于说明
+func newNMActivationError(iface, connection string, state, reason, deviceReason uint32) *NMActivationError {
+	err := &NMActivationError{
+		Interface:  iface,
+		Connection: connection,
+		State:      nmActiveStateNames[state],
+		ReasonCode: reason,
+	}
+	err.Reason, err.Message = nmReason(nmActiveReasons, reason)
+	if deviceReason > 1 {
+		err.DeviceReasonCode = deviceReason
+		err.DeviceReason, err.Message = nmReason(nmDeviceReasons, deviceReason)
+	}
+	return err
+}
+
+// nmClient NetworkManager D-Bus客户端，连接在首次使用时建立，断开后自动重连
+// 设置NM_DBUS_ADDRESS时连接到指定总线(如会话总线上的模拟服务)，否则使用系统总线
+type nmClient struct {
+	address         string
+	activateTimeout time.Duration // 等待连接激活完成的时间
+	scanTimeout     time.Duration // 等待扫描完成的时间
+
+	mu   sync.Mutex
+	conn *dbus.Conn
+}
+
+// newNMClient 按环境变量创建NetworkManager客户端
+func newNMClient() *nmClient {
+	return &nmClient{
+		address:         os.Getenv("NM_DBUS_ADDRESS"),
+		activateTimeout: time.Duration(getEnvInt("NM_ACTIVATE_TIMEOUT", 45)) * time.Second,
+		scanTimeout:     15 * time.Second,
+	}
+}
+
+// bus 返回D-Bus连接
+func (c *nmClient) bus() (*dbus.Conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil && c.conn.Connected() {
+		return c.conn, nil
+	}
+
+	var conn *dbus.Conn
+	var err error
+	if c.address != "" {
+		conn, err = dbus.Connect(c.address)
+	} else {
+		conn, err = dbus.ConnectSystemBus()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("连接D-Bus失败: %v", err)
+	}
+	c.conn = conn
+	return conn, nil
+}
+
+// available 判断NetworkManager是否在运行
+func (c *nmClient) available() bool {
+	conn, err := c.bus()
+	if err != nil {
+		return false
+	}
+	var owned bool
+	if err := conn.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, nmService).Store(&owned); err != nil {
+		return false
+	}
+	return owned
+}
+
+// call 调用对象方法并把返回值存入out
+func (c *nmClient) call(path dbus.ObjectPath, method string, args []interface{}, out ...interface{}) error {
+	conn, err := c.bus()
+	if err != nil {
+		return err
+	}
+	call := conn.Object(nmService, path).Call(method, 0, args...)
+	if call.Err != nil {
+		return fmt.Errorf("调用NetworkManager %s 失败: %v", method[strings.LastIndex(method, ".")+1:], call.Err)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return call.Store(out...)
+}
+
+// props 读取对象在指定接口上的全部属性
+func (c *nmClient) props(path dbus.ObjectPath, iface string) (map[string]dbus.Variant, error) {
+	props := make(map[string]dbus.Variant)
+	if err := c.call(path, "org.freedesktop.DBus.Properties.GetAll", []interface{}{iface}, &props); err != nil {
+		return nil, err
+	}
+	return props, nil
+}
+
+// nmProp 取出指定类型的属性值，不存在或类型不符时返回零值
+func nmProp[T any](props map[string]dbus.Variant, key string) T {
+	var zero T
+	if v, ok := props[key]; ok {
+		if value, ok := v.Value().(T); ok {
+			return value
+		}
+	}
+	return zero
+}
+
+// nmValidPath 判断对象路径是否指向实际对象，NetworkManager用"/"表示空
+func nmValidPath(path dbus.ObjectPath) bool {
+	return path != "" && path != "/"
+}
+
+// nmDevice NetworkManager中的一个设备
+type nmDevice struct {
+	path             dbus.ObjectPath
+	iface            string
+	deviceType       uint32
+	state            uint32
+	managed          bool
+	hwAddress        string
+	driver           string
+	driverVersion    string
+	ip4Config        dbus.ObjectPath
+	ip6Config        dbus.ObjectPath
+	activeConnection dbus.ObjectPath
+	available        []dbus.ObjectPath // 可用于该设备的已保存连接
+}
+
+// loadDevice 读取设备属性
+func (c *nmClient) loadDevice(path dbus.ObjectPath) (nmDevice, error) {
+	props, err := c.props(path, nmDeviceIface)
+	if err != nil {
+		return nmDevice{}, err
+	}
+	return nmDevice{
+		path:             path,
+		iface:            nmProp[string](props, "Interface"),
+		deviceType:       nmProp[uint32](props, "DeviceType"),
+		state:            nmProp[uint32](props, "State"),
+		managed:          nmProp[bool](props, "Managed"),
+		hwAddress:        strings.ToLower(nmProp[string](props, "HwAddress")),
+		driver:           nmProp[string](props, "Driver"),
+		driverVersion:    nmProp[string](props, "DriverVersion"),
+		ip4Config:        nmProp[dbus.ObjectPath](props, "Ip4Config"),
+		ip6Config:        nmProp[dbus.ObjectPath](props, "Ip6Config"),
+		activeConnection: nmProp[dbus.ObjectPath](props, "ActiveConnection"),
+		available:        nmProp[[]dbus.ObjectPath](props, "AvailableConnections"),
+	}, nil
+}
+
+// devices 列出NetworkManager中的全部设备
+func (c *nmClient) devices() ([]nmDevice, error) {
+	var paths []dbus.ObjectPath
+	if err := c.call(nmPath, nmService+".GetDevices", nil, &paths); err != nil {
+		return nil, err
+	}
+	devices := make([]nmDevice, 0, len(paths))
+	for _, path := range paths {
+		device, err := c.loadDevice(path)
+		if err != nil {
+			log.Printf("读取NetworkManager设备 %s 失败: %v", path, err)
+			continue
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+// device 按网卡名查找设备
+func (c *nmClient) device(name string) (nmDevice, error) {
+	var path dbus.ObjectPath
+	if err := c.call(nmPath, nmService+".GetDeviceByIpIface", []interface{}{name}, &path); err != nil {
+		return nmDevice{}, fmt.Errorf("NetworkManager中找不到网卡 %s: %v", name, err)
+	}
+	return c.loadDevice(path)
+}
+
+// wifiDevice 按网卡名查找无线设备
+func (c *nmClient) wifiDevice(name string) (nmDevice, error) {
+	device, err := c.device(name)
+	if err != nil {
+		return device, err
+	}
+	if device.deviceType != nmDeviceTypeWiFi {
+		return device, fmt.Errorf("网卡 %s 不是无线网卡", name)
+	}
+	return device, nil
+}
+
+// nmIPConfig 设备当前生效的IP配置
+type nmIPConfig struct {
+	addresses []string // CIDR形式
+	gateway   string
+	dns       []string
+}
+
+// ipConfig 读取IP4Config/IP6Config对象，路径为空时返回空配置
+func (c *nmClient) ipConfig(path dbus.ObjectPath, ipv6 bool) (nmIPConfig, error) {
+	var config nmIPConfig
+	if !nmValidPath(path) {
+		return config, nil
+	}
+	iface := nmIP4ConfigIface
+	if ipv6 {
+		iface = nmIP6ConfigIface
+	}
+	props, err := c.props(path, iface)
+	if err != nil {
+		return config, err
+	}
+
+	for _, data := range nmProp[[]map[string]dbus.Variant](props, "AddressData") {
+		address := nmProp[string](data, "address")
+		if address != "" {
+			config.addresses = append(config.addresses, fmt.Sprintf("%s/%d", address, nmProp[uint32](data, "prefix")))
+		}
+	}
+	config.gateway = nmProp[string](props, "Gateway")
+
+	if ipv6 {
+		for _, raw := range nmProp[[][]byte](props, "Nameservers") {
+			if len(raw) == net.IPv6len {
+				config.dns = append(config.dns, net.IP(raw).String())
+			}
+		}
+		return config, nil
+	}
+	// NameserverData从1.14开始提供，更早的版本只有网络字节序的Nameservers
+	if data := nmProp[[]map[string]dbus.Variant](props, "NameserverData"); len(data) > 0 {
+		for _, server := range data {
+			if address := nmProp[string](server, "address"); address != "" {
+				config.dns = append(config.dns, address)
+			}
+		}
+	} else {
+		for _, raw := range nmProp[[]uint32](props, "Nameservers") {
+			config.dns = append(config.dns, nmUint32ToIP(raw).String())
+		}
+	}
+	return config, nil
+}
+
+// nmIPToUint32 把IPv4地址转换为NetworkManager使用的网络字节序uint32
+func nmIPToUint32(ip net.IP) uint32 {
+	return binary.NativeEndian.Uint32(ip.To4())
+}
+
+// nmUint32ToIP 把网络字节序uint32还原为IPv4地址
+func nmUint32ToIP(value uint32) net.IP {
+	ip := make(net.IP, net.IPv4len)
+	binary.NativeEndian.PutUint32(ip, value)
+	return ip
+}
+
+// nmAP 扫描到的一个接入点
+type nmAP struct {
+	path    dbus.ObjectPath
+	hotspot models.WiFiHotspot
+}
+
+// accessPoints 读取设备当前可见的全部接入点
+func (c *nmClient) accessPoints(device nmDevice) ([]nmAP, error) {
+	var paths []dbus.ObjectPath
+	if err := c.call(device.path, nmWirelessIface+".GetAllAccessPoints", nil, &paths); err != nil {
+		return nil, err
+	}
+	var active dbus.ObjectPath
+	if props, err := c.props(device.path, nmWirelessIface); err == nil {
+		active = nmProp[dbus.ObjectPath](props, "ActiveAccessPoint")
+	}
+	aps := make([]nmAP, 0, len(paths))
+	for _, path := range paths {
+		props, err := c.props(path, nmAccessPoint)
+		if err != nil {
+			// 接入点可能在读取前消失
+			continue
+		}
+		freq := int(nmProp[uint32](props, "Frequency"))
+		strength := int(nmProp[byte](props, "Strength"))
+		flags, wpaFlags, rsnFlags := nmProp[uint32](props, "Flags"), nmProp[uint32](props, "WpaFlags"), nmProp[uint32](props, "RsnFlags")
+		aps = append(aps, nmAP{path: path, hotspot: models.WiFiHotspot{
+			SSID:           string(nmProp[[]byte](props, "Ssid")),
+			SignalStrength: strength,
+			SignalDBm:      percentToDBm(strength),
+			Security:       nmAPSecurity(flags, wpaFlags, rsnFlags),
+			SecurityInfo:   nmAPSecurityInfo(flags, wpaFlags, rsnFlags),
+			BSSID:          strings.ToUpper(nmProp[string](props, "HwAddress")),
+			Channel:        frequencyToChannel(freq),
+			Frequency:      freq,
+			Band:           frequencyBand(freq),
+			ChannelWidth:   int(nmProp[uint32](props, "Bandwidth")), // NetworkManager 1.46及以上提供
+			Rate:           int(nmProp[uint32](props, "MaxBitrate") / 1000),
+			Connected:      path == active,
+		}})
+	}
+	return aps, nil
+}
+
+// nmAPSecurityInfo 按AP的安全标志生成加密方式详情，组播加密算法优先取RSN的；NetworkManager不提供管理帧保护信息
+func nmAPSecurityInfo(flags, wpaFlags, rsnFlags uint32) models.WiFiSecurity {
+	groupFlags := rsnFlags & nmAPSecGroupMask
+	if groupFlags == 0 {
+		groupFlags = wpaFlags & nmAPSecGroupMask
+	}
+	var akms, ciphers []string
+	var group string
+	for _, flag := range nmAPSecFlags {
+		switch {
+		case flag.bit&nmAPSecGroupMask != 0:
+			if groupFlags&flag.bit != 0 && group == "" {
+				group = flag.value
+			}
+		case (wpaFlags|rsnFlags)&flag.bit == 0:
+		case strings.HasPrefix(flag.name, "pair_"):
+			ciphers = append(ciphers, flag.value)
+		default:
+			akms = append(akms, flag.value)
+		}
+	}
+	if flags&nmAPFlagPrivacy != 0 && wpaFlags == 0 && rsnFlags == 0 {
+		akms = append(akms, "wep")
+	}
+	security := newWiFiSecurity(akms, ciphers, group)
+	security.WPS = flags&nmAPFlagsWPS != 0
+	return security
+}
+
+// nmcliSecFlags 把nmcli WPA-FLAGS/RSN-FLAGS列(如"pair_ccmp group_ccmp psk sae")转换为安全标志
+func nmcliSecFlags(value string) uint32 {
+	var bits uint32
+	for _, name := range strings.Fields(value) {
+		for _, flag := range nmAPSecFlags {
+			if strings.EqualFold(flag.name, name) {
+				bits |= flag.bit
+			}
+		}
+	}
+	return bits
+}
+
+// nmAPSecurity 按AP的安全标志生成与nmcli SECURITY列一致的加密类型，开放网络为空
+func nmAPSecurity(flags, wpaFlags, rsnFlags uint32) string {
+	var parts []string
+	if flags&nmAPFlagPrivacy != 0 && wpaFlags == 0 && rsnFlags == 0 {
+		parts = append(parts, "WEP")
+	}
+	if wpaFlags != 0 {
+		parts = append(parts, "WPA1")
+	}
+	if rsnFlags&(nmAPSecKeyMgmtPSK|nmAPSecKeyMgmt8021X) != 0 {
+		parts = append(parts, "WPA2")
+	}
+	if rsnFlags&(nmAPSecKeyMgmtSAE|nmAPSecEAPSuiteB192) != 0 {
+		parts = append(parts, "WPA3")
+	}
+	if rsnFlags&(nmAPSecKeyMgmtOWE|nmAPSecKeyMgmtOWETM) != 0 {
+		parts = append(parts, "OWE")
+	}
+	if (wpaFlags|rsnFlags)&nmAPSecKeyMgmt8021X != 0 {
+		parts = append(parts, "802.1X")
+	}
+	return strings.Join(parts, " ")
+}
+
+// scan 请求扫描并等待LastScan更新，扫描被拒绝(如正在扫描或频率受限)或超时时返回现有结果
+func (c *nmClient) scan(name string) ([]models.WiFiHotspot, error) {
+	device, err := c.wifiDevice(name)
+	if err != nil {
+		return nil, err
+	}
+
+	lastScan := func() int64 {
+		props, err := c.props(device.path, nmWirelessIface)
+		if err != nil {
+			return 0
+		}
+		return nmProp[int64](props, "LastScan")
+	}
+	before := lastScan()
+	if err := c.call(device.path, nmWirelessIface+".RequestScan", []interface{}{map[string]dbus.Variant{}}); err != nil {
+		log.Printf("请求扫描接口 %s 失败: %v，使用现有扫描结果", name, err)
+	} else {
+		for deadline := time.Now().Add(c.scanTimeout); time.Now().Before(deadline); time.Sleep(500 * time.Millisecond) {
+			if lastScan() != before {
+				break
+			}
+		}
+	}
+
+	aps, err := c.accessPoints(device)
+	if err != nil {
+		return nil, err
+	}
+	hotspots := make([]models.WiFiHotspot, 0, len(aps))
+	for _, ap := range aps {
+		hotspots = append(hotspots, ap.hotspot)
+	}
+	log.Printf("NetworkManager扫描完成，发现 %d 个热点", len(hotspots))
+	return hotspots, nil
+}
+
+// nmSettings 连接设置(a{sa{sv}})
+type nmSettings map[string]map[string]dbus.Variant
+
+// nmConnection 一个已保存的连接
+type nmConnection struct {
+	path     dbus.ObjectPath
+	settings nmSettings
+}
+
+func (n nmConnection) id() string {
+	return nmProp[string](n.settings["connection"], "id")
+}
+
+func (n nmConnection) connType() string {
+	return nmProp[string](n.settings["connection"], "type")
+}
+
+func (n nmConnection) ssid() string {
+	return string(nmProp[[]byte](n.settings["802-11-wireless"], "ssid"))
+}
+
+// connections 列出全部已保存的连接(不含密码)
+func (c *nmClient) connections() ([]nmConnection, error) {
+	var paths []dbus.ObjectPath
+	if err := c.call(nmSettingsPath, nmSettingsIface+".ListConnections", nil, &paths); err != nil {
+		return nil, err
+	}
+	connections := make([]nmConnection, 0, len(paths))
+	for _, path := range paths {
+		settings, err := c.settings(path)
+		if err != nil {
+			log.Printf("读取NetworkManager连接 %s 失败: %v", path, err)
+			continue
+		}
+		connections = append(connections, nmConnection{path: path, settings: settings})
+	}
+	return connections, nil
+}
+
+// settings 读取连接设置
+func (c *nmClient) settings(path dbus.ObjectPath) (nmSettings, error) {
+	settings := make(nmSettings)
+	if err := c.call(path, nmConnectionIface+".GetSettings", nil, &settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// mergeSecrets 把连接的密码合并进设置，更新连接时不带密码会把已保存的密码清空
+func (c *nmClient) mergeSecrets(path dbus.ObjectPath, settings nmSettings) {
+	for _, section := range []string{"802-11-wireless-security", "802-1x"} {
+		if _, ok := settings[section]; !ok {
+			continue
+		}
+		secrets := make(nmSettings)
+		if err := c.call(path, nmConnectionIface+".GetSecrets", []interface{}{section}, &secrets); err != nil {
+			log.Printf("读取连接 %s 的密码失败: %v", path, err)
+			continue
+		}
+		for key, value := range secrets[section] {
+			settings[section][key] = value
+		}
+	}
+}
+
+// wifiProfiles 列出已保存的WiFi连接，包含明文密码
+func (c *nmClient) wifiProfiles() ([]models.WiFiProfile, error) {
+	connections, err := c.connections()
+	if err != nil {
+		return nil, err
+	}
+	profiles := make([]models.WiFiProfile, 0)
+	for _, connection := range connections {
+		if connection.connType() != "802-11-wireless" {
+			continue
+		}
+		profile := models.WiFiProfile{Name: connection.id(), SSID: connection.ssid()}
+		if profile.SSID == "" {
+			profile.SSID = profile.Name
+		}
+		if security, ok := connection.settings["802-11-wireless-security"]; ok {
+			profile.Security = nmProp[string](security, "key-mgmt")
+			c.mergeSecrets(connection.path, connection.settings)
+			profile.Password = nmProp[string](connection.settings["802-11-wireless-security"], "psk")
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// wifiSettings 生成WiFi连接设置；keyMgmt为空时由NetworkManager按接入点补全认证方式
+func wifiSettings(name, ssid, password, keyMgmt string, hidden bool) nmSettings {
+	settings := nmSettings{
+		"connection": {
+			"id":   dbus.MakeVariant(name),
+			"type": dbus.MakeVariant("802-11-wireless"),
+		},
+		"802-11-wireless": {
+			"ssid": dbus.MakeVariant([]byte(ssid)),
+			"mode": dbus.MakeVariant("infrastructure"),
+		},
+	}
+	if hidden {
+		settings["802-11-wireless"]["hidden"] = dbus.MakeVariant(true)
+	}
+	if password != "" {
+		security := map[string]dbus.Variant{"psk": dbus.MakeVariant(password)}
+		if keyMgmt != "" {
+			security["key-mgmt"] = dbus.MakeVariant(keyMgmt)
+		}
+		settings["802-11-wireless-security"] = security
+	}
+	return settings
+}
+
+// nmKeyMgmt 返回WPA系列认证方式对应的NetworkManager key-mgmt
+func nmKeyMgmt(auth string) string {
+	if auth == wifiAuthWPA3 {
+		return "sae"
+	}
+	return "wpa-psk"
+}
+
+// addWiFiProfile 保存WiFi连接，同名连接会被替换
+func (c *nmClient) addWiFiProfile(profile models.WiFiProfile) error {
+	name := profile.Name
+	if name == "" {
+		name = profile.SSID
+	}
+	connections, err := c.connections()
+	if err != nil {
+		return err
+	}
+	for _, connection := range connections {
+		if connection.id() == name {
+			if err := c.call(connection.path, nmConnectionIface+".Delete", nil); err != nil {
+				log.Printf("删除旧WiFi连接 %s 失败: %v", name, err)
+			}
+		}
+	}
+
+	// 保存时没有接入点可供补全，需要明确认证方式
+	auth, err := wifiProfileAuthFor(profile)
+	if err != nil {
+		return err
+	}
+	settings := wifiSettings(name, profile.SSID, "", "", false)
+	switch auth {
+	case wifiAuthOpen:
+	case wifiAuthWEP:
+		settings["802-11-wireless-security"] = map[string]dbus.Variant{
+			"key-mgmt":     dbus.MakeVariant("none"),
+			"wep-key-type": dbus.MakeVariant(uint32(1)),
+			"wep-key0":     dbus.MakeVariant(profile.Password),
+		}
+	default:
+		settings = wifiSettings(name, profile.SSID, profile.Password, nmKeyMgmt(auth), false)
+	}
+	var path dbus.ObjectPath
+	if err := c.call(nmSettingsPath, nmSettingsIface+".AddConnection", []interface{}{settings}, &path); err != nil {
+		return fmt.Errorf("创建WiFi连接失败: %v", err)
+	}
+	return nil
+}
+
+// connect 连接WiFi: 不提供密码且已有该SSID的连接时直接激活它，否则新建连接并激活，
+// 成功后删除同一SSID的旧连接，失败时删除新建的连接并返回带原因的NMActivationError
+func (c *nmClient) connect(name, ssid, password string) error {
+	device, err := c.wifiDevice(name)
+	if err != nil {
+		return err
+	}
+
+	// 选择信号最强的同名接入点，找不到时按隐藏网络处理
+	aps, err := c.accessPoints(device)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(aps, func(i, j int) bool { return aps[i].hotspot.SignalStrength > aps[j].hotspot.SignalStrength })
+	specific := dbus.ObjectPath("/")
+	for _, ap := range aps {
+		if ap.hotspot.SSID == ssid {
+			specific = ap.path
+			break
+		}
+	}
+
+	connections, err := c.connections()
+	if err != nil {
+		return err
+	}
+	var existing []nmConnection
+	for _, connection := range connections {
+		if connection.connType() == "802-11-wireless" && connection.ssid() == ssid {
+			existing = append(existing, connection)
+		}
+	}
+
+	conn, err := c.bus()
+	if err != nil {
+		return err
+	}
+	// 先订阅状态变化信号再激活，避免错过
+	signals := make(chan *dbus.Signal, 32)
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+	matches := [][]dbus.MatchOption{
+		{dbus.WithMatchInterface(nmActiveIface), dbus.WithMatchMember("StateChanged")},
+		{dbus.WithMatchObjectPath(device.path), dbus.WithMatchInterface(nmDeviceIface), dbus.WithMatchMember("StateChanged")},
+	}
+	for _, match := range matches {
+		if err := conn.AddMatchSignal(match...); err != nil {
+			return fmt.Errorf("订阅NetworkManager信号失败: %v", err)
+		}
+		defer conn.RemoveMatchSignal(match...)
+	}
+
+	var connPath, active dbus.ObjectPath
+	created := false
+	if password == "" && len(existing) > 0 {
+		connPath = existing[0].path
+		if err := c.call(nmPath, nmService+".ActivateConnection", []interface{}{connPath, device.path, specific}, &active); err != nil {
+			return err
+		}
+	} else {
+		keyMgmt := ""
+		if !nmValidPath(specific) && password != "" {
+			keyMgmt = "wpa-psk"
+		}
+		settings := wifiSettings(ssid, ssid, password, keyMgmt, !nmValidPath(specific))
+		if err := c.call(nmPath, nmService+".AddAndActivateConnection", []interface{}{settings, device.path, specific}, &connPath, &active); err != nil {
+			return err
+		}
+		created = true
+	}
+
+	if err := c.waitActivated(name, ssid, device.path, active, signals); err != nil {
+		if created {
+			c.call(connPath, nmConnectionIface+".Delete", nil)
+		}
+		return err
+	}
+	if created {
+		for _, connection := range existing {
+			if err := c.call(connection.path, nmConnectionIface+".Delete", nil); err != nil {
+				log.Printf("删除旧WiFi连接 %s 失败: %v", connection.id(), err)
+			}
+		}
+	}
+	log.Printf("接口 %s 已通过NetworkManager连接到 %s", name, ssid)
+	return nil
+}
+
+// waitActivated 等待激活连接进入activated或deactivated状态
+func (c *nmClient) waitActivated(name, connection string, device, active dbus.ObjectPath, signals chan *dbus.Signal) error {
+	// 信号可能在订阅生效前已发出，先读取一次当前状态
+	var deviceReason uint32
+	if props, err := c.props(active, nmActiveIface); err == nil {
+		switch nmProp[uint32](props, "State") {
+		case nmActiveStateActivated:
+			return nil
+		case nmActiveStateDeactivated:
+			return newNMActivationError(name, connection, nmActiveStateDeactivated, 0, 0)
+		}
+	}
+
+	timeout := time.NewTimer(c.activateTimeout)
+	defer timeout.Stop()
+	for {
+		select {
+		case signal := <-signals:
+			switch {
+			case signal.Path == active && signal.Name == nmActiveIface+".StateChanged" && len(signal.Body) >= 2:
+				state, _ := signal.Body[0].(uint32)
+				reason, _ := signal.Body[1].(uint32)
+				switch state {
+				case nmActiveStateActivated:
+					return nil
+				case nmActiveStateDeactivated:
+					return newNMActivationError(name, connection, state, reason, deviceReason)
+				}
+			case signal.Path == device && signal.Name == nmDeviceIface+".StateChanged" && len(signal.Body) >= 3:
+				if state, _ := signal.Body[0].(uint32); state == nmDeviceStateFailed {
+					deviceReason, _ = signal.Body[2].(uint32)
+				}
+			}
+		case <-timeout.C:
+			c.call(nmPath, nmService+".DeactivateConnection", []interface{}{active})
+			return newNMActivationError(name, connection, nmActiveStateDeactivated, 6, deviceReason)
+		}
+	}
+}
+
+// updateIPSettings 修改网卡所用连接的ipv4/ipv6设置并保存；连接已激活时立即重新应用到网卡，否则激活该连接
+// 预演模式下只记录等价的nmcli命令
+func (c *nmClient) updateIPSettings(runner CommandRunner, name string, ipv6 bool, equivalent string, modify func(section map[string]dbus.Variant)) error {
+	device, err := c.device(name)
+	if err != nil {
+		return err
+	}
+
+	var connPath dbus.ObjectPath
+	if nmValidPath(device.activeConnection) {
+		props, err := c.props(device.activeConnection, nmActiveIface)
+		if err != nil {
+			return err
+		}
+		connPath = nmProp[dbus.ObjectPath](props, "Connection")
+	} else if len(device.available) > 0 {
+		connPath = device.available[0]
+	}
+	if !nmValidPath(connPath) {
+		return fmt.Errorf("网卡 %s 没有可用的NetworkManager连接", name)
+	}
+
+	settings, err := c.settings(connPath)
+	if err != nil {
+		return err
+	}
+	key := "ipv4"
+	if ipv6 {
+		key = "ipv6"
+	}
+	section := settings[key]
+	if section == nil {
+		section = make(map[string]dbus.Variant)
+	}
+	// addresses是已废弃的旧格式，同时存在时会覆盖address-data
+	delete(section, "addresses")
+	modify(section)
+	settings[key] = section
+
+	return applyOp(runner, fmt.Sprintf("nmcli connection modify %s %s", nmProp[string](settings["connection"], "id"), equivalent),
+		fmt.Sprintf("修改网卡 %s 的NetworkManager连接", name), func() error {
+			c.mergeSecrets(connPath, settings)
+			if err := c.call(connPath, nmConnectionIface+".Update", []interface{}{settings}); err != nil {
+				return fmt.Errorf("保存连接设置失败: %v", err)
+			}
+			if nmValidPath(device.activeConnection) {
+				if err := c.call(device.path, nmDeviceIface+".Reapply", []interface{}{settings, uint64(0), uint32(0)}); err != nil {
+					return fmt.Errorf("应用连接设置失败: %v", err)
+				}
+				return nil
+			}
+			var active dbus.ObjectPath
+			return c.call(nmPath, nmService+".ActivateConnection", []interface{}{connPath, device.path, dbus.ObjectPath("/")}, &active)
+		})
+}
+
+// nmAddressData 把CIDR地址列表转换为address-data
+func nmAddressData(addresses []*net.IPNet) []map[string]dbus.Variant {
+	data := make([]map[string]dbus.Variant, 0, len(addresses))
+	for _, addr := range addresses {
+		ones, _ := addr.Mask.Size()
+		data = append(data, map[string]dbus.Variant{
+			"address": dbus.MakeVariant(addr.IP.String()),
+			"prefix":  dbus.MakeVariant(uint32(ones)),
+		})
+	}
+	return data
+}
+
+// nmDNSValue 把DNS服务器转换为ipv4.dns(au)或ipv6.dns(aay)的值
+func nmDNSValue(ipv6 bool, servers []string) dbus.Variant {
+	if ipv6 {
+		values := make([][]byte, 0, len(servers))
+		for _, server := range servers {
+			if ip := net.ParseIP(server); ip != nil && ip.To4() == nil {
+				values = append(values, []byte(ip.To16()))
+			}
+		}
+		return dbus.MakeVariant(values)
+	}
+	values := make([]uint32, 0, len(servers))
+	for _, server := range servers {
+		if ip := net.ParseIP(server).To4(); ip != nil {
+			values = append(values, nmIPToUint32(ip))
+		}
+	}
+	return dbus.MakeVariant(values)
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"networkconfig/models"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// nmBackend 通过NetworkManager D-Bus接口管理网卡地址和WiFi的Linux后端，
+// 修改保存在NetworkManager连接中，重连和重启后仍然有效；网卡硬件信息和热点沿用linuxBackend的实现
+type nmBackend struct {
+	*linuxBackend
+}
+
+// newNMBackend 创建NetworkManager后端
+func newNMBackend(debug bool, runner CommandRunner) *nmBackend {
+	linux := newLinuxBackend(debug, runner)
+	linux.wifiBackend = wifiBackendNetworkManager
+	return &nmBackend{linuxBackend: linux}
+}
+
+// withRunner 返回使用指定命令执行器的后端副本
+func (b *nmBackend) withRunner(runner CommandRunner) Backend {
+	return &nmBackend{linuxBackend: b.linuxBackend.withRunner(runner).(*linuxBackend)}
+}
+
+// Name 返回后端名称
+func (b *nmBackend) Name() string {
+	return BackendNetworkManager
+}
+
+// nmListedDeviceTypes 网卡列表中显示的设备类型，其余(网桥、veth、回环等)视为虚拟设备
+var nmListedDeviceTypes = map[uint32]bool{
+	nmDeviceTypeEthernet: true,
+	nmDeviceTypeWiFi:     true,
+	nmDeviceTypeModem:    true,
+	nmDeviceTypeBond:     true,
+	nmDeviceTypeVLAN:     true,
+}
+
+// ListInterfaces 列出NetworkManager中的物理网卡
+func (b *nmBackend) ListInterfaces() ([]InterfaceFast, error) {
+	devices, err := b.nm.devices()
+	if err != nil {
+		return nil, fmt.Errorf("获取网卡列表失败: %v", err)
+	}
+
+	var interfaces []InterfaceFast
+	for _, device := range devices {
+		if !nmListedDeviceTypes[device.deviceType] {
+			continue
+		}
+		item := InterfaceFast{Name: device.iface, Status: "down", ProductName: device.driver}
+		if iface, err := net.InterfaceByName(device.iface); err == nil {
+			item.Status = getInterfaceStatusFast(iface.Flags)
+		}
+		if item.ProductName == "" {
+			item.ProductName = device.iface
+		}
+		interfaces = append(interfaces, item)
+	}
+	return interfaces, nil
+}
+
+// GetInterface 获取网卡信息，网关、DNS、DHCP模式和已连接的SSID以NetworkManager为准
+func (b *nmBackend) GetInterface(name string) (models.Interface, error) {
+	ifaceInfo, err := b.linuxBackend.GetInterface(name)
+	if err != nil {
+		return ifaceInfo, err
+	}
+
+	device, err := b.nm.device(name)
+	if err != nil {
+		log.Printf("读取网卡 %s 的NetworkManager信息失败: %v", name, err)
+		return ifaceInfo, nil
+	}
+	if device.driverVersion != "" {
+		ifaceInfo.Driver.Version = device.driverVersion
+	}
+	if device.state == nmDeviceStateUnmanaged {
+		ifaceInfo.Driver.Status = "unmanaged"
+	}
+
+	if ip4, err := b.nm.ipConfig(device.ip4Config, false); err == nil && nmValidPath(device.ip4Config) {
+		ifaceInfo.IPv4Config.Gateway = ip4.gateway
+		ifaceInfo.IPv4Config.DNS = ip4.dns
+	}
+	if ip6, err := b.nm.ipConfig(device.ip6Config, true); err == nil && nmValidPath(device.ip6Config) {
+		ifaceInfo.IPv6Config.Gateway = ip6.gateway
+		ifaceInfo.IPv6Config.DNS = ip6.dns
+	}
+
+	if nmValidPath(device.activeConnection) {
+		if props, err := b.nm.props(device.activeConnection, nmActiveIface); err == nil {
+			if settings, err := b.nm.settings(nmProp[dbus.ObjectPath](props, "Connection")); err == nil {
+				ifaceInfo.DHCPEnabled = nmProp[string](settings["ipv4"], "method") == "auto"
+				ifaceInfo.IPv4Config.DHCP = ifaceInfo.DHCPEnabled
+				if device.deviceType == nmDeviceTypeWiFi {
+					ifaceInfo.ConnectedSSID = string(nmProp[[]byte](settings["802-11-wireless"], "ssid"))
+				}
+			}
+		}
+	}
+	return ifaceInfo, nil
+}
+
+// ConfigureIPv4 修改网卡连接的IPv4设置并立即应用
+func (b *nmBackend) ConfigureIPv4(name string, config models.IPv4Config) error {
+	if config.DHCP {
+		log.Printf("开始为接口 %s 配置DHCP自动获取IP", name)
+		return b.nm.updateIPSettings(b.runner, name, false, "ipv4.method auto", func(section map[string]dbus.Variant) {
+			section["method"] = dbus.MakeVariant("auto")
+			section["address-data"] = dbus.MakeVariant(nmAddressData(nil))
+			delete(section, "gateway")
+			setNMDNS(section, false, config.DNS, config.DNSAuto)
+		})
+	}
+
+	ip := net.ParseIP(config.IP).To4()
+	if ip == nil {
+		return fmt.Errorf("无效的IPv4地址: %s", config.IP)
+	}
+	maskIP := net.ParseIP(config.Mask).To4()
+	if maskIP == nil {
+		return fmt.Errorf("无效的子网掩码: %s", config.Mask)
+	}
+	mask := net.IPMask(maskIP)
+	if ones, bits := mask.Size(); ones == 0 && bits == 0 {
+		return fmt.Errorf("子网掩码不连续: %s", config.Mask)
+	}
+	addresses := append([]*net.IPNet{{IP: ip, Mask: mask}}, secondaryAddresses(config.IP, config.Addresses)...)
+
+	log.Printf("开始配置接口 %s 的静态IPv4设置: IP=%s, Mask=%s, Gateway=%s", name, config.IP, config.Mask, config.Gateway)
+	return b.nm.updateIPSettings(b.runner, name, false, fmt.Sprintf("ipv4.method manual ipv4.addresses %s ipv4.gateway %q", nmAddressList(addresses), config.Gateway),
+		func(section map[string]dbus.Variant) {
+			section["method"] = dbus.MakeVariant("manual")
+			section["address-data"] = dbus.MakeVariant(nmAddressData(addresses))
+			setNMGateway(section, config.Gateway)
+			setNMDNS(section, false, config.DNS, false)
+		})
+}
+
+// ConfigureIPv6 修改网卡连接的IPv6设置并立即应用
+func (b *nmBackend) ConfigureIPv6(name string, config models.IPv6Config) error {
+	ip := net.ParseIP(config.IP)
+	if ip == nil || ip.To4() != nil {
+		return fmt.Errorf("无效的IPv6地址: %s", config.IP)
+	}
+	prefixLen := config.PrefixLen
+	if prefixLen == 0 {
+		prefixLen = 64
+	}
+	if prefixLen < 1 || prefixLen > 128 {
+		return fmt.Errorf("无效的IPv6前缀长度: %d", config.PrefixLen)
+	}
+	addresses := append([]*net.IPNet{{IP: ip, Mask: net.CIDRMask(prefixLen, 128)}}, secondaryAddresses(config.IP, config.Addresses)...)
+
+	log.Printf("开始配置接口 %s 的IPv6设置: %s/%d", name, config.IP, prefixLen)
+	return b.nm.updateIPSettings(b.runner, name, true, fmt.Sprintf("ipv6.method manual ipv6.addresses %s ipv6.gateway %q", nmAddressList(addresses), config.Gateway),
+		func(section map[string]dbus.Variant) {
+			section["method"] = dbus.MakeVariant("manual")
+			section["address-data"] = dbus.MakeVariant(nmAddressData(addresses))
+			setNMGateway(section, config.Gateway)
+			setNMDNS(section, true, config.DNS, false)
+		})
+}
+
+// AddAddress 在网卡连接中追加一个静态地址
+func (b *nmBackend) AddAddress(name, address string) error {
+	addr, err := parseHostCIDR(address)
+	if err != nil {
+		return err
+	}
+	ipv6 := addr.IP.To4() == nil
+	return b.nm.updateIPSettings(b.runner, name, ipv6, fmt.Sprintf("+%s.addresses %s", nmSection(ipv6), addr), func(section map[string]dbus.Variant) {
+		addresses := append(nmSectionAddresses(section), addr)
+		section["address-data"] = dbus.MakeVariant(nmAddressData(addresses))
+	})
+}
+
+// RemoveAddress 从网卡连接中删除一个静态地址
+func (b *nmBackend) RemoveAddress(name, address string) error {
+	addr, err := parseHostCIDR(address)
+	if err != nil {
+		return err
+	}
+	ipv6 := addr.IP.To4() == nil
+	return b.nm.updateIPSettings(b.runner, name, ipv6, fmt.Sprintf("-%s.addresses %s", nmSection(ipv6), addr), func(section map[string]dbus.Variant) {
+		var addresses []*net.IPNet
+		for _, existing := range nmSectionAddresses(section) {
+			if !existing.IP.Equal(addr.IP) {
+				addresses = append(addresses, existing)
+			}
+		}
+		section["address-data"] = dbus.MakeVariant(nmAddressData(addresses))
+	})
+}
+
+// SetDNS 设置网卡连接的DNS服务器，servers为空时恢复为自动获取
+func (b *nmBackend) SetDNS(name string, ipv6 bool, servers []string) error {
+	return b.nm.updateIPSettings(b.runner, name, ipv6, fmt.Sprintf("%s.dns %q", nmSection(ipv6), strings.Join(servers, ",")), func(section map[string]dbus.Variant) {
+		setNMDNS(section, ipv6, servers, len(servers) == 0)
+	})
+}
+
+// SetGateway 设置网卡连接的默认网关
+func (b *nmBackend) SetGateway(name string, ipv6 bool, gateway string) error {
+	gw := net.ParseIP(gateway)
+	if gw == nil || (gw.To4() == nil) != ipv6 {
+		return fmt.Errorf("无效的网关地址: %s", gateway)
+	}
+	return b.nm.updateIPSettings(b.runner, name, ipv6, fmt.Sprintf("%s.gateway %s", nmSection(ipv6), gateway), func(section map[string]dbus.Variant) {
+		setNMGateway(section, gateway)
+	})
+}
+
+func nmSection(ipv6 bool) string {
+	if ipv6 {
+		return "ipv6"
+	}
+	return "ipv4"
+}
+
+// nmAddressList 返回nmcli形式的地址列表，用于预演记录
+func nmAddressList(addresses []*net.IPNet) string {
+	values := make([]string, 0, len(addresses))
+	for _, addr := range addresses {
+		values = append(values, addr.String())
+	}
+	return strings.Join(values, ",")
+}
+
+// nmSectionAddresses 读取连接设置中已有的address-data
+func nmSectionAddresses(section map[string]dbus.Variant) []*net.IPNet {
+	var addresses []*net.IPNet
+	for _, data := range nmProp[[]map[string]dbus.Variant](section, "address-data") {
+		addr, err := parseHostCIDR(fmt.Sprintf("%s/%d", nmProp[string](data, "address"), nmProp[uint32](data, "prefix")))
+		if err == nil {
+			addresses = append(addresses, addr)
+		}
+	}
+	return addresses
+}
+
+// setNMGateway 设置或清除网关
+func setNMGateway(section map[string]dbus.Variant, gateway string) {
+	if gateway == "" {
+		delete(section, "gateway")
+		return
+	}
+	section["gateway"] = dbus.MakeVariant(gateway)
+}
+
+// setNMDNS 设置DNS；auto为true时清空手动DNS并使用DHCP下发的DNS，servers为空且auto为false时保持不变
+func setNMDNS(section map[string]dbus.Variant, ipv6 bool, servers []string, auto bool) {
+	switch {
+	case auto:
+		section["dns"] = nmDNSValue(ipv6, nil)
+		section["ignore-auto-dns"] = dbus.MakeVariant(false)
+	case len(servers) > 0:
+		section["dns"] = nmDNSValue(ipv6, servers)
+		section["ignore-auto-dns"] = dbus.MakeVariant(true)
+	}
+	// dns-data(1.42+)与dns同时存在时优先，统一使用dns
+	delete(section, "dns-data")
+}
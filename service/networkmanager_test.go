@@ -0,0 +1,731 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"networkconfig/models"
+	"os/exec"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// 模拟NetworkManager中的对象路径
+const (
+	fakeNMWiFiDevice  = dbus.ObjectPath("/org/freedesktop/NetworkManager/Devices/3")
+	fakeNMEthDevice   = dbus.ObjectPath("/org/freedesktop/NetworkManager/Devices/2")
+	fakeNMUSBDevice   = dbus.ObjectPath("/org/freedesktop/NetworkManager/Devices/4")
+	fakeNMActive      = dbus.ObjectPath("/org/freedesktop/NetworkManager/ActiveConnection/1")
+	fakeNMHome        = dbus.ObjectPath("/org/freedesktop/NetworkManager/Settings/1")
+	fakeNMOffice      = dbus.ObjectPath("/org/freedesktop/NetworkManager/Settings/2")
+	fakeNMWired       = dbus.ObjectPath("/org/freedesktop/NetworkManager/Settings/3")
+	fakeNMAPHome5G    = dbus.ObjectPath("/org/freedesktop/NetworkManager/AccessPoint/10")
+	fakeNMAPHome24G   = dbus.ObjectPath("/org/freedesktop/NetworkManager/AccessPoint/11")
+	fakeNMAPCafe      = dbus.ObjectPath("/org/freedesktop/NetworkManager/AccessPoint/12")
+	fakeNMSettingsDir = "/org/freedesktop/NetworkManager/Settings/"
+)
+
+// fakeNMOutcome 激活连接后模拟NetworkManager发出的状态变化信号
+// deviceReason非0时先发出设备进入failed状态的信号，state为0时不发出激活连接的信号(模拟超时)
+type fakeNMOutcome struct {
+	deviceReason uint32
+	state        uint32
+	reason       uint32
+}
+
+// fakeNM 导出到私有会话总线上的NetworkManager模拟对象，只实现nmClient用到的方法、属性和信号
+// 包含一块已连接到Home的无线网卡wlan0、一块未激活的有线网卡eth0和一块没有可用连接的usb0
+type fakeNM struct {
+	conn    *dbus.Conn
+	outcome fakeNMOutcome
+
+	mu          sync.Mutex
+	props       map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+	connections map[dbus.ObjectPath]nmSettings
+	secrets     map[dbus.ObjectPath]nmSettings
+	updated     map[dbus.ObjectPath]nmSettings // Update和Reapply收到的设置
+	added       nmSettings                     // 最近一次AddAndActivateConnection收到的设置
+	calls       []string
+	nextID      int
+}
+
+// startFakeNM 启动私有的dbus-daemon并在其上导出模拟NetworkManager，返回连接到该总线的客户端
+func startFakeNM(t *testing.T) (*nmClient, *fakeNM) {
+	t.Helper()
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("没有找到dbus-daemon，跳过NetworkManager D-Bus测试")
+	}
+	cmd := exec.Command(daemon, "--session", "--nofork", "--print-address=1")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("启动dbus-daemon失败: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("读取dbus-daemon地址失败: %v", err)
+	}
+	address = strings.TrimSpace(address)
+
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatalf("连接私有总线失败: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	fake := newFakeNM(conn)
+	if reply, err := conn.RequestName(nmService, dbus.NameFlagDoNotQueue); err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("注册%s失败: %v", nmService, err)
+	}
+
+	client := &nmClient{address: address, activateTimeout: 2 * time.Second, scanTimeout: time.Second}
+	t.Cleanup(func() {
+		if client.conn != nil {
+			client.conn.Close()
+		}
+	})
+	return client, fake
+}
+
+// newFakeNM 创建模拟对象并导出到conn
+func newFakeNM(conn *dbus.Conn) *fakeNM {
+	f := &fakeNM{
+		conn:    conn,
+		outcome: fakeNMOutcome{state: nmActiveStateActivated},
+		props: map[dbus.ObjectPath]map[string]map[string]dbus.Variant{
+			fakeNMWiFiDevice: {
+				nmDeviceIface: {
+					"Interface":            dbus.MakeVariant("wlan0"),
+					"DeviceType":           dbus.MakeVariant(uint32(nmDeviceTypeWiFi)),
+					"State":                dbus.MakeVariant(uint32(nmDeviceStateActivated)),
+					"Managed":              dbus.MakeVariant(true),
+					"HwAddress":            dbus.MakeVariant("00:11:22:33:44:55"),
+					"Ip4Config":            dbus.MakeVariant(dbus.ObjectPath("/")),
+					"Ip6Config":            dbus.MakeVariant(dbus.ObjectPath("/")),
+					"ActiveConnection":     dbus.MakeVariant(fakeNMActive),
+					"AvailableConnections": dbus.MakeVariant([]dbus.ObjectPath{fakeNMHome, fakeNMOffice}),
+				},
+				nmWirelessIface: {
+					"ActiveAccessPoint": dbus.MakeVariant(fakeNMAPHome5G),
+					"LastScan":          dbus.MakeVariant(int64(1000)),
+				},
+			},
+			fakeNMEthDevice: {
+				nmDeviceIface: {
+					"Interface":            dbus.MakeVariant("eth0"),
+					"DeviceType":           dbus.MakeVariant(uint32(nmDeviceTypeEthernet)),
+					"State":                dbus.MakeVariant(uint32(30)),
+					"Managed":              dbus.MakeVariant(true),
+					"ActiveConnection":     dbus.MakeVariant(dbus.ObjectPath("/")),
+					"AvailableConnections": dbus.MakeVariant([]dbus.ObjectPath{fakeNMWired}),
+				},
+			},
+			fakeNMUSBDevice: {
+				nmDeviceIface: {
+					"Interface":            dbus.MakeVariant("usb0"),
+					"DeviceType":           dbus.MakeVariant(uint32(nmDeviceTypeEthernet)),
+					"State":                dbus.MakeVariant(uint32(20)),
+					"ActiveConnection":     dbus.MakeVariant(dbus.ObjectPath("/")),
+					"AvailableConnections": dbus.MakeVariant([]dbus.ObjectPath{}),
+				},
+			},
+			fakeNMActive: {
+				nmActiveIface: {
+					"State":      dbus.MakeVariant(uint32(nmActiveStateActivated)),
+					"Connection": dbus.MakeVariant(fakeNMHome),
+				},
+			},
+			fakeNMAPHome5G: {
+				nmAccessPoint: fakeNMAccessPoint("Home", "a4:2b:b0:11:22:33", 5180, 82, 0, 0, 0x188, 866700, 80),
+			},
+			fakeNMAPHome24G: {
+				nmAccessPoint: fakeNMAccessPoint("Home", "a4:2b:b0:11:22:34", 2437, 47, nmAPFlagPrivacy|0x2, 0, 0x588, 144400, 20),
+			},
+			fakeNMAPCafe: {
+				nmAccessPoint: fakeNMAccessPoint("Cafe", "00:0c:42:aa:bb:cc", 2412, 60, 0, 0, 0, 54000, 0),
+			},
+		},
+		connections: map[dbus.ObjectPath]nmSettings{
+			fakeNMHome: {
+				"connection":               {"id": dbus.MakeVariant("Home"), "type": dbus.MakeVariant("802-11-wireless")},
+				"802-11-wireless":          {"ssid": dbus.MakeVariant([]byte("Home"))},
+				"802-11-wireless-security": {"key-mgmt": dbus.MakeVariant("wpa-psk")},
+				"ipv4": {
+					"method":    dbus.MakeVariant("auto"),
+					"addresses": dbus.MakeVariant([][]uint32{{0x170aa8c0, 24, 0}}),
+				},
+			},
+			fakeNMOffice: {
+				"connection":               {"id": dbus.MakeVariant("Office"), "type": dbus.MakeVariant("802-11-wireless")},
+				"802-11-wireless":          {"ssid": dbus.MakeVariant([]byte("Office"))},
+				"802-11-wireless-security": {"key-mgmt": dbus.MakeVariant("wpa-eap")},
+				"802-1x":                   {"eap": dbus.MakeVariant([]string{"peap"}), "identity": dbus.MakeVariant("alice")},
+			},
+			fakeNMWired: {
+				"connection": {"id": dbus.MakeVariant("Wired"), "type": dbus.MakeVariant("802-3-ethernet")},
+				"ipv4":       {"method": dbus.MakeVariant("auto")},
+			},
+		},
+		secrets: map[dbus.ObjectPath]nmSettings{
+			fakeNMHome: {"802-11-wireless-security": {"psk": dbus.MakeVariant("home-secret")}},
+			fakeNMOffice: {
+				"802-11-wireless-security": {},
+				"802-1x":                   {"password": dbus.MakeVariant("office-secret")},
+			},
+		},
+		updated: make(map[dbus.ObjectPath]nmSettings),
+		nextID:  100,
+	}
+
+	conn.Export(fakeNMManager{f}, nmPath, nmService)
+	conn.Export(fakeNMSettings{f}, nmSettingsPath, nmSettingsIface)
+	for path := range f.props {
+		f.exportProps(path)
+	}
+	for _, device := range []dbus.ObjectPath{fakeNMWiFiDevice, fakeNMEthDevice, fakeNMUSBDevice} {
+		conn.Export(fakeNMDevice{f, device}, device, nmDeviceIface)
+	}
+	conn.Export(fakeNMWireless{f, fakeNMWiFiDevice}, fakeNMWiFiDevice, nmWirelessIface)
+	for path := range f.connections {
+		conn.Export(fakeNMConnection{f, path}, path, nmConnectionIface)
+	}
+	return f
+}
+
+// fakeNMAccessPoint 生成接入点属性
+func fakeNMAccessPoint(ssid, bssid string, freq uint32, strength byte, flags, wpaFlags, rsnFlags, maxBitrate, bandwidth uint32) map[string]dbus.Variant {
+	return map[string]dbus.Variant{
+		"Ssid":       dbus.MakeVariant([]byte(ssid)),
+		"HwAddress":  dbus.MakeVariant(bssid),
+		"Frequency":  dbus.MakeVariant(freq),
+		"Strength":   dbus.MakeVariant(strength),
+		"Flags":      dbus.MakeVariant(flags),
+		"WpaFlags":   dbus.MakeVariant(wpaFlags),
+		"RsnFlags":   dbus.MakeVariant(rsnFlags),
+		"MaxBitrate": dbus.MakeVariant(maxBitrate),
+		"Bandwidth":  dbus.MakeVariant(bandwidth),
+	}
+}
+
+// exportProps 为对象导出org.freedesktop.DBus.Properties
+func (f *fakeNM) exportProps(path dbus.ObjectPath) {
+	f.conn.Export(fakeNMProperties{f, path}, path, "org.freedesktop.DBus.Properties")
+}
+
+// record 记录一次方法调用，如"Update /org/freedesktop/NetworkManager/Settings/1"
+func (f *fakeNM) record(method string, path dbus.ObjectPath) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, fmt.Sprintf("%s %s", method, path))
+}
+
+// called 返回调用记录中以method开头的项
+func (f *fakeNM) called(method string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var calls []string
+	for _, call := range f.calls {
+		if strings.HasPrefix(call, method+" ") {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// hasConnection 判断连接是否仍然存在
+func (f *fakeNM) hasConnection(path dbus.ObjectPath) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.connections[path]
+	return ok
+}
+
+// addConnection 保存连接并导出，密码与设置分开保存
+func (f *fakeNM) addConnection(settings nmSettings) dbus.ObjectPath {
+	f.mu.Lock()
+	f.nextID++
+	path := dbus.ObjectPath(fmt.Sprintf("%s%d", fakeNMSettingsDir, f.nextID))
+	f.connections[path] = settings
+	f.mu.Unlock()
+	f.conn.Export(fakeNMConnection{f, path}, path, nmConnectionIface)
+	return path
+}
+
+// activate 创建激活连接，稍后按outcome发出状态变化信号
+func (f *fakeNM) activate(connection, device dbus.ObjectPath) dbus.ObjectPath {
+	f.mu.Lock()
+	f.nextID++
+	active := dbus.ObjectPath(fmt.Sprintf("/org/freedesktop/NetworkManager/ActiveConnection/%d", f.nextID))
+	f.props[active] = map[string]map[string]dbus.Variant{
+		nmActiveIface: {
+			"State":      dbus.MakeVariant(uint32(nmActiveStateActivating)),
+			"Connection": dbus.MakeVariant(connection),
+		},
+	}
+	outcome := f.outcome
+	f.mu.Unlock()
+	f.exportProps(active)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		if outcome.deviceReason != 0 {
+			f.conn.Emit(device, nmDeviceIface+".StateChanged", uint32(nmDeviceStateFailed), uint32(50), outcome.deviceReason)
+		}
+		if outcome.state != 0 {
+			f.conn.Emit(active, nmActiveIface+".StateChanged", outcome.state, outcome.reason)
+		}
+	}()
+	return active
+}
+
+// fakeNMProperties 对象的org.freedesktop.DBus.Properties接口
+type fakeNMProperties struct {
+	nm   *fakeNM
+	path dbus.ObjectPath
+}
+
+func (p fakeNMProperties) GetAll(iface string) (map[string]dbus.Variant, *dbus.Error) {
+	p.nm.mu.Lock()
+	defer p.nm.mu.Unlock()
+	props, ok := p.nm.props[p.path][iface]
+	if !ok {
+		return nil, dbus.MakeFailedError(fmt.Errorf("对象 %s 没有接口 %s", p.path, iface))
+	}
+	return props, nil
+}
+
+// fakeNMManager org.freedesktop.NetworkManager接口
+type fakeNMManager struct{ nm *fakeNM }
+
+func (m fakeNMManager) GetDevices() ([]dbus.ObjectPath, *dbus.Error) {
+	return []dbus.ObjectPath{fakeNMEthDevice, fakeNMWiFiDevice, fakeNMUSBDevice}, nil
+}
+
+func (m fakeNMManager) GetDeviceByIpIface(name string) (dbus.ObjectPath, *dbus.Error) {
+	m.nm.mu.Lock()
+	defer m.nm.mu.Unlock()
+	for path, props := range m.nm.props {
+		if device, ok := props[nmDeviceIface]; ok && nmProp[string](device, "Interface") == name {
+			return path, nil
+		}
+	}
+	return "", dbus.NewError(nmService+".UnknownDevice", []interface{}{"No device found for the requested iface."})
+}
+
+func (m fakeNMManager) ActivateConnection(connection, device, specific dbus.ObjectPath) (dbus.ObjectPath, *dbus.Error) {
+	m.nm.record("ActivateConnection", connection)
+	return m.nm.activate(connection, device), nil
+}
+
+func (m fakeNMManager) AddAndActivateConnection(settings map[string]map[string]dbus.Variant, device, specific dbus.ObjectPath) (dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	m.nm.mu.Lock()
+	m.nm.added = settings
+	m.nm.mu.Unlock()
+	path := m.nm.addConnection(settings)
+	m.nm.record("AddAndActivateConnection", specific)
+	return path, m.nm.activate(path, device), nil
+}
+
+func (m fakeNMManager) DeactivateConnection(active dbus.ObjectPath) *dbus.Error {
+	m.nm.record("DeactivateConnection", active)
+	return nil
+}
+
+// fakeNMSettings org.freedesktop.NetworkManager.Settings接口
+type fakeNMSettings struct{ nm *fakeNM }
+
+func (s fakeNMSettings) ListConnections() ([]dbus.ObjectPath, *dbus.Error) {
+	s.nm.mu.Lock()
+	defer s.nm.mu.Unlock()
+	paths := make([]dbus.ObjectPath, 0, len(s.nm.connections))
+	for path := range s.nm.connections {
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func (s fakeNMSettings) AddConnection(settings map[string]map[string]dbus.Variant) (dbus.ObjectPath, *dbus.Error) {
+	return s.nm.addConnection(settings), nil
+}
+
+// fakeNMDevice org.freedesktop.NetworkManager.Device接口
+type fakeNMDevice struct {
+	nm   *fakeNM
+	path dbus.ObjectPath
+}
+
+func (d fakeNMDevice) Reapply(settings map[string]map[string]dbus.Variant, version uint64, flags uint32) *dbus.Error {
+	d.nm.record("Reapply", d.path)
+	return nil
+}
+
+// fakeNMWireless org.freedesktop.NetworkManager.Device.Wireless接口，扫描立即完成
+type fakeNMWireless struct {
+	nm   *fakeNM
+	path dbus.ObjectPath
+}
+
+func (w fakeNMWireless) GetAllAccessPoints() ([]dbus.ObjectPath, *dbus.Error) {
+	return []dbus.ObjectPath{fakeNMAPHome5G, fakeNMAPHome24G, fakeNMAPCafe}, nil
+}
+
+func (w fakeNMWireless) RequestScan(options map[string]dbus.Variant) *dbus.Error {
+	w.nm.record("RequestScan", w.path)
+	w.nm.mu.Lock()
+	defer w.nm.mu.Unlock()
+	props := w.nm.props[w.path][nmWirelessIface]
+	props["LastScan"] = dbus.MakeVariant(nmProp[int64](props, "LastScan") + 1)
+	return nil
+}
+
+// fakeNMConnection org.freedesktop.NetworkManager.Settings.Connection接口
+type fakeNMConnection struct {
+	nm   *fakeNM
+	path dbus.ObjectPath
+}
+
+func (c fakeNMConnection) GetSettings() (map[string]map[string]dbus.Variant, *dbus.Error) {
+	c.nm.mu.Lock()
+	defer c.nm.mu.Unlock()
+	settings, ok := c.nm.connections[c.path]
+	if !ok {
+		return nil, dbus.MakeFailedError(fmt.Errorf("连接 %s 不存在", c.path))
+	}
+	return settings, nil
+}
+
+func (c fakeNMConnection) GetSecrets(section string) (map[string]map[string]dbus.Variant, *dbus.Error) {
+	c.nm.record("GetSecrets", c.path)
+	c.nm.mu.Lock()
+	defer c.nm.mu.Unlock()
+	secrets, ok := c.nm.secrets[c.path][section]
+	if !ok {
+		return nil, dbus.NewError(nmService+".Settings.Connection.SettingNotFound", []interface{}{"setting not found"})
+	}
+	return map[string]map[string]dbus.Variant{section: secrets}, nil
+}
+
+func (c fakeNMConnection) Update(settings map[string]map[string]dbus.Variant) *dbus.Error {
+	c.nm.record("Update", c.path)
+	c.nm.mu.Lock()
+	defer c.nm.mu.Unlock()
+	c.nm.updated[c.path] = settings
+	return nil
+}
+
+func (c fakeNMConnection) Delete() *dbus.Error {
+	c.nm.record("Delete", c.path)
+	c.nm.mu.Lock()
+	delete(c.nm.connections, c.path)
+	c.nm.mu.Unlock()
+	c.nm.conn.Export(nil, c.path, nmConnectionIface)
+	return nil
+}
+
+func TestNMClientScan(t *testing.T) {
+	client, fake := startFakeNM(t)
+
+	hotspots, err := client.scan("wlan0")
+	if err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+	want := []models.WiFiHotspot{
+		{SSID: "Home", BSSID: "A4:2B:B0:11:22:33", SignalStrength: 82, SignalDBm: percentToDBm(82), Frequency: 5180,
+			Band: models.HotspotBand5GHz, Channel: 36, ChannelWidth: 80, Rate: 866, Security: "WPA2", Connected: true,
+			SecurityInfo: models.WiFiSecurity{AKM: []string{"psk"}, Ciphers: []string{"ccmp"}, GroupCipher: "ccmp"}},
+		{SSID: "Home", BSSID: "A4:2B:B0:11:22:34", SignalStrength: 47, SignalDBm: percentToDBm(47), Frequency: 2437,
+			Band: models.HotspotBand24GHz, Channel: 6, ChannelWidth: 20, Rate: 144, Security: "WPA2 WPA3",
+			SecurityInfo: models.WiFiSecurity{AKM: []string{"psk", "sae"}, Ciphers: []string{"ccmp"}, GroupCipher: "ccmp", WPS: true}},
+		{SSID: "Cafe", BSSID: "00:0C:42:AA:BB:CC", SignalStrength: 60, SignalDBm: percentToDBm(60), Frequency: 2412,
+			Band: models.HotspotBand24GHz, Channel: 1, Rate: 54,
+			SecurityInfo: models.WiFiSecurity{AKM: []string{}, Ciphers: []string{}}},
+	}
+	if !reflect.DeepEqual(hotspots, want) {
+		t.Errorf("扫描结果不一致\n得到: %+v\n应为: %+v", hotspots, want)
+	}
+	if calls := fake.called("RequestScan"); len(calls) != 1 {
+		t.Errorf("应请求一次扫描，调用记录: %v", calls)
+	}
+
+	if _, err := client.scan("eth0"); err == nil || !strings.Contains(err.Error(), "不是无线网卡") {
+		t.Errorf("有线网卡扫描应返回错误，得到: %v", err)
+	}
+	if _, err := client.scan("wlan9"); err == nil || !strings.Contains(err.Error(), "找不到网卡") {
+		t.Errorf("不存在的网卡扫描应返回错误，得到: %v", err)
+	}
+}
+
+func TestNMClientMergeSecrets(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      dbus.ObjectPath
+		sections  int // 应读取密码的设置节数
+		wantKey   [2]string
+		wantValue string
+	}{
+		{name: "WPA-PSK", path: fakeNMHome, sections: 1, wantKey: [2]string{"802-11-wireless-security", "psk"}, wantValue: "home-secret"},
+		{name: "802.1X", path: fakeNMOffice, sections: 2, wantKey: [2]string{"802-1x", "password"}, wantValue: "office-secret"},
+		{name: "没有密码的有线连接", path: fakeNMWired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, fake := startFakeNM(t)
+			settings, err := client.settings(tt.path)
+			if err != nil {
+				t.Fatalf("读取连接设置失败: %v", err)
+			}
+			client.mergeSecrets(tt.path, settings)
+
+			if calls := fake.called("GetSecrets"); len(calls) != tt.sections {
+				t.Errorf("应读取 %d 个设置节的密码，调用记录: %v", tt.sections, calls)
+			}
+			if tt.wantValue != "" {
+				if got := nmProp[string](settings[tt.wantKey[0]], tt.wantKey[1]); got != tt.wantValue {
+					t.Errorf("%s.%s 应为 %q，得到 %q", tt.wantKey[0], tt.wantKey[1], tt.wantValue, got)
+				}
+			}
+			// 其他设置保持不变
+			if id := nmProp[string](settings["connection"], "id"); id == "" {
+				t.Error("合并密码后连接名称丢失")
+			}
+		})
+	}
+
+	t.Run("读取密码失败时保持原设置", func(t *testing.T) {
+		client, fake := startFakeNM(t)
+		fake.mu.Lock()
+		delete(fake.secrets, fakeNMHome)
+		fake.mu.Unlock()
+
+		settings, err := client.settings(fakeNMHome)
+		if err != nil {
+			t.Fatalf("读取连接设置失败: %v", err)
+		}
+		client.mergeSecrets(fakeNMHome, settings)
+		if _, ok := settings["802-11-wireless-security"]["psk"]; ok {
+			t.Error("读取密码失败时不应出现psk")
+		}
+		if got := nmProp[string](settings["802-11-wireless-security"], "key-mgmt"); got != "wpa-psk" {
+			t.Errorf("key-mgmt应保持为wpa-psk，得到 %q", got)
+		}
+	})
+}
+
+func TestNMClientUpdateIPSettings(t *testing.T) {
+	manual := func(section map[string]dbus.Variant) {
+		section["method"] = dbus.MakeVariant("manual")
+		section["address-data"] = dbus.MakeVariant([]map[string]dbus.Variant{
+			{"address": dbus.MakeVariant("192.168.10.20"), "prefix": dbus.MakeVariant(uint32(24))},
+		})
+	}
+	tests := []struct {
+		name       string
+		iface      string
+		plan       bool
+		connection dbus.ObjectPath
+		wantCalls  []string
+		wantErr    string
+	}{
+		{
+			name:       "已激活的连接立即重新应用",
+			iface:      "wlan0",
+			connection: fakeNMHome,
+			wantCalls:  []string{"GetSecrets " + string(fakeNMHome), "Update " + string(fakeNMHome), "Reapply " + string(fakeNMWiFiDevice)},
+		},
+		{
+			name:       "未激活的连接保存后激活",
+			iface:      "eth0",
+			connection: fakeNMWired,
+			wantCalls:  []string{"Update " + string(fakeNMWired), "ActivateConnection " + string(fakeNMWired)},
+		},
+		{
+			name:  "预演模式只记录等价命令",
+			iface: "wlan0",
+			plan:  true,
+		},
+		{
+			name:    "没有可用连接",
+			iface:   "usb0",
+			wantErr: "没有可用的NetworkManager连接",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, fake := startFakeNM(t)
+			var runner CommandRunner = ExecRunner{}
+			if tt.plan {
+				runner = NewPlanRunner(&ReplayRunner{})
+			}
+
+			err := client.updateIPSettings(runner, tt.iface, false, "ipv4.method manual", manual)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("应返回包含 %q 的错误，得到: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("修改IP设置失败: %v", err)
+			}
+
+			fake.mu.Lock()
+			calls := append([]string(nil), fake.calls...)
+			updated := fake.updated[tt.connection]
+			fake.mu.Unlock()
+			if tt.plan {
+				if len(calls) != 0 {
+					t.Errorf("预演模式不应修改连接，调用记录: %v", calls)
+				}
+				steps := runner.(*PlanRunner).Steps()
+				if len(steps) != 1 || steps[0].Command != "nmcli connection modify Home ipv4.method manual" {
+					t.Errorf("预演步骤不正确: %+v", steps)
+				}
+				return
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("调用记录应为 %v，得到 %v", tt.wantCalls, calls)
+			}
+
+			ipv4 := updated["ipv4"]
+			if got := nmProp[string](ipv4, "method"); got != "manual" {
+				t.Errorf("ipv4.method应为manual，得到 %q", got)
+			}
+			if _, ok := ipv4["addresses"]; ok {
+				t.Error("应删除旧格式的ipv4.addresses")
+			}
+			if data := nmProp[[]map[string]dbus.Variant](ipv4, "address-data"); len(data) != 1 || nmProp[string](data[0], "address") != "192.168.10.20" {
+				t.Errorf("ipv4.address-data不正确: %v", data)
+			}
+			// 更新连接时必须带上已保存的密码，否则会被清空
+			if _, ok := updated["802-11-wireless-security"]; ok {
+				if got := nmProp[string](updated["802-11-wireless-security"], "psk"); got != "home-secret" {
+					t.Errorf("更新时应带上已保存的密码，得到 %q", got)
+				}
+			}
+		})
+	}
+}
+
+func TestNMClientConnect(t *testing.T) {
+	tests := []struct {
+		name        string
+		ssid        string
+		password    string
+		outcome     fakeNMOutcome
+		timeout     time.Duration
+		wantErr     *NMActivationError
+		wantCalls   map[string]int
+		homeRemains bool
+	}{
+		{
+			name:      "新建连接成功后删除同一SSID的旧连接",
+			ssid:      "Home",
+			password:  "new-secret",
+			outcome:   fakeNMOutcome{state: nmActiveStateActivated},
+			wantCalls: map[string]int{"AddAndActivateConnection": 1, "Delete": 1},
+		},
+		{
+			name:     "密码错误",
+			ssid:     "Home",
+			password: "wrong-secret",
+			outcome:  fakeNMOutcome{deviceReason: 8, state: nmActiveStateDeactivated, reason: 9},
+			wantErr: &NMActivationError{Interface: "wlan0", Connection: "Home", State: "deactivated",
+				Reason: "no-secrets", ReasonCode: 9, DeviceReason: "supplicant-disconnect", DeviceReasonCode: 8,
+				Message: "认证过程中断开，通常是密码错误"},
+			wantCalls:   map[string]int{"AddAndActivateConnection": 1, "Delete": 1},
+			homeRemains: true,
+		},
+		{
+			name:     "找不到隐藏网络",
+			ssid:     "Hidden",
+			password: "hidden-secret",
+			outcome:  fakeNMOutcome{deviceReason: 53, state: nmActiveStateDeactivated, reason: 1},
+			wantErr: &NMActivationError{Interface: "wlan0", Connection: "Hidden", State: "deactivated",
+				Reason: "none", ReasonCode: 1, DeviceReason: "ssid-not-found", DeviceReasonCode: 53,
+				Message: "找不到该SSID的网络"},
+			wantCalls:   map[string]int{"AddAndActivateConnection": 1, "Delete": 1},
+			homeRemains: true,
+		},
+		{
+			name:    "激活超时",
+			ssid:    "Cafe",
+			timeout: 200 * time.Millisecond,
+			outcome: fakeNMOutcome{},
+			wantErr: &NMActivationError{Interface: "wlan0", Connection: "Cafe", State: "deactivated",
+				Reason: "connect-timeout", ReasonCode: 6, Message: "连接超时"},
+			wantCalls:   map[string]int{"AddAndActivateConnection": 1, "DeactivateConnection": 1, "Delete": 1},
+			homeRemains: true,
+		},
+		{
+			name:        "不提供密码时激活已保存的连接",
+			ssid:        "Home",
+			outcome:     fakeNMOutcome{state: nmActiveStateActivated},
+			wantCalls:   map[string]int{"ActivateConnection": 1},
+			homeRemains: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, fake := startFakeNM(t)
+			fake.outcome = tt.outcome
+			if tt.timeout > 0 {
+				client.activateTimeout = tt.timeout
+			}
+
+			err := client.connect("wlan0", tt.ssid, tt.password)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("连接失败: %v", err)
+				}
+			} else {
+				var activationErr *NMActivationError
+				if !errors.As(err, &activationErr) {
+					t.Fatalf("应返回NMActivationError，得到: %v", err)
+				}
+				if !reflect.DeepEqual(activationErr, tt.wantErr) {
+					t.Errorf("错误不一致\n得到: %+v\n应为: %+v", activationErr, tt.wantErr)
+				}
+			}
+
+			for _, method := range []string{"ActivateConnection", "AddAndActivateConnection", "DeactivateConnection", "Delete"} {
+				if got := len(fake.called(method)); got != tt.wantCalls[method] {
+					t.Errorf("%s 应调用 %d 次，得到 %d 次: %v", method, tt.wantCalls[method], got, fake.called(method))
+				}
+			}
+			if fake.hasConnection(fakeNMHome) != tt.homeRemains {
+				t.Errorf("已保存的连接Home是否保留应为 %v", tt.homeRemains)
+			}
+		})
+	}
+
+	t.Run("隐藏网络按wpa-psk新建", func(t *testing.T) {
+		client, fake := startFakeNM(t)
+		if err := client.connect("wlan0", "Hidden", "hidden-secret"); err != nil {
+			t.Fatalf("连接失败: %v", err)
+		}
+		fake.mu.Lock()
+		added := fake.added
+		fake.mu.Unlock()
+		if !nmProp[bool](added["802-11-wireless"], "hidden") || nmProp[string](added["802-11-wireless-security"], "key-mgmt") != "wpa-psk" {
+			t.Errorf("隐藏网络应设置hidden和key-mgmt=wpa-psk，得到: %v", added)
+		}
+		if calls := fake.called("AddAndActivateConnection"); len(calls) != 1 || calls[0] != "AddAndActivateConnection /" {
+			t.Errorf("隐藏网络不应指定接入点: %v", calls)
+		}
+	})
+}
@@ -0,0 +1,348 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"networkconfig/models"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// PlanRunner 预演模式的命令执行器
+// 只读命令(netsh show、ipconfig、route print等)照常执行以获取当前状态，
+// 会修改系统配置的命令只记录不执行，并返回空输出表示成功
+type PlanRunner struct {
+	inner CommandRunner
+	mu    sync.Mutex
+	steps []models.PlanStep
+}
+
+// NewPlanRunner 创建预演runner，只读命令交给inner执行
+func NewPlanRunner(inner CommandRunner) *PlanRunner {
+	if inner == nil {
+		inner = ExecRunner{}
+	}
+	return &PlanRunner{inner: inner}
+}
+
+// Run 执行只读命令，记录修改命令
+func (r *PlanRunner) Run(c *Command, combined bool) ([]byte, error) {
+	if isReadOnlyCommand(c.Name, c.Args) {
+		return r.inner.Run(c, combined)
+	}
+	r.record(c.String(), "")
+	return []byte{}, nil
+}
+
+// LookPath 查找可执行文件，不修改系统状态
+func (r *PlanRunner) LookPath(file string) (string, error) {
+	return r.inner.LookPath(file)
+}
+
+// Steps 返回已记录的步骤
+func (r *PlanRunner) Steps() []models.PlanStep {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.PlanStep(nil), r.steps...)
+}
+
+// record 记录一条将要执行的命令
+func (r *PlanRunner) record(command, description string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.steps = append(r.steps, models.PlanStep{Command: command, Description: description})
+	log.Printf("[预演] 记录命令(未执行): %s", command)
+}
+
+// applyOp 执行一步不经过命令行的系统修改(如rtnetlink、写配置文件)
+// 预演模式下不执行fn，只记录等价命令和说明
+func applyOp(runner CommandRunner, equivalent, description string, fn func() error) error {
+	if plan, ok := runner.(*PlanRunner); ok {
+		plan.record(equivalent, description)
+		return nil
+	}
+	return fn()
+}
+
+// readOnlyPowerShellScripts 只读取系统状态的PowerShell脚本，预演模式只执行与其中某个脚本完全一致的命令
+// 脚本中的%s为网卡名等参数，其他脚本一律视为会修改系统
+var readOnlyPowerShellScripts = []*regexp.Regexp{
+	powerShellScriptPattern(psOSVersionScript),
+	powerShellScriptPattern(psExecutionPolicyScript),
+	powerShellScriptPattern(psUserExecutionPolicyScript),
+	powerShellScriptPattern(psActiveAdaptersScript),
+	powerShellScriptPattern(psSharedAccessScript),
+	powerShellScriptPattern(psHardwareInfoScript),
+	powerShellScriptPattern(psPhysicalAdapterScript),
+	powerShellScriptPattern(psAdapterCaptionScript),
+	powerShellScriptPattern(psDriverInfoScript),
+	powerShellScriptPattern(psAddressOriginsScript),
+	powerShellScriptPattern(psDNSServersScript),
+}
+
+// powerShellScriptPattern 把脚本模板转换为完整匹配的正则表达式
+// 参数中不能出现引号(转义成两个单引号的除外)、$和反引号，避免在字符串中插入其他命令
+func powerShellScriptPattern(script string) *regexp.Regexp {
+	parts := strings.Split(script, "%s")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile(`^` + strings.Join(parts, "(?:[^'\"$`]|'')*") + `$`)
+}
+
+// nmcliValueOptions 后面跟一个参数的nmcli全局选项
+var nmcliValueOptions = map[string]bool{
+	"-m": true, "--mode": true,
+	"-c": true, "--colors": true,
+	"-f": true, "--fields": true,
+	"-g": true, "--get-values": true,
+	"-e": true, "--escape": true,
+	"-w": true, "--wait": true,
+}
+
+// nmcliReadOnlyVerbs 只读的nmcli动词
+var nmcliReadOnlyVerbs = map[string]bool{"show": true, "list": true, "status": true}
+
+// isReadOnlyNmcli 跳过全局选项后检查对象后面的动词，如"nmcli -t connection show"
+// device wifi的动词在wifi之后，如"nmcli device wifi list"
+func isReadOnlyNmcli(args []string) bool {
+	i := 0
+	for i < len(args) && strings.HasPrefix(args[i], "-") {
+		if nmcliValueOptions[args[i]] {
+			i++
+		}
+		i++
+	}
+	if i+1 >= len(args) {
+		return false
+	}
+	object, verb := args[i], args[i+1]
+	if strings.HasPrefix("device", object) && verb == "wifi" {
+		if i+2 >= len(args) {
+			return false
+		}
+		verb = args[i+2]
+	}
+	return nmcliReadOnlyVerbs[verb]
+}
+
+// netshContexts netsh的上下文关键字，命令形如"netsh interface ipv4 show config"，动词在上下文之后
+var netshContexts = map[string]bool{
+	"interface": true, "ipv4": true, "ipv6": true, "ip": true, "portproxy": true, "tcp": true,
+	"wlan": true, "lan": true, "advfirewall": true, "firewall": true, "http": true, "winhttp": true,
+}
+
+// isReadOnlyNetsh 跳过上下文关键字后检查动词，只有show是只读的，如"netsh wlan show profiles"
+// 参数中的show(如name=show或网卡名show)不算
+func isReadOnlyNetsh(args []string) bool {
+	i := 0
+	for i < len(args) && netshContexts[strings.ToLower(args[i])] {
+		i++
+	}
+	return i > 0 && i < len(args) && strings.EqualFold(args[i], "show")
+}
+
+// iwReadOnlyDevCommands "iw dev <网卡>"后面只读的命令
+var iwReadOnlyDevCommands = map[string]bool{
+	"scan": true, "scan dump": true, "link": true, "info": true, "station dump": true,
+}
+
+// isReadOnlyIw 只有列表中的iw命令是只读的，如"iw dev"、"iw list"、"iw dev wlan0 link"、"iw phy phy0 info"
+func isReadOnlyIw(args []string) bool {
+	switch {
+	case len(args) == 1:
+		return args[0] == "dev" || args[0] == "list" || args[0] == "phy"
+	case len(args) == 2 && args[0] == "reg":
+		return args[1] == "get"
+	case len(args) == 3 && args[0] == "phy":
+		return args[2] == "info"
+	case len(args) >= 3 && args[0] == "dev":
+		return iwReadOnlyDevCommands[strings.Join(args[2:], " ")]
+	}
+	return false
+}
+
+// isReadOnlyPowerShell 只有-Command后面的脚本在只读脚本列表中时才是只读命令
+func isReadOnlyPowerShell(args []string) bool {
+	for i, arg := range args {
+		if !strings.EqualFold(arg, "-Command") {
+			continue
+		}
+		if i != len(args)-2 {
+			return false
+		}
+		for _, pattern := range readOnlyPowerShellScripts {
+			if pattern.MatchString(args[i+1]) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// isReadOnlyCommand 判断命令是否只读取系统状态
+func isReadOnlyCommand(name string, args []string) bool {
+	switch strings.ToLower(name) {
+	case "netsh":
+		return isReadOnlyNetsh(args)
+	case "ipconfig":
+		for _, arg := range args {
+			if strings.EqualFold(arg, "/release") || strings.EqualFold(arg, "/renew") {
+				return false
+			}
+		}
+		return true
+	case "route":
+		return len(args) > 0 && strings.EqualFold(args[0], "print")
+	case "powershell":
+		return isReadOnlyPowerShell(args)
+	case "nmcli":
+		return isReadOnlyNmcli(args)
+	case "iw":
+		return isReadOnlyIw(args)
+	case "iwlist":
+		return len(args) == 2 && (args[1] == "scan" || args[1] == "scanning")
+	case "resolvectl":
+		return len(args) == 0 || args[0] == "status" || args[0] == "query"
+	default:
+		return false
+	}
+}
+
+// runnerBackend 可以替换命令执行器的后端
+type runnerBackend interface {
+	// commandRunner 返回后端当前使用的命令执行器
+	commandRunner() CommandRunner
+	// withRunner 返回使用指定命令执行器的后端副本
+	withRunner(runner CommandRunner) Backend
+}
+
+// PlanInterface 预演网卡配置，返回将要执行的命令、字段变更和警告，不修改系统
+func (s *NetworkService) PlanInterface(name string, config models.InterfaceConfig) (models.ConfigPlan, error) {
+	plan := models.ConfigPlan{
+		Interface: name,
+		Backend:   s.backend.Name(),
+		Steps:     []models.PlanStep{},
+		Changes:   []models.PlanChange{},
+		Warnings:  []string{},
+	}
+
+	config, errs := s.prepareInterfaceConfig(name, config)
+	if len(errs) > 0 {
+		return plan, errs
+	}
+
+	swapper, ok := s.backend.(runnerBackend)
+	if !ok {
+		return plan, fmt.Errorf("后端 %s 不支持预演: %w", s.backend.Name(), ErrNotSupported)
+	}
+
+	current, err := s.backend.GetInterface(name)
+	if err != nil {
+		log.Printf("预演时获取接口 %s 当前配置失败: %v", name, err)
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("无法读取当前配置: %v", err))
+	}
+
+	runner := NewPlanRunner(swapper.commandRunner())
+	planner := swapper.withRunner(runner)
+
+	if config.IPv4Config != nil {
+		plan.Changes = append(plan.Changes, diffIPv4(current, *config.IPv4Config)...)
+		plan.Warnings = append(plan.Warnings, ipv4PlanWarnings(current, *config.IPv4Config)...)
+		if err := planner.ConfigureIPv4(name, *config.IPv4Config); err != nil {
+			return plan, fmt.Errorf("预演IPv4配置失败: %v", err)
+		}
+	}
+
+	if config.IPv6Config != nil {
+		plan.Changes = append(plan.Changes, diffIPv6(current, *config.IPv6Config)...)
+		if err := planner.ConfigureIPv6(name, *config.IPv6Config); err != nil {
+			return plan, fmt.Errorf("预演IPv6配置失败: %v", err)
+		}
+	}
+
+	plan.Steps = append(plan.Steps, runner.Steps()...)
+	if len(plan.Steps) == 0 {
+		plan.Warnings = append(plan.Warnings, "没有需要执行的命令，当前配置已满足要求")
+	}
+
+	log.Printf("接口 %s 的配置预演完成: %d 条命令, %d 项变更, %d 条警告",
+		name, len(plan.Steps), len(plan.Changes), len(plan.Warnings))
+	return plan, nil
+}
+
+// diffIPv4 比较当前IPv4配置与目标配置
+func diffIPv4(current models.Interface, config models.IPv4Config) []models.PlanChange {
+	var changes []models.PlanChange
+	add := func(field, before, after string) {
+		if before != after {
+			changes = append(changes, models.PlanChange{Field: field, Before: before, After: after})
+		}
+	}
+
+	before := current.IPv4Config
+	add("ipv4.dhcp", fmt.Sprint(current.DHCPEnabled), fmt.Sprint(config.DHCP))
+	if !config.DHCP {
+		add("ipv4.ip", before.IP, config.IP)
+		add("ipv4.mask", before.Mask, config.Mask)
+		if len(config.Addresses) > 1 || len(before.Addresses) > 1 {
+			add("ipv4.addresses", strings.Join(before.Addresses, ","), strings.Join(config.Addresses, ","))
+		}
+		add("ipv4.gateway", before.Gateway, config.Gateway)
+	}
+	if config.DNSAuto {
+		add("ipv4.dns", strings.Join(before.DNS, ","), "dhcp")
+	} else if len(config.DNS) > 0 {
+		add("ipv4.dns", strings.Join(before.DNS, ","), strings.Join(config.DNS, ","))
+	}
+	return changes
+}
+
+// diffIPv6 比较当前IPv6配置与目标配置
+func diffIPv6(current models.Interface, config models.IPv6Config) []models.PlanChange {
+	var changes []models.PlanChange
+	add := func(field, before, after string) {
+		if before != after {
+			changes = append(changes, models.PlanChange{Field: field, Before: before, After: after})
+		}
+	}
+
+	before := current.IPv6Config
+	add("ipv6.ip", before.IP, config.IP)
+	add("ipv6.prefix_len", fmt.Sprint(before.PrefixLen), fmt.Sprint(config.PrefixLen))
+	if len(config.Addresses) > 1 {
+		add("ipv6.addresses", strings.Join(globalIPv6Addresses(before), ","), strings.Join(config.Addresses, ","))
+	}
+	if config.Gateway != "" {
+		add("ipv6.gateway", before.Gateway, config.Gateway)
+	}
+	if len(config.DNS) > 0 {
+		add("ipv6.dns", strings.Join(before.DNS, ","), strings.Join(config.DNS, ","))
+	}
+	return changes
+}
+
+// ipv4PlanWarnings 生成IPv4配置预演的警告
+func ipv4PlanWarnings(current models.Interface, config models.IPv4Config) []string {
+	var warnings []string
+	if !config.DHCP {
+		if config.Gateway == "" {
+			warnings = append(warnings, "未指定网关，接口将没有默认路由")
+		}
+		if len(config.DNS) == 0 {
+			warnings = append(warnings, "未指定DNS服务器，将保留现有DNS设置")
+		}
+		if current.IPv4Config.IP != "" && current.IPv4Config.IP != config.IP {
+			warnings = append(warnings, fmt.Sprintf("接口IP将从 %s 变为 %s，通过该地址的远程连接会中断",
+				current.IPv4Config.IP, config.IP))
+		}
+	} else if current.DHCPEnabled {
+		warnings = append(warnings, "接口已经是DHCP状态，将跳过地址设置")
+	}
+	if len(config.DNS) > 0 && config.DNSAuto {
+		warnings = append(warnings, "同时指定了DNS服务器和dnsAuto，DHCP模式下将自动获取DNS")
+	}
+	return warnings
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestIsReadOnlyCommand(t *testing.T) {
+	powershell := func(script string) []string {
+		return []string{"-NoProfile", "-NonInteractive", "-Command", script}
+	}
+	tests := []struct {
+		name string
+		cmd  string
+		args []string
+		want bool
+	}{
+		// nmcli: 跳过全局选项后按对象后的动词判断
+		{name: "nmcli device show", cmd: "nmcli", args: []string{"-t", "--escape", "yes", "device", "show", "wlan0"}, want: true},
+		{name: "nmcli -f connection show", cmd: "nmcli", args: []string{"-t", "--escape", "yes", "-f", "NAME,TYPE", "connection", "show"}, want: true},
+		{name: "nmcli -s -g connection show", cmd: "nmcli", args: []string{"-s", "-g", "802-11-wireless.ssid,802-11-wireless-security.psk", "connection", "show", "id", "Home"}, want: true},
+		{name: "nmcli device status", cmd: "nmcli", args: []string{"device", "status"}, want: true},
+		{name: "nmcli device wifi list", cmd: "nmcli", args: []string{"-t", "device", "wifi", "list", "ifname", "wlan0"}, want: true},
+		{name: "nmcli device wifi connect", cmd: "nmcli", args: []string{"device", "wifi", "connect", "Home", "password", "secret"}},
+		{name: "nmcli device wifi缺少动词", cmd: "nmcli", args: []string{"device", "wifi"}},
+		{name: "连接名包含show", cmd: "nmcli", args: []string{"connection", "modify", "showroom", "ipv4.method", "manual"}},
+		{name: "连接名为show", cmd: "nmcli", args: []string{"connection", "delete", "id", "show"}},
+		{name: "字段列表为show", cmd: "nmcli", args: []string{"-f", "show", "connection", "up", "Home"}},
+		{name: "连接名包含list", cmd: "nmcli", args: []string{"connection", "up", "id", "guest list"}},
+		{name: "nmcli device set", cmd: "nmcli", args: []string{"device", "set", "wlan0", "managed", "no"}},
+		{name: "nmcli只有对象", cmd: "nmcli", args: []string{"general"}},
+
+		// PowerShell: 只有列表中的完整脚本是只读的
+		{name: "系统版本", cmd: "powershell", args: []string{"-Command", psOSVersionScript}, want: true},
+		{name: "执行策略", cmd: "powershell", args: powershell(psUserExecutionPolicyScript), want: true},
+		{name: "硬件信息", cmd: "powershell", args: powershell(fmt.Sprintf(psHardwareInfoScript, "以太网 2", "以太网 2")), want: true},
+		{name: "驱动信息", cmd: "powershell", args: []string{"-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-Command", fmt.Sprintf(psDriverInfoScript, "Wi-Fi", "Wi-Fi")}, want: true},
+		{name: "地址来源(转义的单引号)", cmd: "powershell", args: powershell(fmt.Sprintf(psAddressOriginsScript, "O''Brien")), want: true},
+		{name: "IPv6 DNS", cmd: "powershell", args: powershell(fmt.Sprintf(psDNSServersScript, "Ethernet", "IPv6")), want: true},
+		{name: "网卡名中插入命令", cmd: "powershell", args: powershell(fmt.Sprintf(psPhysicalAdapterScript, "x' } | Disable-NetAdapter -Confirm:$false; { '"))},
+		{name: "双引号中的子表达式", cmd: "powershell", args: powershell(fmt.Sprintf(psDNSServersScript, "$(Remove-NetIPAddress -Confirm:$false)", "IPv4"))},
+		{name: "只读脚本后追加命令", cmd: "powershell", args: powershell(psExecutionPolicyScript + "; Set-ExecutionPolicy Unrestricted")},
+		{name: "Get-开头的修改管道", cmd: "powershell", args: powershell("Get-NetAdapter | Disable-NetAdapter -Confirm:$false")},
+		{name: "设置执行策略", cmd: "powershell", args: powershell("Set-ExecutionPolicy -Scope CurrentUser -ExecutionPolicy RemoteSigned -Force")},
+		{name: "脚本文件", cmd: "powershell", args: []string{"-NoProfile", "-NonInteractive", "-File", "hotspot.ps1", "diagnostic"}},
+		{name: "-Command后有多余参数", cmd: "powershell", args: []string{"-Command", psExecutionPolicyScript, "Set-ExecutionPolicy"}},
+
+		// netsh: 跳过上下文后按动词判断
+		{name: "netsh show", cmd: "netsh", args: []string{"wlan", "show", "interfaces"}, want: true},
+		{name: "netsh interface show", cmd: "netsh", args: []string{"interface", "show", "interface", "Ethernet"}, want: true},
+		{name: "netsh ipv4 show", cmd: "netsh", args: []string{"interface", "ipv4", "show", "dnsservers", `name="Ethernet"`}, want: true},
+		{name: "netsh set", cmd: "netsh", args: []string{"interface", "ipv4", "set", "address", "name=Ethernet", "source=dhcp"}},
+		{name: "netsh网卡名为show", cmd: "netsh", args: []string{"interface", "ipv4", "set", "address", "show", "dhcp"}},
+		{name: "netsh配置名为show", cmd: "netsh", args: []string{"wlan", "delete", "profile", "name", "show"}},
+		{name: "netsh停止承载网络", cmd: "netsh", args: []string{"wlan", "stop", "hostednetwork"}},
+		{name: "netsh只有上下文", cmd: "netsh", args: []string{"wlan"}},
+
+		// iw/iwlist: 只有列表中的命令是只读的
+		{name: "iw dev", cmd: "iw", args: []string{"dev"}, want: true},
+		{name: "iw list", cmd: "iw", args: []string{"list"}, want: true},
+		{name: "iw scan", cmd: "iw", args: []string{"dev", "wlan0", "scan"}, want: true},
+		{name: "iw scan dump", cmd: "iw", args: []string{"dev", "wlan0", "scan", "dump"}, want: true},
+		{name: "iw link", cmd: "iw", args: []string{"dev", "wlan0", "link"}, want: true},
+		{name: "iw info", cmd: "iw", args: []string{"dev", "wlan0", "info"}, want: true},
+		{name: "iw station dump", cmd: "iw", args: []string{"dev", "wlan0", "station", "dump"}, want: true},
+		{name: "iw phy info", cmd: "iw", args: []string{"phy", "phy0", "info"}, want: true},
+		{name: "iw reg get", cmd: "iw", args: []string{"reg", "get"}, want: true},
+		{name: "iw删除网卡", cmd: "iw", args: []string{"dev", "wlan0", "del"}},
+		{name: "iw断开连接", cmd: "iw", args: []string{"dev", "wlan0", "disconnect"}},
+		{name: "iw添加虚拟网卡", cmd: "iw", args: []string{"phy", "phy0", "interface", "add", "ap0", "type", "__ap"}},
+		{name: "iw设置国家码", cmd: "iw", args: []string{"reg", "set", "CN"}},
+		{name: "iw网卡名为scan", cmd: "iw", args: []string{"dev", "scan", "set", "type", "monitor"}},
+		{name: "iw station del", cmd: "iw", args: []string{"dev", "wlan0", "station", "del", "aa:bb:cc:dd:ee:ff"}},
+		{name: "iwlist scan", cmd: "iwlist", args: []string{"wlan0", "scan"}, want: true},
+		{name: "iwlist带额外参数", cmd: "iwlist", args: []string{"wlan0", "scan", "essid", "Home"}},
+
+		// 其他命令
+		{name: "ipconfig /renew", cmd: "ipconfig", args: []string{"/renew"}},
+		{name: "route print", cmd: "route", args: []string{"print"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isReadOnlyCommand(tt.cmd, tt.args); got != tt.want {
+				t.Errorf("isReadOnlyCommand(%s %s) = %v，应为 %v", tt.cmd, strings.Join(tt.args, " "), got, tt.want)
+			}
+		})
+	}
+}
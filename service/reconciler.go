@@ -0,0 +1,433 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"networkconfig/models"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoDesiredState 表示尚未设置期望状态
+var ErrNoDesiredState = errors.New("desired state not set")
+
+// 漂移项类型
+const (
+	DriftKindInterface = "interface"
+	DriftKindHotspot   = "hotspot"
+)
+
+// Reconciler 期望状态调和服务
+// 定期比较期望状态与GetInterface/GetHotspotStatus返回的实际状态，并按需修正
+type Reconciler struct {
+	networkService *NetworkService
+	enabled        bool
+	interval       time.Duration
+	autoFix        bool
+	stateFile      string
+	mu             sync.Mutex
+	desired        *models.DesiredState
+	stopChan       chan struct{}
+	wg             sync.WaitGroup
+	debug          bool
+}
+
+// NewReconciler 创建期望状态调和服务，并加载已保存的期望状态
+func NewReconciler(networkService *NetworkService, debug bool) *Reconciler {
+	// 从环境变量读取配置
+	enabled := getEnvBool("RECONCILE_ENABLED", false)
+	interval := getEnvInt("RECONCILE_INTERVAL", 60)
+	autoFix := getEnvBool("RECONCILE_AUTO_FIX", true)
+	stateFile := os.Getenv("DESIRED_STATE_FILE")
+	if stateFile == "" {
+		stateFile = "desired_state.json"
+	}
+
+	r := &Reconciler{
+		networkService: networkService,
+		enabled:        enabled,
+		interval:       time.Duration(interval) * time.Second,
+		autoFix:        autoFix,
+		stateFile:      stateFile,
+		stopChan:       make(chan struct{}),
+		debug:          debug,
+	}
+	r.load()
+	return r
+}
+
+// Start 启动调和服务
+func (r *Reconciler) Start() {
+	if !r.enabled {
+		log.Println("期望状态调和服务未启用")
+		return
+	}
+
+	r.wg.Add(1)
+	go r.reconcileLoop()
+	log.Printf("期望状态调和服务已启动，检查间隔: %v, 自动修正: %v", r.interval, r.autoFix)
+}
+
+// Stop 停止调和服务
+func (r *Reconciler) Stop() {
+	if !r.enabled {
+		return
+	}
+
+	close(r.stopChan)
+	r.wg.Wait()
+	log.Println("期望状态调和服务已停止")
+}
+
+// reconcileLoop 调和循环
+func (r *Reconciler) reconcileLoop() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stopChan:
+			return
+		case <-ticker.C:
+			if _, ok := r.DesiredState(); !ok {
+				continue
+			}
+			if r.autoFix {
+				r.Reconcile()
+			} else if report := r.Drift(); !report.InSync {
+				log.Printf("检测到 %d 项配置与期望状态不一致，自动修正未启用", len(report.Items))
+			}
+		}
+	}
+}
+
+// DesiredState 返回当前期望状态，未设置时ok为false
+func (r *Reconciler) DesiredState() (state models.DesiredState, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.desired == nil {
+		return models.DesiredState{}, false
+	}
+	return *r.desired, true
+}
+
+// SetDesiredState 设置并保存期望状态
+func (r *Reconciler) SetDesiredState(state models.DesiredState) error {
+	if errs := validateDesiredState(state); len(errs) > 0 {
+		return errs
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.save(&state); err != nil {
+		return err
+	}
+	r.desired = &state
+	log.Printf("期望状态已更新: %d 个网卡, 热点: %v", len(state.Interfaces), state.Hotspot != nil)
+	return nil
+}
+
+// validateDesiredState 校验期望状态中的网卡名称和地址配置
+func validateDesiredState(state models.DesiredState) ValidationErrors {
+	var errs ValidationErrors
+	seen := make(map[string]bool)
+	for i, iface := range state.Interfaces {
+		prefix := fmt.Sprintf("interfaces[%d].", i)
+		if iface.Name == "" {
+			errs.add(prefix+"name", ValidationRequired, "网卡名称不能为空")
+		} else if seen[iface.Name] {
+			errs.add(prefix+"name", ValidationDuplicateInterface, "网卡 %s 重复", iface.Name)
+		}
+		seen[iface.Name] = true
+
+		if iface.IPv4Config != nil {
+			errs = append(errs, ValidateIPv4Config(*iface.IPv4Config).withPrefix(prefix)...)
+		}
+		if iface.IPv6Config != nil {
+			errs = append(errs, ValidateIPv6Config(*iface.IPv6Config).withPrefix(prefix)...)
+		}
+	}
+	return errs
+}
+
+// ClearDesiredState 清除期望状态，之后不再检查和修正
+func (r *Reconciler) ClearDesiredState() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.Remove(r.stateFile); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除期望状态文件失败: %v", err)
+	}
+	r.desired = nil
+	log.Println("期望状态已清除")
+	return nil
+}
+
+// load 从文件加载期望状态，文件不存在时保持未设置
+func (r *Reconciler) load() {
+	data, err := os.ReadFile(r.stateFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("读取期望状态文件 %s 失败: %v", r.stateFile, err)
+		}
+		return
+	}
+
+	var state models.DesiredState
+	if err := json.Unmarshal(data, &state); err != nil {
+		log.Printf("解析期望状态文件 %s 失败: %v", r.stateFile, err)
+		return
+	}
+	r.desired = &state
+	log.Printf("已从 %s 加载期望状态", r.stateFile)
+}
+
+// save 把期望状态写入文件，调用方需持有锁
+func (r *Reconciler) save(state *models.DesiredState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化期望状态失败: %v", err)
+	}
+	if dir := filepath.Dir(r.stateFile); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建期望状态目录失败: %v", err)
+		}
+	}
+	// 期望状态中可能包含热点密码
+	if err := os.WriteFile(r.stateFile, data, 0600); err != nil {
+		return fmt.Errorf("保存期望状态失败: %v", err)
+	}
+	return nil
+}
+
+// Drift 比较期望状态与实际状态，返回所有不一致的字段
+func (r *Reconciler) Drift() models.DriftReport {
+	report := models.DriftReport{
+		CheckedAt: time.Now(),
+		InSync:    true,
+		Items:     []models.DriftItem{},
+	}
+
+	desired, ok := r.scheduledDesiredState()
+	if !ok {
+		return report
+	}
+
+	for _, iface := range desired.Interfaces {
+		actual, err := r.networkService.GetInterface(iface.Name)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("获取接口 %s 信息失败: %v", iface.Name, err))
+			continue
+		}
+		report.Items = append(report.Items, interfaceDrift(iface, actual)...)
+	}
+
+	if desired.Hotspot != nil {
+		status, err := r.networkService.GetHotspotStatus()
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("获取热点状态失败: %v", err))
+		} else {
+			report.Items = append(report.Items, hotspotDrift(*desired.Hotspot, status)...)
+		}
+	}
+
+	report.InSync = len(report.Items) == 0 && len(report.Errors) == 0
+	if r.debug || !report.InSync {
+		log.Printf("期望状态检查完成: %d 项不一致, %d 个错误", len(report.Items), len(report.Errors))
+	}
+	return report
+}
+
+// Reconcile 检查漂移并把不一致的配置修正为期望状态，返回修正前的漂移报告
+// 有未确认变更的网卡会被跳过，避免覆盖正在等待确认的配置
+func (r *Reconciler) Reconcile() models.DriftReport {
+	report := r.Drift()
+	if len(report.Items) == 0 {
+		return report
+	}
+
+	desired, ok := r.scheduledDesiredState()
+	if !ok {
+		return report
+	}
+
+	// 按 类型/名称 汇总不一致的地址族或热点字段
+	drifted := make(map[string]map[string]bool)
+	for _, item := range report.Items {
+		key := item.Kind + "/" + item.Name
+		if drifted[key] == nil {
+			drifted[key] = make(map[string]bool)
+		}
+		drifted[key][strings.SplitN(item.Field, ".", 2)[0]] = true
+	}
+
+	for _, iface := range desired.Interfaces {
+		families := drifted[DriftKindInterface+"/"+iface.Name]
+		if len(families) == 0 {
+			continue
+		}
+		if r.networkService.hasPendingChange(iface.Name) {
+			log.Printf("接口 %s 有未确认的变更，跳过调和", iface.Name)
+			continue
+		}
+
+		var config models.InterfaceConfig
+		if families["ipv4"] {
+			config.IPv4Config = iface.IPv4Config
+		}
+		if families["ipv6"] {
+			config.IPv6Config = iface.IPv6Config
+		}
+		log.Printf("正在把接口 %s 修正为期望状态", iface.Name)
+		if err := r.networkService.ConfigureInterface(iface.Name, config); err != nil {
+			log.Printf("修正接口 %s 失败: %v", iface.Name, err)
+			report.Errors = append(report.Errors, fmt.Sprintf("修正接口 %s 失败: %v", iface.Name, err))
+		}
+	}
+
+	if fields := drifted[DriftKindHotspot+"/"+DriftKindHotspot]; desired.Hotspot != nil && len(fields) > 0 {
+		if err := r.fixHotspot(*desired.Hotspot, fields); err != nil {
+			log.Printf("修正热点失败: %v", err)
+			report.Errors = append(report.Errors, fmt.Sprintf("修正热点失败: %v", err))
+		}
+	}
+
+	return report
+}
+
+// fixHotspot 修正热点配置，SSID不一致时需要期望状态中提供密码
+func (r *Reconciler) fixHotspot(desired models.DesiredHotspot, fields map[string]bool) error {
+	if fields["ssid"] {
+		if desired.Password == "" {
+			return fmt.Errorf("修正热点SSID需要在期望状态中提供密码")
+		}
+		enabled := desired.Enabled == nil || *desired.Enabled
+		log.Printf("正在把热点SSID修正为 %s", desired.SSID)
+		return r.networkService.ConfigureHotspot(models.HotspotConfig{
+			SSID:     desired.SSID,
+			Password: desired.Password,
+			Enabled:  enabled,
+		})
+	}
+
+	if fields["enabled"] && desired.Enabled != nil {
+		log.Printf("正在把热点状态修正为 enabled=%v", *desired.Enabled)
+		return r.networkService.SetHotspotStatus(*desired.Enabled)
+	}
+	return nil
+}
+
+// interfaceDrift 比较单个网卡的期望配置与实际配置
+// DriftItem.Name为网卡名称，Field以ipv4./ipv6.开头
+func interfaceDrift(desired models.DesiredInterface, actual models.Interface) []models.DriftItem {
+	var items []models.DriftItem
+	add := func(field, want, got string) {
+		if want != got {
+			items = append(items, models.DriftItem{
+				Kind:    DriftKindInterface,
+				Name:    desired.Name,
+				Field:   field,
+				Desired: want,
+				Actual:  got,
+			})
+		}
+	}
+
+	if desired.IPv4Config != nil {
+		// 期望状态可能只写了CIDR形式的address，先展开再比较
+		v4, _ := NormalizeIPv4Config(*desired.IPv4Config)
+		add("ipv4.dhcp", fmt.Sprint(v4.DHCP), fmt.Sprint(actual.DHCPEnabled))
+		if !v4.DHCP {
+			add("ipv4.ip", v4.IP, actual.IPv4Config.IP)
+			add("ipv4.mask", v4.Mask, actual.IPv4Config.Mask)
+			if len(v4.Addresses) > 1 {
+				add("ipv4.addresses", sortedJoin(v4.Addresses), sortedJoin(actual.IPv4Config.Addresses))
+			}
+			if v4.Gateway != "" {
+				add("ipv4.gateway", v4.Gateway, actual.IPv4Config.Gateway)
+			}
+		}
+		if !(v4.DHCP && v4.DNSAuto) && len(v4.DNS) > 0 {
+			add("ipv4.dns", strings.Join(v4.DNS, ","), strings.Join(actual.IPv4Config.DNS, ","))
+		}
+	}
+
+	if desired.IPv6Config != nil {
+		v6, _ := NormalizeIPv6Config(*desired.IPv6Config)
+		if len(v6.Addresses) > 1 {
+			// 链路本地地址由系统生成，不参与比较
+			add("ipv6.addresses", sortedJoin(v6.Addresses), sortedJoin(globalIPv6Addresses(actual.IPv6Config)))
+		}
+		if want, got := net.ParseIP(v6.IP), net.ParseIP(actual.IPv6Config.IP); want == nil || !want.Equal(got) {
+			add("ipv6.ip", v6.IP, actual.IPv6Config.IP)
+		}
+		if v6.PrefixLen > 0 {
+			add("ipv6.prefix_len", fmt.Sprint(v6.PrefixLen), fmt.Sprint(actual.IPv6Config.PrefixLen))
+		}
+		if v6.Gateway != "" {
+			add("ipv6.gateway", v6.Gateway, actual.IPv6Config.Gateway)
+		}
+		if len(v6.DNS) > 0 {
+			add("ipv6.dns", strings.Join(v6.DNS, ","), strings.Join(actual.IPv6Config.DNS, ","))
+		}
+	}
+
+	return items
+}
+
+// sortedJoin 排序后用逗号连接，用于比较与顺序无关的地址列表
+func sortedJoin(values []string) string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// hotspotDrift 比较热点的期望配置与实际状态
+// DriftItem.Name固定为hotspot，Field为ssid或enabled
+func hotspotDrift(desired models.DesiredHotspot, status models.HotspotStatus) []models.DriftItem {
+	var items []models.DriftItem
+	if desired.SSID != "" && desired.SSID != status.SSID {
+		items = append(items, models.DriftItem{
+			Kind: DriftKindHotspot, Name: DriftKindHotspot, Field: "ssid",
+			Desired: desired.SSID, Actual: status.SSID,
+		})
+	}
+	if desired.Enabled != nil && *desired.Enabled != status.Enabled {
+		items = append(items, models.DriftItem{
+			Kind: DriftKindHotspot, Name: DriftKindHotspot, Field: "enabled",
+			Desired: fmt.Sprint(*desired.Enabled), Actual: fmt.Sprint(status.Enabled),
+		})
+	}
+	return items
+}
+
+// scheduledDesiredState 返回期望状态，热点计划规则有开关要求时以计划为准
+func (r *Reconciler) scheduledDesiredState() (models.DesiredState, bool) {
+	desired, ok := r.DesiredState()
+	if !ok || desired.Hotspot == nil {
+		return desired, ok
+	}
+	if enabled, scheduled := r.networkService.hotspotScheduledState(); scheduled {
+		hotspot := *desired.Hotspot
+		hotspot.Enabled = &enabled
+		desired.Hotspot = &hotspot
+	}
+	return desired, ok
+}
+
+// hotspotDisabled 期望状态是否要求关闭热点
+func (r *Reconciler) hotspotDisabled() bool {
+	desired, ok := r.scheduledDesiredState()
+	return ok && desired.Hotspot != nil && desired.Hotspot.Enabled != nil && !*desired.Hotspot.Enabled
+}
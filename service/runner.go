@@ -0,0 +1,409 @@
+package service
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// CommandRunner 执行外部命令(netsh、PowerShell、nmcli、iwlist等)的抽象
+// 通过注入不同实现，可以录制真实输出或在没有目标系统的环境中回放
+type CommandRunner interface {
+	// Run 执行命令，combined为true时返回标准输出和标准错误的合并内容
+	Run(cmd *Command, combined bool) ([]byte, error)
+	// LookPath 在PATH中查找可执行文件
+	LookPath(file string) (string, error)
+}
+
+// Command 描述一次外部命令调用，方法与exec.Cmd的常用方法保持一致
+type Command struct {
+	Name   string
+	Args   []string
+	Env    []string // 完整环境变量，nil表示继承当前进程环境
+	runner CommandRunner
+}
+
+// NewCommand 创建由指定runner执行的命令
+func NewCommand(runner CommandRunner, name string, args ...string) *Command {
+	if runner == nil {
+		runner = ExecRunner{}
+	}
+	return &Command{Name: name, Args: args, runner: runner}
+}
+
+// Output 执行命令并返回标准输出
+func (c *Command) Output() ([]byte, error) {
+	return c.runner.Run(c, false)
+}
+
+// CombinedOutput 执行命令并返回标准输出和标准错误
+func (c *Command) CombinedOutput() ([]byte, error) {
+	return c.runner.Run(c, true)
+}
+
+// Run 执行命令，只关心是否成功
+func (c *Command) Run() error {
+	_, err := c.runner.Run(c, true)
+	return err
+}
+
+// String 返回便于记录日志的命令行
+func (c *Command) String() string {
+	return strings.TrimSpace(c.Name + " " + strings.Join(c.Args, " "))
+}
+
+// ExecRunner 使用os/exec真实执行命令
+type ExecRunner struct{}
+
+// Run 执行命令
+func (ExecRunner) Run(c *Command, combined bool) ([]byte, error) {
+	cmd := exec.Command(c.Name, c.Args...)
+	cmd.Env = c.Env
+	if combined {
+		return cmd.CombinedOutput()
+	}
+	return cmd.Output()
+}
+
+// LookPath 在PATH中查找可执行文件
+func (ExecRunner) LookPath(file string) (string, error) {
+	return exec.LookPath(file)
+}
+
+// CommandFixture 一次命令调用的录制结果
+type CommandFixture struct {
+	Name         string   `json:"name"`
+	Args         []string `json:"args"`
+	Combined     bool     `json:"combined"`
+	Output       string   `json:"output,omitempty"`
+	OutputBase64 string   `json:"output_base64,omitempty"` // 输出不是合法UTF-8(如GBK)时使用
+	Stderr       string   `json:"stderr,omitempty"`
+	ExitCode     int      `json:"exit_code"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// lookPathFixtureName LookPath调用在录制文件中使用的伪命令名
+const lookPathFixtureName = "@lookpath"
+
+// ReplayError 回放录制的失败调用时返回的错误
+type ReplayError struct {
+	ExitCode int
+	Stderr   string
+	Message  string
+}
+
+func (e *ReplayError) Error() string {
+	return e.Message
+}
+
+// commandStderr 返回命令失败时的标准错误输出，支持真实执行的*exec.ExitError和回放的*ReplayError
+func commandStderr(err error) ([]byte, bool) {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Stderr, true
+	}
+	var replayErr *ReplayError
+	if errors.As(err, &replayErr) {
+		return []byte(replayErr.Stderr), true
+	}
+	return nil, false
+}
+
+// ErrFixtureNotFound 表示回放目录中没有对应命令的录制结果
+var ErrFixtureNotFound = errors.New("command fixture not found")
+
+var fixtureNameRegex = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// redactedValue 录制文件中代替密码的占位符
+const redactedValue = "******"
+
+// secretArgNames 后一个参数是密码的nmcli参数名
+var secretArgNames = []string{"password", "psk", "wifi-sec.psk", "802-11-wireless-security.psk", "wifi-sec.wep-key0"}
+
+var (
+	// PowerShell脚本中的Passphrase = '...'，单引号字符串中的'写作''
+	passphraseScriptRegex = regexp.MustCompile(`(Passphrase\s*=\s*)'(?:[^']|'')*'`)
+	// ConvertTo-Json输出中的"Passphrase": "..."
+	passphraseJSONRegex = regexp.MustCompile(`("Passphrase"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	// netsh wlan show profile key=clear和show hostednetwork setting=security输出中的密钥
+	netshKeyLineRegex = regexp.MustCompile(`(?m)^(\s*(?:Key Content|关键内容|User security key|用户安全密钥)\s*:)[^\r\n]*`)
+)
+
+// redactArgs 返回把密码替换为占位符后的参数副本：
+// netsh的key=<密码>、nmcli的password/psk <密码>、PowerShell脚本中的Passphrase = '<密码>'
+func redactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		switch {
+		case i > 0 && containsString(secretArgNames, args[i-1]):
+			arg = redactedValue
+		case strings.HasPrefix(arg, "key=") && arg != "key=clear":
+			arg = "key=" + redactedValue
+		default:
+			arg = passphraseScriptRegex.ReplaceAllString(arg, "${1}'"+redactedValue+"'")
+		}
+		redacted[i] = arg
+	}
+	return redacted
+}
+
+// redactOutput 把命令输出中的密码替换为占位符，GBK编码的输出先转换为UTF-8处理再转换回去
+func redactOutput(name string, args []string, output []byte) []byte {
+	text := string(output)
+	gbk := !utf8.Valid(output)
+	if gbk {
+		decoded, err := DecodeToUTF8(output)
+		if err != nil {
+			return output
+		}
+		text = string(decoded)
+	}
+
+	text = netshKeyLineRegex.ReplaceAllString(text, "${1} "+redactedValue)
+	text = passphraseJSONRegex.ReplaceAllString(text, `${1}"`+redactedValue+`"`)
+	if name == "nmcli" {
+		text = redactNmcliValues(args, text)
+	}
+
+	if gbk {
+		encoded, err := simplifiedchinese.GBK.NewEncoder().String(text)
+		if err != nil {
+			return output
+		}
+		text = encoded
+	}
+	return []byte(text)
+}
+
+// redactNmcliValues nmcli -g按字段顺序每行输出一个值，把psk字段所在的行替换为占位符
+func redactNmcliValues(args []string, text string) string {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] != "-g" && args[i] != "--get-values" {
+			continue
+		}
+		lines := strings.Split(text, "\n")
+		for j, field := range strings.Split(args[i+1], ",") {
+			if strings.HasSuffix(field, ".psk") && j < len(lines) && lines[j] != "" {
+				lines[j] = redactedValue
+			}
+		}
+		return strings.Join(lines, "\n")
+	}
+	return text
+}
+
+// fixtureKey 根据命令名和参数生成录制文件名
+func fixtureKey(name string, args []string) string {
+	sum := sha1.Sum([]byte(name + "\x00" + strings.Join(args, "\x00")))
+	prefix := fixtureNameRegex.ReplaceAllString(strings.Join(append([]string{name}, args...), "_"), "_")
+	if len(prefix) > 48 {
+		prefix = prefix[:48]
+	}
+	return strings.Trim(prefix, "_") + "-" + hex.EncodeToString(sum[:])[:12]
+}
+
+// RecordingRunner 执行真实命令并把输出录制到目录下，每条不同的命令一个JSON文件
+// 参数和输出中的密码在写入文件前替换为占位符，回放时按替换后的参数匹配
+type RecordingRunner struct {
+	inner CommandRunner
+	dir   string
+	mu    sync.Mutex
+}
+
+// NewRecordingRunner 创建录制runner，inner为nil时使用ExecRunner
+func NewRecordingRunner(inner CommandRunner, dir string) (*RecordingRunner, error) {
+	if inner == nil {
+		inner = ExecRunner{}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建录制目录失败: %v", err)
+	}
+	return &RecordingRunner{inner: inner, dir: dir}, nil
+}
+
+// Run 执行命令并录制结果
+func (r *RecordingRunner) Run(c *Command, combined bool) ([]byte, error) {
+	output, err := r.inner.Run(c, combined)
+
+	fixture := CommandFixture{
+		Name:     c.Name,
+		Args:     redactArgs(c.Args),
+		Combined: combined,
+	}
+	if recorded := redactOutput(c.Name, c.Args, output); utf8.Valid(recorded) {
+		fixture.Output = string(recorded)
+	} else {
+		fixture.OutputBase64 = base64.StdEncoding.EncodeToString(recorded)
+	}
+	if err != nil {
+		fixture.Error = err.Error()
+		fixture.ExitCode = -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			fixture.ExitCode = exitErr.ExitCode()
+			fixture.Stderr = string(redactOutput(c.Name, c.Args, exitErr.Stderr))
+		}
+	}
+
+	r.save(fixture)
+	return output, err
+}
+
+// LookPath 查找可执行文件并录制结果
+func (r *RecordingRunner) LookPath(file string) (string, error) {
+	path, err := r.inner.LookPath(file)
+
+	fixture := CommandFixture{Name: lookPathFixtureName, Args: []string{file}, Output: path}
+	if err != nil {
+		fixture.Error = err.Error()
+		fixture.ExitCode = -1
+	}
+	r.save(fixture)
+	return path, err
+}
+
+// save 把录制结果追加到该命令的录制文件中
+func (r *RecordingRunner) save(fixture CommandFixture) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	path := filepath.Join(r.dir, fixtureKey(fixture.Name, fixture.Args)+".json")
+
+	var fixtures []CommandFixture
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &fixtures); err != nil {
+			log.Printf("解析已有录制文件 %s 失败，将覆盖: %v", path, err)
+			fixtures = nil
+		}
+	}
+	fixtures = append(fixtures, fixture)
+
+	data, err := json.MarshalIndent(fixtures, "", "  ")
+	if err != nil {
+		log.Printf("序列化录制结果失败: %v", err)
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Printf("写入录制文件 %s 失败: %v", path, err)
+	}
+}
+
+// ReplayRunner 从录制目录回放命令输出，不执行任何真实命令
+// 同一命令多次调用时按录制顺序依次返回，超出次数后重复最后一次结果
+type ReplayRunner struct {
+	mu       sync.Mutex
+	fixtures map[string][]CommandFixture
+	calls    map[string]int
+}
+
+// NewReplayRunner 加载录制目录中的所有JSON文件
+func NewReplayRunner(dir string) (*ReplayRunner, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("读取回放目录失败: %v", err)
+	}
+
+	r := &ReplayRunner{
+		fixtures: make(map[string][]CommandFixture),
+		calls:    make(map[string]int),
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("读取录制文件 %s 失败: %v", file, err)
+		}
+		var fixtures []CommandFixture
+		if err := json.Unmarshal(data, &fixtures); err != nil {
+			return nil, fmt.Errorf("解析录制文件 %s 失败: %v", file, err)
+		}
+		for _, fixture := range fixtures {
+			r.Add(fixture)
+		}
+	}
+	return r, nil
+}
+
+// Add 添加一条录制结果，可用于在代码中直接构造回放数据
+func (r *ReplayRunner) Add(fixture CommandFixture) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.fixtures == nil {
+		r.fixtures = make(map[string][]CommandFixture)
+		r.calls = make(map[string]int)
+	}
+	key := fixtureKey(fixture.Name, redactArgs(fixture.Args))
+	r.fixtures[key] = append(r.fixtures[key], fixture)
+}
+
+// next 取出命令对应的下一条录制结果，参数中的密码不参与匹配
+func (r *ReplayRunner) next(name string, args []string) (CommandFixture, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := fixtureKey(name, redactArgs(args))
+	fixtures := r.fixtures[key]
+	if len(fixtures) == 0 {
+		return CommandFixture{}, false
+	}
+	i := r.calls[key]
+	if i >= len(fixtures) {
+		i = len(fixtures) - 1
+	}
+	r.calls[key]++
+	return fixtures[i], true
+}
+
+// Run 回放命令输出
+func (r *ReplayRunner) Run(c *Command, combined bool) ([]byte, error) {
+	fixture, ok := r.next(c.Name, c.Args)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrFixtureNotFound, c)
+	}
+
+	output := []byte(fixture.Output)
+	if fixture.OutputBase64 != "" {
+		decoded, err := base64.StdEncoding.DecodeString(fixture.OutputBase64)
+		if err != nil {
+			return nil, fmt.Errorf("解码录制输出失败: %v", err)
+		}
+		output = decoded
+	}
+	if combined && !fixture.Combined && fixture.Stderr != "" {
+		output = append(output, fixture.Stderr...)
+	}
+
+	if fixture.Error != "" {
+		return output, &ReplayError{
+			ExitCode: fixture.ExitCode,
+			Stderr:   fixture.Stderr,
+			Message:  fixture.Error,
+		}
+	}
+	return output, nil
+}
+
+// LookPath 回放可执行文件查找结果
+func (r *ReplayRunner) LookPath(file string) (string, error) {
+	fixture, ok := r.next(lookPathFixtureName, []string{file})
+	if !ok {
+		return "", fmt.Errorf("%w: %s %s", ErrFixtureNotFound, lookPathFixtureName, file)
+	}
+	if fixture.Error != "" {
+		return "", &ReplayError{ExitCode: fixture.ExitCode, Message: fixture.Error}
+	}
+	return fixture.Output, nil
+}
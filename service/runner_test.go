@@ -0,0 +1,123 @@
+package service
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+func TestRecordingRunnerRedactsSecrets(t *testing.T) {
+	const secret = "Secret123"
+	gbkOutput, err := simplifiedchinese.GBK.NewEncoder().String("    关键内容            : " + secret + "\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		cmd    string
+		args   []string
+		output string
+	}{
+		{
+			name: "netsh承载网络密钥",
+			cmd:  "netsh",
+			args: []string{"wlan", "set", "hostednetwork", "mode=allow", "ssid=NetConfig-AP", "key=" + secret},
+		},
+		{
+			name: "nmcli连接密码",
+			cmd:  "nmcli",
+			args: []string{"device", "wifi", "connect", "Home", "password", secret, "ifname", "wlan0"},
+		},
+		{
+			name: "nmcli修改psk",
+			cmd:  "nmcli",
+			args: []string{"connection", "modify", "Home", "wifi-sec.key-mgmt", "wpa-psk", "wifi-sec.psk", secret},
+		},
+		{
+			name: "PowerShell脚本中的Passphrase",
+			cmd:  "powershell",
+			args: []string{"-Command", "$config.Ssid = 'AP'\n$config.Passphrase = 'Sec''" + secret + "'\n"},
+		},
+		{
+			name:   "netsh配置文件密钥输出",
+			cmd:    "netsh",
+			args:   []string{"wlan", "show", "profile", "name=Home", "key=clear"},
+			output: "    Authentication         : WPA2-Personal\r\n    Key Content            : " + secret + "\r\n",
+		},
+		{
+			name:   "GBK编码的netsh输出",
+			cmd:    "netsh",
+			args:   []string{"wlan", "show", "profile", "name=办公室", "key=clear"},
+			output: gbkOutput,
+		},
+		{
+			name:   "PowerShell输出中的Passphrase",
+			cmd:    "powershell",
+			args:   []string{"-Command", "Get-HotspotConfig"},
+			output: "{\r\n    \"SSID\":  \"AP\",\r\n    \"Passphrase\":  \"" + secret + "\"\r\n}\r\n",
+		},
+		{
+			name:   "nmcli -g输出的psk",
+			cmd:    "nmcli",
+			args:   []string{"-s", "-g", "802-11-wireless.ssid,802-11-wireless-security.key-mgmt,802-11-wireless-security.psk", "connection", "show", "id", "Home"},
+			output: "Home\nwpa-psk\n" + secret + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &ReplayRunner{}
+			inner.Add(CommandFixture{Name: tt.cmd, Args: tt.args, Combined: true, Output: tt.output})
+
+			dir := t.TempDir()
+			recorder, err := NewRecordingRunner(inner, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			output, err := NewCommand(recorder, tt.cmd, tt.args...).CombinedOutput()
+			if err != nil {
+				t.Fatalf("录制命令失败: %v", err)
+			}
+			if string(output) != tt.output {
+				t.Errorf("录制时应返回原始输出，得到: %q", output)
+			}
+
+			files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+			if len(files) != 1 {
+				t.Fatalf("应生成1个录制文件，得到 %d 个", len(files))
+			}
+			data, err := os.ReadFile(files[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Contains(data, []byte(secret)) {
+				t.Errorf("录制文件中包含密码:\n%s", data)
+			}
+
+			// 回放时密码不参与匹配，使用其他密码的同一命令也能找到录制结果
+			replay, err := NewReplayRunner(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			args := make([]string, len(tt.args))
+			for i, arg := range tt.args {
+				args[i] = strings.ReplaceAll(arg, secret, "Other456")
+			}
+			replayed, err := NewCommand(replay, tt.cmd, args...).CombinedOutput()
+			if err != nil {
+				t.Fatalf("回放命令失败: %v", err)
+			}
+			if tt.output != "" {
+				text, _ := DecodeToUTF8(replayed)
+				if !strings.Contains(string(text), redactedValue) {
+					t.Errorf("回放输出中的密码应为占位符，得到: %q", text)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,382 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"networkconfig/models"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 计划任务的检查间隔和保留的历史记录条数
+const (
+	schedulerTick       = 20 * time.Second
+	schedulerMaxHistory = 200
+)
+
+// 轮换密码的默认长度，生成的密码只使用不易混淆的字母和数字，便于口头传达
+const (
+	defaultRotatedPasswordLength = 16
+	passwordAlphabet             = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz23456789"
+)
+
+// Scheduler 热点计划任务服务，按cron规则开关热点和轮换密码
+// 规则和执行历史保存在同一个文件中，服务重启后继续生效
+type Scheduler struct {
+	networkService *NetworkService
+	file           string
+	mu             sync.Mutex
+	rules          []models.ScheduleRule
+	history        []models.ScheduledAction
+	lastCheck      time.Time
+	stopChan       chan struct{}
+	wg             sync.WaitGroup
+	debug          bool
+}
+
+// scheduleFile 计划任务文件内容
+type scheduleFile struct {
+	Rules   []models.ScheduleRule    `json:"rules"`
+	History []models.ScheduledAction `json:"history"`
+}
+
+// NewScheduler 创建计划任务服务，并加载已保存的规则
+func NewScheduler(networkService *NetworkService, debug bool) *Scheduler {
+	file := os.Getenv("HOTSPOT_SCHEDULE_FILE")
+	if file == "" {
+		file = "hotspot_schedule.json"
+	}
+
+	s := &Scheduler{
+		networkService: networkService,
+		file:           file,
+		rules:          []models.ScheduleRule{},
+		history:        []models.ScheduledAction{},
+		stopChan:       make(chan struct{}),
+		debug:          debug,
+	}
+	s.load()
+	return s
+}
+
+// Start 启动计划任务服务，先按最近一次应触发的开关规则同步热点状态
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	s.lastCheck = time.Now()
+	s.mu.Unlock()
+
+	if enabled, ruleID, ok := s.hotspotWanted(time.Now()); ok {
+		status, err := s.networkService.GetHotspotStatus()
+		if err == nil && status.Enabled != enabled {
+			action := models.ScheduleActionDisable
+			if enabled {
+				action = models.ScheduleActionEnable
+			}
+			log.Printf("按计划规则 %s 同步热点状态: enabled=%v", ruleID, enabled)
+			s.run(models.ScheduleRule{ID: ruleID, Action: action}, time.Now())
+		}
+	}
+
+	s.wg.Add(1)
+	go s.scheduleLoop()
+	log.Printf("热点计划任务服务已启动，共 %d 条规则", len(s.Schedule().Rules))
+}
+
+// Stop 停止计划任务服务
+func (s *Scheduler) Stop() {
+	close(s.stopChan)
+	s.wg.Wait()
+	log.Println("热点计划任务服务已停止")
+}
+
+// scheduleLoop 定期检查是否有到期的规则
+func (s *Scheduler) scheduleLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+			s.runDue(time.Now())
+		}
+	}
+}
+
+// runDue 执行上次检查之后到期的规则，同一规则错过多次时只执行一次
+func (s *Scheduler) runDue(now time.Time) {
+	s.mu.Lock()
+	since := s.lastCheck
+	s.lastCheck = now
+	rules := append([]models.ScheduleRule{}, s.rules...)
+	s.mu.Unlock()
+	if since.IsZero() {
+		return
+	}
+
+	for _, rule := range rules {
+		if rule.Disabled {
+			continue
+		}
+		cron, err := parseCron(rule.Cron)
+		if err != nil {
+			continue
+		}
+		if at, ok := cron.next(since); ok && !at.After(now) {
+			s.run(rule, at)
+		}
+	}
+}
+
+// run 执行一条规则的动作并记录结果
+func (s *Scheduler) run(rule models.ScheduleRule, at time.Time) {
+	var err error
+	switch rule.Action {
+	case models.ScheduleActionEnable:
+		if err = s.networkService.SetHotspotStatus(true); err == nil {
+			if aclErr := s.networkService.reapplyHotspotACL(); aclErr != nil {
+				log.Printf("重新应用热点过滤列表失败: %v", aclErr)
+			}
+		}
+	case models.ScheduleActionDisable:
+		err = s.networkService.SetHotspotStatus(false)
+	case models.ScheduleActionRotatePassword:
+		err = s.networkService.RotateHotspotPassword(rule.PasswordLength)
+	default:
+		err = fmt.Errorf("未知的计划动作 %s", rule.Action)
+	}
+
+	record := models.ScheduledAction{
+		RuleID: rule.ID,
+		Action: rule.Action,
+		Time:   at,
+		Status: models.ScheduleStatusSuccess,
+	}
+	if err != nil {
+		record.Status = models.ScheduleStatusFailed
+		record.Error = err.Error()
+		log.Printf("执行计划规则 %s (%s) 失败: %v", rule.ID, rule.Action, err)
+	} else {
+		log.Printf("已执行计划规则 %s (%s)", rule.ID, rule.Action)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = append(s.history, record)
+	if len(s.history) > schedulerMaxHistory {
+		s.history = s.history[len(s.history)-schedulerMaxHistory:]
+	}
+	if err := s.save(); err != nil {
+		log.Printf("保存计划任务历史失败: %v", err)
+	}
+}
+
+// hotspotWanted 返回按开关规则此刻热点应处的状态，即最近一次已触发的enable/disable规则
+// 没有开关规则时ok为false
+func (s *Scheduler) hotspotWanted(now time.Time) (enabled bool, ruleID string, ok bool) {
+	var latest time.Time
+	for _, rule := range s.Schedule().Rules {
+		if rule.Disabled || (rule.Action != models.ScheduleActionEnable && rule.Action != models.ScheduleActionDisable) {
+			continue
+		}
+		cron, err := parseCron(rule.Cron)
+		if err != nil {
+			continue
+		}
+		if at, found := cron.prev(now); found && (!ok || at.After(latest)) {
+			latest, enabled, ruleID, ok = at, rule.Action == models.ScheduleActionEnable, rule.ID, true
+		}
+	}
+	return enabled, ruleID, ok
+}
+
+// Schedule 返回当前计划规则
+func (s *Scheduler) Schedule() models.HotspotSchedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return models.HotspotSchedule{Rules: append([]models.ScheduleRule{}, s.rules...)}
+}
+
+// SetSchedule 校验并保存计划规则，未指定ID的规则自动编号
+func (s *Scheduler) SetSchedule(schedule models.HotspotSchedule) (models.HotspotSchedule, error) {
+	rules := make([]models.ScheduleRule, 0, len(schedule.Rules))
+	var errs ValidationErrors
+	ids := make(map[string]bool)
+	for i, rule := range schedule.Rules {
+		prefix := fmt.Sprintf("rules[%d].", i)
+		if rule.ID == "" {
+			rule.ID = fmt.Sprintf("rule-%d", i+1)
+		}
+		if ids[rule.ID] {
+			errs.add(prefix+"id", ValidationInvalidValue, "规则ID %s 重复", rule.ID)
+		}
+		ids[rule.ID] = true
+
+		if _, err := parseCron(rule.Cron); err != nil {
+			errs.add(prefix+"cron", ValidationInvalidValue, "%v", err)
+		}
+		switch rule.Action {
+		case models.ScheduleActionEnable, models.ScheduleActionDisable:
+		case models.ScheduleActionRotatePassword:
+			if rule.PasswordLength != 0 && (rule.PasswordLength < 8 || rule.PasswordLength > 63) {
+				errs.add(prefix+"password_length", ValidationInvalidValue, "密码长度必须为8-63个字符")
+			}
+		default:
+			errs.add(prefix+"action", ValidationInvalidValue, "动作 %s 无效，应为enable、disable或rotate_password", rule.Action)
+		}
+		rules = append(rules, rule)
+	}
+	if len(errs) > 0 {
+		return models.HotspotSchedule{}, errs
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous := s.rules
+	s.rules = rules
+	if err := s.save(); err != nil {
+		s.rules = previous
+		return models.HotspotSchedule{}, err
+	}
+	log.Printf("热点计划规则已更新: %d 条", len(rules))
+	return models.HotspotSchedule{Rules: append([]models.ScheduleRule{}, rules...)}, nil
+}
+
+// Actions 返回今后limit个计划动作和最近limit条执行记录
+func (s *Scheduler) Actions(now time.Time, limit int) models.ScheduledActions {
+	result := models.ScheduledActions{
+		Upcoming: []models.ScheduledAction{},
+		Past:     []models.ScheduledAction{},
+	}
+
+	// 每条规则最多取limit个触发时间，合并后再取最早的limit个
+	for _, rule := range s.Schedule().Rules {
+		if rule.Disabled {
+			continue
+		}
+		cron, err := parseCron(rule.Cron)
+		if err != nil {
+			continue
+		}
+		at := now
+		for i := 0; i < limit; i++ {
+			next, ok := cron.next(at)
+			if !ok {
+				break
+			}
+			result.Upcoming = append(result.Upcoming, models.ScheduledAction{
+				RuleID: rule.ID,
+				Action: rule.Action,
+				Time:   next,
+				Status: models.ScheduleStatusPending,
+			})
+			at = next
+		}
+	}
+	sort.SliceStable(result.Upcoming, func(i, j int) bool {
+		return result.Upcoming[i].Time.Before(result.Upcoming[j].Time)
+	})
+	if len(result.Upcoming) > limit {
+		result.Upcoming = result.Upcoming[:limit]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.history) - 1; i >= 0 && len(result.Past) < limit; i-- {
+		result.Past = append(result.Past, s.history[i])
+	}
+	return result
+}
+
+// load 从文件加载规则和历史，文件不存在时为空
+func (s *Scheduler) load() {
+	data, err := os.ReadFile(s.file)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("读取热点计划任务文件 %s 失败: %v", s.file, err)
+		}
+		return
+	}
+
+	var content scheduleFile
+	if err := json.Unmarshal(data, &content); err != nil {
+		log.Printf("解析热点计划任务文件 %s 失败: %v", s.file, err)
+		return
+	}
+	if content.Rules != nil {
+		s.rules = content.Rules
+	}
+	if content.History != nil {
+		s.history = content.History
+	}
+	log.Printf("已从 %s 加载 %d 条热点计划规则", s.file, len(s.rules))
+}
+
+// save 把规则和历史写入文件，调用方需持有锁
+func (s *Scheduler) save() error {
+	data, err := json.MarshalIndent(scheduleFile{Rules: s.rules, History: s.history}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化热点计划任务失败: %v", err)
+	}
+	if dir := filepath.Dir(s.file); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建热点计划任务目录失败: %v", err)
+		}
+	}
+	if err := os.WriteFile(s.file, data, 0644); err != nil {
+		return fmt.Errorf("保存热点计划任务失败: %v", err)
+	}
+	return nil
+}
+
+// generatePassword 用crypto/rand生成指定长度的随机密码
+func generatePassword(length int) (string, error) {
+	if length == 0 {
+		length = defaultRotatedPasswordLength
+	}
+	if length < 8 || length > 63 {
+		return "", fmt.Errorf("密码长度必须为8-63个字符")
+	}
+
+	password := make([]byte, length)
+	max := big.NewInt(int64(len(passwordAlphabet)))
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("生成随机密码失败: %v", err)
+		}
+		password[i] = passwordAlphabet[n.Int64()]
+	}
+	return string(password), nil
+}
+
+// RotateHotspotPassword 保持热点的其他配置和运行状态不变，换成新的随机密码
+func (s *NetworkService) RotateHotspotPassword(length int) error {
+	config, err := s.backend.GetHotspotConfig()
+	if err != nil {
+		return fmt.Errorf("读取热点配置失败: %w", err)
+	}
+	password, err := generatePassword(length)
+	if err != nil {
+		return err
+	}
+	config.Password = password
+	// 只换密码，不修改看门狗的期望状态
+	if errs := ValidateHotspotConfig(config); len(errs) > 0 {
+		return fmt.Errorf("更新热点密码失败: %w", errs)
+	}
+	if err := s.backend.ConfigureHotspot(config); err != nil {
+		return fmt.Errorf("更新热点密码失败: %w", err)
+	}
+	log.Printf("热点 %s 的密码已轮换", config.SSID)
+	return nil
+}
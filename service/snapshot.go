@@ -0,0 +1,158 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"networkconfig/models"
+	"os"
+	"time"
+)
+
+// ErrSnapshotVersion 表示快照格式版本不受支持
+var ErrSnapshotVersion = errors.New("unsupported snapshot version")
+
+// ExportSnapshot 导出所有网卡、已保存的WiFi配置和移动热点配置
+// 单项读取失败只记录日志，不影响其他项的导出
+func (s *NetworkService) ExportSnapshot() (models.NetworkSnapshot, error) {
+	snapshot := models.NetworkSnapshot{
+		Version:      models.SnapshotVersion,
+		CreatedAt:    time.Now(),
+		Backend:      s.backend.Name(),
+		Interfaces:   []models.InterfaceSnapshot{},
+		WiFiProfiles: []models.WiFiProfile{},
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		snapshot.Hostname = hostname
+	}
+
+	interfaces, err := s.GetInterfacesFast()
+	if err != nil {
+		return snapshot, fmt.Errorf("获取网卡列表失败: %v", err)
+	}
+	for _, fast := range interfaces {
+		iface, err := s.backend.GetInterface(fast.Name)
+		if err != nil {
+			log.Printf("导出快照时获取接口 %s 信息失败: %v", fast.Name, err)
+			continue
+		}
+		snapshot.Interfaces = append(snapshot.Interfaces, models.InterfaceSnapshot{
+			Name:        iface.Name,
+			DHCPEnabled: iface.DHCPEnabled,
+			IPv4Config:  iface.IPv4Config,
+			IPv6Config:  iface.IPv6Config,
+		})
+	}
+
+	if profiles, err := s.backend.ListWiFiProfiles(); err != nil {
+		log.Printf("导出快照时获取WiFi配置失败: %v", err)
+	} else {
+		snapshot.WiFiProfiles = append(snapshot.WiFiProfiles, profiles...)
+	}
+
+	// 导出完整的热点配置(含密码、频段、信道、安全模式等)，恢复时按原样重新配置
+	if config, err := s.backend.GetHotspotConfig(); err != nil {
+		log.Printf("导出快照时获取热点配置失败: %v", err)
+	} else if config.SSID != "" {
+		snapshot.Hotspot = &config
+	}
+
+	log.Printf("导出网络配置快照: %d 个网卡, %d 个WiFi配置, 热点: %v",
+		len(snapshot.Interfaces), len(snapshot.WiFiProfiles), snapshot.Hotspot != nil)
+	return snapshot, nil
+}
+
+// RestoreSnapshot 按快照恢复网络配置，返回每一项的恢复结果
+// 单项失败不会中断后续恢复
+func (s *NetworkService) RestoreSnapshot(snapshot models.NetworkSnapshot) (models.SnapshotRestoreResult, error) {
+	result := models.SnapshotRestoreResult{
+		Version: snapshot.Version,
+		Success: true,
+		Items:   []models.RestoreItemResult{},
+	}
+	if snapshot.Version < 1 || snapshot.Version > models.SnapshotVersion {
+		result.Success = false
+		return result, fmt.Errorf("快照版本 %d，当前支持 1-%d: %w", snapshot.Version, models.SnapshotVersion, ErrSnapshotVersion)
+	}
+
+	add := func(item models.RestoreItemResult) {
+		if !item.Success && !item.Skipped {
+			result.Success = false
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	for _, iface := range snapshot.Interfaces {
+		add(s.restoreInterface(iface))
+	}
+
+	for _, profile := range snapshot.WiFiProfiles {
+		item := models.RestoreItemResult{Kind: models.RestoreKindWiFiProfile, Name: profile.Name}
+		if item.Name == "" {
+			item.Name = profile.SSID
+		}
+		// 不含密码导出的快照中加密网络没有密码，保留本机已有的配置，不能替换为开放网络
+		if profile.Password == "" && wifiProfileAuth(profile.Security, "") != wifiAuthOpen {
+			item.Skipped = true
+			item.Message = "快照中没有该WiFi配置的密码，保留本机已有的配置"
+		} else if err := s.backend.AddWiFiProfile(profile); err != nil {
+			item.Message = err.Error()
+		} else {
+			item.Success = true
+		}
+		add(item)
+	}
+
+	if snapshot.Hotspot != nil {
+		add(s.restoreHotspot(*snapshot.Hotspot))
+	}
+
+	log.Printf("网络配置快照恢复完成，共 %d 项，全部成功: %v", len(result.Items), result.Success)
+	return result, nil
+}
+
+// restoreInterface 恢复单个网卡的地址配置
+func (s *NetworkService) restoreInterface(snapshot models.InterfaceSnapshot) models.RestoreItemResult {
+	item := models.RestoreItemResult{Kind: models.RestoreKindInterface, Name: snapshot.Name}
+
+	if _, err := s.backend.GetInterface(snapshot.Name); err != nil {
+		item.Skipped = true
+		item.Message = fmt.Sprintf("本机没有该网卡: %v", err)
+		return item
+	}
+
+	iface := models.Interface{
+		Name:        snapshot.Name,
+		DHCPEnabled: snapshot.DHCPEnabled,
+		IPv4Config:  snapshot.IPv4Config,
+		IPv6Config:  snapshot.IPv6Config,
+	}
+	config := configFromSnapshot(iface, true, snapshot.IPv6Config.IP != "")
+	if err := s.ConfigureInterface(snapshot.Name, config); err != nil {
+		item.Message = err.Error()
+		return item
+	}
+	item.Success = true
+	return item
+}
+
+// restoreHotspot 按快照中的完整配置重新配置移动热点
+// 早期版本导出的快照中没有密码，此时沿用当前热点的密码
+func (s *NetworkService) restoreHotspot(config models.HotspotConfig) models.RestoreItemResult {
+	item := models.RestoreItemResult{Kind: models.RestoreKindHotspot, Name: config.SSID}
+
+	if config.Password == "" {
+		if current, err := s.backend.GetHotspotConfig(); err != nil {
+			log.Printf("读取当前热点密码失败: %v", err)
+		} else {
+			config.Password = current.Password
+		}
+	}
+
+	if err := s.ConfigureHotspot(config); err != nil {
+		item.Message = err.Error()
+		return item
+	}
+	item.Success = true
+	return item
+}
@@ -0,0 +1,475 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"networkconfig/models"
+	"strings"
+)
+
+// 校验错误码
+const (
+	ValidationRequired             = "required"               // 缺少必填字段
+	ValidationInvalidAddress       = "invalid_address"        // 地址格式错误或不可用于网卡
+	ValidationWrongFamily          = "wrong_family"           // 地址族不匹配(如IPv4配置中填写IPv6地址)
+	ValidationInvalidMask          = "invalid_mask"           // 子网掩码格式错误
+	ValidationNonContiguousMask    = "non_contiguous_mask"    // 子网掩码不连续
+	ValidationInvalidPrefix        = "invalid_prefix"         // IPv6前缀长度超出范围
+	ValidationNetworkAddress       = "network_address"        // 使用了网络地址
+	ValidationBroadcastAddress     = "broadcast_address"      // 使用了广播地址
+	ValidationGatewayOutsideSubnet = "gateway_outside_subnet" // 网关不在子网内
+	ValidationGatewayIsAddress     = "gateway_is_address"     // 网关与本机地址相同
+	ValidationDuplicateDNS         = "duplicate_dns"          // DNS服务器重复
+	ValidationSubnetOverlap        = "subnet_overlap"         // 与其他网卡的子网重叠
+	ValidationDuplicateInterface   = "duplicate_interface"    // 同一网卡出现多次
+	ValidationDuplicateAddress     = "duplicate_address"      // 地址列表中IP重复
+	ValidationConflictingAddress   = "conflicting_address"    // address与ip/mask(prefix_len)不一致
+	ValidationInvalidValue         = "invalid_value"          // 取值不在允许范围内
+	ValidationInvalidMAC           = "invalid_mac"            // MAC地址格式错误
+	ValidationUnsupported          = "unsupported"            // 当前平台后端不支持该选项
+)
+
+// ValidationErrors 配置校验失败时返回的字段级错误列表
+type ValidationErrors []models.ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, item := range e {
+		messages = append(messages, fmt.Sprintf("%s: %s", item.Field, item.Message))
+	}
+	return "配置校验失败: " + strings.Join(messages, "; ")
+}
+
+// add 追加一条校验错误
+func (e *ValidationErrors) add(field, code, format string, args ...interface{}) {
+	*e = append(*e, models.ValidationError{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// withPrefix 给所有字段路径加上前缀，用于嵌套在其他文档中的配置
+func (e ValidationErrors) withPrefix(prefix string) ValidationErrors {
+	result := make(ValidationErrors, 0, len(e))
+	for _, item := range e {
+		item.Field = prefix + item.Field
+		result = append(result, item)
+	}
+	return result
+}
+
+// ValidateIPv4Config 校验IPv4配置的语法和地址关系，不访问系统
+// CIDR形式的address/addresses先按NormalizeIPv4Config展开再校验
+func ValidateIPv4Config(config models.IPv4Config) ValidationErrors {
+	config, errs := NormalizeIPv4Config(config)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if !config.DHCP {
+		ip, subnet := validateIPv4Address(&errs, config.IP, config.Mask)
+		if config.Gateway != "" {
+			validateIPv4Gateway(&errs, config.Gateway, ip, subnet)
+		}
+		validateSecondaryAddresses(&errs, "ipv4_config.addresses", config.IP, config.Addresses)
+	}
+
+	if !(config.DHCP && config.DNSAuto) {
+		validateDNSServers(&errs, "ipv4_config.dns", config.DNS, false)
+	}
+	return errs
+}
+
+// validateIPv4Address 校验静态IPv4地址和子网掩码，返回解析结果供网关校验使用
+func validateIPv4Address(errs *ValidationErrors, address, maskText string) (net.IP, *net.IPNet) {
+	var ip net.IP
+	switch parsed := net.ParseIP(address); {
+	case address == "":
+		errs.add("ipv4_config.ip", ValidationRequired, "静态配置必须提供IP地址")
+	case parsed == nil:
+		errs.add("ipv4_config.ip", ValidationInvalidAddress, "无效的IPv4地址: %s", address)
+	case parsed.To4() == nil:
+		errs.add("ipv4_config.ip", ValidationWrongFamily, "%s 不是IPv4地址", address)
+	case !usableUnicast(parsed):
+		errs.add("ipv4_config.ip", ValidationInvalidAddress, "%s 不能作为网卡地址", address)
+	default:
+		ip = parsed.To4()
+	}
+
+	var mask net.IPMask
+	switch parsed := net.ParseIP(maskText); {
+	case maskText == "":
+		errs.add("ipv4_config.mask", ValidationRequired, "静态配置必须提供子网掩码")
+	case parsed == nil || parsed.To4() == nil:
+		errs.add("ipv4_config.mask", ValidationInvalidMask, "无效的子网掩码: %s", maskText)
+	default:
+		mask = net.IPMask(parsed.To4())
+		if ones, bits := mask.Size(); ones == 0 && bits == 0 {
+			errs.add("ipv4_config.mask", ValidationNonContiguousMask, "子网掩码不连续: %s", maskText)
+			mask = nil
+		} else if ones == 0 {
+			errs.add("ipv4_config.mask", ValidationInvalidMask, "子网掩码不能为0.0.0.0")
+			mask = nil
+		}
+	}
+
+	if ip == nil || mask == nil {
+		return ip, nil
+	}
+
+	subnet := &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+	// /31和/32没有网络地址和广播地址
+	if ones, _ := mask.Size(); ones < 31 {
+		if ip.Equal(subnet.IP) {
+			errs.add("ipv4_config.ip", ValidationNetworkAddress, "%s 是子网 %s 的网络地址", address, subnet)
+		} else if ip.Equal(broadcastAddress(subnet)) {
+			errs.add("ipv4_config.ip", ValidationBroadcastAddress, "%s 是子网 %s 的广播地址", address, subnet)
+		}
+	}
+	return ip, subnet
+}
+
+// validateIPv4Gateway 校验IPv4网关，subnet为nil时只检查格式
+func validateIPv4Gateway(errs *ValidationErrors, gateway string, ip net.IP, subnet *net.IPNet) {
+	gw := net.ParseIP(gateway)
+	switch {
+	case gw == nil:
+		errs.add("ipv4_config.gateway", ValidationInvalidAddress, "无效的网关地址: %s", gateway)
+		return
+	case gw.To4() == nil:
+		errs.add("ipv4_config.gateway", ValidationWrongFamily, "%s 不是IPv4地址", gateway)
+		return
+	case !usableUnicast(gw):
+		errs.add("ipv4_config.gateway", ValidationInvalidAddress, "%s 不能作为网关", gateway)
+		return
+	}
+
+	if ip != nil && gw.Equal(ip) {
+		errs.add("ipv4_config.gateway", ValidationGatewayIsAddress, "网关不能与本机地址 %s 相同", ip)
+		return
+	}
+	if subnet == nil {
+		return
+	}
+	if !subnet.Contains(gw) {
+		errs.add("ipv4_config.gateway", ValidationGatewayOutsideSubnet, "网关 %s 不在子网 %s 内", gateway, subnet)
+		return
+	}
+	if ones, _ := subnet.Mask.Size(); ones < 31 {
+		if gw.Equal(subnet.IP) {
+			errs.add("ipv4_config.gateway", ValidationNetworkAddress, "网关 %s 是子网的网络地址", gateway)
+		} else if gw.Equal(broadcastAddress(subnet)) {
+			errs.add("ipv4_config.gateway", ValidationBroadcastAddress, "网关 %s 是子网的广播地址", gateway)
+		}
+	}
+}
+
+// ValidateIPv6Config 校验IPv6配置的语法和地址关系，不访问系统
+// CIDR形式的address/addresses先按NormalizeIPv6Config展开再校验
+func ValidateIPv6Config(config models.IPv6Config) ValidationErrors {
+	config, errs := NormalizeIPv6Config(config)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	var ip net.IP
+	switch parsed := net.ParseIP(config.IP); {
+	case config.IP == "":
+		errs.add("ipv6_config.ip", ValidationRequired, "必须提供IPv6地址")
+	case parsed == nil:
+		errs.add("ipv6_config.ip", ValidationInvalidAddress, "无效的IPv6地址: %s", config.IP)
+	case parsed.To4() != nil:
+		errs.add("ipv6_config.ip", ValidationWrongFamily, "%s 不是IPv6地址", config.IP)
+	case !usableUnicast(parsed):
+		errs.add("ipv6_config.ip", ValidationInvalidAddress, "%s 不能作为网卡地址", config.IP)
+	default:
+		ip = parsed
+	}
+
+	prefixLen := config.PrefixLen
+	if prefixLen == 0 {
+		prefixLen = 64
+	}
+	if prefixLen < 1 || prefixLen > 128 {
+		errs.add("ipv6_config.prefix_len", ValidationInvalidPrefix, "IPv6前缀长度必须在1-128之间: %d", config.PrefixLen)
+		prefixLen = 0
+	}
+
+	if config.Gateway != "" {
+		gw := net.ParseIP(config.Gateway)
+		switch {
+		case gw == nil:
+			errs.add("ipv6_config.gateway", ValidationInvalidAddress, "无效的网关地址: %s", config.Gateway)
+		case gw.To4() != nil:
+			errs.add("ipv6_config.gateway", ValidationWrongFamily, "%s 不是IPv6地址", config.Gateway)
+		case !usableUnicast(gw):
+			errs.add("ipv6_config.gateway", ValidationInvalidAddress, "%s 不能作为网关", config.Gateway)
+		case ip != nil && gw.Equal(ip):
+			errs.add("ipv6_config.gateway", ValidationGatewayIsAddress, "网关不能与本机地址相同")
+		case ip != nil && prefixLen > 0 && !gw.IsLinkLocalUnicast():
+			// IPv6网关通常是链路本地地址，只有全局地址才要求在前缀内
+			subnet := &net.IPNet{IP: ip.Mask(net.CIDRMask(prefixLen, 128)), Mask: net.CIDRMask(prefixLen, 128)}
+			if !subnet.Contains(gw) {
+				errs.add("ipv6_config.gateway", ValidationGatewayOutsideSubnet, "网关 %s 不在前缀 %s 内", config.Gateway, subnet)
+			}
+		}
+	}
+
+	validateSecondaryAddresses(&errs, "ipv6_config.addresses", config.IP, config.Addresses)
+	validateDNSServers(&errs, "ipv6_config.dns", config.DNS, true)
+	return errs
+}
+
+// validateSecondaryAddresses 校验主地址以外的附加地址，主地址已按IP字段校验
+func validateSecondaryAddresses(errs *ValidationErrors, field, primaryIP string, addresses []string) {
+	primary := net.ParseIP(primaryIP)
+	for i, value := range addresses {
+		if ip, _, err := net.ParseCIDR(value); err != nil || ip.Equal(primary) {
+			continue
+		}
+		validateHostAddress(errs, fmt.Sprintf("%s[%d]", field, i), value)
+	}
+}
+
+// validateHostAddress 校验CIDR形式的地址能否配置在网卡上，IPv4不能是网络地址或广播地址
+func validateHostAddress(errs *ValidationErrors, field, value string) {
+	ip, subnet, err := net.ParseCIDR(value)
+	if err != nil {
+		return
+	}
+	if !usableUnicast(ip) {
+		errs.add(field, ValidationInvalidAddress, "%s 不能作为网卡地址", value)
+		return
+	}
+	if ip.To4() == nil {
+		return
+	}
+	if ones, _ := subnet.Mask.Size(); ones < 31 {
+		if ip.Equal(subnet.IP) {
+			errs.add(field, ValidationNetworkAddress, "%s 是子网 %s 的网络地址", value, subnet)
+		} else if ip.Equal(broadcastAddress(subnet)) {
+			errs.add(field, ValidationBroadcastAddress, "%s 是子网 %s 的广播地址", value, subnet)
+		}
+	}
+}
+
+// validateDNSServers 校验DNS服务器地址格式、地址族和重复项
+func validateDNSServers(errs *ValidationErrors, field string, servers []string, ipv6 bool) {
+	seen := make(map[string]int)
+	for i, server := range servers {
+		path := fmt.Sprintf("%s[%d]", field, i)
+		ip := net.ParseIP(strings.TrimSpace(server))
+		switch {
+		case ip == nil:
+			errs.add(path, ValidationInvalidAddress, "无效的DNS服务器地址: %s", server)
+			continue
+		case (ip.To4() == nil) != ipv6:
+			errs.add(path, ValidationWrongFamily, "DNS服务器 %s 的地址族与配置不一致", server)
+			continue
+		case ip.IsUnspecified() || ip.IsMulticast():
+			errs.add(path, ValidationInvalidAddress, "%s 不能作为DNS服务器", server)
+			continue
+		}
+		if first, ok := seen[ip.String()]; ok {
+			errs.add(path, ValidationDuplicateDNS, "DNS服务器 %s 与第%d项重复", server, first+1)
+			continue
+		}
+		seen[ip.String()] = i
+	}
+}
+
+// ValidateInterfaceConfig 校验网卡配置，除语法外还检查与本机其他网卡的子网是否重叠
+func (s *NetworkService) ValidateInterfaceConfig(name string, config models.InterfaceConfig) ValidationErrors {
+	_, errs := s.prepareInterfaceConfig(name, config)
+	return errs
+}
+
+// prepareInterfaceConfig 规范化并校验网卡配置，返回交给后端执行的配置
+func (s *NetworkService) prepareInterfaceConfig(name string, config models.InterfaceConfig) (models.InterfaceConfig, ValidationErrors) {
+	normalized, errs := normalizeInterfaceConfig(config)
+	if len(errs) > 0 {
+		return normalized, errs
+	}
+
+	if v4 := normalized.IPv4Config; v4 != nil {
+		errs = append(errs, ValidateIPv4Config(*v4)...)
+		if !v4.DHCP {
+			checkAddressesOverlap(&errs, "ipv4_config", name, v4.Addresses)
+		}
+	}
+
+	if v6 := normalized.IPv6Config; v6 != nil {
+		errs = append(errs, ValidateIPv6Config(*v6)...)
+		checkAddressesOverlap(&errs, "ipv6_config", name, v6.Addresses)
+	}
+
+	return normalized, errs
+}
+
+// checkAddressesOverlap 检查每个地址所在子网是否与其他网卡重叠，第一个地址的错误归到ip字段
+func checkAddressesOverlap(errs *ValidationErrors, prefix, name string, addresses []string) {
+	for i, value := range addresses {
+		ip, subnet, err := net.ParseCIDR(value)
+		if err != nil || ip.IsLinkLocalUnicast() {
+			continue
+		}
+		field := fmt.Sprintf("%s.addresses[%d]", prefix, i)
+		if i == 0 {
+			field = prefix + ".ip"
+		}
+		checkSubnetOverlap(errs, field, name, subnet)
+	}
+}
+
+// checkSubnetOverlap 检查子网是否与其他网卡(不含回环和链路本地地址)的子网重叠
+func checkSubnetOverlap(errs *ValidationErrors, field, name string, subnet *net.IPNet) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return
+	}
+	for _, iface := range ifaces {
+		if iface.Name == name || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			other, ok := addr.(*net.IPNet)
+			if !ok || other.IP.IsLinkLocalUnicast() || (other.IP.To4() == nil) != (subnet.IP.To4() == nil) {
+				continue
+			}
+			if subnet.Contains(other.IP) || other.Contains(subnet.IP) {
+				errs.add(field, ValidationSubnetOverlap, "子网 %s 与网卡 %s 的地址 %s 重叠", subnet, iface.Name, other)
+				return
+			}
+		}
+	}
+}
+
+// ValidateHotspotConfig 校验热点配置中与平台无关的规则，平台不支持的选项由各后端在配置时检查
+func ValidateHotspotConfig(config models.HotspotConfig) ValidationErrors {
+	var errs ValidationErrors
+
+	switch {
+	case config.SSID == "":
+		errs.add("ssid", ValidationRequired, "SSID不能为空")
+	case len(config.SSID) > 32:
+		errs.add("ssid", ValidationInvalidValue, "SSID不能超过32字节")
+	case strings.ContainsAny(config.SSID, "\r\n"):
+		errs.add("ssid", ValidationInvalidValue, "SSID不能包含换行")
+	}
+
+	if len(config.Password) < 8 || len(config.Password) > 63 {
+		errs.add("password", ValidationInvalidValue, "密码长度必须为8-63个字符")
+	} else {
+		for _, c := range config.Password {
+			if c < 0x20 || c > 0x7e {
+				errs.add("password", ValidationInvalidValue, "密码只能包含可打印ASCII字符")
+				break
+			}
+		}
+	}
+
+	switch config.Band {
+	case "", models.HotspotBandAuto, models.HotspotBand24GHz, models.HotspotBand5GHz, models.HotspotBand6GHz:
+	default:
+		errs.add("band", ValidationInvalidValue, "频段 %s 无效，应为auto、2.4GHz、5GHz或6GHz", config.Band)
+	}
+	if config.Channel != 0 {
+		if config.Band == "" || config.Band == models.HotspotBandAuto {
+			errs.add("band", ValidationRequired, "指定信道时必须指定频段")
+		} else if !validHotspotChannel(config.Band, config.Channel) {
+			errs.add("channel", ValidationInvalidValue, "信道 %d 不属于%s频段", config.Channel, config.Band)
+		}
+	}
+
+	switch config.Security {
+	case "", models.HotspotSecurityWPA2, models.HotspotSecurityWPA3, models.HotspotSecurityWPA3Transition:
+	default:
+		errs.add("security", ValidationInvalidValue, "安全模式 %s 无效，应为wpa2、wpa3或wpa3-transition", config.Security)
+	}
+	if config.Band == models.HotspotBand6GHz && config.Security != models.HotspotSecurityWPA3 {
+		errs.add("security", ValidationInvalidValue, "6GHz频段只能使用wpa3安全模式")
+	}
+
+	if config.MaxClients < 0 {
+		errs.add("max_clients", ValidationInvalidValue, "最大客户端数不能为负数")
+	}
+
+	// 网卡名最长15字节(IFNAMSIZ-1)，会写入nftables规则，只允许常见字符
+	if uplink := config.Uplink; uplink != "" {
+		valid := len(uplink) <= 15
+		for _, c := range uplink {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_.@:", c)) {
+				valid = false
+			}
+		}
+		if !valid {
+			errs.add("uplink", ValidationInvalidValue, "上行网卡 %s 无效，应为auto、none或网卡名", uplink)
+		}
+	}
+	return errs
+}
+
+// ValidateWiFiScanQuery 校验WiFi扫描结果的排序和过滤条件，频段和加密类型不区分大小写
+func ValidateWiFiScanQuery(query models.WiFiScanQuery) ValidationErrors {
+	var errs ValidationErrors
+
+	switch query.Sort {
+	case "", models.WiFiSortSignal, models.WiFiSortSSID, models.WiFiSortChannel, models.WiFiSortFrequency:
+	default:
+		errs.add("sort", ValidationInvalidValue, "排序字段 %s 无效，应为signal、ssid、channel或frequency", query.Sort)
+	}
+	switch query.Order {
+	case "", "asc", "desc":
+	default:
+		errs.add("order", ValidationInvalidValue, "排序方向 %s 无效，应为asc或desc", query.Order)
+	}
+	if query.MinSignal < 0 || query.MinSignal > 100 {
+		errs.add("min_signal", ValidationInvalidValue, "最小信号强度必须为0-100")
+	}
+	for _, band := range query.Bands {
+		if !containsFold([]string{models.HotspotBand24GHz, models.HotspotBand5GHz, models.HotspotBand6GHz}, band) {
+			errs.add("band", ValidationInvalidValue, "频段 %s 无效，应为2.4GHz、5GHz或6GHz", band)
+		}
+	}
+	for _, security := range query.Security {
+		if !containsFold(wifiSecurityFilters, security) {
+			errs.add("security", ValidationInvalidValue, "加密类型 %s 无效，应为%s之一", security, strings.Join(wifiSecurityFilters, "、"))
+		}
+	}
+	return errs
+}
+
+// validHotspotChannel 判断信道是否属于指定频段(20MHz信道编号)
+func validHotspotChannel(band string, channel int) bool {
+	switch band {
+	case models.HotspotBand24GHz:
+		return channel >= 1 && channel <= 14
+	case models.HotspotBand5GHz:
+		switch {
+		case channel >= 36 && channel <= 64, channel >= 100 && channel <= 144:
+			return channel%4 == 0
+		case channel >= 149 && channel <= 177:
+			return channel%4 == 1
+		}
+		return false
+	case models.HotspotBand6GHz:
+		return channel >= 1 && channel <= 233 && channel%4 == 1
+	}
+	return false
+}
+
+// broadcastAddress 计算IPv4子网的广播地址
+func broadcastAddress(subnet *net.IPNet) net.IP {
+	ip := subnet.IP.To4()
+	broadcast := make(net.IP, len(ip))
+	for i := range ip {
+		broadcast[i] = ip[i] | ^subnet.Mask[i]
+	}
+	return broadcast
+}
+
+// usableUnicast 地址是否可以配置在网卡上或作为网关
+func usableUnicast(ip net.IP) bool {
+	return !ip.IsUnspecified() && !ip.IsLoopback() && !ip.IsMulticast() && !ip.Equal(net.IPv4bcast)
+}
@@ -0,0 +1,532 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"networkconfig/models"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 看门狗保留的恢复记录条数，以及允许设置的检查间隔范围(秒)
+const (
+	watchdogMaxHistory  = 100
+	watchdogMinInterval = 5
+	watchdogMaxInterval = 86400
+)
+
+// WatchdogProbe 看门狗检查项，由具体的看门狗实现
+type WatchdogProbe interface {
+	// Name 看门狗名称
+	Name() string
+	// Wanted 期望状态是否要求被检查的对象处于工作状态，返回false时(如用户主动关闭)不检查也不恢复
+	Wanted() bool
+	// Check 检查实际状态，err表示无法读取状态，此时不做恢复
+	Check() (healthy bool, reason string, err error)
+	// Recover 尝试恢复
+	Recover() error
+}
+
+// WatchdogPolicy 看门狗的检查和恢复策略
+type WatchdogPolicy struct {
+	Enabled         bool          // 是否启用
+	Interval        time.Duration // 检查间隔
+	AutoRecovery    bool          // 检查失败时是否自动恢复
+	InitialBackoff  time.Duration // 第一次恢复失败后的等待时间，之后每次翻倍
+	MaxBackoff      time.Duration // 退避等待时间上限
+	MaxAttempts     int           // 连续恢复多少次仍未正常时熔断，0表示不限
+	CircuitCooldown time.Duration // 熔断持续时间，结束后再尝试一次恢复
+}
+
+// WatchdogHook 看门狗升级事件处理函数
+type WatchdogHook func(event models.WatchdogEvent)
+
+// Watchdog 按策略周期检查一个对象，失败时以指数退避恢复，连续失败过多时熔断并升级
+// 设置了file时，运行时修改的设置和恢复记录保存到该文件，重启后优先于环境变量
+type Watchdog struct {
+	probe    WatchdogProbe
+	file     string
+	mu       sync.Mutex
+	policy   WatchdogPolicy
+	status   models.WatchdogStatus
+	history  []models.RecoveryRecord
+	desired  *bool
+	hooks    []WatchdogHook
+	reload   chan struct{}
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+	debug    bool
+}
+
+// watchdogFile 看门狗持久化文件内容
+type watchdogFile struct {
+	Settings models.MonitorSettings  `json:"settings"`
+	Desired  *bool                   `json:"desired,omitempty"`
+	History  []models.RecoveryRecord `json:"history"`
+}
+
+// NewWatchdog 创建看门狗，file为空时不持久化
+func NewWatchdog(probe WatchdogProbe, policy WatchdogPolicy, file string, debug bool) *Watchdog {
+	w := &Watchdog{
+		probe:    probe,
+		file:     file,
+		policy:   policy,
+		status:   models.WatchdogStatus{Name: probe.Name(), State: models.WatchdogStatePending},
+		history:  []models.RecoveryRecord{},
+		reload:   make(chan struct{}, 1),
+		stopChan: make(chan struct{}),
+		debug:    debug,
+	}
+	w.load()
+	return w
+}
+
+// OnEscalate 注册升级事件处理函数，熔断和熔断后恢复正常时调用
+func (w *Watchdog) OnEscalate(hook WatchdogHook) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.hooks = append(w.hooks, hook)
+}
+
+// Start 启动看门狗，未启用时也会运行检查循环，以便运行时启用
+func (w *Watchdog) Start() {
+	w.wg.Add(1)
+	go w.loop()
+
+	policy := w.Policy()
+	if !policy.Enabled {
+		log.Printf("看门狗 %s 未启用", w.probe.Name())
+		return
+	}
+	log.Printf("看门狗 %s 已启动，检查间隔: %v, 自动恢复: %v, 最多连续恢复: %d 次",
+		w.probe.Name(), policy.Interval, policy.AutoRecovery, policy.MaxAttempts)
+}
+
+// Stop 停止看门狗
+func (w *Watchdog) Stop() {
+	close(w.stopChan)
+	w.wg.Wait()
+	log.Printf("看门狗 %s 已停止", w.probe.Name())
+}
+
+// Policy 返回当前策略
+func (w *Watchdog) Policy() WatchdogPolicy {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.policy
+}
+
+// Info 返回设置、状态和恢复记录
+func (w *Watchdog) Info() models.MonitorInfo {
+	status := w.Status()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	info := models.MonitorInfo{
+		Enabled:         w.policy.Enabled,
+		IntervalSeconds: int(w.policy.Interval / time.Second),
+		AutoRecovery:    w.policy.AutoRecovery,
+		Status:          status,
+		History:         make([]models.RecoveryRecord, 0, len(w.history)),
+	}
+	for i := len(w.history) - 1; i >= 0; i-- {
+		info.History = append(info.History, w.history[i])
+	}
+	return info
+}
+
+// UpdateSettings 修改启用状态、检查间隔和自动恢复，立即生效并保存
+func (w *Watchdog) UpdateSettings(settings models.MonitorSettings) (models.MonitorInfo, error) {
+	var errs ValidationErrors
+	if settings.IntervalSeconds != nil && (*settings.IntervalSeconds < watchdogMinInterval || *settings.IntervalSeconds > watchdogMaxInterval) {
+		errs.add("interval_seconds", ValidationInvalidValue, "检查间隔必须为%d-%d秒", watchdogMinInterval, watchdogMaxInterval)
+	}
+	if len(errs) > 0 {
+		return models.MonitorInfo{}, errs
+	}
+
+	w.mu.Lock()
+	previous := w.policy
+	w.applySettingsLocked(settings)
+	if err := w.saveLocked(); err != nil {
+		w.policy = previous
+		w.mu.Unlock()
+		return models.MonitorInfo{}, err
+	}
+	policy := w.policy
+	w.mu.Unlock()
+
+	// 唤醒检查循环，按新的间隔重新计时
+	select {
+	case w.reload <- struct{}{}:
+	default:
+	}
+	log.Printf("看门狗 %s 设置已更新，启用: %v, 检查间隔: %v, 自动恢复: %v",
+		w.probe.Name(), policy.Enabled, policy.Interval, policy.AutoRecovery)
+	return w.Info(), nil
+}
+
+// applySettingsLocked 把设置中非空的字段应用到策略，调用方需持有锁
+func (w *Watchdog) applySettingsLocked(settings models.MonitorSettings) {
+	if settings.Enabled != nil {
+		w.policy.Enabled = *settings.Enabled
+	}
+	if settings.IntervalSeconds != nil {
+		w.policy.Interval = time.Duration(*settings.IntervalSeconds) * time.Second
+	}
+	if settings.AutoRecovery != nil {
+		w.policy.AutoRecovery = *settings.AutoRecovery
+	}
+}
+
+// SetDesired 记录用户最近一次主动开启或关闭的期望状态并保存，检查时优先于其他规则
+func (w *Watchdog) SetDesired(enabled bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.desired = &enabled
+	if err := w.saveLocked(); err != nil {
+		log.Printf("保存看门狗 %s 期望状态失败: %v", w.probe.Name(), err)
+	}
+}
+
+// Desired 返回用户最近一次主动设置的期望状态，没有设置过时ok为false
+// 命令行工具在另一个进程中修改期望状态，因此每次都从文件重新读取
+func (w *Watchdog) Desired() (enabled bool, ok bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file != "" {
+		if data, err := os.ReadFile(w.file); err == nil {
+			var content watchdogFile
+			if err := json.Unmarshal(data, &content); err == nil && content.Desired != nil {
+				w.desired = content.Desired
+			}
+		}
+	}
+	if w.desired == nil {
+		return false, false
+	}
+	return *w.desired, true
+}
+
+// Status 返回当前状态的副本
+func (w *Watchdog) Status() models.WatchdogStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	status := w.status
+	status.Enabled = w.policy.Enabled
+	status.AutoRecovery = w.policy.AutoRecovery
+	status.IntervalSeconds = int(w.policy.Interval / time.Second)
+	status.MaxAttempts = w.policy.MaxAttempts
+	if !w.policy.Enabled {
+		status.State = models.WatchdogStateDisabled
+	}
+	return status
+}
+
+// loop 检查循环，设置修改后重新计时
+func (w *Watchdog) loop() {
+	defer w.wg.Done()
+
+	for {
+		timer := time.NewTimer(w.Policy().Interval)
+		select {
+		case <-w.stopChan:
+			timer.Stop()
+			return
+		case <-w.reload:
+			timer.Stop()
+		case <-timer.C:
+			if w.Policy().Enabled {
+				w.check(time.Now())
+			}
+		}
+	}
+}
+
+// check 执行一次检查，需要时按退避和熔断策略恢复
+func (w *Watchdog) check(now time.Time) {
+	name := w.probe.Name()
+	if w.debug {
+		log.Printf("看门狗 %s 正在检查...", name)
+	}
+
+	if !w.probe.Wanted() {
+		w.mu.Lock()
+		w.status.LastCheck = &now
+		w.status.LastResult = "期望状态为关闭，跳过检查"
+		w.status.State = models.WatchdogStateStandby
+		w.resetLocked()
+		w.mu.Unlock()
+		if w.debug {
+			log.Printf("看门狗 %s: 期望状态为关闭，跳过检查", name)
+		}
+		return
+	}
+
+	healthy, reason, err := w.probe.Check()
+
+	w.mu.Lock()
+	w.status.LastCheck = &now
+	if err != nil {
+		w.status.State = models.WatchdogStateError
+		w.status.LastResult = err.Error()
+		w.mu.Unlock()
+		log.Printf("看门狗 %s 检查失败: %v", name, err)
+		return
+	}
+	w.status.LastResult = reason
+
+	if healthy {
+		var event *models.WatchdogEvent
+		if w.status.State == models.WatchdogStateCircuitOpen {
+			event = &models.WatchdogEvent{Type: models.WatchdogEventCircuitClosed, Reason: "检查恢复正常"}
+		}
+		if w.status.ConsecutiveFailures > 0 {
+			log.Printf("看门狗 %s 检查恢复正常", name)
+		} else if w.debug {
+			log.Printf("看门狗 %s 检查正常", name)
+		}
+		w.status.State = models.WatchdogStateOK
+		w.resetLocked()
+		w.mu.Unlock()
+		if event != nil {
+			w.escalate(*event, now)
+		}
+		return
+	}
+
+	w.status.ConsecutiveFailures++
+	log.Printf("看门狗 %s 检测到异常(连续第 %d 次): %s", name, w.status.ConsecutiveFailures, reason)
+	if w.status.State != models.WatchdogStateCircuitOpen {
+		w.status.State = models.WatchdogStateFailing
+	}
+
+	policy := w.policy
+	switch {
+	case !policy.AutoRecovery:
+		w.mu.Unlock()
+		log.Printf("看门狗 %s 未启用自动恢复，跳过恢复操作", name)
+		return
+	case w.status.CircuitOpenUntil != nil && now.Before(*w.status.CircuitOpenUntil):
+		w.mu.Unlock()
+		if w.debug {
+			log.Printf("看门狗 %s 处于熔断状态，%v 之前不再恢复", name, w.status.CircuitOpenUntil.Format(time.RFC3339))
+		}
+		return
+	case w.status.NextAttempt != nil && now.Before(*w.status.NextAttempt):
+		w.mu.Unlock()
+		if w.debug {
+			log.Printf("看门狗 %s 退避中，%v 之后再尝试恢复", name, w.status.NextAttempt.Format(time.RFC3339))
+		}
+		return
+	}
+	w.status.Attempts++
+	attempt := w.status.Attempts
+	w.mu.Unlock()
+
+	log.Printf("看门狗 %s 正在尝试第 %d 次恢复...", name, attempt)
+	started := time.Now()
+	recoverErr := w.probe.Recover()
+	done := now.Add(time.Since(started))
+
+	w.mu.Lock()
+	record := models.RecoveryRecord{
+		Time:    now,
+		Attempt: attempt,
+		Reason:  reason,
+		Outcome: models.RecoveryOutcomeSuccess,
+	}
+	if recoverErr != nil {
+		w.status.FailedRecoveries++
+		record.Outcome = models.RecoveryOutcomeFailed
+		record.Error = recoverErr.Error()
+		log.Printf("看门狗 %s 恢复失败: %v", name, recoverErr)
+	} else {
+		w.status.Recoveries++
+		w.status.LastRecovery = &done
+		log.Printf("看门狗 %s 恢复操作完成，等待下次检查确认", name)
+	}
+	w.history = append(w.history, record)
+	if len(w.history) > watchdogMaxHistory {
+		w.history = w.history[len(w.history)-watchdogMaxHistory:]
+	}
+	if err := w.saveLocked(); err != nil {
+		log.Printf("保存看门狗 %s 恢复记录失败: %v", name, err)
+	}
+
+	// 恢复后仍未正常时，下次恢复前按指数退避等待
+	next := done.Add(watchdogBackoff(policy, attempt))
+	w.status.NextAttempt = &next
+
+	if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+		until := done.Add(policy.CircuitCooldown)
+		w.status.CircuitOpenUntil = &until
+		w.status.State = models.WatchdogStateCircuitOpen
+		escalation := "连续恢复后仍未正常"
+		if recoverErr != nil {
+			escalation = recoverErr.Error()
+		}
+		w.mu.Unlock()
+		log.Printf("看门狗 %s 已连续恢复 %d 次，熔断至 %s", name, attempt, until.Format(time.RFC3339))
+		w.escalate(models.WatchdogEvent{Type: models.WatchdogEventCircuitOpen, Reason: escalation}, done)
+		return
+	}
+	w.mu.Unlock()
+}
+
+// resetLocked 清除故障计数和退避、熔断状态，调用方需持有锁
+func (w *Watchdog) resetLocked() {
+	w.status.ConsecutiveFailures = 0
+	w.status.Attempts = 0
+	w.status.NextAttempt = nil
+	w.status.CircuitOpenUntil = nil
+}
+
+// escalate 把升级事件交给所有处理函数
+func (w *Watchdog) escalate(event models.WatchdogEvent, now time.Time) {
+	w.mu.Lock()
+	event.Watchdog = w.probe.Name()
+	event.Time = now
+	event.ConsecutiveFailures = w.status.ConsecutiveFailures
+	hooks := append([]WatchdogHook{}, w.hooks...)
+	w.mu.Unlock()
+
+	log.Printf("看门狗 %s 升级事件: %s, %s", event.Watchdog, event.Type, event.Reason)
+	for _, hook := range hooks {
+		hook(event)
+	}
+}
+
+// load 从文件加载设置和恢复记录，文件中的设置优先于环境变量
+func (w *Watchdog) load() {
+	if w.file == "" {
+		return
+	}
+	data, err := os.ReadFile(w.file)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("读取看门狗 %s 设置文件 %s 失败: %v", w.probe.Name(), w.file, err)
+		}
+		return
+	}
+
+	var content watchdogFile
+	if err := json.Unmarshal(data, &content); err != nil {
+		log.Printf("解析看门狗 %s 设置文件 %s 失败: %v", w.probe.Name(), w.file, err)
+		return
+	}
+	if interval := content.Settings.IntervalSeconds; interval != nil && (*interval < watchdogMinInterval || *interval > watchdogMaxInterval) {
+		log.Printf("看门狗 %s 设置文件中的检查间隔 %d 无效，忽略", w.probe.Name(), *interval)
+		content.Settings.IntervalSeconds = nil
+	}
+	w.applySettingsLocked(content.Settings)
+	w.desired = content.Desired
+	if content.History != nil {
+		w.history = content.History
+	}
+	log.Printf("已从 %s 加载看门狗 %s 的设置", w.file, w.probe.Name())
+}
+
+// saveLocked 把当前设置和恢复记录写入文件，调用方需持有锁
+func (w *Watchdog) saveLocked() error {
+	if w.file == "" {
+		return nil
+	}
+	interval := int(w.policy.Interval / time.Second)
+	content := watchdogFile{
+		Settings: models.MonitorSettings{
+			Enabled:         &w.policy.Enabled,
+			IntervalSeconds: &interval,
+			AutoRecovery:    &w.policy.AutoRecovery,
+		},
+		Desired: w.desired,
+		History: w.history,
+	}
+	data, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化看门狗设置失败: %v", err)
+	}
+	if dir := filepath.Dir(w.file); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建看门狗设置目录失败: %v", err)
+		}
+	}
+	if err := os.WriteFile(w.file, data, 0644); err != nil {
+		return fmt.Errorf("保存看门狗设置失败: %v", err)
+	}
+	return nil
+}
+
+// watchdogBackoff 计算第attempt次恢复之后的等待时间
+func watchdogBackoff(policy WatchdogPolicy, attempt int) time.Duration {
+	backoff := policy.InitialBackoff
+	for i := 1; i < attempt && backoff < policy.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+		backoff = policy.MaxBackoff
+	}
+	return backoff
+}
+
+// webhookEscalation 返回把升级事件以JSON POST到url的处理函数
+func webhookEscalation(url string) WatchdogHook {
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+	}
+	return func(event models.WatchdogEvent) {
+		data, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("序列化看门狗事件失败: %v", err)
+			return
+		}
+		resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+		if err != nil {
+			log.Printf("发送看门狗事件到 %s 失败: %v", url, err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Printf("发送看门狗事件到 %s 失败，状态码: %d", url, resp.StatusCode)
+		}
+	}
+}
+
+// WatchdogStatuses 返回所有看门狗的状态
+func (s *NetworkService) WatchdogStatuses() []models.WatchdogStatus {
+	statuses := make([]models.WatchdogStatus, 0, len(s.watchdogs))
+	for _, w := range s.watchdogs {
+		statuses = append(statuses, w.Status())
+	}
+	return statuses
+}
+
+// GetHotspotMonitor 获取热点监控的设置、状态和恢复记录
+func (s *NetworkService) GetHotspotMonitor() models.MonitorInfo {
+	return s.hotspotMonitor.Info()
+}
+
+// UpdateHotspotMonitor 修改热点监控设置，立即生效并保存
+func (s *NetworkService) UpdateHotspotMonitor(settings models.MonitorSettings) (models.MonitorInfo, error) {
+	return s.hotspotMonitor.UpdateSettings(settings)
+}
+
+// StartWatchdogs 启动所有看门狗
+func (s *NetworkService) StartWatchdogs() {
+	for _, w := range s.watchdogs {
+		w.Start()
+	}
+}
+
+// StopWatchdogs 停止所有看门狗
+func (s *NetworkService) StopWatchdogs() {
+	for _, w := range s.watchdogs {
+		w.Stop()
+	}
+}
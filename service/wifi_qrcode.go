@@ -0,0 +1,132 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// ErrWiFiProfileNotFound 表示没有找到指定的WiFi配置
+var ErrWiFiProfileNotFound = errors.New("wifi profile not found")
+
+// WiFiQRPayload 生成手机扫码连接WiFi使用的"WIFI:T:WPA;S:<SSID>;P:<密码>;;"文本
+// 密码为空时为开放网络(T:nopass)，SSID和密码中的\ ; , : "按规范用反斜杠转义
+func WiFiQRPayload(ssid, password, authType string) string {
+	if password == "" {
+		authType = "nopass"
+	}
+	var b strings.Builder
+	b.WriteString("WIFI:T:" + authType + ";S:" + escapeWiFiQRField(ssid) + ";")
+	if password != "" {
+		b.WriteString("P:" + escapeWiFiQRField(password) + ";")
+	}
+	b.WriteString(";")
+	return b.String()
+}
+
+// escapeWiFiQRField 转义WIFI二维码字段中的特殊字符
+func escapeWiFiQRField(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, `:`, `\:`, `"`, `\"`).Replace(value)
+}
+
+// wifiQRAuthType 把WiFi配置的认证方式转换为二维码的T字段，WPA2/WPA3都使用WPA
+func wifiQRAuthType(security, password string) string {
+	security = strings.ToLower(security)
+	switch {
+	case password == "":
+		return "nopass"
+	case strings.Contains(security, "wep") || security == "none":
+		// nmcli中key-mgmt为none表示静态WEP
+		return "WEP"
+	default:
+		return "WPA"
+	}
+}
+
+// HotspotQRPayload 按当前热点配置生成WiFi二维码内容
+func (s *NetworkService) HotspotQRPayload() (string, error) {
+	config, err := s.backend.GetHotspotConfig()
+	if err != nil {
+		return "", err
+	}
+	if config.SSID == "" || config.Password == "" {
+		return "", fmt.Errorf("读取不到热点SSID或密码: %w", ErrHotspotNotConfigured)
+	}
+	return WiFiQRPayload(config.SSID, config.Password, "WPA"), nil
+}
+
+// WiFiProfileQRPayload 按已保存的WiFi配置生成二维码内容，name可以是配置名称或SSID
+func (s *NetworkService) WiFiProfileQRPayload(name string) (string, error) {
+	profiles, err := s.backend.ListWiFiProfiles()
+	if err != nil {
+		return "", err
+	}
+	for _, profile := range profiles {
+		if profile.Name == name || profile.SSID == name {
+			return WiFiQRPayload(profile.SSID, profile.Password, wifiQRAuthType(profile.Security, profile.Password)), nil
+		}
+	}
+	return "", fmt.Errorf("WiFi配置 %s 不存在: %w", name, ErrWiFiProfileNotFound)
+}
+
+// newWiFiQRCode 以M级纠错生成二维码，WiFi内容最多约200字节，M级可以兼顾尺寸和容错
+func newWiFiQRCode(payload string) (*qrcode.QRCode, error) {
+	code, err := qrcode.New(payload, qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("生成二维码失败: %v", err)
+	}
+	return code, nil
+}
+
+// RenderQRCodePNG 生成边长为size像素的PNG二维码
+func RenderQRCodePNG(payload string, size int) ([]byte, error) {
+	code, err := newWiFiQRCode(payload)
+	if err != nil {
+		return nil, err
+	}
+	return code.PNG(size)
+}
+
+// RenderQRCodeSVG 生成SVG二维码，每个模块为一个单位，由viewBox缩放
+func RenderQRCodeSVG(payload string) ([]byte, error) {
+	code, err := newWiFiQRCode(payload)
+	if err != nil {
+		return nil, err
+	}
+	bitmap := code.Bitmap()
+	size := len(bitmap)
+
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			// 合并同一行中连续的黑色模块
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size)
+	b.WriteString("<title>WiFi QR code</title>")
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/>`, size, size)
+	fmt.Fprintf(&b, `<path d="%s" fill="#000"/>`, path.String())
+	b.WriteString("</svg>\n")
+	return []byte(b.String()), nil
+}
+
+// RenderQRCodeTerminal 用半高方块字符生成适合深色背景终端显示的二维码
+func RenderQRCodeTerminal(payload string) (string, error) {
+	code, err := newWiFiQRCode(payload)
+	if err != nil {
+		return "", err
+	}
+	return code.ToSmallString(false), nil
+}
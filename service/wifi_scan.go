@@ -0,0 +1,274 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"networkconfig/models"
+	"sort"
+	"strings"
+)
+
+// wifiSecurityFilters 可用于过滤的加密类型，与扫描结果Security摘要中的词一致，open表示开放网络
+var wifiSecurityFilters = []string{"open", "wep", "wpa1", "wpa2", "wpa3", "owe", "802.1x"}
+
+// GetWiFiHotspots 扫描WiFi热点，按条件过滤后排序
+func (s *NetworkService) GetWiFiHotspots(interfaceName string, query models.WiFiScanQuery) ([]models.WiFiHotspot, error) {
+	if errs := ValidateWiFiScanQuery(query); len(errs) > 0 {
+		return nil, errs
+	}
+	scanned, err := s.backend.ScanWiFi(interfaceName)
+	if err != nil {
+		return nil, err
+	}
+
+	hotspots := filterWiFiHotspots(scanned, query)
+	sortWiFiHotspots(hotspots, query)
+	log.Printf("接口 %s 扫描到 %d 个热点，过滤后 %d 个", interfaceName, len(scanned), len(hotspots))
+	return hotspots, nil
+}
+
+// GetWiFiNetworks 扫描WiFi热点并把同一SSID和加密类型的接入点合并为一个网络，隐藏网络的每个接入点单独列出
+func (s *NetworkService) GetWiFiNetworks(interfaceName string, query models.WiFiScanQuery) ([]models.WiFiNetwork, error) {
+	hotspots, err := s.GetWiFiHotspots(interfaceName, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// 接入点已经排好序，网络按其第一个接入点出现的顺序排列，与排序条件一致
+	networks := make([]models.WiFiNetwork, 0)
+	index := make(map[string]int)
+	for _, hotspot := range hotspots {
+		key := hotspot.SSID + "\x00" + hotspot.Security
+		if hotspot.SSID == "" {
+			key = "\x00" + hotspot.BSSID
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(networks)
+			index[key] = i
+			networks = append(networks, models.WiFiNetwork{
+				SSID:           hotspot.SSID,
+				Security:       hotspot.Security,
+				SignalStrength: hotspot.SignalStrength,
+				SignalDBm:      hotspot.SignalDBm,
+				Bands:          []string{},
+			})
+		}
+		network := &networks[i]
+		if hotspot.SignalStrength > network.SignalStrength {
+			network.SignalStrength = hotspot.SignalStrength
+			network.SignalDBm = hotspot.SignalDBm
+		}
+		if hotspot.Band != "" && !containsString(network.Bands, hotspot.Band) {
+			network.Bands = append(network.Bands, hotspot.Band)
+		}
+		network.Connected = network.Connected || hotspot.Connected
+		network.AccessPoints = append(network.AccessPoints, hotspot)
+	}
+	return networks, nil
+}
+
+// filterWiFiHotspots 按最小信号强度、频段和加密类型过滤
+func filterWiFiHotspots(hotspots []models.WiFiHotspot, query models.WiFiScanQuery) []models.WiFiHotspot {
+	result := make([]models.WiFiHotspot, 0, len(hotspots))
+	for _, hotspot := range hotspots {
+		if hotspot.SignalStrength < query.MinSignal {
+			continue
+		}
+		if len(query.Bands) > 0 && !containsFold(query.Bands, hotspot.Band) {
+			continue
+		}
+		if len(query.Security) > 0 && !matchWiFiSecurity(hotspot.Security, query.Security) {
+			continue
+		}
+		result = append(result, hotspot)
+	}
+	return result
+}
+
+// matchWiFiSecurity 判断加密类型摘要是否包含任一过滤条件
+func matchWiFiSecurity(security string, filters []string) bool {
+	for _, filter := range filters {
+		if strings.EqualFold(filter, "open") {
+			if security == "" {
+				return true
+			}
+			continue
+		}
+		if containsFold(strings.Fields(security), filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// WiFi配置的认证方式，由netsh的身份验证或nmcli的key-mgmt归一化而来
+const (
+	wifiAuthUnknown    = ""
+	wifiAuthOpen       = "open"
+	wifiAuthWEP        = "wep"
+	wifiAuthWPA        = "wpa"  // WPA-Personal
+	wifiAuthWPA2       = "wpa2" // WPA2-Personal
+	wifiAuthWPA3       = "wpa3" // WPA3-Personal(SAE)
+	wifiAuthEnterprise = "802.1x"
+)
+
+// wifiProfileAuth 归一化WiFi配置的认证方式，如WPA2-Personal、WPA3 - 个人、wpa-psk、sae
+// 没有认证方式时有密码按WPA2处理，没有密码时无法判断是否为开放网络，返回wifiAuthUnknown
+func wifiProfileAuth(security, password string) string {
+	auth := strings.ToLower(strings.ReplaceAll(security, " ", ""))
+	switch {
+	case auth == "":
+		if password != "" {
+			return wifiAuthWPA2
+		}
+		return wifiAuthUnknown
+	case strings.Contains(auth, "802.1x") || strings.Contains(auth, "enterprise") || strings.Contains(auth, "企业") || strings.Contains(auth, "eap"):
+		return wifiAuthEnterprise
+	case strings.HasPrefix(auth, "wpa3") || auth == "sae":
+		return wifiAuthWPA3
+	case strings.HasPrefix(auth, "wpa2") || auth == "wpa-psk":
+		return wifiAuthWPA2
+	case strings.HasPrefix(auth, "wpa"):
+		return wifiAuthWPA
+	case auth == "wep" || auth == "none" || auth == "shared" || auth == "共享":
+		// nmcli中key-mgmt为none表示静态WEP
+		return wifiAuthWEP
+	case auth == "open" || auth == "开放式":
+		return wifiAuthOpen
+	default:
+		return wifiAuthUnknown
+	}
+}
+
+// wifiProfileAuthFor 返回保存WiFi配置时使用的认证方式
+// 不支持802.1X企业级配置；不是开放网络的配置没有密码时返回错误，避免替换成开放网络
+func wifiProfileAuthFor(profile models.WiFiProfile) (string, error) {
+	auth := wifiProfileAuth(profile.Security, profile.Password)
+	switch {
+	case auth == wifiAuthEnterprise:
+		return "", fmt.Errorf("不支持保存802.1X企业级WiFi配置 %s", profile.SSID)
+	case auth == wifiAuthOpen:
+		return auth, nil
+	case profile.Password == "":
+		return "", fmt.Errorf("WiFi配置 %s 没有密码，且认证方式(%s)不是开放网络", profile.SSID, profile.Security)
+	case auth == wifiAuthUnknown:
+		return wifiAuthWPA2, nil
+	}
+	return auth, nil
+}
+
+// sortWiFiHotspots 按排序字段稳定排序，字段相同时依次按信号强度、SSID和BSSID排列
+func sortWiFiHotspots(hotspots []models.WiFiHotspot, query models.WiFiScanQuery) {
+	field := query.Sort
+	if field == "" {
+		field = models.WiFiSortSignal
+	}
+	// 信号强度默认从强到弱，其余字段默认升序
+	descending := field == models.WiFiSortSignal
+	switch query.Order {
+	case "asc":
+		descending = false
+	case "desc":
+		descending = true
+	}
+
+	compare := func(a, b models.WiFiHotspot) int {
+		switch field {
+		case models.WiFiSortSSID:
+			return strings.Compare(strings.ToLower(a.SSID), strings.ToLower(b.SSID))
+		case models.WiFiSortChannel:
+			return a.Channel - b.Channel
+		case models.WiFiSortFrequency:
+			return a.Frequency - b.Frequency
+		}
+		return a.SignalStrength - b.SignalStrength
+	}
+	sort.SliceStable(hotspots, func(i, j int) bool {
+		a, b := hotspots[i], hotspots[j]
+		if c := compare(a, b); c != 0 {
+			return (c > 0) == descending
+		}
+		if a.SignalStrength != b.SignalStrength {
+			return a.SignalStrength > b.SignalStrength
+		}
+		if a.SSID != b.SSID {
+			return a.SSID < b.SSID
+		}
+		return a.BSSID < b.BSSID
+	})
+}
+
+// normalizeAKM 把各工具给出的认证套件名称统一为小写形式，如"IEEE 802.1X"、"EAP"为802.1x，"FT/PSK"为ft-psk，"PSK-SHA256"为psk-sha256
+func normalizeAKM(name string) string {
+	akm := strings.NewReplacer("ieee 802.1x", "802.1x", "/", "-", "sha-256", "sha256", "sha-384", "sha384", "suiteb", "suite-b").
+		Replace(strings.ToLower(strings.TrimSpace(name)))
+	// wpa_supplicant和nmcli用EAP表示802.1X认证
+	parts := strings.Split(akm, "-")
+	for i, part := range parts {
+		if part == "eap" {
+			parts[i] = "802.1x"
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// normalizeCiphers 把加密算法名称统一为小写，去掉空值和重复项
+func normalizeCiphers(names []string) []string {
+	ciphers := make([]string, 0, len(names))
+	for _, name := range names {
+		cipher := strings.ToLower(strings.TrimSpace(name))
+		if cipher == "" || cipher == "none" || containsString(ciphers, cipher) {
+			continue
+		}
+		ciphers = append(ciphers, cipher)
+	}
+	return ciphers
+}
+
+// newWiFiSecurity 创建加密方式详情，AKM和加密算法名称统一为小写
+func newWiFiSecurity(akms, ciphers []string, groupCipher string) models.WiFiSecurity {
+	security := models.WiFiSecurity{AKM: make([]string, 0, len(akms)), Ciphers: normalizeCiphers(ciphers)}
+	for _, name := range akms {
+		if akm := normalizeAKM(name); akm != "" && !containsString(security.AKM, akm) {
+			security.AKM = append(security.AKM, akm)
+		}
+	}
+	if group := normalizeCiphers([]string{groupCipher}); len(group) > 0 {
+		security.GroupCipher = group[0]
+	}
+	return security
+}
+
+// percentToDBm 把NetworkManager的信号百分比换算回dBm，是signalPercent的逆运算
+func percentToDBm(percent int) int {
+	return -40 - (100-clamp(percent, 0, 100))*60/100
+}
+
+// wifiPHYType 按接入点支持的最高标准返回无线类型，没有HT/VHT/HE/EHT信息时按频段推断
+func wifiPHYType(ht, vht, he, eht bool, band string) string {
+	switch {
+	case eht:
+		return "802.11be"
+	case he:
+		return "802.11ax"
+	case vht:
+		return "802.11ac"
+	case ht:
+		return "802.11n"
+	case band == models.HotspotBand24GHz:
+		return "802.11g"
+	case band == models.HotspotBand5GHz:
+		return "802.11a"
+	}
+	return ""
+}
+
+func containsFold(values []string, value string) bool {
+	for _, item := range values {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"networkconfig/models"
+	"strings"
+	"testing"
+)
+
+func TestWiFiProfileAuthFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		security string
+		password string
+		want     string
+		wantErr  bool
+	}{
+		{name: "netsh WPA2-Personal", security: "WPA2-Personal", password: "secret123", want: wifiAuthWPA2},
+		{name: "netsh中文WPA3", security: "WPA3 - 个人", password: "secret123", want: wifiAuthWPA3},
+		{name: "netsh WPA-Personal", security: "WPA-Personal", password: "secret123", want: wifiAuthWPA},
+		{name: "nmcli wpa-psk", security: "wpa-psk", password: "secret123", want: wifiAuthWPA2},
+		{name: "nmcli sae", security: "sae", password: "secret123", want: wifiAuthWPA3},
+		{name: "nmcli静态WEP", security: "none", password: "0123456789", want: wifiAuthWEP},
+		{name: "开放网络", security: "开放式", want: wifiAuthOpen},
+		{name: "没有认证方式时有密码", password: "secret123", want: wifiAuthWPA2},
+		{name: "无法识别的认证方式", security: "WAPI", password: "secret123", want: wifiAuthWPA2},
+		{name: "加密网络没有密码", security: "WPA2-Personal", wantErr: true},
+		{name: "没有认证方式也没有密码", wantErr: true},
+		{name: "企业级", security: "WPA2-Enterprise", password: "secret123", wantErr: true},
+		{name: "nmcli wpa-eap", security: "wpa-eap", password: "secret123", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := wifiProfileAuthFor(models.WiFiProfile{SSID: "Home", Security: tt.security, Password: tt.password})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("wifiProfileAuthFor(%q) 错误 = %v，应返回错误: %v", tt.security, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("wifiProfileAuthFor(%q) = %q，应为 %q", tt.security, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWLANProfileXML(t *testing.T) {
+	tests := []struct {
+		auth     string
+		password string
+		want     []string
+	}{
+		{auth: wifiAuthOpen, want: []string{"<authentication>open</authentication>", "<encryption>none</encryption>"}},
+		{auth: wifiAuthWEP, password: "0123456789", want: []string{"<encryption>WEP</encryption>", "<keyType>networkKey</keyType>"}},
+		{auth: wifiAuthWPA, password: "secret123", want: []string{"<authentication>WPAPSK</authentication>", "<encryption>TKIP</encryption>"}},
+		{auth: wifiAuthWPA2, password: "secret123", want: []string{"<authentication>WPA2PSK</authentication>", "<encryption>AES</encryption>"}},
+		{auth: wifiAuthWPA3, password: "secret123", want: []string{"<authentication>WPA3SAE</authentication>", "<keyType>passPhrase</keyType>"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.auth, func(t *testing.T) {
+			xml := wlanProfileXML("Home", "Home", tt.password, tt.auth)
+			for _, want := range tt.want {
+				if !strings.Contains(xml, want) {
+					t.Errorf("认证方式 %s 的配置文件缺少 %s:\n%s", tt.auth, want, xml)
+				}
+			}
+			if hasKey := strings.Contains(xml, "<sharedKey>"); hasKey != (tt.password != "") {
+				t.Errorf("认证方式 %s 的配置文件包含sharedKey: %v", tt.auth, hasKey)
+			}
+		})
+	}
+}
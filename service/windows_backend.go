@@ -0,0 +1,2040 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net"
+	"net/url"
+	"networkconfig/models"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// windowsBackend 基于netsh和PowerShell的Windows平台后端
+type windowsBackend struct {
+	debug  bool
+	runner CommandRunner
+}
+
+// newWindowsBackend 创建Windows平台后端
+func newWindowsBackend(debug bool, runner CommandRunner) *windowsBackend {
+	return &windowsBackend{debug: debug, runner: runner}
+}
+
+// commandRunner 返回后端使用的命令执行器
+func (b *windowsBackend) commandRunner() CommandRunner {
+	return b.runner
+}
+
+// withRunner 返回使用指定命令执行器的后端副本
+func (b *windowsBackend) withRunner(runner CommandRunner) Backend {
+	return newWindowsBackend(b.debug, runner)
+}
+
+// command 创建由后端runner执行的命令
+func (b *windowsBackend) command(name string, args ...string) *Command {
+	return NewCommand(b.runner, name, args...)
+}
+
+// Name 返回后端名称
+func (b *windowsBackend) Name() string {
+	return BackendWindows
+}
+
+// ListInterfaces 快速获取网卡列表
+func (b *windowsBackend) ListInterfaces() ([]InterfaceFast, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("获取网卡列表失败: %v", err)
+	}
+
+	log.Printf("系统中共发现 %d 个网络接口", len(ifaces))
+
+	var interfaces []InterfaceFast
+	for _, iface := range ifaces {
+		// 跳过回环接口
+		if iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+
+		// 跳过特定虚拟接口
+		if strings.Contains(iface.Name, "Virtual") ||
+			strings.Contains(strings.ToLower(iface.Name), "vethernet") ||
+			strings.Contains(strings.ToLower(iface.Name), "wireguard") ||
+			strings.Contains(strings.ToLower(iface.Name), "virtualbox") ||
+			strings.Contains(strings.ToLower(iface.Name), "vmware") ||
+			strings.Contains(strings.ToLower(iface.Name), "vpn") {
+			continue
+		}
+
+		// 跳过MAC地址为空的无效网卡
+		if iface.HardwareAddr == nil || len(iface.HardwareAddr) == 0 {
+			log.Printf("跳过MAC地址为空的接口: %s", iface.Name)
+			continue
+		}
+
+		ifaceInfo := InterfaceFast{
+			Name:   iface.Name,
+			Status: getInterfaceStatusFast(iface.Flags),
+		}
+		// 获取硬件和驱动信息
+		hardware, err := b.getHardwareInfo(iface.Name)
+		if err != nil {
+			log.Printf("获取接口 %s 硬件信息失败: %v", iface.Name, err)
+		} else {
+			log.Printf("接口 %s 硬件信息: %+v", iface.Name, hardware)
+			ifaceInfo.ProductName = hardware.ProductName
+			if hardware.ProductName == "" {
+				log.Printf("警告: 接口 %s 的产品名称为空，忽略。", iface.Name)
+				continue
+			}
+			if strings.Contains(ifaceInfo.ProductName, "KM-TEST") {
+				log.Printf("警告: 接口 %s 的产品名称包含关键字 KM-TEST，忽略。", iface.Name)
+				continue
+			}
+		}
+
+		interfaces = append(interfaces, ifaceInfo)
+	}
+
+	return interfaces, nil
+}
+
+// bytesToHexString 将字节数组转换为十六进制字符串
+func bytesToHexString(b []byte) string {
+	var buf bytes.Buffer
+	for _, b := range b {
+		buf.WriteString(fmt.Sprintf("%02X", b))
+	}
+	return buf.String()
+}
+
+// GetInterface 获取指定网卡的详细信息
+func (b *windowsBackend) GetInterface(name string) (models.Interface, error) {
+	log.Printf("开始获取接口 %s 的信息", name)
+
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		log.Printf("获取网卡 %s 信息失败: %v", name, err)
+		return models.Interface{}, fmt.Errorf("获取网卡信息失败: %v", err)
+	}
+
+	log.Printf("接口 %s 基本信息: MTU=%d, Flags=%v, HardwareAddr=%s",
+		name, iface.MTU, iface.Flags, iface.HardwareAddr)
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		log.Printf("获取网卡 %s 地址失败: %v", name, err)
+		return models.Interface{}, fmt.Errorf("获取网卡地址失败: %v", err)
+	}
+
+	log.Printf("接口 %s 有 %d 个地址", name, len(addrs))
+
+	// 检查DHCP状态
+	dhcpEnabled := false
+	cmd := b.command("netsh", "interface", "ipv4", "show", "config", "name="+name)
+	if output, err := cmd.Output(); err == nil {
+		lines := strings.Split(string(output), "\n")
+		for _, line := range lines {
+			if strings.Contains(line, "DHCP enabled") {
+				dhcpEnabled = strings.Contains(line, "Yes")
+				break
+			}
+		}
+	}
+
+	ifaceInfo := models.Interface{
+		Name:        iface.Name,
+		Description: b.getInterfaceDescription(name),
+		Status:      getInterfaceStatus(iface.Flags),
+		DHCPEnabled: dhcpEnabled,
+	}
+
+	// 获取硬件和驱动信息
+	hardware, err := b.getHardwareInfo(name)
+	if err != nil {
+		log.Printf("获取接口 %s 硬件信息失败: %v", name, err)
+		ifaceInfo.Hardware = models.Hardware{
+			MACAddress: iface.HardwareAddr.String(),
+		}
+		return ifaceInfo, fmt.Errorf("获取硬件信息失败: %v", err)
+	} else {
+		ifaceInfo.Hardware = hardware
+		log.Printf("接口 %s 硬件信息: %+v", name, hardware)
+
+		// 如果是无线网卡，获取当前连接的SSID
+		if hardware.AdapterType == models.AdapterTypeWireless {
+			ssid, err := b.getConnectedSSID(name)
+			if err != nil {
+				log.Printf("获取接口 %s 的SSID失败: %v", name, err)
+			} else if ssid != "" {
+				ifaceInfo.ConnectedSSID = ssid
+				log.Printf("接口 %s 当前连接的热点: %s", name, ssid)
+			}
+		}
+	}
+
+	//driver, err := b.getDriverInfo(name)
+	//if err != nil {
+	//	log.Printf("获取接口 %s 驱动信息失败: %v", name, err)
+	//	ifaceInfo.Driver = models.Driver{
+	//		Name: iface.Name,
+	//	}
+	//} else {
+	//	ifaceInfo.Driver = driver
+	//	log.Printf("接口 %s 驱动信息: %+v", name, driver)
+	//}
+
+	// 获取IPv4和IPv6配置，ipv4_config/ipv6_config只描述各地址族的主地址
+	ifaceInfo.Addresses = interfaceAddresses(addrs, b.getAddressOrigins(name))
+	if v4 := primaryAddress(ifaceInfo.Addresses, models.AddressFamilyIPv4); v4 != nil {
+		gateway := b.getDefaultGateway(name)
+		dns, static := b.getDNSServers(name)
+		log.Printf("接口 %s IPv4地址: Address=%s, Gateway=%s, DNS=%v", name, v4.Address, gateway, dns)
+
+		ifaceInfo.IPv4Config = models.IPv4Config{
+			IP:      v4.IP,
+			Mask:    net.IP(net.CIDRMask(v4.PrefixLen, 32)).String(),
+			Gateway: gateway,
+			DNS:     dns,
+			DHCP:    ifaceInfo.DHCPEnabled,
+			DNSAuto: ifaceInfo.DHCPEnabled && !static,
+		}
+	}
+	if v6 := primaryAddress(ifaceInfo.Addresses, models.AddressFamilyIPv6); v6 != nil {
+		gateway := b.getIPv6Gateway(name)
+		dns := b.getIPv6DNSServers(name)
+		log.Printf("接口 %s IPv6地址: Address=%s, Gateway=%s, DNS=%v", name, v6.Address, gateway, dns)
+
+		ifaceInfo.IPv6Config = models.IPv6Config{
+			IP:        v6.IP,
+			PrefixLen: v6.PrefixLen,
+			Gateway:   gateway,
+			DNS:       dns,
+		}
+	}
+	fillAddressCIDR(&ifaceInfo)
+
+	log.Printf("成功获取接口 %s 的完整信息", name)
+	return ifaceInfo, nil
+}
+
+// getHardwareInfo 获取网卡硬件信息
+func (b *windowsBackend) getHardwareInfo(name string) (models.Hardware, error) {
+	// 首先尝试使用PowerShell获取信息
+	hw, err := b.getHardwareInfoViaPowerShell(name)
+	if err == nil {
+		return hw, nil
+	}
+
+	log.Printf("通过PowerShell获取接口 %s 硬件信息失败: %v，尝试备用方案", name, err)
+
+	// 检查是否是无线网卡
+	if isWirelessInterface(name) {
+		// 尝试通过netsh获取无线网卡信息
+		hw, err := b.getWirelessInfoViaNetsh(name)
+		if err == nil {
+			log.Printf("成功通过netsh获取接口 %s 的无线网卡信息", name)
+			return hw, nil
+		}
+		log.Printf("通过netsh获取接口 %s 无线网卡信息失败: %v", name, err)
+	}
+
+	// 如果都失败，返回最少信息
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return models.Hardware{}, fmt.Errorf("无法获取网卡基本信息: %v", err)
+	}
+
+	return models.Hardware{
+		MACAddress: iface.HardwareAddr.String(),
+	}, nil
+}
+
+// psHardwareInfoScript 按连接名或设备名读取网卡硬件信息，设置UTF-8编码
+const psHardwareInfoScript = `
+		[Console]::OutputEncoding = [System.Text.Encoding]::UTF8
+		$PSDefaultParameterValues['*:Encoding'] = 'utf8'
+		Get-WmiObject Win32_NetworkAdapter | Where-Object { $_.NetConnectionID -eq '%s' -or $_.Name -eq '%s' } | 
+		Select-Object MACAddress,Manufacturer,ProductName,AdapterType,NetConnectionID,Speed,PNPDeviceID | 
+		ConvertTo-Json -Depth 1
+	`
+
+// psPhysicalAdapterScript 读取网卡是否为物理网卡
+const psPhysicalAdapterScript = `Get-WmiObject Win32_NetworkAdapter | Where-Object { $_.NetConnectionID -eq '%s' } | Select-Object PhysicalAdapter | ConvertTo-Json`
+
+// psAdapterCaptionScript 读取网卡的总线类型描述，设置UTF-8编码
+const psAdapterCaptionScript = `
+		[Console]::OutputEncoding = [System.Text.Encoding]::UTF8
+		$PSDefaultParameterValues['*:Encoding'] = 'utf8'
+		Get-WmiObject Win32_NetworkAdapter | 
+			Where-Object { $_.NetConnectionID -eq '%s' } | 
+			Select-Object Caption | 
+			ConvertTo-Json -Depth 1
+	`
+
+// getHardwareInfoViaPowerShell 通过PowerShell获取硬件信息
+func (b *windowsBackend) getHardwareInfoViaPowerShell(name string) (models.Hardware, error) {
+	// 使用PowerShell命令获取网卡硬件信息，设置UTF-8编码
+	psCmd := fmt.Sprintf(psHardwareInfoScript, name, name)
+
+	cmd := b.command("powershell", "-NoProfile", "-NonInteractive", "-Command", psCmd)
+	cmd.Env = append(os.Environ(), "PYTHONIOENCODING=utf-8")
+
+	log.Printf("执行PowerShell命令获取网卡 %s 的硬件信息", name)
+	output, err := cmd.Output()
+	if err != nil {
+		// 获取错误详情
+		if stderr, ok := commandStderr(err); ok {
+			return models.Hardware{}, fmt.Errorf("执行PowerShell命令失败: %v, stderr: %s", err, string(stderr))
+		}
+		return models.Hardware{}, fmt.Errorf("执行PowerShell命令失败: %v", err)
+	}
+
+	if len(output) == 0 {
+		log.Printf("未找到网卡 %s 的硬件信息", name)
+		return models.Hardware{}, fmt.Errorf("未找到网卡硬件信息: %s", name)
+	}
+
+	// 尝试转换编码
+	decodedOutput, err := DecodeToUTF8(output)
+	if err != nil {
+		log.Printf("转换编码失败: %v", err)
+		return models.Hardware{}, fmt.Errorf("转换编码失败: %v", err)
+	}
+
+	log.Printf("网卡 %s 的原始硬件信息: %s", name, string(decodedOutput))
+
+	// 解析JSON输出
+	var result struct {
+		MACAddress   string `json:"MACAddress"`
+		Manufacturer string `json:"Manufacturer"`
+		ProductName  string `json:"ProductName"`
+		AdapterType  string `json:"AdapterType"`
+		Speed        uint64 `json:"Speed"`
+		PNPDeviceID  string `json:"PNPDeviceID"`
+	}
+
+	if err := json.Unmarshal(decodedOutput, &result); err != nil {
+		log.Printf("解析硬件信息JSON失败: %v", err)
+		return models.Hardware{}, fmt.Errorf("解析硬件信息失败: %v", err)
+	}
+
+	log.Printf("成功解析网卡 %s 的硬件信息: %+v", name, result)
+
+	// 获取物理媒体类型
+	mediaCmd := b.command("powershell", "-Command", fmt.Sprintf(psPhysicalAdapterScript, name))
+
+	mediaOutput, err := mediaCmd.Output()
+	if err == nil {
+		var mediaResult struct {
+			PhysicalAdapter bool `json:"PhysicalAdapter"`
+		}
+		if err := json.Unmarshal(mediaOutput, &mediaResult); err == nil {
+			if mediaResult.PhysicalAdapter {
+				// 获取总线类型
+				// 获取总线类型，设置UTF-8编码
+				busCmd := fmt.Sprintf(psAdapterCaptionScript, name)
+
+				cmd := b.command("powershell", "-NoProfile", "-NonInteractive", "-Command", busCmd)
+				cmd.Env = append(os.Environ(), "PYTHONIOENCODING=utf-8")
+
+				if busOutput, err := cmd.Output(); err == nil {
+					// 转换编码
+					decodedBusOutput, err := DecodeToUTF8(busOutput)
+					if err == nil {
+						var busResult struct {
+							Caption string `json:"Caption"`
+						}
+						if err := json.Unmarshal(decodedBusOutput, &busResult); err == nil {
+							log.Printf("网卡 %s 的总线信息: %s", name, busResult.Caption)
+							// 从Caption中提取总线类型
+							if strings.Contains(busResult.Caption, "PCI") {
+								result.AdapterType = "PCI"
+							} else if strings.Contains(busResult.Caption, "USB") {
+								result.AdapterType = "USB"
+							}
+						} else {
+							log.Printf("解析总线信息JSON失败: %v", err)
+						}
+					} else {
+						log.Printf("转换总线信息编码失败: %v", err)
+					}
+				} else {
+					log.Printf("获取总线信息失败: %v", err)
+				}
+			}
+		}
+	}
+
+	// 转换速度为可读格式
+	speedStr := "Unknown"
+	if result.Speed > 0 {
+		speed := float64(result.Speed) / 1000000 // 转换为Mbps
+		speedStr = fmt.Sprintf("%.0f Mbps", speed)
+	}
+
+	// 确定网卡类型
+	adapterType := models.AdapterTypeEthernet // 默认为有线
+	if strings.Contains(strings.ToLower(result.ProductName), "wireless") ||
+		strings.Contains(strings.ToLower(result.ProductName), "wi-fi") ||
+		strings.Contains(strings.ToLower(result.ProductName), "wlan") {
+		adapterType = models.AdapterTypeWireless
+	}
+
+	return models.Hardware{
+		MACAddress:    result.MACAddress,
+		Manufacturer:  result.Manufacturer,
+		ProductName:   result.ProductName,
+		AdapterType:   adapterType,
+		PhysicalMedia: "Ethernet", // 默认值，可以根据实际情况修改
+		Speed:         speedStr,
+		BusType:       result.AdapterType,
+		PNPDeviceID:   result.PNPDeviceID,
+	}, nil
+}
+
+// isWirelessInterface 判断是否是无线网卡
+func isWirelessInterface(name string) bool {
+	// 根据常见无线网卡命名规则判断
+	lowerName := strings.ToLower(name)
+	return strings.Contains(lowerName, "wi-fi") ||
+		strings.Contains(lowerName, "wireless") ||
+		strings.Contains(lowerName, "wlan")
+}
+
+// getWirelessInfoViaNetsh 通过netsh获取无线网卡信息
+func (b *windowsBackend) getWirelessInfoViaNetsh(interfaceName string) (models.Hardware, error) {
+	log.Printf("尝试通过netsh获取接口 %s 的无线网卡信息", interfaceName)
+
+	// 获取所有无线网卡接口信息
+	cmd := b.command("netsh", "wlan", "show", "interfaces")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("netsh命令执行失败: %v, 输出: %s", err, string(output))
+		return models.Hardware{}, fmt.Errorf("netsh命令执行失败: %v", err)
+	}
+
+	// 将输出转换为字符串
+	outputStr := string(output)
+	log.Printf("netsh原始输出:\n%s", outputStr)
+
+	// 按接口分割输出
+	interfaces := strings.Split(outputStr, "\n\n")
+	var targetOutput string
+	found := false
+
+	// 遍历每个接口块，查找指定的网卡
+	for _, iface := range interfaces {
+		// 从接口块中提取网卡名称
+		lines := strings.Split(iface, "\n")
+		for _, line := range lines {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "Name") || strings.HasPrefix(line, "名称") {
+				parts := strings.SplitN(line, ":", 2)
+				if len(parts) > 1 {
+					name := strings.TrimSpace(parts[1])
+					log.Printf("检查接口: %q 是否匹配目标: %q", name, interfaceName)
+					if name == interfaceName {
+						targetOutput = iface
+						found = true
+						break
+					}
+				}
+			}
+		}
+		if found {
+			break
+		}
+	}
+
+	if !found {
+		log.Printf("在可用的无线网卡列表中未找到接口 %s", interfaceName)
+		return models.Hardware{}, fmt.Errorf("指定的网卡 %s 不是可用的无线网卡", interfaceName)
+	}
+
+	log.Printf("找到目标网卡 %s 的信息块:\n%s", interfaceName, targetOutput)
+	return parseWirelessNetshOutput(targetOutput), nil
+}
+
+// parseWirelessNetshOutput 解析netsh命令输出
+func parseWirelessNetshOutput(output string) models.Hardware {
+	hw := models.Hardware{
+		AdapterType: models.AdapterTypeWireless,
+	}
+
+	log.Printf("开始解析无线网卡信息块...")
+	lines := strings.Split(output, "\n")
+	var rxRate, txRate string
+	var manufacturer string
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) < 2 {
+			continue
+		}
+
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+
+		switch key {
+		case "Description", "描述":
+			hw.ProductName = value
+			// 尝试从描述中提取制造商信息
+			if strings.Contains(value, "Intel") {
+				manufacturer = "Intel Corporation"
+			} else if strings.Contains(value, "Realtek") {
+				manufacturer = "Realtek Semiconductor Corp."
+			} else if strings.Contains(value, "Broadcom") {
+				manufacturer = "Broadcom Inc."
+			} else if strings.Contains(value, "MediaTek") {
+				manufacturer = "MediaTek Inc."
+			}
+			log.Printf("解析到产品名称: %s", value)
+
+		case "Name", "名称":
+			if hw.ProductName == "" {
+				hw.ProductName = value
+				log.Printf("使用网卡名称作为产品名称: %s", value)
+			}
+
+		case "Physical address", "物理地址":
+			hw.MACAddress = value
+			log.Printf("解析到MAC地址: %s", value)
+
+		case "Media type", "媒体类型", "Connection type", "连接类型":
+			hw.PhysicalMedia = value
+			log.Printf("解析到媒体类型: %s", value)
+
+		case "State", "状态":
+			// 记录状态但不存储，可用于调试
+			log.Printf("网卡状态: %s", value)
+
+		case "SSID", "SSID 名称":
+			// 记录当前连接的SSID，可用于调试
+			log.Printf("当前连接的SSID: %s", value)
+
+		case "Receive rate (Mbps)", "接收速率 (Mbps)":
+			rxRate = value
+			log.Printf("解析到接收速率: %s Mbps", value)
+
+		case "Transmit rate (Mbps)", "传输速率 (Mbps)":
+			txRate = value
+			log.Printf("解析到传输速率: %s Mbps", value)
+
+		case "Signal", "信号":
+			// 记录信号强度，可用于调试
+			log.Printf("当前信号强度: %s", value)
+
+		case "Band", "频段":
+			// 记录频段信息，可用于调试
+			log.Printf("工作频段: %s", value)
+
+		case "Radio type", "无线电类型":
+			// 可以用来确定是802.11n/ac等
+			log.Printf("无线电类型: %s", value)
+			if hw.PhysicalMedia == "" {
+				hw.PhysicalMedia = fmt.Sprintf("802.11 %s", value)
+			}
+		}
+	}
+
+	// 设置制造商信息
+	if manufacturer != "" {
+		hw.Manufacturer = manufacturer
+		log.Printf("设置制造商: %s", manufacturer)
+	}
+
+	// 组合速率信息
+	if rxRate != "" || txRate != "" {
+		var speedParts []string
+		if rxRate != "" {
+			speedParts = append(speedParts, fmt.Sprintf("Rx: %s Mbps", rxRate))
+		}
+		if txRate != "" {
+			speedParts = append(speedParts, fmt.Sprintf("Tx: %s Mbps", txRate))
+		}
+		hw.Speed = strings.Join(speedParts, ", ")
+		log.Printf("设置最终速率: %s", hw.Speed)
+	}
+
+	// 设置总线类型为PCI（大多数无线网卡都是PCI设备）
+	hw.BusType = "PCI"
+
+	// 验证必要字段
+	if hw.ProductName == "" {
+		log.Printf("警告: 未能解析到产品名称")
+	}
+	if hw.MACAddress == "" {
+		log.Printf("警告: 未能解析到MAC地址")
+	}
+	if hw.PhysicalMedia == "" {
+		hw.PhysicalMedia = "802.11 Wireless"
+		log.Printf("设置默认媒体类型: %s", hw.PhysicalMedia)
+	}
+
+	log.Printf("无线网卡信息解析完成: %+v", hw)
+	return hw
+}
+
+// psDriverInfoScript 按网卡的PNPDeviceID读取驱动信息，设置UTF-8编码
+const psDriverInfoScript = `
+		[Console]::OutputEncoding = [System.Text.Encoding]::UTF8
+		$PSDefaultParameterValues['*:Encoding'] = 'utf8'
+		$ErrorActionPreference = 'Stop'
+		
+		# 首先获取网络适配器的PNPDeviceID
+		$adapter = Get-WmiObject Win32_NetworkAdapter | Where-Object { $_.NetConnectionID -eq '%s' -or $_.Name -eq '%s' }
+		if ($adapter) {
+			# 使用PNPDeviceID查找对应的驱动程序
+			Get-WmiObject Win32_PnPSignedDriver | 
+				Where-Object { $_.DeviceID -eq $adapter.PNPDeviceID } |
+				Select-Object DriverVersion,DriverProvider,DriverDate,DeviceName,InfName |
+				ConvertTo-Json -Depth 1
+		} else {
+			Write-Error "找不到指定的网络适配器"
+		}
+	`
+
+// getDriverInfo 获取网卡驱动信息
+func (b *windowsBackend) getDriverInfo(name string) (models.Driver, error) {
+	log.Printf("开始获取网卡 %s 的驱动信息", name)
+
+	// 使用PowerShell命令获取网卡驱动信息，设置UTF-8编码
+	psCmd := fmt.Sprintf(psDriverInfoScript, name, name)
+
+	cmd := b.command("powershell", "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-Command", psCmd)
+	cmd.Env = append(os.Environ(),
+		"PYTHONIOENCODING=utf-8",
+		"POWERSHELL_TELEMETRY_OPTOUT=1")
+
+	output, err := cmd.Output()
+	if err != nil {
+		// 获取错误详情
+		if output, ok := commandStderr(err); ok {
+			stderr := string(output)
+			log.Printf("获取网卡 %s 驱动信息时出错: %v\nstderr: %s", name, err, stderr)
+			if strings.Contains(stderr, "找不到指定的网络适配器") {
+				return models.Driver{}, fmt.Errorf("找不到网卡: %s", name)
+			}
+			return models.Driver{}, fmt.Errorf("获取驱动信息失败: %v", err)
+		}
+		log.Printf("执行PowerShell命令失败: %v", err)
+		return models.Driver{}, fmt.Errorf("执行PowerShell命令失败: %v", err)
+	}
+
+	if len(output) == 0 {
+		log.Printf("未找到网卡 %s 的驱动信息", name)
+		return models.Driver{}, fmt.Errorf("未找到网卡 %s 的驱动信息", name)
+	}
+
+	// 尝试转换编码
+	decodedOutput, err := DecodeToUTF8(output)
+	if err != nil {
+		log.Printf("转换驱动信息编码失败: %v", err)
+		return models.Driver{}, fmt.Errorf("转换编码失败: %v", err)
+	}
+
+	log.Printf("网卡 %s 的原始驱动信息: %s", name, string(decodedOutput))
+
+	// 解析JSON输出
+	var result struct {
+		DriverVersion  string `json:"DriverVersion"`
+		DriverProvider string `json:"DriverProvider"`
+		DriverDate     string `json:"DriverDate"`
+		DeviceName     string `json:"DeviceName"`
+		InfName        string `json:"InfName"`
+	}
+
+	if err := json.Unmarshal(decodedOutput, &result); err != nil {
+		log.Printf("解析驱动信息JSON失败: %v", err)
+		return models.Driver{}, fmt.Errorf("解析驱动信息失败: %v", err)
+	}
+
+	log.Printf("成功解析网卡 %s 的驱动信息: %+v", name, result)
+
+	// 格式化安装日期
+	dateInstalled := "Unknown"
+	if result.DriverDate != "" {
+		if date, err := time.Parse("20060102150405.999999-070", result.DriverDate); err == nil {
+			dateInstalled = date.Format("2006-01-02")
+		}
+	}
+
+	return models.Driver{
+		Name:          result.DeviceName,
+		Version:       result.DriverVersion,
+		Provider:      result.DriverProvider,
+		DateInstalled: dateInstalled,
+		Status:        "OK", // 默认值，可以根据实际情况修改
+		Path:          result.InfName,
+	}, nil
+}
+
+// ConfigureIPv4 配置IPv4地址
+func (b *windowsBackend) ConfigureIPv4(name string, config models.IPv4Config) error {
+	if config.DHCP {
+		log.Printf("开始为接口 %s 配置DHCP自动获取IP", name)
+
+		// 检查当前是否已经是DHCP状态
+		currentDHCP, err := b.isDHCPEnabled(name)
+		if err != nil {
+			log.Printf("检查接口 %s 的DHCP状态失败: %v", name, err)
+			return fmt.Errorf("检查DHCP状态失败: %v", err)
+		}
+
+		if !currentDHCP {
+			// 当前不是DHCP状态，需要设置
+			log.Printf("为接口 %s 设置DHCP自动获取IP", name)
+
+			cmd := b.command("netsh",
+				"interface",
+				"ipv4",
+				"set",
+				"address",
+				fmt.Sprintf("name=%s", name),
+				"source=dhcp")
+
+			output, err := cmd.CombinedOutput()
+			if err != nil {
+				log.Printf("设置DHCP失败: %v, 输出: %s", err, string(output))
+				return fmt.Errorf("设置DHCP失败: %v, 输出: %s", err, string(output))
+			}
+			log.Printf("成功设置DHCP自动获取IP")
+		} else {
+			log.Printf("接口 %s 已经是DHCP状态，跳过设置", name)
+		}
+
+		// 设置DNS
+		if config.DNSAuto {
+			if err := b.SetDNS(name, false, nil); err != nil {
+				return err
+			}
+		} else if len(config.DNS) > 0 {
+			if err := b.SetDNS(name, false, config.DNS); err != nil {
+				return err
+			}
+		}
+	} else {
+		log.Printf("开始配置接口 %s 的静态IPv4设置: IP=%s, Mask=%s, Gateway=%s, DNS=%v",
+			name, config.IP, config.Mask, config.Gateway, config.DNS)
+
+		// 验证接口是否存在
+		if _, err := net.InterfaceByName(name); err != nil {
+			return fmt.Errorf("接口 %s 不存在: %v", name, err)
+		}
+
+		// 构造netsh命令参数
+		args := []string{
+			"interface",
+			"ipv4",
+			"set",
+			"address",
+			fmt.Sprintf("name=%s", name), // 直接传递接口名称
+			"static",
+			config.IP,
+			config.Mask,
+		}
+		if config.Gateway != "" {
+			args = append(args, config.Gateway)
+		}
+
+		// 记录完整命令
+		log.Printf("执行命令: netsh %v", args)
+
+		cmd := b.command("netsh", args...)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			log.Printf("命令执行失败: %v\n完整命令: netsh %v\n输出: %s",
+				err, args, string(output))
+			return fmt.Errorf("设置静态IPv4地址失败: %v, 输出: %s", err, string(output))
+		}
+		log.Printf("成功设置静态IPv4地址")
+
+		// set address会替换全部地址，附加地址需要逐个追加
+		for _, addr := range secondaryAddresses(config.IP, config.Addresses) {
+			if err := b.addAddress(name, addr); err != nil {
+				return err
+			}
+		}
+
+		// 设置静态DNS服务器
+		if len(config.DNS) > 0 {
+			if err := b.SetDNS(name, false, config.DNS); err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Printf("接口 %s 的IPv4配置完成", name)
+	return nil
+}
+
+// ConfigureIPv6 配置IPv6地址
+func (b *windowsBackend) ConfigureIPv6(name string, config models.IPv6Config) error {
+	// 设置IPv6地址，未指定前缀长度时使用64，与其他后端一致
+	prefixLen := config.PrefixLen
+	if prefixLen == 0 {
+		prefixLen = 64
+	}
+	cmd := b.command("netsh", "interface", "ipv6", "set", "address",
+		fmt.Sprintf("interface=%s", name),
+		fmt.Sprintf("address=%s/%d", config.IP, prefixLen),
+		"store=persistent")
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("设置IPv6地址失败: %v", err)
+	}
+
+	for _, addr := range secondaryAddresses(config.IP, config.Addresses) {
+		if err := b.addAddress(name, addr); err != nil {
+			return err
+		}
+	}
+
+	// 设置IPv6网关
+	if config.Gateway != "" {
+		if err := b.SetGateway(name, true, config.Gateway); err != nil {
+			return err
+		}
+	}
+
+	// 设置IPv6 DNS服务器
+	if len(config.DNS) > 0 {
+		if err := b.SetDNS(name, true, config.DNS); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addAddress 使用netsh add address为网卡追加一个地址
+func (b *windowsBackend) addAddress(name string, addr *net.IPNet) error {
+	var args []string
+	if addr.IP.To4() != nil {
+		args = []string{"interface", "ipv4", "add", "address",
+			fmt.Sprintf("name=%s", name), addr.IP.String(), net.IP(addr.Mask).String()}
+	} else {
+		args = []string{"interface", "ipv6", "add", "address",
+			fmt.Sprintf("interface=%s", name), fmt.Sprintf("address=%s", addr), "store=persistent"}
+	}
+
+	log.Printf("执行命令: netsh %v", args)
+	output, err := b.command("netsh", args...).CombinedOutput()
+	if err != nil {
+		log.Printf("添加地址失败: %v, 输出: %s", err, string(output))
+		return fmt.Errorf("添加地址 %s 失败: %v, 输出: %s", addr, err, string(output))
+	}
+	log.Printf("成功为接口 %s 添加地址 %s", name, addr)
+	return nil
+}
+
+// AddAddress 为网卡追加一个CIDR形式的地址
+func (b *windowsBackend) AddAddress(name, address string) error {
+	ipNet, err := parseHostCIDR(address)
+	if err != nil {
+		return err
+	}
+	return b.addAddress(name, ipNet)
+}
+
+// RemoveAddress 使用netsh delete address删除网卡上的一个地址
+func (b *windowsBackend) RemoveAddress(name, address string) error {
+	ip, _, err := net.ParseCIDR(address)
+	if err != nil {
+		return fmt.Errorf("无效的地址 %s: %v", address, err)
+	}
+
+	args := []string{"interface", "ipv6", "delete", "address",
+		fmt.Sprintf("interface=%s", name), fmt.Sprintf("address=%s", ip), "store=persistent"}
+	if ip.To4() != nil {
+		args = []string{"interface", "ipv4", "delete", "address",
+			fmt.Sprintf("name=%s", name), fmt.Sprintf("addr=%s", ip)}
+	}
+
+	log.Printf("执行命令: netsh %v", args)
+	output, err := b.command("netsh", args...).CombinedOutput()
+	if err != nil {
+		log.Printf("删除地址失败: %v, 输出: %s", err, string(output))
+		return fmt.Errorf("删除地址 %s 失败: %v, 输出: %s", address, err, string(output))
+	}
+	log.Printf("成功删除接口 %s 的地址 %s", name, address)
+	return nil
+}
+
+// psAddressOriginsScript 通过Get-NetIPAddress读取各地址的来源
+const psAddressOriginsScript = `
+		[Console]::OutputEncoding = [System.Text.Encoding]::UTF8
+		ConvertTo-Json -InputObject @(Get-NetIPAddress -InterfaceAlias '%s' -ErrorAction SilentlyContinue |
+		Select-Object IPAddress,@{n='PrefixOrigin';e={[string]$_.PrefixOrigin}},@{n='SuffixOrigin';e={[string]$_.SuffixOrigin}})
+	`
+
+// getAddressOrigins 通过Get-NetIPAddress获取各地址的来源，失败时返回nil
+func (b *windowsBackend) getAddressOrigins(name string) map[string]string {
+	psCmd := fmt.Sprintf(psAddressOriginsScript, strings.ReplaceAll(name, "'", "''"))
+
+	output, err := b.command("powershell", "-NoProfile", "-NonInteractive", "-Command", psCmd).Output()
+	if err != nil {
+		log.Printf("获取接口 %s 的地址来源失败: %v", name, err)
+		return nil
+	}
+	decoded, err := DecodeToUTF8(output)
+	if err != nil {
+		log.Printf("转换编码失败: %v", err)
+		return nil
+	}
+
+	var entries []struct {
+		IPAddress    string `json:"IPAddress"`
+		PrefixOrigin string `json:"PrefixOrigin"`
+		SuffixOrigin string `json:"SuffixOrigin"`
+	}
+	if err := json.Unmarshal(decoded, &entries); err != nil {
+		log.Printf("解析地址来源失败: %v", err)
+		return nil
+	}
+
+	origins := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		// 链路本地地址带有区域索引，如fe80::1%12
+		address := entry.IPAddress
+		if i := strings.Index(address, "%"); i >= 0 {
+			address = address[:i]
+		}
+		if ip := net.ParseIP(address); ip != nil {
+			origins[ip.String()] = windowsAddressOrigin(entry.PrefixOrigin, entry.SuffixOrigin)
+		}
+	}
+	return origins
+}
+
+// windowsAddressOrigin 把Get-NetIPAddress的PrefixOrigin/SuffixOrigin映射为地址来源
+func windowsAddressOrigin(prefixOrigin, suffixOrigin string) string {
+	switch {
+	case prefixOrigin == "Manual":
+		return models.AddressOriginStatic
+	case prefixOrigin == "Dhcp" || suffixOrigin == "Dhcp":
+		return models.AddressOriginDHCP
+	case prefixOrigin == "RouterAdvertisement" && suffixOrigin == "Random":
+		return models.AddressOriginTemporary
+	case prefixOrigin == "RouterAdvertisement":
+		return models.AddressOriginSLAAC
+	case prefixOrigin == "WellKnown" && suffixOrigin == "Link":
+		return models.AddressOriginLink
+	default:
+		return models.AddressOriginUnknown
+	}
+}
+
+// SetDNS 设置DNS服务器，第一个使用set dns，其余按顺序使用add dns追加
+func (b *windowsBackend) SetDNS(name string, ipv6 bool, servers []string) error {
+	family := "ipv4"
+	if ipv6 {
+		family = "ipv6"
+	}
+
+	if len(servers) == 0 {
+		log.Printf("执行命令: netsh interface %s set dnsservers name=\"%s\" source=dhcp", family, name)
+
+		cmd := b.command("netsh", "interface", family, "set", "dnsservers",
+			fmt.Sprintf("name=%s", name),
+			"source=dhcp")
+
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			log.Printf("设置DNS自动获取失败: %v, 输出: %s", err, string(output))
+			return fmt.Errorf("设置DNS自动获取失败: %v, 输出: %s", err, string(output))
+		}
+		log.Printf("成功设置DNS自动获取")
+		return nil
+	}
+
+	log.Printf("开始设置指定DNS服务器: %v", servers)
+	for i, dns := range servers {
+		var args []string
+		if i == 0 {
+			args = []string{"interface", family, "set", "dns",
+				fmt.Sprintf("name=%s", name),
+				"static",
+				dns}
+		} else {
+			args = []string{"interface", family, "add", "dns",
+				fmt.Sprintf("name=%s", name),
+				dns,
+				fmt.Sprintf("index=%d", i+1)}
+		}
+		log.Printf("执行命令: netsh %v", args)
+
+		output, err := b.command("netsh", args...).CombinedOutput()
+		if err != nil {
+			log.Printf("设置指定DNS服务器失败: %v, 输出: %s", err, string(output))
+			return fmt.Errorf("设置指定DNS服务器失败: %v, 输出: %s", err, string(output))
+		}
+	}
+	log.Printf("成功设置所有指定DNS服务器")
+	return nil
+}
+
+// SetGateway 添加默认路由作为网关
+func (b *windowsBackend) SetGateway(name string, ipv6 bool, gateway string) error {
+	family, prefix, label := "ipv4", "0.0.0.0/0", "IPv4"
+	if ipv6 {
+		family, prefix, label = "ipv6", "::/0", "IPv6"
+	}
+
+	args := []string{"interface", family, "add", "route",
+		prefix,
+		fmt.Sprintf("interface=%s", name),
+		gateway}
+	log.Printf("执行命令: netsh %v", args)
+
+	output, err := b.command("netsh", args...).CombinedOutput()
+	if err != nil {
+		log.Printf("设置网关失败: %v, 输出: %s", err, string(output))
+		return fmt.Errorf("设置%s网关失败: %v", label, err)
+	}
+	return nil
+}
+
+// 辅助函数
+
+func (b *windowsBackend) getInterfaceDescription(name string) string {
+	cmd := b.command("netsh", "interface", "show", "interface", name)
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	// 解析输出获取描述信息
+	return strings.TrimSpace(string(output))
+}
+
+func (b *windowsBackend) getDefaultGateway(name string) string {
+	// 方法1: 使用netsh命令
+	cmd := b.command("netsh", "interface", "ipv4", "show", "route", name)
+	output, err := cmd.Output()
+	if err == nil {
+		gateway := b.parseGateway(string(output))
+		if gateway != "" {
+			log.Printf("通过netsh获取到接口 %s 的网关: %s", name, gateway)
+			return gateway
+		}
+	} else {
+		log.Printf("netsh获取网关失败: %v", err)
+	}
+
+	// 方法2: 使用route print命令
+	cmd = b.command("route", "print", "-4")
+	outputBytes, err := cmd.Output()
+	if err == nil {
+		output := string(outputBytes)
+		gateway := b.parseGateway(output)
+		if gateway != "" {
+			log.Printf("通过route print获取到接口 %s 的网关: %s", name, gateway)
+			return gateway
+		}
+	} else {
+		log.Printf("route print获取网关失败: %v", err)
+	}
+
+	// 方法3: 使用ipconfig命令
+	cmd = b.command("ipconfig")
+	output, err = cmd.Output()
+	if err == nil {
+		lines := strings.Split(string(output), "\n")
+		for i, line := range lines {
+			if strings.Contains(line, name) {
+				// 查找后续的默认网关行
+				for j := i + 1; j < len(lines); j++ {
+					if strings.Contains(lines[j], "默认网关") ||
+						strings.Contains(lines[j], "Default Gateway") {
+						parts := strings.Split(lines[j], ":")
+						if len(parts) > 1 {
+							gateway := strings.TrimSpace(parts[1])
+							if gateway != "" {
+								log.Printf("通过ipconfig获取到接口 %s 的网关: %s", name, gateway)
+								return gateway
+							}
+						}
+					}
+				}
+			}
+		}
+	} else {
+		log.Printf("ipconfig获取网关失败: %v", err)
+	}
+
+	log.Printf("无法获取接口 %s 的网关", name)
+	return ""
+}
+
+func (b *windowsBackend) getIPv6Gateway(name string) string {
+	cmd := b.command("netsh", "interface", "ipv6", "show", "route", name)
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	// 解析输出获取IPv6默认网关
+	return b.parseGateway(string(output))
+}
+
+// getDNSServers 获取网卡的IPv4 DNS服务器，static表示netsh输出中的DNS服务器是静态配置的
+func (b *windowsBackend) getDNSServers(name string) (servers []string, static bool) {
+	cmd := b.command("netsh", "interface", "ipv4", "show", "dnsservers", fmt.Sprintf("name=\"%s\"", name))
+	output, err := cmd.Output()
+	if err != nil {
+		log.Printf("获取接口 %s 的DNS服务器失败: %v", name, err)
+		return []string{"unavailable"}, false
+	}
+
+	servers = []string{}
+	lines := strings.Split(string(output), "\n")
+	inDnsSection := false
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+
+		// 检查是否进入DNS服务器部分
+		if strings.Contains(line, "静态配置的 DNS 服务器") ||
+			strings.Contains(line, "Statically Configured DNS Servers") {
+			inDnsSection = true
+			continue
+		}
+
+		// 只在DNS服务器部分处理
+		if inDnsSection {
+			// 跳过说明行和空行
+			if line == "" ||
+				strings.Contains(line, "用哪个前缀注册") ||
+				strings.Contains(line, "Register with which suffix") {
+				continue
+			}
+
+			// 提取IP地址
+			if ip := net.ParseIP(line); ip != nil {
+				servers = append(servers, ip.String())
+			} else {
+				// 处理可能的多行格式
+				parts := strings.Fields(line)
+				for _, part := range parts {
+					if ip := net.ParseIP(part); ip != nil {
+						servers = append(servers, ip.String())
+					}
+				}
+			}
+		}
+	}
+
+	// 如果没有找到静态配置的DNS服务器，尝试备用方法
+	static = len(servers) > 0
+	if !static {
+		servers = b.getDNSServersAlternative(name)
+	}
+
+	if len(servers) == 0 {
+		return []string{"none"}, false
+	}
+	return servers, static
+}
+
+// psDNSServersScript 通过Get-DnsClientServerAddress读取网卡的DNS服务器，参数为网卡名和地址族(IPv4/IPv6)
+const psDNSServersScript = `
+        [Console]::OutputEncoding = [System.Text.Encoding]::UTF8
+        $PSDefaultParameterValues['*:Encoding'] = 'utf8'
+        (Get-DnsClientServerAddress -InterfaceAlias "%s" -AddressFamily %s).ServerAddresses
+    `
+
+// 备用DNS获取方法
+func (b *windowsBackend) getDNSServersAlternative(name string) []string {
+	// 方法1: 使用ipconfig /all
+	cmd := b.command("ipconfig", "/all")
+	output, err := cmd.Output()
+	if err == nil {
+		lines := strings.Split(string(output), "\n")
+		inInterfaceSection := false
+		servers := []string{}
+
+		for _, line := range lines {
+			line = strings.TrimSpace(line)
+
+			// 检查是否进入目标接口部分
+			if strings.Contains(line, name) {
+				inInterfaceSection = true
+				continue
+			}
+
+			if inInterfaceSection {
+				// 检查DNS服务器行
+				if strings.Contains(line, "DNS Servers") || strings.Contains(line, "DNS 服务器") {
+					parts := strings.Split(line, ":")
+					if len(parts) > 1 {
+						ip := strings.TrimSpace(parts[1])
+						if net.ParseIP(ip) != nil {
+							servers = append(servers, ip)
+						}
+					}
+				}
+
+				// 检查是否离开接口部分
+				if strings.Contains(line, "----------") {
+					break
+				}
+			}
+		}
+
+		if len(servers) > 0 {
+			return servers
+		}
+	}
+
+	// 方法2: 使用Get-DnsClientServerAddress PowerShell命令
+	psCmd := fmt.Sprintf(psDNSServersScript, name, "IPv4")
+
+	cmd = b.command("powershell", "-NoProfile", "-NonInteractive", "-Command", psCmd)
+	cmd.Env = append(os.Environ(), "PYTHONIOENCODING=utf-8")
+
+	output, err = cmd.Output()
+	if err == nil {
+		// 解析输出，每行一个IP地址
+		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+		servers := []string{}
+		for _, line := range lines {
+			line = strings.TrimSpace(line)
+			if ip := net.ParseIP(line); ip != nil {
+				servers = append(servers, ip.String())
+			}
+		}
+		return servers
+	}
+
+	return []string{}
+}
+
+func (b *windowsBackend) getIPv6DNSServers(name string) []string {
+	cmd := b.command("netsh", "interface", "ipv6", "show", "dnsservers", fmt.Sprintf("name=\"%s\"", name))
+	output, err := cmd.Output()
+	if err != nil {
+		log.Printf("获取接口 %s 的IPv6 DNS服务器失败: %v", name, err)
+		return []string{"unavailable"}
+	}
+
+	servers := []string{}
+	lines := strings.Split(string(output), "\n")
+	inDnsSection := false
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+
+		// 检查是否进入DNS服务器部分
+		if strings.Contains(line, "静态配置的 DNS 服务器") ||
+			strings.Contains(line, "Statically Configured DNS Servers") {
+			inDnsSection = true
+			continue
+		}
+
+		// 只在DNS服务器部分处理
+		if inDnsSection {
+			// 跳过说明行和空行
+			if line == "" ||
+				strings.Contains(line, "用哪个前缀注册") ||
+				strings.Contains(line, "Register with which suffix") {
+				continue
+			}
+
+			// 提取IP地址
+			if ip := net.ParseIP(line); ip != nil {
+				servers = append(servers, ip.String())
+			} else {
+				// 处理可能的多行格式
+				parts := strings.Fields(line)
+				for _, part := range parts {
+					if ip := net.ParseIP(part); ip != nil {
+						servers = append(servers, ip.String())
+					}
+				}
+			}
+		}
+	}
+
+	// 如果没有找到DNS服务器，尝试备用方法
+	if len(servers) == 0 {
+		servers = b.getIPv6DNSServersAlternative(name)
+	}
+
+	if len(servers) == 0 {
+		return []string{"none"}
+	}
+	return servers
+}
+
+// 备用IPv6 DNS获取方法
+func (b *windowsBackend) getIPv6DNSServersAlternative(name string) []string {
+	// 方法1: 使用ipconfig /all
+	cmd := b.command("ipconfig", "/all")
+	output, err := cmd.Output()
+	if err == nil {
+		lines := strings.Split(string(output), "\n")
+		inInterfaceSection := false
+		servers := []string{}
+
+		for _, line := range lines {
+			line = strings.TrimSpace(line)
+
+			// 检查是否进入目标接口部分
+			if strings.Contains(line, name) {
+				inInterfaceSection = true
+				continue
+			}
+
+			if inInterfaceSection {
+				// 检查IPv6 DNS服务器行
+				if strings.Contains(line, "DNS Servers") || strings.Contains(line, "DNS 服务器") {
+					parts := strings.Split(line, ":")
+					if len(parts) > 1 {
+						ip := strings.TrimSpace(parts[1])
+						if net.ParseIP(ip) != nil && strings.Contains(ip, ":") { // 确保是IPv6地址
+							servers = append(servers, ip)
+						}
+					}
+				}
+
+				// 检查是否离开接口部分
+				if strings.Contains(line, "----------") {
+					break
+				}
+			}
+		}
+
+		if len(servers) > 0 {
+			return servers
+		}
+	}
+
+	// 方法2: 使用Get-DnsClientServerAddress PowerShell命令
+	psCmd := fmt.Sprintf(psDNSServersScript, name, "IPv6")
+
+	cmd = b.command("powershell", "-NoProfile", "-NonInteractive", "-Command", psCmd)
+	cmd.Env = append(os.Environ(), "PYTHONIOENCODING=utf-8")
+
+	output, err = cmd.Output()
+	if err == nil {
+		// 解析输出，每行一个IP地址
+		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+		servers := []string{}
+		for _, line := range lines {
+			line = strings.TrimSpace(line)
+			if ip := net.ParseIP(line); ip != nil && ip.To4() == nil { // 确保是IPv6地址
+				servers = append(servers, ip.String())
+			}
+		}
+		return servers
+	}
+
+	return []string{}
+}
+
+func (b *windowsBackend) parseGateway(output string) string {
+	lines := strings.Split(output, "\n")
+
+	// 尝试匹配不同格式的网关输出
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+
+		// 格式1: 0.0.0.0/0 <metric> <interface> <gateway>
+		if strings.HasPrefix(line, "0.0.0.0/0") {
+			fields := strings.Fields(line)
+			if len(fields) >= 4 {
+				return fields[3]
+			}
+		}
+
+		// 格式2: 0.0.0.0 <mask> <gateway> <interface> <metric>
+		if strings.HasPrefix(line, "0.0.0.0") {
+			fields := strings.Fields(line)
+			if len(fields) >= 3 {
+				return fields[2]
+			}
+		}
+
+		// 格式3: 默认网关: <gateway>
+		if strings.HasPrefix(line, "默认网关:") || strings.HasPrefix(line, "Default Gateway:") {
+			parts := strings.Split(line, ":")
+			if len(parts) > 1 {
+				return strings.TrimSpace(parts[1])
+			}
+		}
+	}
+
+	// 如果上述方法都失败，尝试使用route print命令
+	cmd := b.command("route", "print", "0.0.0.0")
+	outputBytes, err := cmd.Output()
+	if err == nil {
+		output := string(outputBytes)
+		lines := strings.Split(output, "\n")
+		for _, line := range lines {
+			if strings.Contains(line, "0.0.0.0") {
+				fields := strings.Fields(line)
+				if len(fields) >= 3 && fields[0] == "0.0.0.0" {
+					return fields[2]
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// isDHCPEnabled 检查指定网络接口是否启用了DHCP
+func (b *windowsBackend) isDHCPEnabled(name string) (bool, error) {
+	// 使用netsh命令检查接口配置
+	cmd := b.command("netsh", "interface", "ipv4", "show", "config", "name="+name)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return false, fmt.Errorf("执行netsh命令失败: %v, 输出: %s", err, string(output))
+	}
+
+	// 解析输出查找DHCP状态
+	lines := strings.Split(string(output), "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.Contains(line, "DHCP enabled") {
+			// 检查是否包含"Yes"表示启用
+			return strings.Contains(line, "Yes"), nil
+		}
+	}
+
+	return false, fmt.Errorf("无法从输出中确定DHCP状态: %s", string(output))
+}
+
+// ScanWiFi 使用netsh扫描WiFi热点
+func (b *windowsBackend) ScanWiFi(interfaceName string) ([]models.WiFiHotspot, error) {
+	// 初始化空切片，确保不返回nil
+	hotspots := make([]models.WiFiHotspot, 0)
+
+	log.Printf("开始扫描接口 %s 的WiFi热点...", interfaceName)
+
+	// 构造命令
+	args := []string{
+		"wlan", "show", "networks",
+		"mode=bssid",
+		fmt.Sprintf("interface=%s", interfaceName),
+	}
+	cmd := b.command("netsh", args...)
+	log.Printf("执行命令: netsh %v", args)
+
+	// 执行命令并捕获输出
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("WiFi扫描命令执行失败: %v", err)
+		if stderr, ok := commandStderr(err); ok {
+			log.Printf("命令错误输出: %s", string(stderr))
+		}
+		return hotspots, fmt.Errorf("扫描WiFi失败: %v", err)
+	}
+
+	// 记录原始输出用于调试
+	rawOutput := string(out)
+	log.Printf("WiFi扫描原始输出(前100字符): %q...", safeSubstring(rawOutput, 100))
+	if len(rawOutput) > 1000 {
+		log.Printf("完整输出已记录到调试日志")
+	}
+
+	// 解析输出
+	hotspots, err = parseNetshOutput(rawOutput)
+	if err != nil {
+		log.Printf("解析WiFi扫描输出失败: %v", err)
+		return []models.WiFiHotspot{}, fmt.Errorf("解析WiFi扫描结果失败: %v", err)
+	}
+	if bssid := b.getConnectedBSSID(interfaceName); bssid != "" {
+		for i := range hotspots {
+			hotspots[i].Connected = hotspots[i].BSSID == bssid
+		}
+	}
+
+	log.Printf("成功扫描到 %d 个WiFi热点", len(hotspots))
+	return hotspots, nil
+}
+
+// 解析netsh命令输出 (Windows)
+// mode=bssid输出中每个SSID下可能有多个BSSID，每个BSSID作为一个热点，SSID、身份验证和加密方式由同一SSID下的BSSID共用
+func parseNetshOutput(output string) ([]models.WiFiHotspot, error) {
+	log.Printf("开始解析WiFi扫描结果...")
+	startTime := time.Now()
+	defer func() {
+		log.Printf("WiFi扫描结果解析完成，耗时: %v", time.Since(startTime))
+	}()
+
+	// 初始化空切片，确保不返回nil
+	hotspots := make([]models.WiFiHotspot, 0)
+	var currentHotspot *models.WiFiHotspot
+	var ssid, authentication, encryption string
+	var parseErrors int
+
+	finish := func() {
+		if currentHotspot == nil {
+			return
+		}
+		if currentHotspot.Frequency == 0 {
+			currentHotspot.Frequency = netshChannelFrequency(currentHotspot.Band, currentHotspot.Channel)
+		}
+		if currentHotspot.Band == "" {
+			currentHotspot.Band = frequencyBand(currentHotspot.Frequency)
+		}
+		hotspots = append(hotspots, *currentHotspot)
+		log.Printf("完成解析热点: %s (信号: %d%%, 加密: %s)",
+			currentHotspot.SSID, currentHotspot.SignalStrength, currentHotspot.Security)
+		currentHotspot = nil
+	}
+
+	lines := strings.Split(output, "\n")
+	log.Printf("需要解析 %d 行输出", len(lines))
+
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(line, ":")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		switch {
+		// 检测新SSID开始 (处理中英文标签)
+		case strings.HasPrefix(key, "SSID"):
+			finish()
+			ssid, authentication, encryption = value, "", ""
+			log.Printf("发现新热点: %s (行 %d)", ssid, i+1)
+
+		// 身份验证和加密方式属于SSID，出现在BSSID之前
+		case key == "Authentication" || key == "身份验证":
+			authentication = value
+		case key == "Encryption" || key == "加密":
+			encryption = value
+
+		// 每个BSSID开始一个新热点
+		case strings.HasPrefix(key, "BSSID"):
+			finish()
+			security, info := netshSecurity(authentication, encryption)
+			currentHotspot = &models.WiFiHotspot{
+				SSID:         ssid,
+				BSSID:        strings.ToUpper(value),
+				Security:     security,
+				SecurityInfo: info,
+			}
+
+		case currentHotspot == nil:
+
+		// 解析信号强度 (处理中英文标签)
+		case key == "Signal" || key == "信号":
+			if signal, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(value, "%"))); err == nil {
+				currentHotspot.SignalStrength = signal
+				// Windows的信号质量与RSSI的对应关系: 0%为-100dBm，100%为-50dBm
+				currentHotspot.SignalDBm = signal/2 - 100
+			} else {
+				log.Printf("警告: 无效的信号强度值: %q (行 %d)", value, i+1)
+				parseErrors++
+			}
+
+		case key == "Radio type" || key == "无线电类型":
+			currentHotspot.PHYType = value
+
+		// Windows 11输出频段，如"5 GHz"
+		case key == "Band" || key == "波段":
+			currentHotspot.Band = strings.ReplaceAll(value, " ", "")
+
+		// 解析信道 (处理中英文标签)，Bss Load下的Channel Utilization不是信道
+		case key == "Channel" || key == "频道" || key == "信道":
+			if channel, err := strconv.Atoi(value); err == nil {
+				currentHotspot.Channel = channel
+			} else {
+				log.Printf("警告: 无效的信道值: %q (行 %d)", value, i+1)
+				parseErrors++
+			}
+
+		// 基本速率和其他速率中的最大值作为最大速率
+		case strings.Contains(key, "rates") || strings.Contains(key, "速率"):
+			for _, field := range strings.Fields(value) {
+				if rate, err := strconv.ParseFloat(field, 64); err == nil && int(rate) > currentHotspot.Rate {
+					currentHotspot.Rate = int(rate)
+				}
+			}
+		}
+	}
+
+	// 添加最后一个热点
+	finish()
+
+	// 过滤掉无效热点
+	var validHotspots []models.WiFiHotspot
+	var skipped int
+	for _, hotspot := range hotspots {
+		if hotspot.SSID != "" {
+			validHotspots = append(validHotspots, hotspot)
+		} else {
+			skipped++
+		}
+	}
+
+	log.Printf("解析完成: 共 %d 个热点(有效 %d 个，跳过 %d 个)，解析错误 %d 处",
+		len(hotspots), len(validHotspots), skipped, parseErrors)
+	return validHotspots, nil
+}
+
+// netshSecurity 把netsh的身份验证(如WPA2-Personal、WPA3 - 个人)和加密方式转换为与Linux一致的加密类型和加密方式详情，
+// 无法识别的身份验证方式原样返回
+func netshSecurity(authentication, encryption string) (string, models.WiFiSecurity) {
+	auth := strings.ToUpper(strings.ReplaceAll(authentication, " ", ""))
+	enterprise := strings.Contains(auth, "ENTERPRISE") || strings.Contains(auth, "企业")
+
+	var summary string
+	var akms []string
+	switch {
+	case strings.HasPrefix(auth, "WPA3"):
+		summary, akms = "WPA3", []string{"sae"}
+	case strings.HasPrefix(auth, "WPA2"):
+		summary, akms = "WPA2", []string{"psk"}
+	case strings.HasPrefix(auth, "WPA"):
+		summary, akms = "WPA1", []string{"psk"}
+	case strings.HasPrefix(auth, "OWE"):
+		summary, akms = "OWE", []string{"owe"}
+	case auth == "WEP" || auth == "SHARED" || auth == "共享":
+		summary, akms = "WEP", []string{"wep"}
+	case auth == "OPEN" || auth == "开放式" || auth == "":
+	default:
+		summary = authentication
+	}
+	if enterprise {
+		summary += " 802.1X"
+		akms = []string{"802.1x"}
+	}
+
+	var ciphers []string
+	if encryption != "" && !strings.EqualFold(encryption, "None") && encryption != "无" {
+		ciphers = []string{encryption}
+	}
+	return summary, newWiFiSecurity(akms, ciphers, "")
+}
+
+// netshChannelFrequency 按频段和信道计算中心频率，6GHz信道号与2.4GHz/5GHz重叠，需要频段区分
+func netshChannelFrequency(band string, channel int) int {
+	if band == models.HotspotBand6GHz && channel > 0 {
+		return 5950 + channel*5
+	}
+	return channelToFrequency(channel)
+}
+
+// ConnectWiFi 使用netsh连接WiFi热点
+func (b *windowsBackend) ConnectWiFi(interfaceName, ssid, password string) error {
+	// 记录原始SSID用于日志
+	originalSSID := ssid
+
+	// 确保SSID使用正确的编码
+	log.Printf("处理WiFi连接请求，原始SSID: %q", ssid)
+
+	// 检查SSID是否是URL编码的形式，如果是则进行解码
+	if strings.Contains(ssid, "%") {
+		decodedSSID, err := url.QueryUnescape(ssid)
+		if err != nil {
+			log.Printf("URL解码SSID失败: %v，将继续使用原始SSID", err)
+		} else {
+			ssid = decodedSSID
+			log.Printf("URL解码后的SSID: %q", ssid)
+		}
+	}
+
+	// 使用DecodeToUTF8确保SSID是UTF-8编码
+	ssidBytes := []byte(ssid)
+	decodedSSID, err := DecodeToUTF8(ssidBytes)
+	if err != nil {
+		log.Printf("SSID编码转换失败: %v，将使用当前SSID", err)
+	} else {
+		ssid = string(decodedSSID)
+		log.Printf("编码转换后的SSID: %q", ssid)
+	}
+
+	// 检查解码后的SSID是否仍然包含URL编码字符，如果包含则可能是多次编码
+	if strings.Contains(ssid, "%") {
+		log.Printf("SSID仍包含URL编码字符，尝试再次解码")
+		decodedSSID, err := url.QueryUnescape(ssid)
+		if err != nil {
+			log.Printf("二次URL解码失败: %v", err)
+		} else {
+			ssid = decodedSSID
+			log.Printf("二次URL解码后的SSID: %q", ssid)
+		}
+	}
+
+	// 先扫描可用的WiFi网络
+	log.Printf("开始扫描可用的WiFi网络...")
+	scanCmd := b.command("netsh", "wlan", "show", "networks")
+
+	scanOutput, err := scanCmd.CombinedOutput()
+	if err != nil {
+		log.Printf("扫描WiFi网络失败: %v, 输出: %s", err, string(scanOutput))
+		return fmt.Errorf("扫描WiFi网络失败: %v", err)
+	}
+
+	// 将扫描输出转换为UTF-8编码
+	decodedOutput, err := DecodeToUTF8(scanOutput)
+	if err != nil {
+		log.Printf("转换扫描输出编码失败: %v", err)
+	}
+	scanOutputStr := string(decodedOutput)
+	log.Printf("WiFi扫描原始输出:\n%s", scanOutputStr)
+
+	// 检查SSID是否在可用网络列表中
+	log.Printf("开始检查目标网络 %q 是否在可用列表中...", ssid)
+	available := false
+	var foundNetworks []string
+
+	// 使用正则表达式提取SSID
+	ssidRegex := regexp.MustCompile(`SSID\s+\d+\s*:\s*(.+)`)
+	matches := ssidRegex.FindAllStringSubmatch(scanOutputStr, -1)
+
+	for _, match := range matches {
+		if len(match) > 1 {
+			networkSSID := strings.TrimSpace(match[1])
+			// 如果SSID被引号包围，去除引号
+			networkSSID = strings.Trim(networkSSID, "\"")
+			foundNetworks = append(foundNetworks, networkSSID)
+			log.Printf("发现网络: %q (原始格式)", networkSSID)
+
+			// 尝试不同的编码方式进行比较
+			if networkSSID == ssid {
+				available = true
+				log.Printf("找到完全匹配的目标网络: %q", ssid)
+				break
+			}
+		}
+	}
+
+	if !available {
+		log.Printf("目标WiFi网络 %q 不在可用范围内", ssid)
+		log.Printf("可用网络列表: %v", foundNetworks)
+		log.Printf("请检查网络名称是否正确，以及网络是否在范围内")
+		return fmt.Errorf("WiFi网络 %q 不在可用范围内", ssid)
+	}
+
+	log.Printf("目标网络 %q 在可用范围内，准备连接...", ssid)
+
+	// 构建连接命令，使用双引号包围SSID以处理特殊字符
+	cmd := b.command("netsh", "wlan", "connect",
+		fmt.Sprintf("name=\"%s\"", ssid),
+		fmt.Sprintf("interface=%s", interfaceName))
+
+	if password != "" {
+		log.Printf("WiFi需要密码，创建配置文件")
+
+		// 先删除已有配置文件，不使用双引号，直接使用解码后的SSID
+		deleteCmd := b.command("netsh", "wlan", "delete", "profile",
+			fmt.Sprintf("name=%s", ssid),
+			fmt.Sprintf("interface=%s", interfaceName))
+		if out, err := deleteCmd.CombinedOutput(); err != nil {
+			log.Printf("删除旧配置文件失败(可能不存在): %s", string(out))
+		}
+
+		if err := b.addWLANProfile(wlanProfileXML(ssid, ssid, password, wifiProfileAuth("", password)), interfaceName); err != nil {
+			return err
+		}
+	}
+
+	// 尝试使用不同的连接方法
+	log.Printf("尝试方法1: 使用netsh wlan connect命令连接...")
+	log.Printf("执行WiFi连接命令: netsh wlan connect name=\"%s\" interface=%s", ssid, interfaceName)
+
+	// 设置命令环境变量，确保正确处理UTF-8
+	cmd.Env = append(os.Environ(), "PYTHONIOENCODING=utf-8")
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("方法1连接失败，输出: %s", string(out))
+
+		// 尝试方法2: 使用ssid=代替name=
+		log.Printf("尝试方法2: 使用ssid=参数代替name=...")
+		cmd2 := b.command("netsh", "wlan", "connect",
+			fmt.Sprintf("ssid=\"%s\"", ssid),
+			fmt.Sprintf("interface=%s", interfaceName))
+		cmd2.Env = append(os.Environ(), "PYTHONIOENCODING=utf-8")
+
+		out2, err2 := cmd2.CombinedOutput()
+		if err2 != nil {
+			log.Printf("方法2连接失败，输出: %s", string(out2))
+
+			// 尝试方法3: 不使用引号
+			log.Printf("尝试方法3: 不使用引号包围SSID...")
+			cmd3 := b.command("netsh", "wlan", "connect",
+				fmt.Sprintf("name=%s", ssid),
+				fmt.Sprintf("interface=%s", interfaceName))
+			cmd3.Env = append(os.Environ(), "PYTHONIOENCODING=utf-8")
+
+			out3, err3 := cmd3.CombinedOutput()
+			if err3 != nil {
+				log.Printf("方法3连接失败，输出: %s", string(out3))
+				return fmt.Errorf("所有连接方法均失败，最后错误: %s, %v", string(out3), err3)
+			}
+
+			log.Printf("方法3连接成功")
+			return nil
+		}
+
+		log.Printf("方法2连接成功")
+		return nil
+	}
+
+	log.Printf("方法1连接成功，原始SSID: %q", originalSSID)
+	return nil
+}
+
+// wlanAuthEncryption WLAN配置文件XML中各认证方式对应的authentication、encryption和keyType
+var wlanAuthEncryption = map[string][3]string{
+	wifiAuthOpen: {"open", "none", ""},
+	wifiAuthWEP:  {"open", "WEP", "networkKey"},
+	wifiAuthWPA:  {"WPAPSK", "TKIP", "passPhrase"},
+	wifiAuthWPA2: {"WPA2PSK", "AES", "passPhrase"},
+	wifiAuthWPA3: {"WPA3SAE", "AES", "passPhrase"},
+}
+
+// wlanProfileXML 生成WLAN配置文件XML，auth为wifiProfileAuth归一化的认证方式，
+// 无法识别时password为空生成开放网络配置，否则按WPA2-Personal生成
+func wlanProfileXML(name, ssid, password, auth string) string {
+	// 对XML中的特殊字符进行转义
+	xmlEscapedName := html.EscapeString(name)
+	xmlEscapedSSID := html.EscapeString(ssid)
+	log.Printf("XML转义后的SSID: %q", xmlEscapedSSID)
+
+	params, ok := wlanAuthEncryption[auth]
+	if !ok {
+		params = wlanAuthEncryption[wifiAuthOpen]
+		if password != "" {
+			params = wlanAuthEncryption[wifiAuthWPA2]
+		}
+	}
+	security := fmt.Sprintf(`			<authEncryption>
+				<authentication>%s</authentication>
+				<encryption>%s</encryption>
+				<useOneX>false</useOneX>
+			</authEncryption>`, params[0], params[1])
+	if params[2] != "" {
+		security += fmt.Sprintf(`
+			<sharedKey>
+				<keyType>%s</keyType>
+				<protected>false</protected>
+				<keyMaterial>%s</keyMaterial>
+			</sharedKey>`, params[2], html.EscapeString(password))
+	}
+
+	// 创建XML配置文件，确保使用UTF-8编码
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<WLANProfile xmlns="http://www.microsoft.com/networking/WLAN/profile/v1">
+	<name>%s</name>
+	<SSIDConfig>
+		<SSID>
+			<hex>%s</hex>
+			<name>%s</name>
+		</SSID>
+		<nonBroadcast>false</nonBroadcast>
+	</SSIDConfig>
+	<connectionType>ESS</connectionType>
+	<connectionMode>auto</connectionMode>
+	<autoSwitch>false</autoSwitch>
+	<MSM>
+		<security>
+%s
+		</security>
+	</MSM>
+	<MacRandomization xmlns="http://www.microsoft.com/networking/WLAN/profile/v3">
+		<enableRandomization>false</enableRandomization>
+	</MacRandomization>
+</WLANProfile>`, xmlEscapedName, bytesToHexString([]byte(ssid)), xmlEscapedSSID, security)
+}
+
+// addWLANProfile 通过netsh导入WLAN配置文件，interfaceName为空时对所有无线网卡生效
+func (b *windowsBackend) addWLANProfile(profile, interfaceName string) error {
+	if b.debug {
+		log.Printf("生成的WiFi配置文件内容:\n%s", profile)
+	}
+
+	// 写入临时文件，确保使用UTF-8编码
+	tmpFile, err := os.CreateTemp("", "wifi_*.xml")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	// 写入UTF-8 BOM标记，确保Windows正确识别UTF-8编码
+	utf8BOM := []byte{0xEF, 0xBB, 0xBF}
+	if _, err := tmpFile.Write(utf8BOM); err != nil {
+		return fmt.Errorf("写入UTF-8 BOM失败: %v", err)
+	}
+
+	if _, err := tmpFile.WriteString(profile); err != nil {
+		return fmt.Errorf("写入配置文件失败: %v", err)
+	}
+	tmpFile.Close()
+
+	log.Printf("WiFi配置文件已创建: %s", tmpFile.Name())
+
+	// 添加配置文件
+	args := []string{"wlan", "add", "profile", fmt.Sprintf("filename=%s", tmpFile.Name())}
+	if interfaceName != "" {
+		args = append(args, fmt.Sprintf("interface=%s", interfaceName))
+	}
+	addCmd := b.command("netsh", args...)
+
+	// 设置命令环境变量，确保正确处理UTF-8
+	addCmd.Env = append(os.Environ(), "PYTHONIOENCODING=utf-8")
+
+	addOutput, err := addCmd.CombinedOutput()
+	if err != nil {
+		log.Printf("添加配置文件失败，输出: %s", string(addOutput))
+
+		// 尝试使用备用方法添加配置文件
+		log.Printf("尝试使用备用方法添加配置文件...")
+		addCmd2 := b.command("netsh", "wlan", "add", "profile",
+			fmt.Sprintf("filename=\"%s\"", tmpFile.Name()))
+
+		addOutput2, err2 := addCmd2.CombinedOutput()
+		if err2 != nil {
+			log.Printf("备用方法添加配置文件也失败，输出: %s", string(addOutput2))
+			return fmt.Errorf("添加配置文件失败: %s, %v", string(addOutput2), err2)
+		}
+
+		log.Printf("备用方法成功添加WiFi配置文件")
+	} else {
+		log.Printf("WiFi配置文件已添加，输出: %s", string(addOutput))
+	}
+	return nil
+}
+
+// ListWiFiProfiles 列出已保存的WLAN配置文件，包含明文密码
+func (b *windowsBackend) ListWiFiProfiles() ([]models.WiFiProfile, error) {
+	output, err := b.command("netsh", "wlan", "show", "profiles").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("获取WiFi配置文件列表失败: %v, 输出: %s", err, string(output))
+	}
+	decoded, err := DecodeToUTF8(output)
+	if err != nil {
+		log.Printf("转换WiFi配置文件列表编码失败: %v", err)
+		decoded = output
+	}
+
+	profiles := make([]models.WiFiProfile, 0)
+	for _, name := range parseNetshProfileNames(string(decoded)) {
+		detail, err := b.command("netsh", "wlan", "show", "profile", "name="+name, "key=clear").CombinedOutput()
+		if err != nil {
+			log.Printf("获取WiFi配置文件 %s 详情失败: %v", name, err)
+			profiles = append(profiles, models.WiFiProfile{Name: name, SSID: name})
+			continue
+		}
+		if text, err := DecodeToUTF8(detail); err == nil {
+			detail = text
+		}
+		profiles = append(profiles, parseNetshProfileDetail(name, string(detail)))
+	}
+	return profiles, nil
+}
+
+// parseNetshProfileNames 解析netsh wlan show profiles输出中的配置文件名称
+func parseNetshProfileNames(output string) []string {
+	var names []string
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		if key != "All User Profile" && key != "User Profile" && key != "所有用户配置文件" && key != "用户配置文件" {
+			continue
+		}
+		if name := strings.TrimSpace(parts[1]); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseNetshProfileDetail 解析netsh wlan show profile name=X key=clear的输出
+func parseNetshProfileDetail(name, output string) models.WiFiProfile {
+	profile := models.WiFiProfile{Name: name, SSID: name}
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+		switch key {
+		case "SSID name", "SSID 名称":
+			profile.SSID = strings.Trim(value, "\"“”")
+		case "Authentication", "身份验证":
+			if profile.Security == "" {
+				profile.Security = value
+			}
+		case "Key Content", "关键内容":
+			profile.Password = value
+		}
+	}
+	return profile
+}
+
+// AddWiFiProfile 按认证方式导入WLAN配置文件，同名配置文件会被替换
+func (b *windowsBackend) AddWiFiProfile(profile models.WiFiProfile) error {
+	name := profile.Name
+	if name == "" {
+		name = profile.SSID
+	}
+	auth, err := wifiProfileAuthFor(profile)
+	if err != nil {
+		return err
+	}
+	if out, err := b.command("netsh", "wlan", "delete", "profile", "name="+name).CombinedOutput(); err != nil {
+		log.Printf("删除旧配置文件失败(可能不存在): %s", string(out))
+	}
+	return b.addWLANProfile(wlanProfileXML(name, profile.SSID, profile.Password, auth), "")
+}
+
+// getConnectedBSSID 获取无线网卡当前连接的接入点MAC地址，未连接时为空
+func (b *windowsBackend) getConnectedBSSID(interfaceName string) string {
+	output, err := b.command("netsh", "wlan", "show", "interfaces", "interface="+interfaceName).Output()
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if ok && strings.TrimSpace(key) == "BSSID" {
+			return strings.ToUpper(strings.TrimSpace(value))
+		}
+	}
+	return ""
+}
+
+// getConnectedSSID 获取无线网卡当前连接的SSID
+func (b *windowsBackend) getConnectedSSID(interfaceName string) (string, error) {
+	cmd := b.command("netsh", "wlan", "show", "interfaces", "interface="+interfaceName)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("获取SSID失败: %v", err)
+	}
+
+	// 解析输出查找SSID行
+	lines := strings.Split(string(output), "\n")
+	for _, line := range lines {
+		if strings.Contains(line, "SSID") && strings.Contains(line, ":") {
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) > 1 {
+				return strings.TrimSpace(parts[1]), nil
+			}
+		}
+	}
+	return "", nil // 没有连接热点时返回空
+}
@@ -0,0 +1,550 @@
+package service
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"networkconfig/models"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Linux WiFi后端名称，由LINUX_WIFI_BACKEND选择
+const (
+	wifiBackendAuto           = "auto"           // 依次尝试wpa_supplicant控制接口、NetworkManager D-Bus接口和nmcli
+	wifiBackendNmcli          = "nmcli"          // NetworkManager(nmcli)，失败时回退到iwlist
+	wifiBackendNetworkManager = "networkmanager" // NetworkManager D-Bus接口
+	wifiBackendWPASupplicant  = "wpa_supplicant" // 直接与wpa_supplicant控制接口通信
+)
+
+// wpaSupplicant 通过wpa_supplicant控制接口(ctrl_interface下每个网卡一个unix数据报套接字)扫描和连接WiFi，
+// 适用于没有NetworkManager、只运行wpa_supplicant的系统
+type wpaSupplicant struct {
+	dir            string        // ctrl_interface目录
+	timeout        time.Duration // 单条命令的响应超时
+	scanTimeout    time.Duration // 等待扫描完成的时间
+	connectTimeout time.Duration // 等待连接完成的时间
+}
+
+// newWPASupplicant 按环境变量创建wpa_supplicant控制接口客户端
+func newWPASupplicant() *wpaSupplicant {
+	dir := os.Getenv("WPA_CTRL_DIR")
+	if dir == "" {
+		dir = "/var/run/wpa_supplicant"
+	}
+	return &wpaSupplicant{
+		dir:            dir,
+		timeout:        5 * time.Second,
+		scanTimeout:    15 * time.Second,
+		connectTimeout: time.Duration(getEnvInt("WPA_CONNECT_TIMEOUT", 30)) * time.Second,
+	}
+}
+
+// socket 返回网卡对应的控制接口路径
+func (w *wpaSupplicant) socket(iface string) string {
+	return filepath.Join(w.dir, iface)
+}
+
+// available 判断wpa_supplicant是否为该网卡提供了控制接口
+func (w *wpaSupplicant) available(iface string) bool {
+	info, err := os.Stat(w.socket(iface))
+	return err == nil && info.Mode()&os.ModeSocket != 0
+}
+
+// Scan 触发扫描并等待完成，返回扫描结果；扫描超时或失败时返回wpa_supplicant缓存的上次结果
+func (w *wpaSupplicant) Scan(iface string) ([]models.WiFiHotspot, error) {
+	ctrl, err := dialWPACtrl(w.socket(iface), w.timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer ctrl.Close()
+
+	// ATTACH后才能收到扫描完成事件
+	if err := ctrl.expectOK("ATTACH"); err != nil {
+		return nil, err
+	}
+	defer ctrl.request("DETACH")
+
+	reply, err := ctrl.request("SCAN")
+	if err != nil {
+		return nil, err
+	}
+	switch reply {
+	case "OK", "FAIL-BUSY": // FAIL-BUSY表示已有扫描在进行，等待它完成即可
+		event, err := ctrl.waitEvent(w.scanTimeout, "CTRL-EVENT-SCAN-RESULTS", "CTRL-EVENT-SCAN-FAILED")
+		if err != nil {
+			log.Printf("等待接口 %s 扫描完成失败: %v，使用上次扫描结果", iface, err)
+		} else if strings.Contains(event, "CTRL-EVENT-SCAN-FAILED") {
+			log.Printf("接口 %s 扫描失败: %s，使用上次扫描结果", iface, event)
+		}
+	default:
+		return nil, fmt.Errorf("wpa_supplicant拒绝扫描: %s", reply)
+	}
+
+	results, err := ctrl.request("SCAN_RESULTS")
+	if err != nil {
+		return nil, err
+	}
+	hotspots := parseWPAScanResults(results)
+	if status, err := ctrl.request("STATUS"); err == nil {
+		if state := parseKeyValueLines(status); state["wpa_state"] == "COMPLETED" {
+			for i := range hotspots {
+				hotspots[i].Connected = strings.EqualFold(hotspots[i].BSSID, state["bssid"])
+			}
+		}
+	}
+	log.Printf("wpa_supplicant扫描完成，发现 %d 个热点", len(hotspots))
+	return hotspots, nil
+}
+
+// Connect 添加网络并选择它，等待连接完成；成功后替换同一SSID的旧网络，失败时删除新添加的网络
+func (w *wpaSupplicant) Connect(iface, ssid, password string) error {
+	ctrl, err := dialWPACtrl(w.socket(iface), w.timeout)
+	if err != nil {
+		return err
+	}
+	defer ctrl.Close()
+
+	networks, err := ctrl.request("LIST_NETWORKS")
+	if err != nil {
+		return err
+	}
+
+	reply, err := ctrl.request("ADD_NETWORK")
+	if err != nil {
+		return err
+	}
+	id := reply
+	if _, err := strconv.Atoi(id); err != nil {
+		return fmt.Errorf("添加网络失败: %s", reply)
+	}
+	if err := w.configureNetwork(ctrl, id, ssid, password); err != nil {
+		ctrl.request("REMOVE_NETWORK " + id)
+		return err
+	}
+
+	if err := ctrl.expectOK("ATTACH"); err != nil {
+		ctrl.request("REMOVE_NETWORK " + id)
+		return err
+	}
+	defer ctrl.request("DETACH")
+	if err := ctrl.expectOK("SELECT_NETWORK " + id); err != nil {
+		ctrl.request("REMOVE_NETWORK " + id)
+		return err
+	}
+
+	if err := w.waitConnected(ctrl); err != nil {
+		ctrl.request("REMOVE_NETWORK " + id)
+		// SELECT_NETWORK禁用了其他网络，失败后重新启用
+		ctrl.request("ENABLE_NETWORK all")
+		return err
+	}
+	// 连接成功后才删除同一SSID的旧网络，失败时旧配置保持不变
+	for _, network := range parseWPANetworks(networks) {
+		if network.ssid == ssid {
+			if err := ctrl.expectOK("REMOVE_NETWORK " + network.id); err != nil {
+				log.Printf("删除旧网络 %s 失败: %v", network.id, err)
+			}
+		}
+	}
+	// 未开启update_config时保存会失败，不影响本次连接
+	if err := ctrl.expectOK("SAVE_CONFIG"); err != nil {
+		log.Printf("保存wpa_supplicant配置失败: %v", err)
+	}
+	log.Printf("接口 %s 已通过wpa_supplicant连接到 %s", iface, ssid)
+	return nil
+}
+
+// configureNetwork 设置网络的SSID和认证方式
+// SSID使用十六进制形式，避免引号和非ASCII字符的转义问题
+func (w *wpaSupplicant) configureNetwork(ctrl *wpaCtrl, id, ssid, password string) error {
+	if err := ctrl.expectOK(fmt.Sprintf("SET_NETWORK %s ssid %s", id, hex.EncodeToString([]byte(ssid)))); err != nil {
+		return err
+	}
+	// 隐藏网络也能连接
+	if err := ctrl.expectOK(fmt.Sprintf("SET_NETWORK %s scan_ssid 1", id)); err != nil {
+		return err
+	}
+	if password == "" {
+		return ctrl.expectOK(fmt.Sprintf("SET_NETWORK %s key_mgmt NONE", id))
+	}
+
+	// 64位十六进制为原始PSK，否则为口令
+	psk := `"` + password + `"`
+	if _, err := hex.DecodeString(password); err == nil && len(password) == 64 {
+		psk = password
+	}
+	if reply, err := ctrl.request(fmt.Sprintf("SET_NETWORK %s psk %s", id, psk)); err != nil {
+		return err
+	} else if reply != "OK" {
+		// 不在错误信息中包含密码
+		return fmt.Errorf("设置WiFi密码失败: %s", reply)
+	}
+	// 同时允许WPA2和WPA3，不支持SAE的旧版wpa_supplicant只使用WPA-PSK
+	if err := ctrl.expectOK(fmt.Sprintf("SET_NETWORK %s key_mgmt WPA-PSK SAE", id)); err == nil {
+		return ctrl.expectOK(fmt.Sprintf("SET_NETWORK %s ieee80211w 1", id))
+	}
+	return ctrl.expectOK(fmt.Sprintf("SET_NETWORK %s key_mgmt WPA-PSK", id))
+}
+
+// waitConnected 等待连接完成，密码错误或超时时返回错误
+func (w *wpaSupplicant) waitConnected(ctrl *wpaCtrl) error {
+	deadline := time.Now().Add(w.connectTimeout)
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return fmt.Errorf("连接超时(%v)", w.connectTimeout)
+		}
+		event, err := ctrl.waitEvent(remaining, "CTRL-EVENT-CONNECTED", "CTRL-EVENT-SSID-TEMP-DISABLED", "CTRL-EVENT-NETWORK-NOT-FOUND")
+		if err != nil {
+			// 事件可能在ATTACH之前就已发出，以STATUS为准
+			if status, statusErr := ctrl.request("STATUS"); statusErr == nil && parseKeyValueLines(status)["wpa_state"] == "COMPLETED" {
+				return nil
+			}
+			return fmt.Errorf("连接超时(%v)", w.connectTimeout)
+		}
+		switch {
+		case strings.Contains(event, "CTRL-EVENT-CONNECTED"):
+			return nil
+		case strings.Contains(event, "reason=WRONG_KEY"):
+			return errors.New("连接失败: 密码错误")
+		case strings.Contains(event, "CTRL-EVENT-SSID-TEMP-DISABLED"):
+			return fmt.Errorf("连接失败: %s", event)
+		}
+		// 没找到网络时wpa_supplicant会继续扫描，等到超时为止
+	}
+}
+
+// Status 返回网卡的STATUS信息，如wpa_state、ssid、bssid、ip_address
+func (w *wpaSupplicant) Status(iface string) (map[string]string, error) {
+	ctrl, err := dialWPACtrl(w.socket(iface), w.timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer ctrl.Close()
+
+	reply, err := ctrl.request("STATUS")
+	if err != nil {
+		return nil, err
+	}
+	return parseKeyValueLines(reply), nil
+}
+
+// wpaNetwork LIST_NETWORKS中的一项
+type wpaNetwork struct {
+	id    string
+	ssid  string
+	flags string
+}
+
+// parseWPANetworks 解析LIST_NETWORKS输出，格式为表头加制表符分隔的 network id / ssid / bssid / flags
+func parseWPANetworks(output string) []wpaNetwork {
+	var networks []wpaNetwork
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 || strings.HasPrefix(line, "network id") {
+			continue
+		}
+		network := wpaNetwork{id: fields[0], ssid: decodeWPAString(fields[1])}
+		if len(fields) > 3 {
+			network.flags = fields[3]
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// parseWPAScanResults 解析SCAN_RESULTS输出，格式为表头加制表符分隔的 bssid / frequency / signal level / flags / ssid
+func parseWPAScanResults(output string) []models.WiFiHotspot {
+	hotspots := make([]models.WiFiHotspot, 0)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 4 || strings.HasPrefix(line, "bssid") {
+			continue
+		}
+		hotspot := models.WiFiHotspot{
+			BSSID:        strings.ToUpper(fields[0]),
+			Security:     wpaFlagsSecurity(fields[3]),
+			SecurityInfo: wpaFlagsSecurityInfo(fields[3]),
+		}
+		if len(fields) > 4 {
+			hotspot.SSID = decodeWPAString(fields[4])
+		}
+		if freq, err := strconv.Atoi(fields[1]); err == nil {
+			hotspot.Frequency = freq
+			hotspot.Channel = frequencyToChannel(freq)
+			hotspot.Band = frequencyBand(freq)
+		}
+		if level, err := strconv.Atoi(fields[2]); err == nil {
+			hotspot.SignalStrength = signalPercent(level)
+			if level < 0 {
+				hotspot.SignalDBm = level
+			}
+		}
+		hotspots = append(hotspots, hotspot)
+	}
+	return hotspots
+}
+
+// wpaFlagsSecurity 把扫描结果的flags(如[WPA2-PSK+SAE-CCMP][ESS])转换为与nmcli一致的加密类型，开放网络为空
+func wpaFlagsSecurity(flags string) string {
+	var wep, wpa1, wpa2, wpa3, owe, eap bool
+	for _, flag := range strings.Split(strings.Trim(flags, "[]"), "][") {
+		var keyMgmt string
+		switch {
+		case flag == "WEP":
+			wep = true
+		case strings.HasPrefix(flag, "WPA-"):
+			wpa1 = true
+			keyMgmt = flag[len("WPA-"):]
+		case strings.HasPrefix(flag, "WPA2-"), strings.HasPrefix(flag, "RSN-"):
+			keyMgmt = flag[strings.Index(flag, "-")+1:]
+			if strings.Contains(keyMgmt, "SAE") {
+				wpa3 = true
+			}
+			if strings.Contains(keyMgmt, "PSK") || strings.Contains(keyMgmt, "EAP") {
+				wpa2 = true
+			}
+			if strings.Contains(keyMgmt, "OWE") {
+				owe = true
+			}
+		case flag == "OWE":
+			owe = true
+		}
+		if strings.Contains(keyMgmt, "EAP") {
+			eap = true
+		}
+	}
+
+	var parts []string
+	for _, part := range []struct {
+		set  bool
+		name string
+	}{{wep, "WEP"}, {wpa1, "WPA1"}, {wpa2, "WPA2"}, {wpa3, "WPA3"}, {owe, "OWE"}, {eap, "802.1X"}} {
+		if part.set {
+			parts = append(parts, part.name)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// wpaFlagsSecurityInfo 从扫描结果的flags中解析认证套件、加密算法和WPS，每个WPA/RSN标志形如
+// [WPA2-PSK+SAE-CCMP+TKIP-preauth]，依次为协议、认证套件和加密算法；flags中没有管理帧保护和组播加密算法
+func wpaFlagsSecurityInfo(flags string) models.WiFiSecurity {
+	var akms, ciphers []string
+	var wps bool
+	for _, flag := range strings.Split(strings.Trim(flags, "[]"), "][") {
+		switch {
+		case flag == "WEP":
+			akms = append(akms, "wep")
+		case strings.HasPrefix(flag, "WPS"):
+			wps = true
+		case strings.HasPrefix(flag, "WPA-"), strings.HasPrefix(flag, "WPA2-"), strings.HasPrefix(flag, "RSN-"):
+			rest := strings.TrimSuffix(flag[strings.Index(flag, "-")+1:], "-preauth")
+			// 认证套件名称本身可能带连字符(如EAP-SUITE-B-192)，以第一个加密算法的位置分隔
+			keyMgmt, cipherList := rest, ""
+			for _, cipher := range []string{"-CCMP", "-GCMP", "-TKIP"} {
+				if i := strings.Index(rest, cipher); i >= 0 && i < len(keyMgmt) {
+					keyMgmt, cipherList = rest[:i], rest[i+1:]
+				}
+			}
+			akms = append(akms, strings.Split(keyMgmt, "+")...)
+			ciphers = append(ciphers, strings.Split(cipherList, "+")...)
+		}
+	}
+	security := newWiFiSecurity(akms, ciphers, "")
+	security.WPS = wps
+	return security
+}
+
+// signalPercent 把dBm信号强度换算为百分比，算法与NetworkManager相同(-100dBm为0，-40dBm及以上为100)
+// 部分驱动直接报告0-100的信号质量，原样返回
+func signalPercent(level int) int {
+	if level >= 0 {
+		if level > 100 {
+			return 100
+		}
+		return level
+	}
+	if level < -100 {
+		level = -100
+	}
+	if level > -40 {
+		level = -40
+	}
+	return 100 - (100*(-40-level))/60
+}
+
+// frequencyToChannel 把中心频率(MHz)转换为信道号，无法识别时返回0
+func frequencyToChannel(freq int) int {
+	switch {
+	case freq == 2484:
+		return 14
+	case freq >= 2412 && freq < 2484:
+		return (freq - 2407) / 5
+	case freq >= 5955 && freq <= 7115:
+		return (freq - 5950) / 5
+	case freq >= 5000 && freq < 5955:
+		return (freq - 5000) / 5
+	}
+	return 0
+}
+
+// channelToFrequency 把信道号转换为中心频率(MHz)，大于14的信道按5GHz计算，无法识别时返回0
+func channelToFrequency(channel int) int {
+	switch {
+	case channel == 14:
+		return 2484
+	case channel >= 1 && channel < 14:
+		return 2407 + channel*5
+	case channel > 14 && channel <= 196:
+		return 5000 + channel*5
+	}
+	return 0
+}
+
+// frequencyBand 返回中心频率所在的频段，无法识别时为空
+func frequencyBand(freq int) string {
+	switch {
+	case freq >= 2400 && freq < 2500:
+		return models.HotspotBand24GHz
+	case freq >= 5925 && freq <= 7125:
+		return models.HotspotBand6GHz
+	case freq >= 4900 && freq < 5925:
+		return models.HotspotBand5GHz
+	}
+	return ""
+}
+
+// decodeWPAString 还原wpa_supplicant用printf_encode转义的字符串(\\、\"、\n、\r、\t、\e和\xNN)
+func decodeWPAString(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			out = append(out, s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'e':
+			out = append(out, 0x1b)
+		case 'x':
+			if i+2 < len(s) {
+				if b, err := hex.DecodeString(s[i+1 : i+3]); err == nil {
+					out = append(out, b[0])
+					i += 2
+					continue
+				}
+			}
+			out = append(out, '\\', 'x')
+		default:
+			out = append(out, s[i])
+		}
+	}
+	return string(out)
+}
+
+// wpaCtrlSeq 区分同一进程创建的多个本地套接字
+var wpaCtrlSeq uint32
+
+// wpaCtrl 一条到wpa_supplicant控制接口的连接，请求按顺序发送，不能并发使用
+type wpaCtrl struct {
+	conn    *net.UnixConn
+	local   string
+	timeout time.Duration
+}
+
+// dialWPACtrl 连接控制接口；数据报套接字需要绑定本地地址才能收到回复
+func dialWPACtrl(path string, timeout time.Duration) (*wpaCtrl, error) {
+	local := filepath.Join(os.TempDir(), fmt.Sprintf("networkconfig_wpa_%d_%d", os.Getpid(), atomic.AddUint32(&wpaCtrlSeq, 1)))
+	os.Remove(local)
+	conn, err := net.DialUnix("unixgram",
+		&net.UnixAddr{Name: local, Net: "unixgram"},
+		&net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		os.Remove(local)
+		return nil, fmt.Errorf("连接wpa_supplicant控制接口 %s 失败: %v", path, err)
+	}
+	return &wpaCtrl{conn: conn, local: local, timeout: timeout}, nil
+}
+
+// Close 关闭连接并删除本地套接字文件
+func (c *wpaCtrl) Close() error {
+	err := c.conn.Close()
+	os.Remove(c.local)
+	return err
+}
+
+// request 发送命令并返回去掉结尾换行的回复，期间收到的事件消息(以<级别>开头)被忽略
+func (c *wpaCtrl) request(cmd string) (string, error) {
+	name := strings.Fields(cmd + " ")[0]
+	if _, err := c.conn.Write([]byte(cmd)); err != nil {
+		return "", fmt.Errorf("发送wpa_supplicant命令 %s 失败: %v", name, err)
+	}
+
+	buf := make([]byte, 8192)
+	deadline := time.Now().Add(c.timeout)
+	for {
+		c.conn.SetReadDeadline(deadline)
+		n, err := c.conn.Read(buf)
+		if err != nil {
+			return "", fmt.Errorf("读取wpa_supplicant命令 %s 的回复失败: %v", name, err)
+		}
+		reply := string(buf[:n])
+		if strings.HasPrefix(reply, "<") {
+			continue
+		}
+		return strings.TrimRight(reply, "\n"), nil
+	}
+}
+
+// expectOK 发送命令，回复不是OK时返回错误
+func (c *wpaCtrl) expectOK(cmd string) error {
+	reply, err := c.request(cmd)
+	if err != nil {
+		return err
+	}
+	if reply != "OK" {
+		return fmt.Errorf("wpa_supplicant命令 %s 失败: %s", cmd, reply)
+	}
+	return nil
+}
+
+// waitEvent 等待包含任一名称的事件消息，返回去掉级别前缀的事件内容
+func (c *wpaCtrl) waitEvent(timeout time.Duration, names ...string) (string, error) {
+	buf := make([]byte, 8192)
+	c.conn.SetReadDeadline(time.Now().Add(timeout))
+	for {
+		n, err := c.conn.Read(buf)
+		if err != nil {
+			return "", fmt.Errorf("等待wpa_supplicant事件 %s 失败: %v", strings.Join(names, "/"), err)
+		}
+		msg := string(buf[:n])
+		if !strings.HasPrefix(msg, "<") {
+			continue
+		}
+		if i := strings.Index(msg, ">"); i > 0 {
+			msg = msg[i+1:]
+		}
+		for _, name := range names {
+			if strings.HasPrefix(msg, name) {
+				return strings.TrimRight(msg, "\n"), nil
+			}
+		}
+	}
+}
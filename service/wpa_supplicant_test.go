@@ -0,0 +1,316 @@
+package service
+
+import (
+	"net"
+	"networkconfig/models"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeWPAStep 录制的一次交互：客户端发送的命令，以及之后按顺序发给客户端的回复和事件消息
+type fakeWPAStep struct {
+	request  string
+	messages []string
+}
+
+// loadWPATranscript 读取testdata/wpa_supplicant下录制的交互
+// "> "开头为客户端发送的命令，"< "开头为回复的一行，连续多行合并为一条回复，"! "开头为wpa_supplicant主动发出的事件消息，"#"开头为注释
+func loadWPATranscript(t *testing.T, name string) []fakeWPAStep {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "wpa_supplicant", name))
+	if err != nil {
+		t.Fatalf("读取录制的交互失败: %v", err)
+	}
+
+	var steps []fakeWPAStep
+	var reply []string
+	flush := func() {
+		if reply != nil {
+			steps[len(steps)-1].messages = append(steps[len(steps)-1].messages, strings.Join(reply, "\n")+"\n")
+			reply = nil
+		}
+	}
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if len(line) < 2 || line[1] != ' ' {
+			t.Fatalf("无效的交互行: %q", line)
+		}
+		prefix, text := line[0], line[2:]
+		if prefix != '>' && len(steps) == 0 {
+			t.Fatalf("交互应以命令开始: %q", line)
+		}
+		switch prefix {
+		case '>':
+			flush()
+			steps = append(steps, fakeWPAStep{request: text})
+		case '<':
+			reply = append(reply, text)
+		case '!':
+			flush()
+			steps[len(steps)-1].messages = append(steps[len(steps)-1].messages, text)
+		default:
+			t.Fatalf("无效的交互行: %q", line)
+		}
+	}
+	flush()
+	return steps
+}
+
+// fakeWPA 在unix数据报套接字上回放录制交互的wpa_supplicant控制接口，收到的命令与录制不一致时测试失败
+type fakeWPA struct {
+	conn   *net.UnixConn
+	steps  []fakeWPAStep
+	served atomic.Int32
+	done   chan struct{}
+}
+
+// startFakeWPA 在临时ctrl_interface目录下为wlan0启动回放transcript的控制接口，返回指向该目录的客户端
+// 测试结束时检查录制的命令是否都已收到
+func startFakeWPA(t *testing.T, transcript string) *wpaSupplicant {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows不支持unix数据报套接字")
+	}
+
+	// unix套接字路径长度有限，不使用t.TempDir()
+	dir, err := os.MkdirTemp("", "wpa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: filepath.Join(dir, "wlan0"), Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("创建控制接口失败: %v", err)
+	}
+	fake := &fakeWPA{conn: conn, steps: loadWPATranscript(t, transcript), done: make(chan struct{})}
+	go fake.serve(t)
+
+	t.Cleanup(func() {
+		select {
+		case <-fake.done:
+		case <-time.After(200 * time.Millisecond):
+		}
+		conn.Close()
+		<-fake.done
+		if served := int(fake.served.Load()); served < len(fake.steps) {
+			t.Errorf("没有收到录制的命令 %q(第 %d 条)", fake.steps[served].request, served+1)
+		}
+	})
+
+	return &wpaSupplicant{
+		dir:            dir,
+		timeout:        500 * time.Millisecond,
+		scanTimeout:    500 * time.Millisecond,
+		connectTimeout: 300 * time.Millisecond,
+	}
+}
+
+// serve 按顺序接收命令并发送录制的回复和事件
+func (f *fakeWPA) serve(t *testing.T) {
+	defer close(f.done)
+	buf := make([]byte, 8192)
+	for i, step := range f.steps {
+		n, from, err := f.conn.ReadFromUnix(buf)
+		if err != nil {
+			return
+		}
+		if got := string(buf[:n]); got != step.request {
+			t.Errorf("第 %d 条命令为 %q，应为 %q", i+1, got, step.request)
+			f.conn.WriteToUnix([]byte("UNKNOWN COMMAND\n"), from)
+			return
+		}
+		f.served.Add(1)
+		for _, msg := range step.messages {
+			if _, err := f.conn.WriteToUnix([]byte(msg), from); err != nil {
+				t.Errorf("发送 %q 失败: %v", msg, err)
+				return
+			}
+		}
+	}
+}
+
+func TestDialWPACtrl(t *testing.T) {
+	w := startFakeWPA(t, "ctrl.txt")
+
+	if _, err := dialWPACtrl(filepath.Join(w.dir, "wlan1"), time.Second); err == nil {
+		t.Error("控制接口不存在时应返回错误")
+	}
+
+	ctrl, err := dialWPACtrl(w.socket("wlan0"), w.timeout)
+	if err != nil {
+		t.Fatalf("连接控制接口失败: %v", err)
+	}
+	if info, err := os.Stat(ctrl.local); err != nil || info.Mode()&os.ModeSocket == 0 {
+		t.Errorf("应绑定本地套接字 %s: %v", ctrl.local, err)
+	}
+	other, err := dialWPACtrl(w.socket("wlan0"), w.timeout)
+	if err != nil {
+		t.Fatalf("第二次连接控制接口失败: %v", err)
+	}
+	if other.local == ctrl.local {
+		t.Errorf("同一进程的两个连接使用了相同的本地套接字 %s", ctrl.local)
+	}
+	other.Close()
+
+	// 回放剩余交互，避免测试结束时报告未收到的命令
+	if reply, err := ctrl.request("PING"); err != nil || reply != "PONG" {
+		t.Errorf("PING应返回PONG，得到 %q, %v", reply, err)
+	}
+	ctrl.expectOK("ATTACH")
+	ctrl.waitEvent(w.timeout, "CTRL-EVENT-SCAN-RESULTS")
+	ctrl.request("SCAN_RESULTS")
+
+	ctrl.Close()
+	if _, err := os.Stat(ctrl.local); !os.IsNotExist(err) {
+		t.Errorf("关闭后应删除本地套接字 %s", ctrl.local)
+	}
+}
+
+func TestWPACtrlRequestAndWaitEvent(t *testing.T) {
+	w := startFakeWPA(t, "ctrl.txt")
+	ctrl, err := dialWPACtrl(w.socket("wlan0"), w.timeout)
+	if err != nil {
+		t.Fatalf("连接控制接口失败: %v", err)
+	}
+	defer ctrl.Close()
+
+	// 回复前收到的事件消息被忽略
+	if reply, err := ctrl.request("PING"); err != nil || reply != "PONG" {
+		t.Fatalf("PING应返回PONG，得到 %q, %v", reply, err)
+	}
+	if err := ctrl.expectOK("ATTACH"); err != nil {
+		t.Fatalf("ATTACH失败: %v", err)
+	}
+
+	// 不匹配的事件被跳过，返回去掉级别前缀的事件
+	event, err := ctrl.waitEvent(w.timeout, "CTRL-EVENT-SCAN-RESULTS", "CTRL-EVENT-SCAN-FAILED")
+	if err != nil {
+		t.Fatalf("等待扫描完成事件失败: %v", err)
+	}
+	if event != "CTRL-EVENT-SCAN-RESULTS " {
+		t.Errorf("事件应为 %q，得到 %q", "CTRL-EVENT-SCAN-RESULTS ", event)
+	}
+
+	// 没有更多事件时超时
+	start := time.Now()
+	if _, err := ctrl.waitEvent(100*time.Millisecond, "CTRL-EVENT-CONNECTED"); err == nil {
+		t.Error("没有事件时应超时返回错误")
+	} else if elapsed := time.Since(start); elapsed > w.timeout {
+		t.Errorf("等待事件应在超时后返回，实际等待 %v", elapsed)
+	}
+
+	// 没有回复时请求超时
+	if _, err := ctrl.request("SCAN_RESULTS"); err == nil || !strings.Contains(err.Error(), "SCAN_RESULTS") {
+		t.Errorf("没有回复时应返回包含命令名的错误，得到: %v", err)
+	}
+}
+
+func TestParseWPAScanResults(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []models.WiFiHotspot
+	}{
+		{
+			name:   "只有表头",
+			output: "bssid / frequency / signal level / flags / ssid\n",
+			want:   []models.WiFiHotspot{},
+		},
+		{
+			name:   "WPA2和WPS",
+			output: "bssid / frequency / signal level / flags / ssid\n02:11:22:33:44:55\t2437\t-45\t[WPA2-PSK-CCMP][WPS][ESS]\tHome\n",
+			want: []models.WiFiHotspot{{SSID: "Home", BSSID: "02:11:22:33:44:55", SignalStrength: 92, SignalDBm: -45,
+				Frequency: 2437, Band: models.HotspotBand24GHz, Channel: 6, Security: "WPA2",
+				SecurityInfo: models.WiFiSecurity{AKM: []string{"psk"}, Ciphers: []string{"ccmp"}, WPS: true}}},
+		},
+		{
+			name:   "WPA2/WPA3过渡模式",
+			output: "02:11:22:33:44:66\t5180\t-67\t[WPA2-PSK+SAE-CCMP][ESS]\tHome",
+			want: []models.WiFiHotspot{{SSID: "Home", BSSID: "02:11:22:33:44:66", SignalStrength: 55, SignalDBm: -67,
+				Frequency: 5180, Band: models.HotspotBand5GHz, Channel: 36, Security: "WPA2 WPA3",
+				SecurityInfo: models.WiFiSecurity{AKM: []string{"psk", "sae"}, Ciphers: []string{"ccmp"}}}},
+		},
+		{
+			name:   "6GHz和转义的中文SSID",
+			output: "0a:bb:cc:dd:ee:ff\t5975\t-80\t[RSN-SAE-CCMP][ESS]\t\\xe5\\x8a\\x9e\\xe5\\x85\\xac",
+			want: []models.WiFiHotspot{{SSID: "办公", BSSID: "0A:BB:CC:DD:EE:FF", SignalStrength: 34, SignalDBm: -80,
+				Frequency: 5975, Band: models.HotspotBand6GHz, Channel: 5, Security: "WPA3",
+				SecurityInfo: models.WiFiSecurity{AKM: []string{"sae"}, Ciphers: []string{"ccmp"}}}},
+		},
+		{
+			name:   "隐藏的开放网络和信号质量",
+			output: "0a:bb:cc:dd:ee:00\t2462\t70\t[ESS]\t",
+			want: []models.WiFiHotspot{{BSSID: "0A:BB:CC:DD:EE:00", SignalStrength: 70,
+				Frequency: 2462, Band: models.HotspotBand24GHz, Channel: 11,
+				SecurityInfo: models.WiFiSecurity{AKM: []string{}, Ciphers: []string{}}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseWPAScanResults(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("解析结果不一致\n得到: %+v\n应为: %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWPASupplicantScan(t *testing.T) {
+	w := startFakeWPA(t, "scan.txt")
+	hotspots, err := w.Scan("wlan0")
+	if err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+
+	var got []string
+	for _, hotspot := range hotspots {
+		got = append(got, hotspot.SSID+"/"+hotspot.BSSID+"/"+map[bool]string{true: "connected", false: ""}[hotspot.Connected])
+	}
+	want := []string{"Home/02:11:22:33:44:55/connected", "Home/02:11:22:33:44:66/", "办公/0A:BB:CC:DD:EE:FF/"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("扫描结果应为 %v，得到 %v", want, got)
+	}
+}
+
+func TestWPASupplicantConnect(t *testing.T) {
+	tests := []struct {
+		transcript string
+		ssid       string
+		password   string
+		wantErr    string
+	}{
+		{transcript: "connect_success.txt", ssid: "Home", password: "correct horse"},
+		{transcript: "connect_wrong_key.txt", ssid: "Home", password: "wrong password", wantErr: "密码错误"},
+		{transcript: "connect_timeout.txt", ssid: "Cafe Free WiFi", wantErr: "连接超时"},
+		{transcript: "connect_invalid_psk.txt", ssid: "Home", password: "short", wantErr: "设置WiFi密码失败"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.TrimSuffix(tt.transcript, ".txt"), func(t *testing.T) {
+			w := startFakeWPA(t, tt.transcript)
+			err := w.Connect("wlan0", tt.ssid, tt.password)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("连接失败: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("应返回包含 %q 的错误，得到: %v", tt.wantErr, err)
+			}
+			if tt.password != "" && strings.Contains(err.Error(), tt.password) {
+				t.Errorf("错误信息中不应包含密码: %v", err)
+			}
+		})
+	}
+}